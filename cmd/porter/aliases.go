@@ -22,6 +22,7 @@ func buildAliasCommands(p *porter.Porter) []*cobra.Command {
 		buildArchiveAlias(p),
 		buildExplainAlias(p),
 		buildCopyAlias(p),
+		buildPromoteAlias(p),
 		buildInspectAlias(p),
 		buildLogsAlias(p),
 	}
@@ -120,6 +121,9 @@ func buildListAlias(p *porter.Porter) *cobra.Command {
 func buildArchiveAlias(p *porter.Porter) *cobra.Command {
 	cmd := buildBundleArchiveCommand(p)
 	cmd.Example = strings.Replace(cmd.Example, "porter bundle archive", "porter archive", -1)
+	for _, subCmd := range cmd.Commands() {
+		subCmd.Example = strings.Replace(subCmd.Example, "porter bundle archive", "porter archive", -1)
+	}
 	cmd.Annotations = map[string]string{
 		"group": "alias",
 	}
@@ -144,6 +148,15 @@ func buildCopyAlias(p *porter.Porter) *cobra.Command {
 	return cmd
 }
 
+func buildPromoteAlias(p *porter.Porter) *cobra.Command {
+	cmd := buildBundlePromoteCommand(p)
+	cmd.Example = strings.Replace(cmd.Example, "porter bundle promote", "porter promote", -1)
+	cmd.Annotations = map[string]string{
+		"group": "alias",
+	}
+	return cmd
+}
+
 func buildInspectAlias(p *porter.Porter) *cobra.Command {
 	cmd := buildBundleInspectCommand(p)
 	cmd.Example = strings.Replace(cmd.Example, "porter bundle inspect", "porter inspect", -1)
@@ -27,7 +27,10 @@ func buildBundleCommands(p *porter.Porter) *cobra.Command {
 	cmd.AddCommand(buildBundleArchiveCommand(p))
 	cmd.AddCommand(buildBundleExplainCommand(p))
 	cmd.AddCommand(buildBundleCopyCommand(p))
+	cmd.AddCommand(buildBundlePromoteCommand(p))
+	cmd.AddCommand(buildBundleSearchCommand(p))
 	cmd.AddCommand(buildBundleInspectCommand(p))
+	cmd.AddCommand(buildBundleSBOMCommand(p))
 
 	return cmd
 }
@@ -55,6 +58,7 @@ func buildBundleBuildCommand(p *porter.Porter) *cobra.Command {
   porter build --version 0.1.0
   porter build --file path/to/porter.yaml
   porter build --dir path/to/build/context
+  porter build --update-lock
 `,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			return opts.Validate(p.Context)
@@ -73,6 +77,10 @@ func buildBundleBuildCommand(p *porter.Porter) *cobra.Command {
 		"Path to the Porter manifest. Defaults to `porter.yaml` in the current directory.")
 	f.StringVarP(&opts.Dir, "dir", "d", "",
 		"Path to the build context directory where all bundle assets are located.")
+	f.BoolVar(&opts.InsecureRegistry, "insecure-registry", false,
+		"Don't require TLS when resolving dependencies from a registry")
+	f.BoolVar(&opts.UpdateLock, "update-lock", false,
+		"Resolve the dependencies again and update porter.lock, even when they have not changed in the Porter manifest")
 
 	return cmd
 }
@@ -126,6 +134,8 @@ For example, the 'debug' driver may be specified, which simply logs the info giv
   porter bundle install --parameter-set azure --param test-mode=true --param header-color=blue
   porter bundle install --cred azure --cred kubernetes
   porter bundle install --driver debug
+  porter bundle install --reference getporter/kubernetes:v0.1.0 --dry-run --output yaml
+  porter bundle install --label team=payments --label env=staging
 `,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			return opts.Validate(args, p)
@@ -151,6 +161,11 @@ For example, the 'debug' driver may be specified, which simply logs the info giv
 	f.StringVarP(&opts.Driver, "driver", "d", porter.DefaultDriver,
 		"Specify a driver to use. Allowed values: docker, debug")
 	addBundlePullFlags(f, &opts.BundlePullOptions)
+	addMaxParallelDependenciesFlag(f, opts.BundleActionOptions)
+	addDependencyInstallationFlag(f, opts.BundleActionOptions)
+	addDryRunFlags(f, opts.BundleActionOptions)
+	addForceUnlockFlag(f, opts.BundleActionOptions)
+	addLabelFlag(f, opts.BundleActionOptions)
 	return cmd
 }
 
@@ -172,6 +187,10 @@ For example, the 'debug' driver may be specified, which simply logs the info giv
   porter bundle upgrade --parameter-set azure --param test-mode=true --param header-color=blue
   porter bundle upgrade --cred azure --cred kubernetes
   porter bundle upgrade --driver debug
+  porter bundle upgrade --dry-run --output json
+  porter bundle upgrade --reference getporter/kubernetes:v0.2.0 --require-changes
+  porter bundle upgrade --reference getporter/kubernetes:v0.2.0 --rollback-on-failure
+  porter bundle upgrade --label team=payments --label env=prod
 `,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			return opts.Validate(args, p)
@@ -197,6 +216,15 @@ For example, the 'debug' driver may be specified, which simply logs the info giv
 	f.StringVarP(&opts.Driver, "driver", "d", porter.DefaultDriver,
 		"Specify a driver to use. Allowed values: docker, debug")
 	addBundlePullFlags(f, &opts.BundlePullOptions)
+	addMaxParallelDependenciesFlag(f, opts.BundleActionOptions)
+	addDependencyInstallationFlag(f, opts.BundleActionOptions)
+	addDryRunFlags(f, opts.BundleActionOptions)
+	addForceUnlockFlag(f, opts.BundleActionOptions)
+	addLabelFlag(f, opts.BundleActionOptions)
+	f.BoolVar(&opts.RequireChanges, "require-changes", false,
+		"Do not upgrade when the bundle, parameters and credentials are the same as the last successful action of the installation.")
+	f.BoolVar(&opts.RollbackOnFailure, "rollback-on-failure", false,
+		"When the upgrade fails, re-run the bundle and parameters from the last successful action of the installation. Dependencies are not rolled back.")
 
 	return cmd
 }
@@ -246,6 +274,10 @@ For example, the 'debug' driver may be specified, which simply logs the info giv
 	f.StringVarP(&opts.Driver, "driver", "d", porter.DefaultDriver,
 		"Specify a driver to use. Allowed values: docker, debug")
 	addBundlePullFlags(f, &opts.BundlePullOptions)
+	addMaxParallelDependenciesFlag(f, opts.BundleActionOptions)
+	addDependencyInstallationFlag(f, opts.BundleActionOptions)
+	addDryRunFlags(f, opts.BundleActionOptions)
+	addForceUnlockFlag(f, opts.BundleActionOptions)
 
 	return cmd
 }
@@ -299,6 +331,10 @@ For example, the 'debug' driver may be specified, which simply logs the info giv
 	f.BoolVar(&opts.ForceDelete, "force-delete", false,
 		"UNSAFE. Delete all records associated with the installation, even if uninstall fails. This is intended for cleaning up test data and is not recommended for production environments.")
 	addBundlePullFlags(f, &opts.BundlePullOptions)
+	addMaxParallelDependenciesFlag(f, opts.BundleActionOptions)
+	addDependencyInstallationFlag(f, opts.BundleActionOptions)
+	addDryRunFlags(f, opts.BundleActionOptions)
+	addForceUnlockFlag(f, opts.BundleActionOptions)
 
 	return cmd
 }
@@ -316,8 +352,10 @@ Note: if overrides for registry/tag/reference are provided, this command only re
   porter bundle publish --file myapp/porter.yaml
   porter bundle publish --dir myapp
   porter bundle publish --archive /tmp/mybuns.tgz --reference myrepo/my-buns:0.1.0
+  porter bundle publish --archive /tmp/mybuns.tgz --archive /tmp/mybuns-delta.tgz --reference myrepo/my-buns:0.2.0
   porter bundle publish --tag latest
   porter bundle publish --registry myregistry.com/myorg
+  porter bundle publish --sign --signing-key ~/.porter/signing.key
 		`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			return opts.Validate(p.Context)
@@ -331,12 +369,17 @@ Note: if overrides for registry/tag/reference are provided, this command only re
 	f.StringVarP(&opts.File, "file", "f", "", "Path to the Porter manifest. Defaults to `porter.yaml` in the current directory.")
 	f.StringVarP(&opts.Dir, "dir", "d", "",
 		"Path to the build context directory where all bundle assets are located.")
-	f.StringVarP(&opts.ArchiveFile, "archive", "a", "", "Path to the bundle archive in .tgz format")
+	f.StringSliceVarP(&opts.ArchiveFiles, "archive", "a", nil,
+		"Path to the bundle archive in .tgz format. Repeat to publish a delta archive, starting with the full archive followed by each delta archive in the order they were generated.")
 	f.StringVar(&opts.Tag, "tag", "", "Override the Docker tag portion of the bundle reference, e.g. latest, v0.1.1")
 	f.StringVar(&opts.Registry, "registry", "", "Override the registry portion of the bundle reference, e.g. docker.io, myregistry.com/myorg")
 	addReferenceFlag(f, &opts.BundlePullOptions)
 	addInsecureRegistryFlag(f, &opts.BundlePullOptions)
 	// We aren't using addBundlePullFlags because we don't use --force since we are pushing, and that flag isn't needed
+	f.BoolVar(&opts.Sign, "sign", false,
+		"Sign the bundle after it is published, and push the signature to the same repository as the bundle.")
+	f.StringVar(&opts.SigningKey, "signing-key", "",
+		"Path to the PEM encoded private key used to sign the bundle. Defaults to signing.key in PORTER_HOME.")
 
 	return &cmd
 }
@@ -347,9 +390,14 @@ func buildBundleArchiveCommand(p *porter.Porter) *cobra.Command {
 	cmd := cobra.Command{
 		Use:   "archive FILENAME --reference PUBLISHED_BUNDLE",
 		Short: "Archive a bundle from a reference",
-		Long:  "Archives a bundle by generating a gzipped tar archive containing the bundle, invocation image and any referenced images.",
+		Long: `Archives a bundle by generating a gzipped tar archive containing the bundle, invocation image and any referenced images.
+
+When --base is specified, a delta archive is generated that only contains the image layers that are not in the base archives. Publish a delta archive by passing the base archives and then the delta archive to porter publish --archive.
+
+If archiving is interrupted, the images that were already exported are kept in FILENAME.partial and running the same command again resumes from them.`,
 		Example: `  porter bundle archive mybun.tgz --reference getporter/porter-hello:v0.1.0
   porter bundle archive mybun.tgz --reference localhost:5000/getporter/porter-hello:v0.1.0 --force
+  porter bundle archive mybun-v0.2.0.tgz --reference getporter/porter-hello:v0.2.0 --base mybun-v0.1.0.tgz
 `,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			return opts.Validate(args, p)
@@ -359,7 +407,67 @@ func buildBundleArchiveCommand(p *porter.Porter) *cobra.Command {
 		},
 	}
 
-	addBundlePullFlags(cmd.Flags(), &opts.BundlePullOptions)
+	f := cmd.Flags()
+	addBundlePullFlags(f, &opts.BundlePullOptions)
+	f.StringSliceVar(&opts.BaseArchives, "base", nil,
+		"Generate a delta archive that excludes the image layers in a previously generated archive of the bundle. May be specified multiple times, for example the full archive and each delta archive since.")
+
+	cmd.AddCommand(buildBundleArchiveImportCommand(p))
+	cmd.AddCommand(buildBundleArchiveVerifyCommand(p))
 
 	return &cmd
 }
+
+func buildBundleArchiveImportCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.ArchiveImportOptions{}
+	cmd := &cobra.Command{
+		Use:   "import FILE [DELTA_FILE...] --to-dir DIR",
+		Short: "Import a bundle archive into a directory",
+		Long: `Imports a bundle archive into an OCI image layout directory, so that the bundle can be used without a registry.
+
+Specify a delta archive after the archives that it was generated from. Once imported, use the bundle with --reference oci-layout://DIR.`,
+		Example: `  porter bundle archive import mybuns.tgz --to-dir /srv/bundles/mybuns
+  porter bundle archive import mybuns-v0.1.0.tgz mybuns-v0.2.0.tgz --to-dir /srv/bundles/mybuns-v0.2.0
+  porter install --reference oci-layout:///srv/bundles/mybuns
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Validate(args, p)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.ImportArchive(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.Dir, "to-dir", "",
+		"Directory where the bundle is imported as an OCI image layout. The directory must be empty or not exist.")
+
+	return cmd
+}
+
+func buildBundleArchiveVerifyCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.ArchiveVerifyOptions{}
+	cmd := &cobra.Command{
+		Use:   "verify FILE [DELTA_FILE...]",
+		Short: "Verify the contents of a bundle archive",
+		Long: `Verifies that a bundle archive is complete and has not been corrupted.
+
+The bundle.json must parse and be stamped by Porter, and every invocation image and image in the bundle must be in the archive with the content digest and size from the bundle.json. Specify a delta archive after the archives that it was generated from.`,
+		Example: `  porter bundle archive verify mybuns.tgz
+  porter bundle archive verify mybuns-v0.1.0.tgz mybuns-v0.2.0.tgz
+  porter bundle archive verify mybuns.tgz --output json
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Validate(args, p)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.VerifyArchive(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.RawFormat, "output", "o", "table",
+		"Specify an output format.  Allowed values: table, json")
+
+	return cmd
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"get.porter.sh/porter/pkg/porter"
+	"github.com/spf13/cobra"
+)
+
+func buildCacheCommands(p *porter.Porter) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the bundle cache",
+		Long: `Manage the bundles that Porter has pulled and cached in PORTER_HOME.
+
+The size of the cache is limited by the cache-size setting in the Porter config file. When the cache is full, the least recently used bundles are removed.`,
+		Annotations: map[string]string{
+			"group": "resource",
+		},
+	}
+
+	cmd.AddCommand(buildCacheListCommand(p))
+	cmd.AddCommand(buildCachePruneCommand(p))
+	cmd.AddCommand(buildCacheRemoveCommand(p))
+
+	return cmd
+}
+
+func buildCacheListCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.CacheListOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List cached bundles",
+		Long:  "List the bundles in the cache with their digest, size and when they were last used, starting with the most recently used.",
+		Example: `  porter cache list
+  porter cache list -o json`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.ParseFormat()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.ListCache(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.RawFormat, "output", "o", "table",
+		"Specify an output format.  Allowed values: table, json, yaml")
+
+	return cmd
+}
+
+func buildCachePruneCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.CachePruneOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove unused bundles from the cache",
+		Long:  "Remove the bundles from the cache that haven't been used recently. Either --older-than or --all is required.",
+		Example: `  porter cache prune --older-than 30d
+  porter cache prune --older-than 12h
+  porter cache prune --all`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Validate()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.PruneCache(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.OlderThan, "older-than", "",
+		"Only remove bundles that haven't been used for this long, such as 30d or 12h.")
+	f.BoolVar(&opts.All, "all", false,
+		"Remove every bundle from the cache.")
+
+	return cmd
+}
+
+func buildCacheRemoveCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.CacheRemoveOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "remove REF",
+		Short:   "Remove a bundle from the cache",
+		Long:    "Remove a bundle from the cache, so that it is pulled again the next time that it is used. Bundles that were cached without a tag can be removed by the ID printed by porter cache list.",
+		Example: `  porter cache remove getporter/porter-hello:v0.1.0`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Validate(args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.RemoveFromCache(opts)
+		},
+	}
+
+	return cmd
+}
@@ -17,10 +17,13 @@ func buildBundleCopyCommand(p *porter.Porter) *cobra.Command {
 Source bundle can be either a tagged reference or a digest reference.
 Destination can be either a registry, a registry/repository, or a fully tagged bundle reference. 
 If the source bundle is a digest reference, destination must be a tagged reference.
+
+Use --relocate-images to also copy every invocation image and image referenced by the bundle into the destination registry and organization, preserving their digests. The bundle can then be installed from the destination without access to the original registries.
 `,
 		Example: `  porter bundle copy
   porter bundle copy --source getporter/porter-hello:v0.1.0 --destination portersh
   porter bundle copy --source getporter/porter-hello:v0.1.0 --destination portersh --insecure-registry
+  porter bundle copy --source getporter/porter-hello:v0.1.0 --destination mirror.example.com/portersh --relocate-images --relocation-mapping relocation-mapping.json
 		  `,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			return opts.Validate()
@@ -33,5 +36,8 @@ If the source bundle is a digest reference, destination must be a tagged referen
 	f.StringVarP(&opts.Source, "source", "", "", " The fully qualified source bundle, including tag or digest.")
 	f.StringVarP(&opts.Destination, "destination", "", "", "The registry to copy the bundle to. Can be registry name, registry plus a repo prefix, or a new tagged reference. All images and the bundle will be prefixed with registry.")
 	f.BoolVar(&opts.InsecureRegistry, "insecure-registry", false, "Don't require TLS for registries")
+	f.BoolVar(&opts.RelocateImages, "relocate-images", false, "Copy the images referenced by the bundle into the destination registry and organization.")
+	f.StringVar(&opts.RelocationMapping, "relocation-mapping", "", "Path to write the mapping of the original images to the relocated images. Requires --relocate-images.")
+	f.IntVar(&opts.Concurrency, "concurrency", porter.DefaultCopyConcurrency, "Maximum number of images to copy at the same time when relocating images.")
 	return &cmd
 }
@@ -92,10 +92,13 @@ func buildCredentialsListCommand(p *porter.Porter) *cobra.Command {
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "List credentials",
-		Long:    `List named sets of credentials defined by the user.`,
-		Example: `  porter credentials list [-o table|json|yaml]`,
+		Long: `List named sets of credentials defined by the user.
+
+When --label is specified, only the credential sets used by the last action of installations with matching labels are listed.`,
+		Example: `  porter credentials list [-o table|json|yaml]
+  porter credentials list --label team=payments`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			return opts.ParseFormat()
+			return opts.Validate()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return p.ListCredentials(opts)
@@ -105,6 +108,7 @@ func buildCredentialsListCommand(p *porter.Porter) *cobra.Command {
 	f := cmd.Flags()
 	f.StringVarP(&opts.RawFormat, "output", "o", "table",
 		"Specify an output format.  Allowed values: table, json, yaml")
+	addLabelSelectorFlag(f, &opts.Labels)
 
 	return cmd
 }
@@ -18,9 +18,11 @@ func buildInstallationCommands(p *porter.Porter) *cobra.Command {
 
 	cmd.AddCommand(buildInstallationsListCommand(p))
 	cmd.AddCommand(buildInstallationShowCommand(p))
+	cmd.AddCommand(buildInstallationDiffCommand(p))
 	cmd.AddCommand(buildInstallationOutputsCommands(p))
 	cmd.AddCommand(buildInstallationDeleteCommand(p))
 	cmd.AddCommand(buildInstallationLogCommands(p))
+	cmd.AddCommand(buildInstallationPruneCommand(p))
 
 	return cmd
 }
@@ -37,9 +39,10 @@ A listing of bundles currently installed by Porter will be provided, along with
 
 Optional output formats include json and yaml.`,
 		Example: `  porter installations list
-  porter installations list -o json`,
+  porter installations list -o json
+  porter installations list --label team=payments,env!=prod`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			return opts.ParseFormat()
+			return opts.Validate()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return p.PrintInstallations(opts)
@@ -49,6 +52,7 @@ Optional output formats include json and yaml.`,
 	f := cmd.Flags()
 	f.StringVarP(&opts.RawFormat, "output", "o", "table",
 		"Specify an output format.  Allowed values: table, json, yaml")
+	addLabelSelectorFlag(f, &opts.Labels)
 
 	return cmd
 }
@@ -80,6 +84,48 @@ Optional output formats include json and yaml.
 	return &cmd
 }
 
+func buildInstallationDiffCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.NewInstallationDiffOptions()
+
+	cmd := cobra.Command{
+		Use:   "diff [INSTALLATION]",
+		Short: "Compare an installation with a proposed upgrade",
+		Long: `Compare the last successful action of an installation with what an upgrade would use.
+
+The bundle reference, bundle version, bundle digest, credential set names and parameter values are compared. Sensitive parameter values are not displayed, only whether they changed.
+
+The first argument is the installation name to compare. This defaults to the name of the bundle.`,
+		Example: `  porter installation diff
+  porter installation diff wordpress --reference getporter/wordpress:v0.2.0
+  porter installation diff wordpress --param test-mode=true --cred azure
+  porter installation diff wordpress --output json
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Validate(args, p)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.PrintInstallationDiff(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVarP(&opts.File, "file", "f", "",
+		"Path to the porter manifest file. Defaults to the bundle in the current directory.")
+	f.StringVar(&opts.CNABFile, "cnab-file", "",
+		"Path to the CNAB bundle.json file.")
+	f.StringSliceVarP(&opts.ParameterSets, "parameter-set", "p", nil,
+		"Name of a parameter set file for the bundle. May be either a named set of parameters or a filepath, and specified multiple times.")
+	f.StringSliceVar(&opts.Params, "param", nil,
+		"Define an individual parameter in the form NAME=VALUE. Overrides parameters otherwise set via --parameter-set. May be specified multiple times.")
+	f.StringSliceVarP(&opts.CredentialIdentifiers, "cred", "c", nil,
+		"Credential to use when upgrading the bundle. May be either a named set of credentials or a filepath, and specified multiple times.")
+	f.StringVarP(&opts.RawFormat, "output", "o", "table",
+		"Specify an output format.  Allowed values: table, json, yaml")
+	addBundlePullFlags(f, &opts.BundlePullOptions)
+
+	return &cmd
+}
+
 func buildInstallationDeleteCommand(p *porter.Porter) *cobra.Command {
 	opts := porter.DeleteOptions{}
 
@@ -105,3 +151,35 @@ func buildInstallationDeleteCommand(p *porter.Porter) *cobra.Command {
 
 	return &cmd
 }
+
+func buildInstallationPruneCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.PruneOptions{}
+
+	cmd := cobra.Command{
+		Use:   "prune [INSTALLATION]",
+		Short: "Remove old claims from the history of installations",
+		Long: `Removes the claims, along with their results, outputs and logs, that are not kept by the claim retention policy. When no installation is specified, every installation is pruned.
+
+The policy is defined in the claim-retention section of the config file and may be overridden with the --keep-last and --keep-days flags. A claim is kept when it is one of the most recent claims or is newer than the number of days to keep. The most recent claim, the most recent successful claim, and the claims with the current value of an output, of an installation are always kept.`,
+		Example: `  porter installation prune --keep-last 10
+  porter installation prune wordpress --keep-days 30
+  porter installation prune --keep-last 5 --dry-run
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Validate(args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.PruneInstallations(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.IntVar(&opts.KeepLast, "keep-last", 0,
+		"Number of the most recent claims to keep for each installation. Defaults to keep-last in the claim-retention config.")
+	f.IntVar(&opts.KeepDays, "keep-days", 0,
+		"Number of days to keep claims after they are created. Defaults to keep-days in the claim-retention config.")
+	f.BoolVar(&opts.DryRun, "dry-run", false,
+		"List the claims that would be removed without removing them.")
+
+	return &cmd
+}
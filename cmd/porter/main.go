@@ -67,6 +67,7 @@ func buildRootCommand() *cobra.Command {
 	cmd.AddCommand(buildVersionCommand(p))
 	cmd.AddCommand(buildSchemaCommand(p))
 	cmd.AddCommand(buildStorageCommand(p))
+	cmd.AddCommand(buildCacheCommands(p))
 	cmd.AddCommand(buildRunCommand(p))
 	cmd.AddCommand(buildBundleCommands(p))
 	cmd.AddCommand(buildInstallationCommands(p))
@@ -139,6 +140,38 @@ func addBundlePullFlags(f *pflag.FlagSet, opts *porter.BundlePullOptions) {
 	addForcePullFlag(f, opts)
 }
 
+func addMaxParallelDependenciesFlag(f *pflag.FlagSet, opts *porter.BundleActionOptions) {
+	f.IntVar(&opts.MaxParallelDependencies, "max-parallel-dependencies", 1,
+		"Maximum number of independent dependencies to execute at the same time.")
+}
+
+func addDependencyInstallationFlag(f *pflag.FlagSet, opts *porter.BundleActionOptions) {
+	f.StringSliceVar(&opts.DependencyInstallations, "dependency-installation", nil,
+		"Use an existing installation for a dependency of the bundle, in the form ALIAS=INSTALLATION, instead of installing the dependency with the bundle. May be specified multiple times.")
+}
+
+func addDryRunFlags(f *pflag.FlagSet, opts *porter.BundleActionOptions) {
+	f.BoolVar(&opts.DryRun, "dry-run", false,
+		"Print everything that the action would use, such as the resolved parameters, credentials, dependencies and images, without executing it.")
+	f.StringVarP(&opts.DryRunFormat.RawFormat, "output", "o", string(porter.DryRunDefaultFormat),
+		"Specify an output format for --dry-run.  Allowed values: "+porter.DryRunAllowedFormats.String())
+}
+
+func addForceUnlockFlag(f *pflag.FlagSet, opts *porter.BundleActionOptions) {
+	f.BoolVar(&opts.ForceUnlock, "force-unlock", false,
+		"Remove the locks on the installation and its dependencies before executing the action. Only use this when the action that locked the installation is no longer running.")
+}
+
+func addLabelFlag(f *pflag.FlagSet, opts *porter.BundleActionOptions) {
+	f.StringSliceVar(&opts.Labels, "label", nil,
+		"Label to apply to the installation in the form KEY=VALUE, replacing its existing labels. May be specified multiple times.")
+}
+
+func addLabelSelectorFlag(f *pflag.FlagSet, selector *string) {
+	f.StringVarP(selector, "label", "l", "",
+		"Only include installations with matching labels, for example team=payments,env!=prod. Supports =, ==, !=, KEY and !KEY.")
+}
+
 func addDeprecatedTagFlag(f *pflag.FlagSet, opts *porter.BundlePullOptions) {
 	f.StringVar(&opts.Tag, "tag", "", "")
 	f.MarkDeprecated("tag", "use --reference to declare a full bundle reference")
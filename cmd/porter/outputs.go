@@ -30,6 +30,7 @@ func buildBundleOutputListCommand(p *porter.Porter) *cobra.Command {
 		Long:  "Displays a listing of installation outputs.",
 		Example: `  porter installation outputs list
     porter installation outputs list --installation another-bundle
+    porter installation outputs list --label team=payments
 `,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			return opts.Validate(args, p.Context)
@@ -44,6 +45,7 @@ func buildBundleOutputListCommand(p *porter.Porter) *cobra.Command {
 		"Specify an output format.  Allowed values: table, json, yaml")
 	f.StringVarP(&opts.Name, "installation", "i", "",
 		"Specify the installation to which the output belongs.")
+	addLabelSelectorFlag(f, &opts.Labels)
 
 	return &cmd
 }
@@ -92,10 +92,13 @@ func buildParametersListCommand(p *porter.Porter) *cobra.Command {
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "List parameter sets",
-		Long:    `List named sets of parameters defined by the user.`,
-		Example: `  porter parameters list [-o table|json|yaml]`,
+		Long: `List named sets of parameters defined by the user.
+
+When --label is specified, only the parameter sets used by the last action of installations with matching labels are listed.`,
+		Example: `  porter parameters list [-o table|json|yaml]
+  porter parameters list --label team=payments`,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			return opts.ParseFormat()
+			return opts.Validate()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return p.ListParameters(opts)
@@ -105,6 +108,7 @@ func buildParametersListCommand(p *porter.Porter) *cobra.Command {
 	f := cmd.Flags()
 	f.StringVarP(&opts.RawFormat, "output", "o", "table",
 		"Specify an output format.  Allowed values: table, json, yaml")
+	addLabelSelectorFlag(f, &opts.Labels)
 
 	return cmd
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"get.porter.sh/porter/pkg/porter"
+	"github.com/spf13/cobra"
+)
+
+func buildBundlePromoteCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.PromoteOptions{}
+
+	cmd := cobra.Command{
+		Use:   "promote REFERENCE --to ENVIRONMENT",
+		Short: "Promote a bundle to an environment",
+		Long: `Promote a published bundle to an environment, such as staging or prod, defined in the Porter config.
+
+The bundle is copied to the registry of the environment with the same name and tag. The tag must match the allowed tags of the environment, and tags that are not mutable in the environment are never overwritten. When the environment defines keys, the bundle must be signed by one of them before it is promoted.
+
+The source digest, time and user are recorded in the custom section of the promoted bundle.
+`,
+		Example: `  porter bundle promote getporter/porter-hello:v0.1.0 --to staging
+  porter bundle promote staging.example.com/porter/porter-hello:v0.1.0 --to prod --relocate-images
+  porter bundle promote staging.example.com/porter/porter-hello@sha256:a808aa4e3508d7129742eefda938249574447cce5403dc12d4cbbfe7f4f31e58 --to prod --tag v0.1.0 --sign
+`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Validate(args, p)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.PromoteBundle(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.Environment, "to", "", "Name of the environment from the Porter config to promote the bundle to.")
+	f.StringVar(&opts.Tag, "tag", "", "Override the tag of the promoted bundle. Defaults to the tag of the source bundle.")
+	f.BoolVar(&opts.InsecureRegistry, "insecure-registry", false, "Don't require TLS for registries")
+	f.BoolVar(&opts.RelocateImages, "relocate-images", false, "Copy the images referenced by the bundle into the registry of the environment.")
+	f.IntVar(&opts.Concurrency, "concurrency", porter.DefaultCopyConcurrency, "Maximum number of images to copy at the same time when relocating images.")
+	f.BoolVar(&opts.Sign, "sign", false, "Sign the promoted bundle, and push the signature to the same repository as the bundle.")
+	f.StringVar(&opts.SigningKey, "signing-key", "", "Path to the PEM encoded private key used to sign the promoted bundle. Defaults to signing.key in PORTER_HOME.")
+	return &cmd
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"get.porter.sh/porter/pkg/porter"
+	"github.com/spf13/cobra"
+)
+
+func buildBundleSBOMCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.SBOMOptions{}
+
+	cmd := cobra.Command{
+		Use:   "sbom [REFERENCE]",
+		Short: "Generate a software bill of materials for a bundle",
+		Long: `Generate a software bill of materials (SBOM) for a bundle, in either the SPDX or CycloneDX JSON format.
+
+The SBOM lists the bundle, the dependency bundles that it uses, its invocation images and referenced images with their digests, and the version of Porter and the mixins that the bundle was built with.
+
+When a reference is not specified, the SBOM is generated for the bundle in the current directory.`,
+		Example: `  porter bundle sbom getporter/porter-hello:v0.1.0
+  porter bundle sbom getporter/porter-hello:v0.1.0 --format cyclonedx
+  porter bundle sbom localhost:5000/porter-hello:v0.1.0 --insecure-registry --force
+  porter bundle sbom --file another/porter.yaml
+  porter bundle sbom --cnab-file some/bundle.json > sbom.spdx.json`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Validate(args, p.Context)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.GenerateSBOM(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.Format, "format", porter.SBOMFormatSPDX, "Format of the SBOM, allowed values are: spdx, cyclonedx")
+	f.StringVarP(&opts.File, "file", "f", "", "Path to the Porter manifest. Defaults to `porter.yaml` in the current directory.")
+	f.StringVar(&opts.CNABFile, "cnab-file", "", "Path to the CNAB bundle.json file.")
+	addBundlePullFlags(f, &opts.BundlePullOptions)
+	return &cmd
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"get.porter.sh/porter/pkg/porter"
+	"github.com/spf13/cobra"
+)
+
+func buildBundleSearchCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.BundleSearchOptions{}
+
+	cmd := cobra.Command{
+		Use:   "search REGISTRY/REPOSITORY",
+		Short: "List the bundles in a repository",
+		Long: `List the tags in a repository with the metadata of the bundle that each tag references: the bundle name, version, description, digest and when the invocation image was created.
+
+Use --version to only list tags that are semantic versions matching a version constraint, for example ^1.2 or ">=1.0.0, <2.0.0". Tags that are not bundles, such as bundle signatures, are not listed.`,
+		Example: `  porter bundle search getporter/porter-hello
+  porter bundle search getporter/porter-hello --version ^0.1
+  porter bundle search getporter/porter-hello --version ">=1.0.0-0" --include-prereleases --sort created
+  porter bundle search localhost:5000/porter-hello --insecure-registry -o json`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Validate(args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.SearchBundles(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.Version, "version", "", "Only list tags that are semantic versions matching the version constraint.")
+	f.BoolVar(&opts.IncludePrereleases, "include-prereleases", false, "Include prerelease versions when filtering by --version.")
+	f.StringVar(&opts.Sort, "sort", porter.BundleSearchSortVersion, "Sort the tags by version, created or tag.")
+	f.BoolVar(&opts.InsecureRegistry, "insecure-registry", false, "Don't require TLS for registries")
+	f.StringVarP(&opts.RawFormat, "output", "o", "table",
+		"Output format, allowed values are: table, json, yaml")
+	return &cmd
+}
@@ -18,19 +18,120 @@ func buildStorageCommand(p *porter.Porter) *cobra.Command {
 	}
 
 	cmd.AddCommand(buildStorageMigrateCommand(p))
+	cmd.AddCommand(buildStorageRollbackCommand(p))
+	cmd.AddCommand(buildStorageEncryptCommand(p))
+	cmd.AddCommand(buildStorageExportCommand(p))
+	cmd.AddCommand(buildStorageImportCommand(p))
 
 	return &cmd
 }
 
 func buildStorageMigrateCommand(p *porter.Porter) *cobra.Command {
-	return &cobra.Command{
+	opts := porter.MigrateStorageOptions{}
+
+	cmd := cobra.Command{
 		Use:   "migrate",
 		Short: "Migrate active storage account",
 		Long: `Migrate the data in the active storage account to the schema used by this version of Porter.
 
-Always back up Porter's data before performing a migration. Instructions for backing up are at https://porter.sh/storage-migrate.`,
+Always back up Porter's data before performing a migration. Instructions for backing up are at https://porter.sh/storage-migrate.
+
+The data that is migrated is saved to a snapshot in PORTER_HOME before it is changed. Use porter storage rollback to restore it if the migration fails.`,
+		Example: `  porter storage migrate
+  porter storage migrate --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.MigrateStorage(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.BoolVar(&opts.DryRun, "dry-run", false, "List the changes that the migration would make without changing any data.")
+	return &cmd
+}
+
+func buildStorageRollbackCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.RollbackStorageOptions{}
+
+	cmd := cobra.Command{
+		Use:   "rollback",
+		Short: "Roll back a migration of the active storage account",
+		Long: `Restore the data and schema.json from before a migration, using the snapshot saved by porter storage migrate.
+
+Installations that were migrated are restored to how they were before the migration, so any changes made to them since the migration are lost.`,
+		Example: `  porter storage rollback
+  porter storage rollback --snapshot ~/.porter/20210102150405-migrate-snapshot.tgz`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.RollbackStorage(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.Snapshot, "snapshot", "", "Path of the snapshot to restore. Defaults to the most recent snapshot in PORTER_HOME.")
+	return &cmd
+}
+
+func buildStorageEncryptCommand(p *porter.Porter) *cobra.Command {
+	return &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt the data in the active storage account",
+		Long: `Save all of the installations, credential sets and parameter sets in the active storage account again, so that they are encrypted with the current encryption key of the storage plugin.
+
+Run this command after enabling encryption for the filesystem storage plugin to encrypt the existing data, or after rotating the encryption key to encrypt the data with the new key. Once it completes, the previous key is no longer needed. See https://porter.sh/operators/encrypt-data/ for more information.`,
+		Example: `  porter storage encrypt`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return p.MigrateStorage()
+			return p.EncryptStorage()
 		},
 	}
 }
+
+func buildStorageExportCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.StorageExportOptions{}
+
+	cmd := cobra.Command{
+		Use:   "export",
+		Short: "Export Porter's data to an archive",
+		Long: `Export the installations, credential sets and parameter sets in the active storage account to an archive.
+
+The archive can be imported with porter storage import, for example to move Porter's data to another machine or to a different storage plugin. Installation locks are not exported.`,
+		Example: `  porter storage export --to porter-data.tgz`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Validate()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.ExportStorage(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.To, "to", "", "Path of the archive to create.")
+	return &cmd
+}
+
+func buildStorageImportCommand(p *porter.Porter) *cobra.Command {
+	opts := porter.StorageImportOptions{}
+
+	cmd := cobra.Command{
+		Use:   "import FILE",
+		Short: "Import Porter's data from an archive",
+		Long: `Import the installations, credential sets and parameter sets from an archive created by porter storage export into the active storage account.
+
+When an installation, credential set or parameter set is already in storage, it is handled with the --conflict strategy:
+  skip: keep the existing record and don't import the record from the archive.
+  overwrite: replace the existing record, including the history of an installation, with the record from the archive.
+  fail: don't import anything.
+
+Archives exported by an older version of Porter are migrated to the schema used by this version of Porter before they are imported. Archives exported by a newer version of Porter can't be imported.`,
+		Example: `  porter storage import porter-data.tgz
+  porter storage import porter-data.tgz --conflict overwrite`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.Validate(args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return p.ImportStorage(opts)
+		},
+	}
+
+	f := cmd.Flags()
+	f.StringVar(&opts.Conflict, "conflict", "skip", "How to handle records that are already in storage: skip, overwrite or fail.")
+	return &cmd
+}
@@ -24,6 +24,9 @@ var (
 	// user-provided manifest and any dynamic overrides
 	LOCAL_MANIFEST = filepath.Join(LOCAL_APP, "porter.yaml")
 
+	// LOCK_FILE is the dependency lock file generated next to the porter manifest.
+	LOCK_FILE = "porter.lock"
+
 	// BUNDLE_DIR is the directory where the bundle is located in the CNAB execution environment.
 	BUNDLE_DIR = "/cnab/app"
 
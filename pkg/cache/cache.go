@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	configadapter "get.porter.sh/porter/pkg/cnab/config-adapter"
 	"get.porter.sh/porter/pkg/config"
@@ -16,8 +17,11 @@ import (
 
 type BundleCache interface {
 	FindBundle(tag string) (bun CachedBundle, found bool, err error)
-	StoreBundle(tag string, bun bundle.Bundle, reloMap *relocation.ImageRelocationMap) (CachedBundle, error)
+	StoreBundle(tag string, digest string, bun bundle.Bundle, reloMap *relocation.ImageRelocationMap) (CachedBundle, error)
+	TagBundle(tag string, digest string) error
 	GetCacheDir() (string, error)
+	ListBundles() ([]Entry, error)
+	RemoveBundle(id string) error
 }
 
 var _ BundleCache = &Cache{}
@@ -37,17 +41,45 @@ func New(cfg *config.Config) BundleCache {
 // empty string and the boolean false value are returned. If the bundle is found,
 // and a relocation mapping file is present, it will be returned as well. If the relocation
 // is not found, an empty string is returned.
+// Tags are looked up in the tag index to find the digest of the bundle that
+// they referenced when they were last checked. It is up to the caller to
+// decide if CachedBundle.Checked is recent enough to use the bundle.
 func (c *Cache) FindBundle(bundleTag string) (CachedBundle, bool, error) {
-	cb := CachedBundle{
-		Tag: bundleTag,
-	}
-
 	cacheDir, err := c.GetCacheDir()
 	if err != nil {
 		return CachedBundle{}, false, err
 	}
-	cb.SetCacheDir(cacheDir)
 
+	cb := CachedBundle{
+		Tag:    bundleTag,
+		Digest: getReferenceDigest(bundleTag),
+	}
+	if cb.Digest == "" {
+		idx, found, err := c.readTagIndex(cacheDir, bundleTag)
+		if err != nil {
+			return CachedBundle{}, false, err
+		}
+		if found {
+			cb.Digest = idx.Digest
+			cb.Checked = idx.Checked
+		}
+	}
+
+	if cb.Digest != "" {
+		cb.SetCacheDir(cacheDir)
+		found, err := cb.Load(c.Context)
+		if err != nil {
+			return CachedBundle{}, false, err
+		}
+		if found {
+			return c.useBundle(cb), true, nil
+		}
+	}
+
+	// Fallback to bundles that were cached by their tag
+	cb.Digest = ""
+	cb.Checked = time.Time{}
+	cb.SetCacheDir(cacheDir)
 	found, err := cb.Load(c.Context)
 	if err != nil {
 		return CachedBundle{}, false, err
@@ -55,17 +87,32 @@ func (c *Cache) FindBundle(bundleTag string) (CachedBundle, bool, error) {
 	if !found {
 		return CachedBundle{}, false, nil
 	}
-	return cb, true, nil
+	return c.useBundle(cb), true, nil
+}
 
+// useBundle records that a bundle was used from the cache.
+func (c *Cache) useBundle(cb CachedBundle) CachedBundle {
+	if err := c.touch(cb); err != nil {
+		fmt.Fprintf(c.Err, "WARNING: unable to record that bundle %s was used from the cache: %s\n", cb.Tag, err)
+	}
+	return cb
 }
 
 // StoreBundle will write a given bundle to the bundle cache, in a location derived
-// from the bundleTag. If a relocation mapping is provided, it will be stored along side
+// from the repository and digest of the bundle, or the bundleTag when the digest is unknown. The tag
+// index is updated so that the tag resolves to the digest.
+// If a relocation mapping is provided, it will be stored along side
 // the bundle. If successful, returns the path to the bundle, along with the path to a
 // relocation mapping, if provided. Otherwise, returns an error.
-func (c *Cache) StoreBundle(bundleTag string, bun bundle.Bundle, reloMap *relocation.ImageRelocationMap) (CachedBundle, error) {
+// When the cache is larger than the configured cache-size, the least recently
+// used bundles are removed from the cache.
+func (c *Cache) StoreBundle(bundleTag string, digest string, bun bundle.Bundle, reloMap *relocation.ImageRelocationMap) (CachedBundle, error) {
+	if digest == "" {
+		digest = getReferenceDigest(bundleTag)
+	}
 	cb := CachedBundle{
 		Tag:           bundleTag,
+		Digest:        digest,
 		Bundle:        bun,
 		RelocationMap: reloMap,
 	}
@@ -125,6 +172,30 @@ func (c *Cache) StoreBundle(bundleTag string, bun bundle.Bundle, reloMap *reloca
 
 	}
 
+	now := time.Now()
+	metadata := newEntryMetadata(cb)
+	metadata.Created = now
+	metadata.LastUsed = now
+	if err = c.writeMetadata(cb, metadata); err != nil {
+		return CachedBundle{}, err
+	}
+
+	if cb.Digest == "" {
+		// The tag no longer resolves to a digest that was cached earlier
+		if err = c.removeTagIndex(cacheDir, bundleTag); err != nil {
+			return CachedBundle{}, err
+		}
+	} else if getReferenceDigest(bundleTag) == "" {
+		if err = c.TagBundle(bundleTag, cb.Digest); err != nil {
+			return CachedBundle{}, err
+		}
+		cb.Checked = now
+	}
+
+	if err = c.evict(cb); err != nil {
+		fmt.Fprintf(c.Err, "WARNING: unable to evict bundles from the cache: %s\n", err)
+	}
+
 	return cb, nil
 }
 
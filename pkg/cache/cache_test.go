@@ -92,7 +92,7 @@ func TestCacheWriteNoCacheDir(t *testing.T) {
 	require.NoError(t, err, "bundle should have been valid")
 
 	c := New(cfg.Config)
-	cb, err := c.StoreBundle(kahn1dot01, bun, nil)
+	cb, err := c.StoreBundle(kahn1dot01, "", bun, nil)
 
 	home, err := cfg.Config.GetHomeDir()
 	require.NoError(t, err, "should have had a porter home dir")
@@ -120,7 +120,7 @@ func TestCacheWriteCacheDirExists(t *testing.T) {
 
 	c := New(cfg.Config)
 	var reloMap relocation.ImageRelocationMap
-	cb, err := c.StoreBundle(kahn1dot01, bun, &reloMap)
+	cb, err := c.StoreBundle(kahn1dot01, "", bun, &reloMap)
 
 	expectedCacheDirectory := filepath.Join(cacheDir, kahn1dot0Hash)
 	expectedCacheCNABDirectory := filepath.Join(expectedCacheDirectory, "cnab")
@@ -164,7 +164,7 @@ func TestStoreRelocationMapping(t *testing.T) {
 			cfg := config.NewTestConfig(t)
 			c := New(cfg.Config)
 
-			cb, err := c.StoreBundle(tc.tag, tc.bundle, tc.relocationMapping)
+			cb, err := c.StoreBundle(tc.tag, "", tc.bundle, tc.relocationMapping)
 			assert.NoError(t, err, fmt.Sprintf("didn't expect storage error for test %s", tc.name))
 			assert.Equal(t, tc.wantedReloPath, cb.RelocationFilePath, "didn't get expected path for store")
 
@@ -224,7 +224,7 @@ func TestStoreManifest(t *testing.T) {
 			cfg.TestContext.AddTestDirectory("testdata", cacheDir)
 			c := New(cfg.Config)
 
-			cb, err := c.StoreBundle(tc.tag, tc.bundle, nil)
+			cb, err := c.StoreBundle(tc.tag, "", tc.bundle, nil)
 			require.NoError(t, err, "StoreBundle failed")
 
 			cachedManifestExists, _ := cfg.FileSystem.Exists(cb.BuildManifestPath())
@@ -258,7 +258,7 @@ func TestCache_StoreBundle_Overwrite(t *testing.T) {
 	cfg.FileSystem.Create(junkPath)
 
 	// Refresh the cache
-	cb, err := c.StoreBundle(cb.Tag, bundle.Bundle{}, nil)
+	cb, err := c.StoreBundle(cb.Tag, "", bundle.Bundle{}, nil)
 	require.NoError(t, err, "StoreBundle failed")
 
 	exists, _ := cfg.FileSystem.Exists(cb.BuildBundlePath())
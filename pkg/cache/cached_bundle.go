@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"path/filepath"
+	"time"
 
 	"get.porter.sh/porter/pkg/cnab"
 	"get.porter.sh/porter/pkg/config"
@@ -24,6 +25,16 @@ type CachedBundle struct {
 	// Tag of the cached bundle.
 	Tag string
 
+	// Digest of the bundle manifest in the registry. Bundles with a digest
+	// are cached by their repository and digest, otherwise they are cached
+	// by their tag.
+	Digest string
+
+	// Checked is when the registry last confirmed that the tag referenced
+	// the digest. It is not set when the bundle isn't cached by its digest, or
+	// was referenced by its digest.
+	Checked time.Time
+
 	// Bundle is the cached bundle definition.
 	Bundle bundle.Bundle
 
@@ -47,6 +58,10 @@ type CachedBundle struct {
 
 // GetBundleID is the unique ID of the cached bundle.
 func (cb *CachedBundle) GetBundleID() string {
+	if cb.Digest != "" {
+		return getDigestID(getReferenceRepository(cb.Tag), cb.Digest)
+	}
+
 	// hash the tag, tags have characters that won't work as part of a path
 	// so hashing here to get a path friendly name
 	bid := md5.Sum([]byte(cb.Tag))
@@ -73,6 +88,11 @@ func (cb *CachedBundle) BuildManifestPath() string {
 	return filepath.Join(cb.cacheDir, config.Name)
 }
 
+// BuildMetadataPath generates the location of the cache metadata for the bundle.
+func (cb *CachedBundle) BuildMetadataPath() string {
+	return filepath.Join(cb.cacheDir, "metadata.json")
+}
+
 // Load starts from the bundle tag, and hydrates the cached bundle from the cache.
 func (cb *CachedBundle) Load(cxt *context.Context) (bool, error) {
 	// Check that the bundle exists
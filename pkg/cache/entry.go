@@ -0,0 +1,298 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Entry describes a bundle in the cache.
+type Entry struct {
+	// ID of the bundle in the cache, which is also the name of its cache directory.
+	ID string `json:"id" yaml:"id"`
+
+	// Tags that resolve to the cached bundle. Bundles cached by older versions
+	// of Porter did not record their tag and it is empty.
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Repository of a bundle that is cached by its digest.
+	Repository string `json:"repository,omitempty" yaml:"repository,omitempty"`
+
+	// Digest of the bundle manifest in the registry, or of the cached
+	// bundle.json when the bundle isn't cached by its digest.
+	Digest string `json:"digest" yaml:"digest"`
+
+	// Size of all the cached files for the bundle in bytes.
+	Size int64 `json:"size" yaml:"size"`
+
+	// Created is when the bundle was cached.
+	Created time.Time `json:"created" yaml:"created"`
+
+	// LastUsed is when the bundle was last found in the cache.
+	LastUsed time.Time `json:"lastUsed" yaml:"lastUsed"`
+}
+
+// entryMetadata is stored alongside a cached bundle to track how it is used.
+type entryMetadata struct {
+	// Tag of a bundle that is cached by its tag.
+	Tag string `json:"tag,omitempty"`
+
+	// Repository of a bundle that is cached by its digest.
+	Repository string `json:"repository,omitempty"`
+
+	// Digest of a bundle that is cached by its digest.
+	Digest string `json:"digest,omitempty"`
+
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// newEntryMetadata identifies a cached bundle in its metadata. Bundles cached
+// by digest don't record their tag, because the tag may move to another
+// digest, and the tag index is used instead.
+func newEntryMetadata(cb CachedBundle) entryMetadata {
+	if cb.Digest == "" {
+		return entryMetadata{Tag: cb.Tag}
+	}
+	return entryMetadata{Repository: getReferenceRepository(cb.Tag), Digest: cb.Digest}
+}
+
+// ListBundles returns the bundles in the cache, starting with the most recently used.
+func (c *Cache) ListBundles() ([]Entry, error) {
+	cacheDir, err := c.GetCacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := c.FileSystem.DirExists(cacheDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the cache directory %s", cacheDir)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	dirs, err := c.FileSystem.ReadDir(cacheDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the cache directory %s", cacheDir)
+	}
+
+	tags, err := c.listTagIndex(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	tagsByID := make(map[string][]string, len(tags))
+	for _, idx := range tags {
+		id := getDigestID(getReferenceRepository(idx.Tag), idx.Digest)
+		tagsByID[id] = append(tagsByID[id], idx.Tag)
+	}
+
+	entries := make([]Entry, 0, len(dirs))
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+
+		entry, found, err := c.loadEntry(cacheDir, dir.Name())
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			entry.Tags = append(entry.Tags, tagsByID[entry.ID]...)
+			sort.Strings(entry.Tags)
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// loadEntry reads the metadata for a cached bundle. Directories in the cache
+// that don't contain a bundle are ignored.
+func (c *Cache) loadEntry(cacheDir string, id string) (Entry, bool, error) {
+	cb := CachedBundle{cacheDir: filepath.Join(cacheDir, id)}
+	bundlePath := cb.BuildBundlePath()
+	bundleInfo, err := c.FileSystem.Stat(bundlePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Entry{}, false, nil
+		}
+		return Entry{}, false, errors.Wrapf(err, "unable to read cached bundle %s", bundlePath)
+	}
+
+	entry := Entry{ID: id}
+
+	metadataPath := cb.BuildMetadataPath()
+	data, err := c.FileSystem.ReadFile(metadataPath)
+	if err == nil {
+		var metadata entryMetadata
+		if err = json.Unmarshal(data, &metadata); err != nil {
+			return Entry{}, false, errors.Wrapf(err, "unable to parse cache metadata %s", metadataPath)
+		}
+		if metadata.Tag != "" {
+			entry.Tags = []string{metadata.Tag}
+		}
+		entry.Repository = metadata.Repository
+		entry.Digest = metadata.Digest
+		entry.Created = metadata.Created
+		entry.LastUsed = metadata.LastUsed
+	} else if os.IsNotExist(err) {
+		// Bundles cached by older versions of Porter don't have metadata
+		entry.Created = bundleInfo.ModTime()
+		entry.LastUsed = bundleInfo.ModTime()
+	} else {
+		return Entry{}, false, errors.Wrapf(err, "unable to read cache metadata %s", metadataPath)
+	}
+
+	if entry.Digest == "" {
+		bundleData, err := c.FileSystem.ReadFile(bundlePath)
+		if err != nil {
+			return Entry{}, false, errors.Wrapf(err, "unable to read cached bundle %s", bundlePath)
+		}
+		digest := sha256.Sum256(bundleData)
+		entry.Digest = "sha256:" + hex.EncodeToString(digest[:])
+	}
+
+	err = c.FileSystem.Walk(cb.cacheDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			entry.Size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return Entry{}, false, errors.Wrapf(err, "unable to determine the size of cached bundle %s", cb.cacheDir)
+	}
+
+	return entry, true, nil
+}
+
+// RemoveBundle removes a bundle, identified by its cache ID, from the cache,
+// along with any tags that resolve to it.
+func (c *Cache) RemoveBundle(id string) error {
+	cacheDir, err := c.GetCacheDir()
+	if err != nil {
+		return err
+	}
+
+	// Don't let a bad ID remove anything outside of the cache
+	if id == "" || filepath.Base(id) != id || id == "." || id == ".." {
+		return errors.Errorf("invalid cache id %q", id)
+	}
+
+	tags, err := c.listTagIndex(cacheDir)
+	if err != nil {
+		return err
+	}
+	for _, idx := range tags {
+		if getDigestID(getReferenceRepository(idx.Tag), idx.Digest) != id {
+			continue
+		}
+		if err = c.removeTagIndex(cacheDir, idx.Tag); err != nil {
+			return err
+		}
+	}
+
+	bundleDir := filepath.Join(cacheDir, id)
+	err = c.FileSystem.RemoveAll(bundleDir)
+	return errors.Wrapf(err, "unable to remove cached bundle %s", bundleDir)
+}
+
+// writeMetadata records the tag of a cached bundle and when it was used.
+func (c *Cache) writeMetadata(cb CachedBundle, metadata entryMetadata) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return errors.Wrapf(err, "unable to marshal cache metadata for %s", cb.Tag)
+	}
+
+	metadataPath := cb.BuildMetadataPath()
+	err = c.FileSystem.WriteFile(metadataPath, data, 0644)
+	return errors.Wrapf(err, "unable to write cache metadata %s", metadataPath)
+}
+
+// touch updates when a cached bundle was last used, which determines the
+// order that bundles are evicted when the cache is full.
+func (c *Cache) touch(cb CachedBundle) error {
+	metadata := newEntryMetadata(cb)
+
+	metadataPath := cb.BuildMetadataPath()
+	data, err := c.FileSystem.ReadFile(metadataPath)
+	if err == nil {
+		if err = json.Unmarshal(data, &metadata); err != nil {
+			return errors.Wrapf(err, "unable to parse cache metadata %s", metadataPath)
+		}
+	} else if !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to read cache metadata %s", metadataPath)
+	}
+
+	metadata.LastUsed = time.Now()
+	if metadata.Created.IsZero() {
+		metadata.Created = metadata.LastUsed
+	}
+	return c.writeMetadata(cb, metadata)
+}
+
+// evict removes the least recently used bundles until the cache fits within
+// the configured cache size. The bundle that was just stored is never evicted.
+func (c *Cache) evict(keep CachedBundle) error {
+	maxSize, err := c.Data.GetCacheSize()
+	if err != nil {
+		return err
+	}
+	if maxSize == 0 {
+		return nil
+	}
+
+	entries, err := c.ListBundles()
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, entry := range entries {
+		size += entry.Size
+	}
+
+	keepID := keep.GetBundleID()
+	for i := len(entries) - 1; i >= 0 && size > maxSize; i-- {
+		entry := entries[i]
+		if entry.ID == keepID {
+			continue
+		}
+
+		if err = c.RemoveBundle(entry.ID); err != nil {
+			return err
+		}
+		size -= entry.Size
+		if c.Debug {
+			fmt.Fprintf(c.Err, "Evicted %s from the bundle cache\n", entry.DisplayName())
+		}
+	}
+
+	return nil
+}
+
+// DisplayName identifies the cached bundle by its tags, falling back to its
+// digest, or its ID for bundles that were cached without a tag.
+func (e Entry) DisplayName() string {
+	if len(e.Tags) > 0 {
+		return strings.Join(e.Tags, ", ")
+	}
+	if e.Repository != "" {
+		return e.Repository + "@" + e.Digest
+	}
+	return e.ID
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"get.porter.sh/porter/pkg/config"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storeTestBundle caches a bundle and then sets when it was last used.
+func storeTestBundle(t *testing.T, c *Cache, tag string, lastUsed time.Time) CachedBundle {
+	cb, err := c.StoreBundle(tag, "", bundle.Bundle{Name: "kubekahn", Version: "1.0.0"}, nil)
+	require.NoError(t, err, "StoreBundle failed")
+	require.NoError(t, c.writeMetadata(cb, entryMetadata{Tag: tag, Created: lastUsed, LastUsed: lastUsed}))
+	return cb
+}
+
+func TestCache_ListBundles(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.NewTestConfig(t)
+	home, err := cfg.Config.GetHomeDir()
+	require.NoError(t, err, "should have had a porter home dir")
+	cfg.TestContext.AddTestDirectory("testdata", filepath.Join(home, "cache"))
+	c := New(cfg.Config).(*Cache)
+
+	now := time.Now()
+	legacyBundle := filepath.Join(home, "cache", kahn1dot0Hash, "cnab", "bundle.json")
+	require.NoError(t, cfg.FileSystem.Chtimes(legacyBundle, now.Add(-2*time.Hour), now.Add(-2*time.Hour)))
+	storeTestBundle(t, c, kahnlatest, now.Add(-time.Hour))
+
+	entries, err := c.ListBundles()
+	require.NoError(t, err, "ListBundles failed")
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, kahnlatestHash, entries[0].ID)
+	assert.Equal(t, []string{kahnlatest}, entries[0].Tags)
+	assert.Contains(t, entries[0].Digest, "sha256:")
+	assert.NotZero(t, entries[0].Size)
+	assert.True(t, now.Add(-time.Hour).Equal(entries[0].LastUsed), "LastUsed should come from the cache metadata")
+
+	// The test data was cached without metadata, like older versions of Porter did
+	assert.Equal(t, kahn1dot0Hash, entries[1].ID)
+	assert.Empty(t, entries[1].Tags)
+	assert.True(t, now.Add(-2*time.Hour).Equal(entries[1].LastUsed), "LastUsed should default to when the bundle was cached")
+	assert.NotZero(t, entries[1].Size)
+
+	_, found, err := c.FindBundle(kahn1dot01)
+	require.NoError(t, err, "FindBundle failed")
+	require.True(t, found, "the bundle should be cached")
+
+	entries, err = c.ListBundles()
+	require.NoError(t, err, "ListBundles failed")
+	require.Len(t, entries, 2)
+	assert.Equal(t, []string{kahn1dot01}, entries[0].Tags, "finding a bundle should record its tag and when it was used")
+	assert.WithinDuration(t, time.Now(), entries[0].LastUsed, time.Minute)
+}
+
+func TestCache_ListBundles_NoCacheDir(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.NewTestConfig(t)
+	c := New(cfg.Config)
+
+	entries, err := c.ListBundles()
+	require.NoError(t, err, "a missing cache directory should not be an error")
+	assert.Empty(t, entries)
+}
+
+func TestCache_RemoveBundle(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.NewTestConfig(t)
+	c := New(cfg.Config).(*Cache)
+	cb := storeTestBundle(t, c, kahnlatest, time.Now())
+
+	require.NoError(t, c.RemoveBundle(cb.GetBundleID()), "RemoveBundle failed")
+	_, found, err := c.FindBundle(kahnlatest)
+	require.NoError(t, err, "FindBundle failed")
+	assert.False(t, found, "the bundle should have been removed from the cache")
+
+	err = c.RemoveBundle("../..")
+	require.EqualError(t, err, `invalid cache id "../.."`)
+}
+
+func TestCache_StoreBundle_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.NewTestConfig(t)
+	c := New(cfg.Config).(*Cache)
+
+	now := time.Now()
+	storeTestBundle(t, c, "deislabs/kubekahn:1.0", now.Add(-time.Hour))
+	storeTestBundle(t, c, "deislabs/kubekahn:1.1", now.Add(-2*time.Hour))
+	storeTestBundle(t, c, "deislabs/kubekahn:1.2", now)
+
+	entries, err := c.ListBundles()
+	require.NoError(t, err, "ListBundles failed")
+	require.Len(t, entries, 3)
+
+	// Only leave room for two bundles, with some slack because the size of the metadata varies
+	cfg.Data = &config.Data{CacheSize: fmt.Sprint(2*entries[0].Size + entries[0].Size/2)}
+	_, err = c.StoreBundle("deislabs/kubekahn:1.3", "", bundle.Bundle{Name: "kubekahn", Version: "1.0.0"}, nil)
+	require.NoError(t, err, "StoreBundle failed")
+
+	entries, err = c.ListBundles()
+	require.NoError(t, err, "ListBundles failed")
+	var tags []string
+	for _, entry := range entries {
+		tags = append(tags, entry.Tags...)
+	}
+	assert.Equal(t, []string{"deislabs/kubekahn:1.3", "deislabs/kubekahn:1.2"}, tags)
+}
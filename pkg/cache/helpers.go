@@ -11,7 +11,7 @@ var _ BundleCache = &TestCache{}
 type TestCache struct {
 	cache           BundleCache
 	FindBundleMock  func(string) (CachedBundle, bool, error)
-	StoreBundleMock func(string, bundle.Bundle, *relocation.ImageRelocationMap) (CachedBundle, error)
+	StoreBundleMock func(string, string, bundle.Bundle, *relocation.ImageRelocationMap) (CachedBundle, error)
 }
 
 func NewTestCache(cache BundleCache) *TestCache {
@@ -27,13 +27,25 @@ func (c *TestCache) FindBundle(tag string) (CachedBundle, bool, error) {
 	return c.cache.FindBundle(tag)
 }
 
-func (c *TestCache) StoreBundle(tag string, bun bundle.Bundle, reloMap *relocation.ImageRelocationMap) (CachedBundle, error) {
+func (c *TestCache) StoreBundle(tag string, digest string, bun bundle.Bundle, reloMap *relocation.ImageRelocationMap) (CachedBundle, error) {
 	if c.StoreBundleMock != nil {
-		return c.StoreBundleMock(tag, bun, reloMap)
+		return c.StoreBundleMock(tag, digest, bun, reloMap)
 	}
-	return c.cache.StoreBundle(tag, bun, reloMap)
+	return c.cache.StoreBundle(tag, digest, bun, reloMap)
+}
+
+func (c *TestCache) TagBundle(tag string, digest string) error {
+	return c.cache.TagBundle(tag, digest)
 }
 
 func (c *TestCache) GetCacheDir() (string, error) {
 	return c.cache.GetCacheDir()
 }
+
+func (c *TestCache) ListBundles() ([]Entry, error) {
+	return c.cache.ListBundles()
+}
+
+func (c *TestCache) RemoveBundle(id string) error {
+	return c.cache.RemoveBundle(id)
+}
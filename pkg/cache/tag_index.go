@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
+)
+
+// tagIndex records the digest of the bundle that a tag referenced the last
+// time that the tag was checked against the registry.
+type tagIndex struct {
+	// Tag of the bundle, as it was specified when the bundle was cached.
+	Tag string `json:"tag"`
+
+	// Digest of the bundle manifest that the tag referenced.
+	Digest string `json:"digest"`
+
+	// Checked is when the registry confirmed that the tag referenced the digest.
+	Checked time.Time `json:"checked"`
+}
+
+// getTagIndexDir is the directory in the cache that holds the tag index.
+func getTagIndexDir(cacheDir string) string {
+	return filepath.Join(cacheDir, "tags")
+}
+
+// getTagIndexPath is the location of the index file for a tag.
+func getTagIndexPath(cacheDir string, tag string) string {
+	cb := CachedBundle{Tag: tag}
+	return filepath.Join(getTagIndexDir(cacheDir), cb.GetBundleID()+".json")
+}
+
+// getDigestID is the cache ID of a bundle that is cached by its digest.
+// The repository is part of the ID because the same bundle may be pushed to
+// different repositories, and its relocation mapping is specific to the
+// repository that it was pulled from.
+func getDigestID(repository string, digest string) string {
+	// hash the digest reference, it has characters that won't work as part of
+	// a path, such as the colon in the digest on Windows
+	id := md5.Sum([]byte(repository + "@" + digest))
+	return hex.EncodeToString(id[:])
+}
+
+// getReferenceDigest returns the digest from a bundle reference that is
+// pinned to a digest, such as getporter/porter-hello@sha256:abc123,
+// otherwise an empty string is returned.
+func getReferenceDigest(ref string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ""
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		return digested.Digest().String()
+	}
+	return ""
+}
+
+// getReferenceRepository returns the repository from a bundle reference,
+// without the tag or digest.
+func getReferenceRepository(ref string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ""
+	}
+	return reference.FamiliarName(named)
+}
+
+func (c *Cache) readTagIndex(cacheDir string, tag string) (tagIndex, bool, error) {
+	indexPath := getTagIndexPath(cacheDir, tag)
+	data, err := c.FileSystem.ReadFile(indexPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tagIndex{}, false, nil
+		}
+		return tagIndex{}, false, errors.Wrapf(err, "unable to read the cache index for %s at %s", tag, indexPath)
+	}
+
+	var idx tagIndex
+	if err = json.Unmarshal(data, &idx); err != nil {
+		return tagIndex{}, false, errors.Wrapf(err, "unable to parse the cache index for %s at %s", tag, indexPath)
+	}
+	return idx, true, nil
+}
+
+func (c *Cache) writeTagIndex(cacheDir string, idx tagIndex) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return errors.Wrapf(err, "unable to marshal the cache index for %s", idx.Tag)
+	}
+
+	err = c.FileSystem.MkdirAll(getTagIndexDir(cacheDir), os.ModePerm)
+	if err != nil {
+		return errors.Wrap(err, "unable to create the cache index directory")
+	}
+
+	indexPath := getTagIndexPath(cacheDir, idx.Tag)
+	err = c.FileSystem.WriteFile(indexPath, data, 0644)
+	return errors.Wrapf(err, "unable to write the cache index for %s at %s", idx.Tag, indexPath)
+}
+
+func (c *Cache) removeTagIndex(cacheDir string, tag string) error {
+	indexPath := getTagIndexPath(cacheDir, tag)
+	err := c.FileSystem.Remove(indexPath)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "unable to remove the cache index for %s at %s", tag, indexPath)
+	}
+	return nil
+}
+
+// listTagIndex returns every tag in the tag index.
+func (c *Cache) listTagIndex(cacheDir string) ([]tagIndex, error) {
+	indexDir := getTagIndexDir(cacheDir)
+	exists, err := c.FileSystem.DirExists(indexDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the cache index directory %s", indexDir)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	files, err := c.FileSystem.ReadDir(indexDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the cache index directory %s", indexDir)
+	}
+
+	tags := make([]tagIndex, 0, len(files))
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+
+		indexPath := filepath.Join(indexDir, f.Name())
+		data, err := c.FileSystem.ReadFile(indexPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read the cache index %s", indexPath)
+		}
+
+		var idx tagIndex
+		if err = json.Unmarshal(data, &idx); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse the cache index %s", indexPath)
+		}
+		tags = append(tags, idx)
+	}
+	return tags, nil
+}
+
+// TagBundle records that the registry confirmed that a tag references the
+// bundle with the specified digest, which must already be cached.
+func (c *Cache) TagBundle(tag string, digest string) error {
+	cacheDir, err := c.GetCacheDir()
+	if err != nil {
+		return err
+	}
+
+	err = c.writeTagIndex(cacheDir, tagIndex{Tag: tag, Digest: digest, Checked: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	// The tag now resolves through the index, remove any copy of the bundle
+	// that was cached by its tag by older versions of Porter
+	legacy := CachedBundle{Tag: tag}
+	legacy.SetCacheDir(cacheDir)
+	err = c.FileSystem.RemoveAll(legacy.cacheDir)
+	return errors.Wrapf(err, "unable to remove the cached bundle %s", legacy.cacheDir)
+}
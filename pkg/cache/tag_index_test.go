@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"get.porter.sh/porter/pkg/config"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-to-oci/relocation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	kahnDigest1 = "sha256:887e7e65e39277f8744bd00278760b06887e7e65e39277f8744bd00278760b06"
+	kahnDigest2 = "sha256:fd4bbe38665531d10bb653140842a370fd4bbe38665531d10bb653140842a370"
+)
+
+func TestCache_StoreBundle_ByDigest(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.NewTestConfig(t)
+	c := New(cfg.Config).(*Cache)
+
+	// Cache the bundle by its tag, like older versions of Porter
+	legacy, err := c.StoreBundle(kahnlatest, "", bundle.Bundle{Name: "kubekahn", Version: "1.0.0"}, nil)
+	require.NoError(t, err, "StoreBundle failed")
+	assert.Equal(t, kahnlatestHash, legacy.GetBundleID())
+
+	cb, err := c.StoreBundle(kahnlatest, kahnDigest1, bundle.Bundle{Name: "kubekahn", Version: "1.0.0"}, nil)
+	require.NoError(t, err, "StoreBundle failed")
+	assert.Equal(t, getDigestID("deislabs/kubekahn", kahnDigest1), cb.GetBundleID())
+
+	exists, err := cfg.FileSystem.Exists(legacy.BuildBundlePath())
+	require.NoError(t, err)
+	assert.False(t, exists, "the bundle cached by tag should have been removed")
+
+	found, ok, err := c.FindBundle(kahnlatest)
+	require.NoError(t, err, "FindBundle failed")
+	require.True(t, ok, "the tag should resolve to the bundle cached by digest")
+	assert.Equal(t, kahnDigest1, found.Digest)
+	assert.WithinDuration(t, time.Now(), found.Checked, time.Minute)
+	assert.Equal(t, cb.BundlePath, found.BundlePath)
+
+	found, ok, err = c.FindBundle("deislabs/kubekahn@" + kahnDigest1)
+	require.NoError(t, err, "FindBundle failed")
+	require.True(t, ok, "the digest reference should resolve to the cached bundle")
+	assert.Equal(t, kahnDigest1, found.Digest)
+	assert.True(t, found.Checked.IsZero(), "a digest reference should not have a checked time")
+}
+
+func TestCache_TagBundle(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.NewTestConfig(t)
+	c := New(cfg.Config).(*Cache)
+
+	_, err := c.StoreBundle("deislabs/kubekahn:1.0", kahnDigest1, bundle.Bundle{Name: "kubekahn", Version: "1.0.0"}, nil)
+	require.NoError(t, err, "StoreBundle failed")
+	_, err = c.StoreBundle(kahnlatest, kahnDigest2, bundle.Bundle{Name: "kubekahn", Version: "2.0.0"}, nil)
+	require.NoError(t, err, "StoreBundle failed")
+
+	// Move latest back to 1.0
+	require.NoError(t, c.TagBundle(kahnlatest, kahnDigest1), "TagBundle failed")
+	found, ok, err := c.FindBundle(kahnlatest)
+	require.NoError(t, err, "FindBundle failed")
+	require.True(t, ok, "the tag should resolve to the bundle")
+	assert.Equal(t, "1.0.0", found.Bundle.Version)
+
+	entries, err := c.ListBundles()
+	require.NoError(t, err, "ListBundles failed")
+	require.Len(t, entries, 2)
+	byDigest := map[string]Entry{}
+	for _, e := range entries {
+		byDigest[e.Digest] = e
+	}
+	assert.Equal(t, []string{"deislabs/kubekahn:1.0", kahnlatest}, byDigest[kahnDigest1].Tags)
+	assert.Empty(t, byDigest[kahnDigest2].Tags, "no tags should resolve to the old bundle")
+	assert.Equal(t, "deislabs/kubekahn@"+kahnDigest2, byDigest[kahnDigest2].DisplayName())
+
+	require.NoError(t, c.RemoveBundle(byDigest[kahnDigest1].ID), "RemoveBundle failed")
+	_, ok, err = c.FindBundle(kahnlatest)
+	require.NoError(t, err, "FindBundle failed")
+	assert.False(t, ok, "the tags should be removed with the bundle")
+
+	cacheDir, err := c.GetCacheDir()
+	require.NoError(t, err)
+	tags, err := c.listTagIndex(cacheDir)
+	require.NoError(t, err)
+	assert.Empty(t, tags, "the tag index should be empty")
+}
+
+func TestCache_StoreBundle_SameDigestDifferentRepository(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.NewTestConfig(t)
+	c := New(cfg.Config).(*Cache)
+
+	bun := bundle.Bundle{Name: "kubekahn", Version: "1.0.0"}
+	origReloMap := relocation.ImageRelocationMap{"deislabs/kubekahn-installer:1.0": "deislabs/kubekahn@sha256:abc123"}
+	copyReloMap := relocation.ImageRelocationMap{"deislabs/kubekahn-installer:1.0": "localhost:5000/kubekahn@sha256:abc123"}
+	orig, err := c.StoreBundle(kahnlatest, kahnDigest1, bun, &origReloMap)
+	require.NoError(t, err, "StoreBundle failed")
+	copied, err := c.StoreBundle("localhost:5000/kubekahn:latest", kahnDigest1, bun, &copyReloMap)
+	require.NoError(t, err, "StoreBundle failed")
+	assert.NotEqual(t, orig.GetBundleID(), copied.GetBundleID(), "bundles from different repositories should be cached separately")
+
+	found, ok, err := c.FindBundle(kahnlatest)
+	require.NoError(t, err, "FindBundle failed")
+	require.True(t, ok, "the original bundle should still be cached")
+	assert.Equal(t, origReloMap, *found.RelocationMap, "the relocation mapping for the original repository should be used")
+
+	found, ok, err = c.FindBundle("localhost:5000/kubekahn@" + kahnDigest1)
+	require.NoError(t, err, "FindBundle failed")
+	require.True(t, ok, "the copied bundle should be cached")
+	assert.Equal(t, copyReloMap, *found.RelocationMap, "the relocation mapping for the copied repository should be used")
+
+	entries, err := c.ListBundles()
+	require.NoError(t, err, "ListBundles failed")
+	assert.Len(t, entries, 2)
+}
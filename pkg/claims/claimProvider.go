@@ -0,0 +1,30 @@
+package claims
+
+import (
+	"time"
+
+	"github.com/cnabio/cnab-go/claim"
+)
+
+// ClaimProvider interface for managing claims and coordinating changes to
+// an installation.
+type ClaimProvider interface {
+	claim.Provider
+
+	// LockInstallation acquires an advisory lock on an installation, preventing
+	// anyone else from modifying its claims until the lock is released or expires.
+	LockInstallation(installation string, owner string, duration time.Duration) (InstallationLock, error)
+
+	// RenewInstallationLock extends a lock on an installation acquired with
+	// LockInstallation, so that it doesn't expire while the action is running.
+	RenewInstallationLock(installation string, duration time.Duration) (InstallationLock, error)
+
+	// UnlockInstallation releases a lock on an installation acquired with LockInstallation.
+	UnlockInstallation(installation string) error
+
+	// ForceUnlockInstallation removes the lock on an installation, regardless of who holds it.
+	ForceUnlockInstallation(installation string) error
+
+	// ReadInstallationLock returns the current lock on an installation.
+	ReadInstallationLock(installation string) (InstallationLock, error)
+}
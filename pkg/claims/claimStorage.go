@@ -1,23 +1,41 @@
 package claims
 
 import (
+	"sync"
+
 	"get.porter.sh/porter/pkg/config"
 	"get.porter.sh/porter/pkg/storage"
 	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/utils/crud"
 )
 
-var _ claim.Provider = &ClaimStorage{}
+var _ ClaimProvider = &ClaimStorage{}
 
 // ClaimStorage provides access to backing claim storage by instantiating
 // plugins that implement claim (CRUD) storage.
 type ClaimStorage struct {
 	*config.Config
 	claim.Store
+
+	// locks is the datastore where installation locks are saved.
+	locks crud.ManagedStore
+
+	// heldLocks maps an installation to the id of the lock held by this process.
+	heldLocks map[string]string
+
+	// locksMu serializes access to heldLocks.
+	locksMu sync.Mutex
 }
 
 func NewClaimStorage(storage *storage.Manager) *ClaimStorage {
+	return newClaimStorage(storage.Config, storage)
+}
+
+func newClaimStorage(c *config.Config, store crud.ManagedStore) *ClaimStorage {
 	return &ClaimStorage{
-		Config: storage.Config,
-		Store:  claim.NewClaimStore(storage, nil, nil),
+		Config:    c,
+		Store:     claim.NewClaimStore(store, nil, nil),
+		locks:     store,
+		heldLocks: make(map[string]string),
 	}
 }
@@ -2,23 +2,25 @@ package claims
 
 import (
 	"testing"
+	"time"
 
 	"github.com/cnabio/cnab-go/bundle"
 	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/utils/crud"
 	"github.com/stretchr/testify/require"
 )
 
-var _ claim.Provider = TestClaimProvider{}
+var _ ClaimProvider = TestClaimProvider{}
 
 type TestClaimProvider struct {
-	claim.Store
+	*ClaimStorage
 	t *testing.T
 }
 
 func NewTestClaimProvider(t *testing.T) TestClaimProvider {
 	return TestClaimProvider{
-		t:     t,
-		Store: claim.NewMockStore(nil, nil),
+		t:            t,
+		ClaimStorage: newClaimStorage(nil, crud.NewBackingStore(crud.NewMockStore())),
 	}
 }
 
@@ -47,3 +49,12 @@ func (p TestClaimProvider) CreateOutput(c claim.Claim, r claim.Result, name stri
 	require.NoError(p.t, err, "SaveOutput failed")
 	return o
 }
+
+// CreateLock locks an installation on behalf of someone else, as if another
+// process held the lock.
+func (p TestClaimProvider) CreateLock(installation string, owner string, duration time.Duration) InstallationLock {
+	other := newClaimStorage(nil, p.locks)
+	lock, err := other.LockInstallation(installation, owner, duration)
+	require.NoError(p.t, err, "LockInstallation failed")
+	return lock
+}
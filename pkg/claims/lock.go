@@ -0,0 +1,289 @@
+package claims
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/pkg/errors"
+)
+
+// ItemTypeLocks is the item type used to store installation locks.
+const ItemTypeLocks = "locks"
+
+// InstallationLock is an advisory lock on an installation, which prevents
+// concurrent actions from modifying the installation at the same time.
+type InstallationLock struct {
+	// ID uniquely identifies the lock, so that a lock that was replaced after
+	// it expired is not released by its original holder.
+	ID string `json:"id"`
+
+	// Installation that is locked.
+	Installation string `json:"installation"`
+
+	// Owner describes who holds the lock, for example USER@HOST.
+	Owner string `json:"owner"`
+
+	// Acquired is when the lock was acquired.
+	Acquired time.Time `json:"acquired"`
+
+	// Expires is when the lock is no longer honored, so that an action that
+	// was interrupted doesn't lock the installation forever.
+	Expires time.Time `json:"expires"`
+}
+
+// IsExpired determines if the lock is no longer honored.
+func (l InstallationLock) IsExpired() bool {
+	return !time.Now().Before(l.Expires)
+}
+
+// ErrInstallationLocked is returned when an installation is locked by someone else.
+type ErrInstallationLocked struct {
+	Lock InstallationLock
+}
+
+func (e ErrInstallationLocked) Error() string {
+	return fmt.Sprintf("installation %s is locked by %s until %s. If the lock is no longer needed, use --force-unlock to remove it",
+		e.Lock.Installation, e.Lock.Owner, e.Lock.Expires.Format(time.RFC3339))
+}
+
+// IsInstallationLocked determines if the error is because the installation is locked by someone else.
+func IsInstallationLocked(err error) bool {
+	_, ok := errors.Cause(err).(ErrInstallationLocked)
+	return ok
+}
+
+// ErrLockNotFound is returned when an installation is not locked.
+var ErrLockNotFound = errors.New("installation is not locked")
+
+func (s *ClaimStorage) LockInstallation(installation string, owner string, duration time.Duration) (InstallationLock, error) {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+
+	existing, err := s.ReadInstallationLock(installation)
+	if err != nil && err != ErrLockNotFound {
+		return InstallationLock{}, err
+	}
+	if err == nil && !existing.IsExpired() && existing.ID != s.heldLocks[installation] {
+		return InstallationLock{}, ErrInstallationLocked{Lock: existing}
+	}
+
+	id, err := claim.NewULID()
+	if err != nil {
+		return InstallationLock{}, errors.Wrap(err, "could not generate a lock id")
+	}
+	now := time.Now()
+	lock := InstallationLock{
+		ID:           id,
+		Installation: installation,
+		Owner:        owner,
+		Acquired:     now,
+		Expires:      now.Add(duration),
+	}
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return InstallationLock{}, errors.Wrapf(err, "could not marshal the lock for installation %s", installation)
+	}
+	err = s.locks.Save(ItemTypeLocks, "", installation, data)
+	if err != nil {
+		return InstallationLock{}, errors.Wrapf(err, "could not save the lock for installation %s", installation)
+	}
+
+	// The datastore doesn't support an atomic create, so read the lock back
+	// to detect when someone else acquired it at the same time
+	saved, err := s.ReadInstallationLock(installation)
+	if err != nil {
+		return InstallationLock{}, err
+	}
+	if saved.ID != lock.ID {
+		return InstallationLock{}, ErrInstallationLocked{Lock: saved}
+	}
+
+	s.heldLocks[installation] = lock.ID
+	return lock, nil
+}
+
+func (s *ClaimStorage) RenewInstallationLock(installation string, duration time.Duration) (InstallationLock, error) {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+
+	id, ok := s.heldLocks[installation]
+	if !ok {
+		return InstallationLock{}, errors.Errorf("cannot renew the lock for installation %s because it is not held", installation)
+	}
+
+	lock, err := s.ReadInstallationLock(installation)
+	if err != nil {
+		if err == ErrLockNotFound {
+			delete(s.heldLocks, installation)
+			return InstallationLock{}, errors.Errorf("cannot renew the lock for installation %s because it was removed", installation)
+		}
+		return InstallationLock{}, err
+	}
+
+	// Our lock expired and someone else has it now
+	if lock.ID != id {
+		delete(s.heldLocks, installation)
+		return InstallationLock{}, ErrInstallationLocked{Lock: lock}
+	}
+
+	lock.Expires = time.Now().Add(duration)
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return InstallationLock{}, errors.Wrapf(err, "could not marshal the lock for installation %s", installation)
+	}
+	err = s.locks.Save(ItemTypeLocks, "", installation, data)
+	return lock, errors.Wrapf(err, "could not save the lock for installation %s", installation)
+}
+
+func (s *ClaimStorage) UnlockInstallation(installation string) error {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+
+	id, ok := s.heldLocks[installation]
+	if !ok {
+		return nil
+	}
+	delete(s.heldLocks, installation)
+
+	existing, err := s.ReadInstallationLock(installation)
+	if err != nil {
+		if err == ErrLockNotFound {
+			return nil
+		}
+		return err
+	}
+
+	// Our lock expired and someone else has it now
+	if existing.ID != id {
+		return nil
+	}
+
+	return s.deleteLock(installation)
+}
+
+func (s *ClaimStorage) ForceUnlockInstallation(installation string) error {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+
+	delete(s.heldLocks, installation)
+
+	_, err := s.ReadInstallationLock(installation)
+	if err != nil {
+		if err == ErrLockNotFound {
+			return nil
+		}
+		return err
+	}
+
+	return s.deleteLock(installation)
+}
+
+func (s *ClaimStorage) ReadInstallationLock(installation string) (InstallationLock, error) {
+	data, err := s.locks.Read(ItemTypeLocks, installation)
+	if err != nil {
+		if strings.Contains(err.Error(), crud.ErrRecordDoesNotExist.Error()) {
+			return InstallationLock{}, ErrLockNotFound
+		}
+		return InstallationLock{}, errors.Wrapf(err, "could not read the lock for installation %s", installation)
+	}
+
+	var lock InstallationLock
+	err = json.Unmarshal(data, &lock)
+	return lock, errors.Wrapf(err, "could not unmarshal the lock for installation %s", installation)
+}
+
+func (s *ClaimStorage) deleteLock(installation string) error {
+	err := s.locks.Delete(ItemTypeLocks, installation)
+	return errors.Wrapf(err, "could not remove the lock for installation %s", installation)
+}
+
+// checkInstallationLock returns an error when the installation is locked by
+// someone else. Installations that are locked can be read but not modified.
+func (s *ClaimStorage) checkInstallationLock(installation string) error {
+	s.locksMu.Lock()
+	defer s.locksMu.Unlock()
+
+	lock, err := s.ReadInstallationLock(installation)
+	if err != nil {
+		if err == ErrLockNotFound {
+			return nil
+		}
+		return err
+	}
+
+	if lock.IsExpired() || lock.ID == s.heldLocks[installation] {
+		return nil
+	}
+	return ErrInstallationLocked{Lock: lock}
+}
+
+// checkClaimLock returns an error when the installation of the claim is
+// locked by someone else.
+func (s *ClaimStorage) checkClaimLock(claimID string) error {
+	c, err := s.Store.ReadClaim(claimID)
+	if err != nil {
+		// Let the operation report that the claim doesn't exist
+		return nil
+	}
+	return s.checkInstallationLock(c.Installation)
+}
+
+// checkResultLock returns an error when the installation of the result is
+// locked by someone else.
+func (s *ClaimStorage) checkResultLock(resultID string) error {
+	r, err := s.Store.ReadResult(resultID)
+	if err != nil {
+		// Let the operation report that the result doesn't exist
+		return nil
+	}
+	return s.checkClaimLock(r.ClaimID)
+}
+
+// SaveClaim persists the claim, unless the installation is locked by someone else.
+// Outputs don't record their installation and are saved by the same action
+// as the claim, so only claims and results are checked when they are saved.
+func (s *ClaimStorage) SaveClaim(c claim.Claim) error {
+	if err := s.checkInstallationLock(c.Installation); err != nil {
+		return err
+	}
+	return s.Store.SaveClaim(c)
+}
+
+func (s *ClaimStorage) SaveResult(r claim.Result) error {
+	if err := s.checkClaimLock(r.ClaimID); err != nil {
+		return err
+	}
+	return s.Store.SaveResult(r)
+}
+
+func (s *ClaimStorage) DeleteInstallation(installation string) error {
+	if err := s.checkInstallationLock(installation); err != nil {
+		return err
+	}
+	return s.Store.DeleteInstallation(installation)
+}
+
+func (s *ClaimStorage) DeleteClaim(claimID string) error {
+	if err := s.checkClaimLock(claimID); err != nil {
+		return err
+	}
+	return s.Store.DeleteClaim(claimID)
+}
+
+func (s *ClaimStorage) DeleteResult(resultID string) error {
+	if err := s.checkResultLock(resultID); err != nil {
+		return err
+	}
+	return s.Store.DeleteResult(resultID)
+}
+
+func (s *ClaimStorage) DeleteOutput(resultID string, outputName string) error {
+	if err := s.checkResultLock(resultID); err != nil {
+		return err
+	}
+	return s.Store.DeleteOutput(resultID, outputName)
+}
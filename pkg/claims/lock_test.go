@@ -0,0 +1,139 @@
+package claims
+
+import (
+	"testing"
+	"time"
+
+	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/storage"
+	"get.porter.sh/porter/pkg/storage/filesystem"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimStorage_LockInstallation(t *testing.T) {
+	t.Parallel()
+
+	config := config.NewTestConfig(t)
+	_, home := config.TestContext.UseFilesystem()
+	config.SetHomeDir(home)
+	defer config.TestContext.Cleanup()
+
+	dataStore := filesystem.NewStore(*config.Config, hclog.NewNullLogger())
+	mgr := storage.NewManager(config.Config, dataStore)
+
+	// Each claim storage represents a separate porter process
+	alice := NewClaimStorage(mgr)
+	bob := NewClaimStorage(mgr)
+
+	bun := bundle.Bundle{Name: "mybuns", Version: "1.0.0"}
+	c, err := claim.New("mybuns", claim.ActionInstall, bun, nil)
+	require.NoError(t, err)
+
+	lock, err := alice.LockInstallation("mybuns", "alice@localhost", time.Hour)
+	require.NoError(t, err, "LockInstallation failed")
+	assert.Equal(t, "alice@localhost", lock.Owner)
+
+	t.Run("lock holder can modify the installation", func(t *testing.T) {
+		require.NoError(t, alice.SaveClaim(c), "SaveClaim failed")
+		r, err := c.NewResult(claim.StatusSucceeded)
+		require.NoError(t, err)
+		require.NoError(t, alice.SaveResult(r), "SaveResult failed")
+	})
+
+	t.Run("others can read but not modify the installation", func(t *testing.T) {
+		_, err := bob.LockInstallation("mybuns", "bob@localhost", time.Hour)
+		require.Error(t, err)
+		assert.True(t, IsInstallationLocked(err), "expected a locked error, got %v", err)
+		assert.Contains(t, err.Error(), "installation mybuns is locked by alice@localhost")
+
+		_, err = bob.ReadLastClaim("mybuns")
+		require.NoError(t, err, "the claims should be readable while locked")
+
+		upgrade, err := c.NewClaim(claim.ActionUpgrade, bun, nil)
+		require.NoError(t, err)
+		err = bob.SaveClaim(upgrade)
+		assert.True(t, IsInstallationLocked(err), "SaveClaim should fail while locked, got %v", err)
+
+		err = bob.DeleteClaim(c.ID)
+		assert.True(t, IsInstallationLocked(err), "DeleteClaim should fail while locked, got %v", err)
+
+		err = bob.DeleteInstallation("mybuns")
+		assert.True(t, IsInstallationLocked(err), "DeleteInstallation should fail while locked, got %v", err)
+	})
+
+	t.Run("unlock", func(t *testing.T) {
+		require.NoError(t, alice.UnlockInstallation("mybuns"), "UnlockInstallation failed")
+
+		_, err := alice.ReadInstallationLock("mybuns")
+		assert.Equal(t, ErrLockNotFound, err, "the lock should be removed")
+
+		_, err = bob.LockInstallation("mybuns", "bob@localhost", time.Hour)
+		require.NoError(t, err, "the installation should be lockable after it is unlocked")
+	})
+
+	t.Run("previous holder cannot release a new lock", func(t *testing.T) {
+		require.NoError(t, alice.UnlockInstallation("mybuns"), "UnlockInstallation failed")
+
+		lock, err := alice.ReadInstallationLock("mybuns")
+		require.NoError(t, err, "the lock held by someone else should not be removed")
+		assert.Equal(t, "bob@localhost", lock.Owner)
+	})
+
+	t.Run("force unlock", func(t *testing.T) {
+		require.NoError(t, alice.ForceUnlockInstallation("mybuns"), "ForceUnlockInstallation failed")
+
+		_, err := alice.ReadInstallationLock("mybuns")
+		assert.Equal(t, ErrLockNotFound, err, "the lock should be removed")
+	})
+
+	t.Run("expired lock", func(t *testing.T) {
+		_, err := bob.LockInstallation("mybuns", "bob@localhost", -time.Minute)
+		require.NoError(t, err, "LockInstallation failed")
+
+		_, err = alice.LockInstallation("mybuns", "alice@localhost", time.Hour)
+		require.NoError(t, err, "an expired lock should not prevent locking the installation")
+	})
+}
+
+func TestClaimStorage_RenewInstallationLock(t *testing.T) {
+	t.Parallel()
+
+	config := config.NewTestConfig(t)
+	_, home := config.TestContext.UseFilesystem()
+	config.SetHomeDir(home)
+	defer config.TestContext.Cleanup()
+
+	dataStore := filesystem.NewStore(*config.Config, hclog.NewNullLogger())
+	mgr := storage.NewManager(config.Config, dataStore)
+	alice := NewClaimStorage(mgr)
+	bob := NewClaimStorage(mgr)
+
+	_, err := alice.RenewInstallationLock("mybuns", time.Hour)
+	require.EqualError(t, err, "cannot renew the lock for installation mybuns because it is not held")
+
+	lock, err := alice.LockInstallation("mybuns", "alice@localhost", time.Minute)
+	require.NoError(t, err, "LockInstallation failed")
+
+	renewed, err := alice.RenewInstallationLock("mybuns", time.Hour)
+	require.NoError(t, err, "RenewInstallationLock failed")
+	assert.Equal(t, lock.ID, renewed.ID, "the lock should be renewed, not replaced")
+	assert.True(t, renewed.Expires.After(lock.Expires), "the lock should expire later")
+
+	saved, err := bob.ReadInstallationLock("mybuns")
+	require.NoError(t, err)
+	assert.Equal(t, renewed.Expires.Unix(), saved.Expires.Unix(), "the renewed lock should be saved")
+
+	t.Run("lock taken by someone else", func(t *testing.T) {
+		_, err := alice.LockInstallation("mybuns", "alice@localhost", -time.Minute)
+		require.NoError(t, err)
+		_, err = bob.LockInstallation("mybuns", "bob@localhost", time.Hour)
+		require.NoError(t, err, "the expired lock should be replaced")
+
+		_, err = alice.RenewInstallationLock("mybuns", time.Hour)
+		assert.True(t, IsInstallationLocked(err), "the lock held by someone else should not be renewed, got %v", err)
+	})
+}
@@ -0,0 +1,112 @@
+package claims
+
+import (
+	"sort"
+	"time"
+
+	"get.porter.sh/porter/pkg/config"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/pkg/errors"
+)
+
+// RetentionPolicy determines which claims in the history of an installation are kept.
+type RetentionPolicy struct {
+	// KeepLast is the number of the most recent claims to keep. When 0, the
+	// number of claims is not limited.
+	KeepLast int
+
+	// KeepFor is how long claims are kept after they are created. When 0, the
+	// age of claims is not limited.
+	KeepFor time.Duration
+}
+
+// NewRetentionPolicy creates a retention policy from the claim-retention config.
+func NewRetentionPolicy(r config.ClaimRetention) RetentionPolicy {
+	return RetentionPolicy{
+		KeepLast: r.KeepLast,
+		KeepFor:  time.Duration(r.KeepDays) * 24 * time.Hour,
+	}
+}
+
+// IsEmpty determines if the policy keeps every claim.
+func (p RetentionPolicy) IsEmpty() bool {
+	return p.KeepLast <= 0 && p.KeepFor <= 0
+}
+
+// SelectPrunable returns the claims of an installation that are not kept by
+// the policy, oldest first. A claim is kept when it is one of the last claims
+// or is newer than KeepFor. The most recent claim, the most recent claim with
+// a successful result, and the claims in outputClaims, are always kept so that
+// the status, outputs and rollback claim of the installation are not lost.
+// The installation must be read with its results, e.g. with
+// claim.Provider.ReadInstallation, and outputClaims should be the claims
+// returned by ListLastOutputClaims.
+func (p RetentionPolicy) SelectPrunable(i claim.Installation, outputClaims map[string]bool, now time.Time) []claim.Claim {
+	if p.IsEmpty() || len(i.Claims) == 0 {
+		return nil
+	}
+
+	// The claims are sorted from oldest to newest
+	claims := i.Claims
+	lastIndex := len(claims) - 1
+	lastSuccessIndex := -1
+	for j := lastIndex; j >= 0; j-- {
+		if claims[j].GetStatus() == claim.StatusSucceeded {
+			lastSuccessIndex = j
+			break
+		}
+	}
+
+	var prunable []claim.Claim
+	for j, c := range claims {
+		if j == lastIndex || j == lastSuccessIndex || outputClaims[c.ID] {
+			continue
+		}
+		if p.KeepLast > 0 && j > lastIndex-p.KeepLast {
+			continue
+		}
+		if p.KeepFor > 0 && now.Sub(c.Created) < p.KeepFor {
+			continue
+		}
+		prunable = append(prunable, c)
+	}
+	return prunable
+}
+
+// ListLastOutputClaims returns the ids of the claims with the most recent value
+// of each output of an installation. An output is only saved by the actions
+// that generate it, such as a password that is generated by install, so its
+// most recent value may be held by any claim in the installation's history.
+// The claims are selected in the same way as claim.Provider.ReadLastOutputs:
+// the last result, sorted by id, that saved the output.
+func ListLastOutputClaims(p claim.Provider, i claim.Installation) (map[string]bool, error) {
+	var results claim.Results
+	for _, c := range i.Claims {
+		resultIDs, err := p.ListResults(c.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not list the results of claim %s", c.ID)
+		}
+		for _, resultID := range resultIDs {
+			results = append(results, claim.Result{ID: resultID, ClaimID: c.ID})
+		}
+	}
+
+	// output name -> id of the claim with the last value
+	sort.Sort(results)
+	lastOutputs := map[string]string{}
+	for _, r := range results {
+		outputs, err := p.ListOutputs(r.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not list the outputs of result %s", r.ID)
+		}
+		for _, output := range outputs {
+			lastOutputs[output] = r.ClaimID
+		}
+	}
+
+	claimIDs := make(map[string]bool, len(lastOutputs))
+	for _, claimID := range lastOutputs {
+		claimIDs[claimID] = true
+	}
+	return claimIDs, nil
+}
@@ -0,0 +1,110 @@
+package claims
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"get.porter.sh/porter/pkg/config"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createHistory saves a claim for each status, one day apart, with the last
+// claim created now, and returns the installation with the claim ids in order.
+func createHistory(t *testing.T, p TestClaimProvider, now time.Time, statuses ...string) (claim.Installation, []string) {
+	bun := bundle.Bundle{Name: "mybuns", Version: "1.0.0"}
+	var ids []string
+	for i, status := range statuses {
+		action := claim.ActionUpgrade
+		if i == 0 {
+			action = claim.ActionInstall
+		}
+		c, err := claim.New("mybuns", action, bun, nil)
+		require.NoError(t, err)
+		c.Created = now.Add(time.Duration(i-len(statuses)+1) * 24 * time.Hour)
+		require.NoError(t, p.SaveClaim(c))
+		p.CreateResult(c, status)
+		ids = append(ids, c.ID)
+	}
+
+	i, err := p.ReadInstallation("mybuns")
+	require.NoError(t, err)
+	return i, ids
+}
+
+func TestNewRetentionPolicy(t *testing.T) {
+	p := NewRetentionPolicy(config.ClaimRetention{KeepLast: 3, KeepDays: 2})
+	assert.Equal(t, RetentionPolicy{KeepLast: 3, KeepFor: 48 * time.Hour}, p)
+	assert.False(t, p.IsEmpty())
+	assert.True(t, NewRetentionPolicy(config.ClaimRetention{}).IsEmpty())
+}
+
+func TestRetentionPolicy_SelectPrunable(t *testing.T) {
+	now := time.Now()
+	s := claim.StatusSucceeded
+	f := claim.StatusFailed
+
+	testcases := []struct {
+		name      string
+		policy    RetentionPolicy
+		statuses  []string
+		wantPrune []int
+	}{
+		{name: "empty policy", statuses: []string{s, s, s}},
+		{name: "keep last", policy: RetentionPolicy{KeepLast: 2}, statuses: []string{s, s, s, s}, wantPrune: []int{0, 1}},
+		{name: "keep for", policy: RetentionPolicy{KeepFor: 36 * time.Hour}, statuses: []string{s, s, s, s}, wantPrune: []int{0, 1}},
+		{name: "keep last or keep for", policy: RetentionPolicy{KeepLast: 1, KeepFor: 36 * time.Hour}, statuses: []string{s, s, s, s}, wantPrune: []int{0, 1}},
+		{name: "keep latest success", policy: RetentionPolicy{KeepLast: 1}, statuses: []string{s, s, f, f}, wantPrune: []int{0, 2}},
+		{name: "keep latest claim", policy: RetentionPolicy{KeepFor: time.Minute}, statuses: []string{s, f, s}, wantPrune: []int{0, 1}},
+		{name: "no success", policy: RetentionPolicy{KeepLast: 1}, statuses: []string{f, f, f}, wantPrune: []int{0, 1}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewTestClaimProvider(t)
+			i, ids := createHistory(t, p, now, tc.statuses...)
+
+			var got []string
+			for _, c := range tc.policy.SelectPrunable(i, nil, now) {
+				got = append(got, c.ID)
+			}
+			var want []string
+			for _, index := range tc.wantPrune {
+				want = append(want, ids[index])
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestRetentionPolicy_SelectPrunable_Outputs(t *testing.T) {
+	now := time.Now()
+	s := claim.StatusSucceeded
+	p := NewTestClaimProvider(t)
+	i, ids := createHistory(t, p, now, s, s, s, s)
+
+	// The password is generated by install, and the port is changed by each upgrade
+	for j, c := range i.Claims {
+		r, err := p.ReadLastResult(c.ID)
+		require.NoError(t, err)
+		if j == 0 {
+			p.CreateOutput(c, r, "password", []byte("abc123"))
+		}
+		if j < 3 {
+			p.CreateOutput(c, r, "port", []byte(fmt.Sprintf("%d", 8080+j)))
+		}
+	}
+
+	outputClaims, err := ListLastOutputClaims(p, i)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{ids[0]: true, ids[2]: true}, outputClaims, "the claims with the last value of each output should be listed")
+
+	var got []string
+	for _, c := range (RetentionPolicy{KeepLast: 1}).SelectPrunable(i, outputClaims, now) {
+		got = append(got, c.ID)
+	}
+	assert.Equal(t, []string{ids[1]}, got, "claims with the last value of an output should be kept")
+}
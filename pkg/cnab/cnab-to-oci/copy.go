@@ -0,0 +1,62 @@
+package cnabtooci
+
+import (
+	"github.com/docker/distribution/reference"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pkg/errors"
+)
+
+// CopyImage copies an image, or an image index, to another repository without
+// modifying its manifest so that the content digest is preserved. When the
+// destination isn't tagged, the image is pushed by digest.
+// Returns the digest of the copied image.
+func (r *Registry) CopyImage(source string, destination string, insecureRegistry bool) (string, error) {
+	nameOpts, remoteOpts := getRemoteOptions(insecureRegistry)
+
+	srcRef, err := name.ParseReference(source, nameOpts...)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid source image %s", source)
+	}
+	destNamed, err := ParseOCIReference(destination)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid destination image %s", destination)
+	}
+	destRepo, err := name.NewRepository(destNamed.Name(), nameOpts...)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid destination image %s", destination)
+	}
+
+	desc, err := remote.Get(srcRef, remoteOpts...)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to retrieve image %s", source)
+	}
+	imageDigest := desc.Digest.String()
+
+	var destRef name.Reference = destRepo.Digest(imageDigest)
+	if tagged, ok := destNamed.(reference.Tagged); ok {
+		destRef = destRepo.Tag(tagged.Tag())
+	}
+
+	switch desc.MediaType {
+	case types.OCIImageIndex, types.DockerManifestList:
+		ii, err := desc.ImageIndex()
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to read image index %s", source)
+		}
+		if err = remote.WriteIndex(destRef, ii, remoteOpts...); err != nil {
+			return "", errors.Wrapf(err, "unable to copy image %s to %s", source, destRef)
+		}
+	default:
+		img, err := desc.Image()
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to read image %s", source)
+		}
+		if err = remote.Write(destRef, img, remoteOpts...); err != nil {
+			return "", errors.Wrapf(err, "unable to copy image %s to %s", source, destRef)
+		}
+	}
+
+	return imageDigest, nil
+}
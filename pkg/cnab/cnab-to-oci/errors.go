@@ -0,0 +1,60 @@
+package cnabtooci
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/pkg/errors"
+)
+
+// IsNotFound determines if an error from the registry is because the
+// reference does not exist, as opposed to a failure to connect or
+// authenticate to the registry.
+func IsNotFound(err error) bool {
+	cause := errors.Cause(err)
+	if errdefs.IsNotFound(cause) {
+		return true
+	}
+
+	if terr, ok := cause.(*transport.Error); ok {
+		if terr.StatusCode == http.StatusNotFound {
+			return true
+		}
+		for _, diag := range terr.Errors {
+			if diag.Code == transport.ManifestUnknownErrorCode || diag.Code == transport.NameUnknownErrorCode {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ErrNotABundle is returned when a reference in a registry is not a bundle,
+// such as an image or a bundle signature.
+type ErrNotABundle struct {
+	Reference string
+	Reason    error
+}
+
+func (e ErrNotABundle) Error() string {
+	return fmt.Sprintf("%s is not a bundle: %s", e.Reference, e.Reason)
+}
+
+// IsNotABundle determines if an error is because the reference is not a bundle.
+func IsNotABundle(err error) bool {
+	_, ok := errors.Cause(err).(ErrNotABundle)
+	return ok
+}
+
+// isNotABundleError determines if an error from cnab-to-oci is because the
+// media type of the manifest that was pulled is not a bundle index.
+// cnab-to-oci doesn't return typed errors, so the error message is checked
+// instead. Other pull errors, such as failing to get the bundle config, may be
+// caused by the network or authentication and are not matched.
+func isNotABundleError(err error) bool {
+	msg := err.Error()
+	return strings.HasPrefix(msg, "invalid media type ") && strings.HasSuffix(msg, " for bundle manifest")
+}
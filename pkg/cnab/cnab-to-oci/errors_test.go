@@ -0,0 +1,43 @@
+package cnabtooci
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsNotFound(t *testing.T) {
+	testcases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "containerd not found", err: errors.Wrap(errors.Wrap(errdefs.ErrNotFound, "getporter/mybuns:v0.1.0"), "unable to resolve the digest"), want: true},
+		{name: "404", err: &transport.Error{StatusCode: http.StatusNotFound}, want: true},
+		{name: "manifest unknown", err: errors.Wrap(&transport.Error{StatusCode: http.StatusBadRequest, Errors: []transport.Diagnostic{{Code: transport.ManifestUnknownErrorCode}}}, "oops"), want: true},
+		{name: "unauthorized", err: &transport.Error{StatusCode: http.StatusUnauthorized, Errors: []transport.Diagnostic{{Code: transport.UnauthorizedErrorCode}}}},
+		{name: "server error", err: &transport.Error{StatusCode: http.StatusInternalServerError}},
+		{name: "other error", err: errors.New("connection refused")},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, IsNotFound(tc.err))
+		})
+	}
+}
+
+func TestIsNotABundle(t *testing.T) {
+	err := ErrNotABundle{Reference: "getporter/mybuns:sha256-abc123.sig", Reason: errors.New(`invalid media type "application/vnd.oci.image.manifest.v1+json" for bundle manifest`)}
+	assert.True(t, IsNotABundle(errors.Wrap(err, "oops")))
+	assert.EqualError(t, err, `getporter/mybuns:sha256-abc123.sig is not a bundle: invalid media type "application/vnd.oci.image.manifest.v1+json" for bundle manifest`)
+	assert.False(t, IsNotABundle(errors.New("unauthorized")))
+
+	assert.True(t, isNotABundleError(errors.New(`invalid media type "application/vnd.oci.image.manifest.v1+json" for bundle manifest`)))
+	assert.False(t, isNotABundleError(errors.New(`failed to resolve bundle manifest "getporter/mybuns:v0.1.0": unauthorized`)))
+	assert.False(t, isNotABundleError(errors.New(`failed to get bundle config manifest from "getporter/mybuns:v0.1.0": unauthorized`)), "failing to fetch the bundle config may be caused by the network or authentication")
+}
@@ -1,8 +1,12 @@
 package cnabtooci
 
 import (
+	"time"
+
+	"get.porter.sh/porter/pkg/signing"
 	"github.com/cnabio/cnab-go/bundle"
 	"github.com/cnabio/cnab-to-oci/relocation"
+	"github.com/pkg/errors"
 )
 
 var _ RegistryProvider = &TestRegistry{}
@@ -10,7 +14,15 @@ var _ RegistryProvider = &TestRegistry{}
 type TestRegistry struct {
 	MockPullBundle          func(tag string, insecureRegistry bool) (bun bundle.Bundle, reloMap *relocation.ImageRelocationMap, err error)
 	MockPushBundle          func(bun bundle.Bundle, tag string, insecureRegistry bool) (reloMap *relocation.ImageRelocationMap, err error)
+	MockPushRelocatedBundle func(bun bundle.Bundle, tag string, reloMap relocation.ImageRelocationMap, insecureRegistry bool) (*relocation.ImageRelocationMap, error)
+	MockCopyImage           func(source string, destination string, insecureRegistry bool) (digest string, err error)
 	MockPushInvocationImage func(invocationImage string) (imageDigest string, err error)
+	MockGetBundleDigest     func(tag string, insecureRegistry bool) (digest string, err error)
+	MockListTags            func(repository string, insecureRegistry bool) (tags []string, err error)
+	MockGetImageCreated     func(image string, insecureRegistry bool) (created time.Time, err error)
+	MockPushSignature       func(tag string, sig signing.Signature, insecureRegistry bool) error
+	MockPullSignature       func(tag string, insecureRegistry bool) (sig signing.Signature, err error)
+	MockLoadImage           func(layoutDir string, imageDigest string, tag string) error
 }
 
 func NewTestRegistry() *TestRegistry {
@@ -33,9 +45,66 @@ func (t TestRegistry) PushBundle(bun bundle.Bundle, tag string, insecureRegistry
 	return nil, nil
 }
 
+func (t TestRegistry) PushRelocatedBundle(bun bundle.Bundle, tag string, reloMap relocation.ImageRelocationMap, insecureRegistry bool) (*relocation.ImageRelocationMap, error) {
+	if t.MockPushRelocatedBundle != nil {
+		return t.MockPushRelocatedBundle(bun, tag, reloMap, insecureRegistry)
+	}
+
+	return nil, nil
+}
+
+func (t TestRegistry) CopyImage(source string, destination string, insecureRegistry bool) (string, error) {
+	if t.MockCopyImage != nil {
+		return t.MockCopyImage(source, destination, insecureRegistry)
+	}
+	return "", nil
+}
+
 func (t TestRegistry) PushInvocationImage(invocationImage string) (string, error) {
 	if t.MockPushInvocationImage != nil {
 		return t.MockPushInvocationImage(invocationImage)
 	}
 	return "", nil
 }
+
+func (t TestRegistry) GetBundleDigest(tag string, insecureRegistry bool) (string, error) {
+	if t.MockGetBundleDigest != nil {
+		return t.MockGetBundleDigest(tag, insecureRegistry)
+	}
+	return "", nil
+}
+
+func (t TestRegistry) ListTags(repository string, insecureRegistry bool) ([]string, error) {
+	if t.MockListTags != nil {
+		return t.MockListTags(repository, insecureRegistry)
+	}
+	return nil, nil
+}
+
+func (t TestRegistry) GetImageCreated(image string, insecureRegistry bool) (time.Time, error) {
+	if t.MockGetImageCreated != nil {
+		return t.MockGetImageCreated(image, insecureRegistry)
+	}
+	return time.Time{}, nil
+}
+
+func (t TestRegistry) PushSignature(tag string, sig signing.Signature, insecureRegistry bool) error {
+	if t.MockPushSignature != nil {
+		return t.MockPushSignature(tag, sig, insecureRegistry)
+	}
+	return nil
+}
+
+func (t TestRegistry) PullSignature(tag string, insecureRegistry bool) (signing.Signature, error) {
+	if t.MockPullSignature != nil {
+		return t.MockPullSignature(tag, insecureRegistry)
+	}
+	return signing.Signature{}, errors.Errorf("%s is not signed", tag)
+}
+
+func (t TestRegistry) LoadImage(layoutDir string, imageDigest string, tag string) error {
+	if t.MockLoadImage != nil {
+		return t.MockLoadImage(layoutDir, imageDigest, tag)
+	}
+	return nil
+}
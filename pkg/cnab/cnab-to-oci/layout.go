@@ -0,0 +1,67 @@
+package cnabtooci
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pkg/errors"
+)
+
+// LoadImage loads an image from an OCI image layout into the Docker image
+// cache with the specified tag, so that it can be used without a registry.
+// Images that were already loaded with the tag are not loaded again.
+func (r *Registry) LoadImage(layoutDir string, imageDigest string, tag string) error {
+	ctx := context.Background()
+	cli, err := r.getDockerClient()
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := cli.Client().ImageInspectWithRaw(ctx, tag); err == nil {
+		return nil
+	}
+
+	hash, err := v1.NewHash(imageDigest)
+	if err != nil {
+		return errors.Wrapf(err, "invalid image digest %s", imageDigest)
+	}
+	ii, err := layout.ImageIndexFromPath(layoutDir)
+	if err != nil {
+		return errors.Wrapf(err, "could not read the OCI layout at %s", layoutDir)
+	}
+	img, err := ii.Image(hash)
+	if err != nil {
+		return errors.Wrapf(err, "could not find image %s in the OCI layout at %s", imageDigest, layoutDir)
+	}
+	ref, err := name.NewTag(tag)
+	if err != nil {
+		return errors.Wrapf(err, "invalid image tag %s", tag)
+	}
+
+	if r.Debug {
+		fmt.Fprintf(r.Err, "Loading image %s from the OCI layout at %s as %s\n", imageDigest, layoutDir, tag)
+	}
+
+	// Convert the image to the format used by docker save while it is loaded
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(tarball.Write(ref, img, pw))
+	}()
+	defer pr.Close()
+
+	resp, err := cli.Client().ImageLoad(ctx, pr, true)
+	if err != nil {
+		return errors.Wrapf(err, "could not load image %s into docker", tag)
+	}
+	defer resp.Body.Close()
+
+	err = jsonmessage.DisplayJSONMessagesStream(resp.Body, ioutil.Discard, 0, false, nil)
+	return errors.Wrapf(err, "could not load image %s into docker", tag)
+}
@@ -1,6 +1,9 @@
 package cnabtooci
 
 import (
+	"time"
+
+	"get.porter.sh/porter/pkg/signing"
 	"github.com/cnabio/cnab-go/bundle"
 	"github.com/cnabio/cnab-to-oci/relocation"
 )
@@ -13,8 +16,35 @@ type RegistryProvider interface {
 	// PushBundle pushes a bundle to an OCI registry.
 	PushBundle(bun bundle.Bundle, tag string, insecureRegistry bool) (*relocation.ImageRelocationMap, error)
 
+	// PushRelocatedBundle pushes a bundle to an OCI registry, resolving the images
+	// in the bundle from the locations in the relocation mapping.
+	PushRelocatedBundle(bun bundle.Bundle, tag string, reloMap relocation.ImageRelocationMap, insecureRegistry bool) (*relocation.ImageRelocationMap, error)
+
+	// CopyImage copies an image to another repository, preserving its digest.
+	// Returns the digest of the copied image.
+	CopyImage(source string, destination string, insecureRegistry bool) (string, error)
+
 	// PushInvocationImage pushes the invocation image from the Docker image cache to the specified location
 	// the expected format of the invocationImage is REGISTRY/NAME:TAG.
 	// Returns the image digest from the registry.
 	PushInvocationImage(invocationImage string) (string, error)
+
+	// GetBundleDigest returns the digest of the bundle manifest at the
+	// specified location, without pulling the bundle.
+	GetBundleDigest(tag string, insecureRegistry bool) (string, error)
+
+	// ListTags returns the tags in a repository, in the format REGISTRY/NAME.
+	ListTags(repository string, insecureRegistry bool) ([]string, error)
+
+	// GetImageCreated returns when an image in a registry was created.
+	GetImageCreated(image string, insecureRegistry bool) (time.Time, error)
+
+	// PushSignature pushes a signature for the bundle at the specified location.
+	PushSignature(tag string, sig signing.Signature, insecureRegistry bool) error
+
+	// PullSignature pulls the signature for the bundle at the specified location.
+	PullSignature(tag string, insecureRegistry bool) (signing.Signature, error)
+
+	// LoadImage loads an image from an OCI image layout into the Docker image cache with the specified tag.
+	LoadImage(layoutDir string, imageDigest string, tag string) error
 }
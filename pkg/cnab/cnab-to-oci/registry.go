@@ -70,6 +70,9 @@ func (r *Registry) PullBundle(tag string, insecureRegistry bool) (bundle.Bundle,
 
 	bun, reloMap, err := remotes.Pull(context.Background(), ref, r.createResolver(insecureRegistries))
 	if err != nil {
+		if isNotABundleError(err) {
+			return bundle.Bundle{}, nil, ErrNotABundle{Reference: tag, Reason: err}
+		}
 		return bundle.Bundle{}, nil, errors.Wrap(err, "unable to pull remote bundle")
 	}
 
@@ -86,6 +89,17 @@ func (r *Registry) PullBundle(tag string, insecureRegistry bool) (bundle.Bundle,
 }
 
 func (r *Registry) PushBundle(bun bundle.Bundle, tag string, insecureRegistry bool) (*relocation.ImageRelocationMap, error) {
+	return r.pushBundle(bun, tag, nil, insecureRegistry)
+}
+
+// PushRelocatedBundle pushes a bundle to an OCI registry, resolving the images
+// in the bundle from the locations in the relocation mapping instead of the
+// locations in the bundle.
+func (r *Registry) PushRelocatedBundle(bun bundle.Bundle, tag string, reloMap relocation.ImageRelocationMap, insecureRegistry bool) (*relocation.ImageRelocationMap, error) {
+	return r.pushBundle(bun, tag, reloMap, insecureRegistry)
+}
+
+func (r *Registry) pushBundle(bun bundle.Bundle, tag string, reloMap relocation.ImageRelocationMap, insecureRegistry bool) (*relocation.ImageRelocationMap, error) {
 	ref, err := ParseOCIReference(tag) //tag from manifest
 	if err != nil {
 		return nil, errors.Wrap(err, "invalid bundle tag reference. expected value is REGISTRY/bundle:tag")
@@ -98,7 +112,17 @@ func (r *Registry) PushBundle(bun bundle.Bundle, tag string, insecureRegistry bo
 
 	resolver := r.createResolver(insecureRegistries)
 
-	rm, err := remotes.FixupBundle(context.Background(), &bun, ref, resolver, remotes.WithEventCallback(r.displayEvent), remotes.WithAutoBundleUpdate())
+	fixupOpts := []remotes.FixupOption{remotes.WithEventCallback(r.displayEvent), remotes.WithAutoBundleUpdate()}
+	if len(reloMap) > 0 {
+		// FixupBundle updates the map that it is given, so pass a copy
+		m := make(relocation.ImageRelocationMap, len(reloMap))
+		for img, relocatedImg := range reloMap {
+			m[img] = relocatedImg
+		}
+		fixupOpts = append(fixupOpts, remotes.WithRelocationMap(m))
+	}
+
+	rm, err := remotes.FixupBundle(context.Background(), &bun, ref, resolver, fixupOpts...)
 	if err != nil {
 		return nil, errors.Wrap(err, "error preparing the bundle with cnab-to-oci before pushing")
 	}
@@ -169,6 +193,29 @@ func (r *Registry) PushInvocationImage(invocationImage string) (string, error) {
 	return string(dist.Descriptor.Digest), nil
 }
 
+// GetBundleDigest returns the digest of the bundle manifest at the specified location,
+// without pulling the bundle.
+func (r *Registry) GetBundleDigest(tag string, insecureRegistry bool) (string, error) {
+	ref, err := ParseOCIReference(tag)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid bundle tag format, expected REGISTRY/name:tag")
+	}
+
+	var insecureRegistries []string
+	if insecureRegistry {
+		reg := reference.Domain(ref)
+		insecureRegistries = append(insecureRegistries, reg)
+	}
+
+	resolver := r.createResolver(insecureRegistries)
+	_, desc, err := resolver.Resolve(context.Background(), ref.String())
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to resolve the digest of %s", tag)
+	}
+
+	return desc.Digest.String(), nil
+}
+
 func (r *Registry) createResolver(insecureRegistries []string) containerdRemotes.Resolver {
 	return remotes.CreateResolver(dockerconfig.LoadDefaultConfigFile(r.Out), insecureRegistries...)
 }
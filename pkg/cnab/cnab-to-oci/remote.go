@@ -0,0 +1,58 @@
+package cnabtooci
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+)
+
+// ListTags returns the tags in a repository, in the format REGISTRY/NAME.
+func (r *Registry) ListTags(repository string, insecureRegistry bool) ([]string, error) {
+	nameOpts, remoteOpts := getRemoteOptions(insecureRegistry)
+	repo, err := name.NewRepository(repository, nameOpts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid repository %s", repository)
+	}
+
+	tags, err := remote.List(repo, remoteOpts...)
+	return tags, errors.Wrapf(err, "error listing tags for %s", repository)
+}
+
+// GetImageCreated returns when an image in a registry was created, from the
+// image configuration.
+func (r *Registry) GetImageCreated(image string, insecureRegistry bool) (time.Time, error) {
+	nameOpts, remoteOpts := getRemoteOptions(insecureRegistry)
+	ref, err := name.ParseReference(image, nameOpts...)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid image %s", image)
+	}
+
+	img, err := remote.Image(ref, remoteOpts...)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "unable to retrieve image %s", image)
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "unable to read the configuration of image %s", image)
+	}
+	return cfg.Created.Time, nil
+}
+
+// getRemoteOptions returns the options for connecting to a registry with
+// go-containerregistry, using the credentials from the docker config.
+func getRemoteOptions(insecureRegistry bool) ([]name.Option, []remote.Option) {
+	var nameOpts []name.Option
+	remoteOpts := []remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)}
+	if insecureRegistry {
+		nameOpts = append(nameOpts, name.Insecure)
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		remoteOpts = append(remoteOpts, remote.WithTransport(transport))
+	}
+	return nameOpts, remoteOpts
+}
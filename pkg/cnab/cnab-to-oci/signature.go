@@ -0,0 +1,194 @@
+package cnabtooci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"get.porter.sh/porter/pkg/signing"
+	"github.com/containerd/containerd/errdefs"
+	containerdRemotes "github.com/containerd/containerd/remotes"
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MediaTypeSignatureConfig is the media type of the config of a bundle signature artifact.
+	MediaTypeSignatureConfig = "application/vnd.porter.signature.config.v1+json"
+
+	// MediaTypeSignature is the media type of the layer that contains a bundle signature.
+	MediaTypeSignature = "application/vnd.porter.signature.v1+json"
+)
+
+// GetSignatureReference returns the location of the signature for a bundle
+// manifest, which is stored in the same repository as the bundle with a tag
+// derived from the digest of the bundle manifest.
+func GetSignatureReference(repository reference.Named, manifestDigest string) (reference.Named, error) {
+	tag := strings.Replace(manifestDigest, ":", "-", 1) + ".sig"
+	ref, err := reference.WithTag(reference.TrimNamed(repository), tag)
+	return ref, errors.Wrapf(err, "invalid signature reference for bundle manifest %s", manifestDigest)
+}
+
+// PushSignature pushes a signature for the bundle at the specified location,
+// as an artifact in the same repository as the bundle.
+func (r *Registry) PushSignature(tag string, sig signing.Signature, insecureRegistry bool) error {
+	sigRef, err := r.resolveSignatureReference(tag, insecureRegistry)
+	if err != nil {
+		return err
+	}
+
+	sigData, err := json.Marshal(sig)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the bundle signature")
+	}
+	configData := []byte("{}")
+
+	manifest := ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config:    newDescriptor(MediaTypeSignatureConfig, configData),
+		Layers:    []ocispec.Descriptor{newDescriptor(MediaTypeSignature, sigData)},
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the signature manifest")
+	}
+
+	ctx := context.Background()
+	resolver := r.createResolver(r.getInsecureRegistries(sigRef, insecureRegistry))
+	pusher, err := resolver.Pusher(ctx, sigRef.String())
+	if err != nil {
+		return errors.Wrapf(err, "could not push the signature to %s", sigRef)
+	}
+
+	// The manifest must be pushed last, after the content that it references
+	err = pushContent(ctx, pusher, manifest.Config, configData)
+	if err == nil {
+		err = pushContent(ctx, pusher, manifest.Layers[0], sigData)
+	}
+	if err == nil {
+		err = pushContent(ctx, pusher, newDescriptor(ocispec.MediaTypeImageManifest, manifestData), manifestData)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not push the signature to %s", sigRef)
+	}
+
+	fmt.Fprintf(r.Out, "Bundle signature pushed to %s\n", sigRef)
+	return nil
+}
+
+// PullSignature pulls the signature for the bundle at the specified location.
+func (r *Registry) PullSignature(tag string, insecureRegistry bool) (signing.Signature, error) {
+	sigRef, err := r.resolveSignatureReference(tag, insecureRegistry)
+	if err != nil {
+		return signing.Signature{}, err
+	}
+
+	ctx := context.Background()
+	resolver := r.createResolver(r.getInsecureRegistries(sigRef, insecureRegistry))
+	name, desc, err := resolver.Resolve(ctx, sigRef.String())
+	if err != nil {
+		return signing.Signature{}, errors.Wrapf(err, "could not find a signature for %s", tag)
+	}
+	fetcher, err := resolver.Fetcher(ctx, name)
+	if err != nil {
+		return signing.Signature{}, errors.Wrapf(err, "could not pull the signature for %s", tag)
+	}
+
+	manifestData, err := fetchContent(ctx, fetcher, desc)
+	if err != nil {
+		return signing.Signature{}, errors.Wrapf(err, "could not pull the signature manifest for %s", tag)
+	}
+	var manifest ocispec.Manifest
+	if err = json.Unmarshal(manifestData, &manifest); err != nil {
+		return signing.Signature{}, errors.Wrapf(err, "could not parse the signature manifest for %s", tag)
+	}
+
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != MediaTypeSignature {
+			continue
+		}
+
+		sigData, err := fetchContent(ctx, fetcher, layer)
+		if err != nil {
+			return signing.Signature{}, errors.Wrapf(err, "could not pull the signature for %s", tag)
+		}
+		var sig signing.Signature
+		err = json.Unmarshal(sigData, &sig)
+		return sig, errors.Wrapf(err, "could not parse the signature for %s", tag)
+	}
+
+	return signing.Signature{}, errors.Errorf("%s does not contain a bundle signature", sigRef)
+}
+
+// resolveSignatureReference returns the location of the signature for the
+// bundle manifest that the tag currently points to.
+func (r *Registry) resolveSignatureReference(tag string, insecureRegistry bool) (reference.Named, error) {
+	ref, err := ParseOCIReference(tag)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid bundle tag format, expected REGISTRY/name:tag")
+	}
+
+	manifestDigest, err := r.GetBundleDigest(tag, insecureRegistry)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetSignatureReference(ref, manifestDigest)
+}
+
+func (r *Registry) getInsecureRegistries(ref reference.Named, insecureRegistry bool) []string {
+	if !insecureRegistry {
+		return nil
+	}
+	return []string{reference.Domain(ref)}
+}
+
+func newDescriptor(mediaType string, data []byte) ocispec.Descriptor {
+	return ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+}
+
+func pushContent(ctx context.Context, pusher containerdRemotes.Pusher, desc ocispec.Descriptor, data []byte) error {
+	w, err := pusher.Push(ctx, desc)
+	if err != nil {
+		if errdefs.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	defer w.Close()
+
+	if _, err = w.Write(data); err != nil {
+		return err
+	}
+	err = w.Commit(ctx, desc.Size, desc.Digest)
+	if err != nil && !errdefs.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func fetchContent(ctx context.Context, fetcher containerdRemotes.Fetcher, desc ocispec.Descriptor) ([]byte, error) {
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	if digest.FromBytes(data) != desc.Digest {
+		return nil, errors.Errorf("the content does not match the expected digest %s", desc.Digest)
+	}
+	return data, nil
+}
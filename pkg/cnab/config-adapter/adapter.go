@@ -301,7 +301,9 @@ func (c *ManifestConverter) generateBundleImages() map[string]bundle.Image {
 	return images
 }
 
-func (c *ManifestConverter) generateDependencies() *extensions.Dependencies {
+// GenerateDependencies builds the dependencies extension from the manifest.
+// Returns nil when the manifest does not declare any dependencies.
+func (c *ManifestConverter) GenerateDependencies() *extensions.Dependencies {
 
 	if len(c.Manifest.Dependencies) == 0 {
 		return nil
@@ -482,7 +484,7 @@ func (c *ManifestConverter) generateCustomExtensions(b *bundle.Bundle) map[strin
 	}
 
 	// Add the dependency extension
-	deps := c.generateDependencies()
+	deps := c.GenerateDependencies()
 	if deps != nil && len(deps.Requires) > 0 {
 		customExtensions[extensions.DependenciesExtensionKey] = deps
 	}
@@ -426,7 +426,7 @@ func TestManifestConverter_generateBundleOutputs(t *testing.T) {
 	require.Equal(t, wantDefinitions, defs)
 }
 
-func TestManifestConverter_generateDependencies(t *testing.T) {
+func TestManifestConverter_GenerateDependencies(t *testing.T) {
 	t.Parallel()
 
 	testcases := []struct {
@@ -473,7 +473,7 @@ func TestManifestConverter_generateDependencies(t *testing.T) {
 
 			a := NewManifestConverter(c.Context, m, nil, nil)
 
-			deps := a.generateDependencies()
+			deps := a.GenerateDependencies()
 			require.Len(t, deps.Requires, 4, "incorrect number of dependencies were generated")
 			require.Equal(t, []string{"mysql", "ad", "storage", "dep-with-tag"}, deps.Sequence, "incorrect sequence was generated")
 
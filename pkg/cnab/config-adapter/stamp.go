@@ -8,6 +8,7 @@ import (
 	"fmt"
 
 	"get.porter.sh/porter/pkg"
+	"get.porter.sh/porter/pkg/cnab/extensions"
 	"get.porter.sh/porter/pkg/config"
 	"get.porter.sh/porter/pkg/context"
 	"get.porter.sh/porter/pkg/manifest"
@@ -34,6 +35,9 @@ type Stamp struct {
 	// Version and commit define the version of the Porter used when a bundle was built.
 	Version string `json:"version"`
 	Commit  string `json:"commit"`
+
+	// Dependencies are the dependency bundles resolved when the bundle was built.
+	Dependencies []extensions.DependencyLock `json:"dependencies,omitempty"`
 }
 
 // DecodeManifest base64 decodes the manifest stored in the stamp
@@ -61,8 +65,10 @@ func (s Stamp) WriteManifest(cxt *context.Context, path string) error {
 }
 
 // MixinRecord contains information about a mixin used in a bundle
-// For now it is a placeholder for data that we would like to include in the future.
-type MixinRecord struct{}
+type MixinRecord struct {
+	// Version of the mixin used to build the bundle.
+	Version string `json:"version,omitempty"`
+}
 
 func (c *ManifestConverter) GenerateStamp() (Stamp, error) {
 	stamp := Stamp{}
@@ -74,10 +80,14 @@ func (c *ManifestConverter) GenerateStamp() (Stamp, error) {
 	}
 	stamp.EncodedManifest = base64.StdEncoding.EncodeToString(rawManifest)
 
-	// Remember the mixins used in the bundle
+	// Remember the mixins used in the bundle, and the version that was installed when it was built
+	versions := make(map[string]string, len(c.Mixins))
+	for _, m := range c.Mixins {
+		versions[m.Name] = m.Version
+	}
 	stamp.Mixins = make(map[string]MixinRecord, len(c.Manifest.Mixins))
 	for _, m := range c.Manifest.Mixins {
-		stamp.Mixins[m.Name] = MixinRecord{}
+		stamp.Mixins[m.Name] = MixinRecord{Version: versions[m.Name]}
 	}
 
 	digest, err := c.DigestManifest()
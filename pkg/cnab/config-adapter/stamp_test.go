@@ -5,6 +5,8 @@ import (
 
 	"get.porter.sh/porter/pkg"
 	"get.porter.sh/porter/pkg/manifest"
+	"get.porter.sh/porter/pkg/mixin"
+	"get.porter.sh/porter/pkg/pkgmgmt"
 
 	"get.porter.sh/porter/pkg/config"
 	"github.com/cnabio/cnab-go/bundle"
@@ -172,3 +174,20 @@ func TestConfig_GenerateStamp_IncludeVersion(t *testing.T) {
 	assert.Equal(t, "v1.2.3", stamp.Version)
 	assert.Equal(t, "abc123", stamp.Commit)
 }
+
+func TestConfig_GenerateStamp_IncludeMixinVersions(t *testing.T) {
+	c := config.NewTestConfig(t)
+	c.TestContext.AddTestFile("../../manifest/testdata/simple.porter.yaml", config.Name)
+
+	m, err := manifest.LoadManifestFrom(c.Context, config.Name)
+	require.NoError(t, err, "could not load manifest")
+
+	installedMixins := []mixin.Metadata{
+		{Name: "exec", VersionInfo: pkgmgmt.VersionInfo{Version: "v1.0.0"}},
+		{Name: "helm", VersionInfo: pkgmgmt.VersionInfo{Version: "v0.13.0"}},
+	}
+	a := NewManifestConverter(c.Context, m, nil, installedMixins)
+	stamp, err := a.GenerateStamp()
+	require.NoError(t, err, "GenerateStamp failed")
+	assert.Equal(t, map[string]MixinRecord{"exec": {Version: "v1.0.0"}}, stamp.Mixins, "only the mixins used by the bundle should be recorded")
+}
@@ -1,7 +1,10 @@
 package extensions
 
 import (
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/cnabio/cnab-go/bundle"
@@ -10,12 +13,67 @@ import (
 	"github.com/pkg/errors"
 )
 
+// DependencyLock records the bundle that a dependency was resolved to.
 type DependencyLock struct {
-	Alias     string
-	Reference string
+	// Alias of the dependency in the parent bundle.
+	Alias string `json:"alias"`
+
+	// Reference is the resolved bundle reference in the format REGISTRY/NAME:TAG.
+	Reference string `json:"reference"`
+
+	// Digest of the resolved bundle, when known.
+	Digest string `json:"digest,omitempty"`
+}
+
+// GetPullReference returns the reference that should be used to pull the dependency.
+// When the lock has a digest, the reference is pinned to that digest so that a
+// tag that has since been moved cannot change which bundle is used.
+func (l DependencyLock) GetPullReference() (string, error) {
+	if l.Digest == "" {
+		return l.Reference, nil
+	}
+
+	ref, err := reference.ParseNormalizedNamed(l.Reference)
+	if err != nil {
+		return "", errors.Wrapf(err, "error parsing dependency (%s) reference %q", l.Alias, l.Reference)
+	}
+
+	pinned, err := reference.ParseNormalizedNamed(ref.Name() + "@" + l.Digest)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid digest %q for dependency %s", l.Digest, l.Alias)
+	}
+
+	return reference.FamiliarString(pinned), nil
+}
+
+// IsSameRepository determines if a bundle reference is from the same repository
+// as the dependency, ignoring the tag or digest.
+func (l DependencyLock) IsSameRepository(bundleReference string) (bool, error) {
+	want, err := reference.ParseNormalizedNamed(l.Reference)
+	if err != nil {
+		return false, errors.Wrapf(err, "error parsing dependency (%s) reference %q", l.Alias, l.Reference)
+	}
+
+	got, err := reference.ParseNormalizedNamed(bundleReference)
+	if err != nil {
+		return false, errors.Wrapf(err, "error parsing bundle reference %q", bundleReference)
+	}
+
+	return want.Name() == got.Name(), nil
+}
+
+// TagLister lists the tags in a repository, in the format REGISTRY/NAME.
+type TagLister interface {
+	ListTags(repository string, insecureRegistry bool) ([]string, error)
 }
 
 type DependencySolver struct {
+	// Registry is used to list the tags of a dependency when resolving its
+	// version. When it is not set, the tags are listed with crane.
+	Registry TagLister
+
+	// InsecureRegistry allows connecting to an unsecured registry when listing tags.
+	InsecureRegistry bool
 }
 
 func (s *DependencySolver) ResolveDependencies(bun bundle.Bundle) ([]DependencyLock, error) {
@@ -24,13 +82,19 @@ func (s *DependencySolver) ResolveDependencies(bun bundle.Bundle) ([]DependencyL
 	}
 
 	rawDeps, err := ReadDependencies(bun)
-	// We need make sure the Dependencies are ordered by the desired sequence
-	orderedDeps := rawDeps.ListBySequence()
-
 	if err != nil {
 		return nil, errors.Wrapf(err, "error executing dependencies for %s", bun.Name)
 	}
 
+	return s.Resolve(rawDeps)
+}
+
+// Resolve determines the bundle to use for each dependency, returned in the
+// order that the dependencies should be executed.
+func (s *DependencySolver) Resolve(deps Dependencies) ([]DependencyLock, error) {
+	// We need make sure the Dependencies are ordered by the desired sequence
+	orderedDeps := deps.ListBySequence()
+
 	q := make([]DependencyLock, 0, len(orderedDeps))
 	for _, dep := range orderedDeps {
 		ref, err := s.ResolveVersion(dep.Name, dep)
@@ -70,13 +134,18 @@ func (s *DependencySolver) ResolveVersion(name string, dep Dependency) (referenc
 		return reference.WithTag(ref, tag)
 	}
 
-	return nil, errors.Errorf("not implemented: dependency version range specified for %s", name)
+	tag, err := s.determineVersionInRange(dep)
+	if err != nil {
+		return nil, err
+	}
+
+	return reference.WithTag(ref, tag)
 }
 
 func (s *DependencySolver) determineDefaultTag(dep Dependency) (string, error) {
-	tags, err := crane.ListTags(dep.Bundle)
+	tags, err := s.listTags(dep.Bundle)
 	if err != nil {
-		return "", errors.Wrapf(err, "error listing tags for %s", dep.Bundle)
+		return "", err
 	}
 
 	allowPrereleases := false
@@ -85,22 +154,14 @@ func (s *DependencySolver) determineDefaultTag(dep Dependency) (string, error) {
 	}
 
 	var hasLatest bool
-	versions := make(semver.Collection, 0, len(tags))
 	for _, tag := range tags {
 		if tag == "latest" {
 			hasLatest = true
-			continue
-		}
-
-		version, err := semver.NewVersion(tag)
-		if err == nil {
-			if !allowPrereleases && version.Prerelease() != "" {
-				continue
-			}
-			versions = append(versions, version)
+			break
 		}
 	}
 
+	versions := FilterVersions(tags, allowPrereleases, nil)
 	if len(versions) == 0 {
 		if hasLatest {
 			return "latest", nil
@@ -113,3 +174,144 @@ func (s *DependencySolver) determineDefaultTag(dep Dependency) (string, error) {
 
 	return versions[0].Original(), nil
 }
+
+// determineVersionInRange returns the highest semver tag in the registry
+// that satisfies at least one of the dependency's version ranges.
+func (s *DependencySolver) determineVersionInRange(dep Dependency) (string, error) {
+	constraints := make([]*semver.Constraints, 0, len(dep.Version.Ranges))
+	for _, r := range dep.Version.Ranges {
+		c, err := semver.NewConstraint(r)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid version range %q for %s", r, dep.Bundle)
+		}
+		constraints = append(constraints, c)
+	}
+
+	tags, err := s.listTags(dep.Bundle)
+	if err != nil {
+		return "", err
+	}
+
+	versions := FilterVersions(tags, dep.Version.AllowPrereleases, constraints)
+	if len(versions) == 0 {
+		return "", errors.Errorf("none of the tags defined in the registry for %s satisfy the version ranges %v", dep.Bundle, dep.Version.Ranges)
+	}
+
+	sort.Sort(sort.Reverse(versions))
+
+	return versions[0].Original(), nil
+}
+
+// listTags returns the tags in the repository of a dependency bundle.
+func (s *DependencySolver) listTags(bundleRef string) ([]string, error) {
+	if s.Registry == nil {
+		tags, err := crane.ListTags(bundleRef)
+		return tags, errors.Wrapf(err, "error listing tags for %s", bundleRef)
+	}
+
+	ref, err := reference.ParseNormalizedNamed(bundleRef)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing bundle %q as OCI reference", bundleRef)
+	}
+	return s.Registry.ListTags(ref.Name(), s.InsecureRegistry)
+}
+
+// FilterVersions returns the tags that are semver formatted and, when
+// constraints are specified, satisfy at least one of them. Tags that are not
+// valid semantic versions are ignored.
+//
+// Prerelease versions are only included when allowPrereleases is true. Since
+// semver constraints never match a prerelease unless the constraint itself
+// has a prerelease, the constraints are rewritten with prerelease bounds
+// before checking a prerelease, so that it is matched by its semver order.
+// For example, >=1.4.0 doesn't match v1.4.0-rc1, and <1.5 matches v1.5.0-beta1.
+func FilterVersions(tags []string, allowPrereleases bool, constraints []*semver.Constraints) semver.Collection {
+	versions := make(semver.Collection, 0, len(tags))
+	for _, tag := range tags {
+		version, err := semver.NewVersion(tag)
+		if err != nil {
+			continue
+		}
+
+		if !allowPrereleases && version.Prerelease() != "" {
+			continue
+		}
+
+		if len(constraints) > 0 && !satisfiesAny(version, constraints) {
+			continue
+		}
+
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// satisfiesAny determines if a version satisfies at least one of the constraints.
+func satisfiesAny(version *semver.Version, constraints []*semver.Constraints) bool {
+	for _, c := range constraints {
+		if version.Prerelease() != "" {
+			var err error
+			c, err = prereleaseConstraint(c, version)
+			if err != nil {
+				continue
+			}
+		}
+
+		if c.Check(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// boundRegex splits a single constraint without a prerelease, such as >=v1.2,
+// into its operator, v prefix and version.
+var boundRegex = regexp.MustCompile(`^(!=|>=|=>|<=|=<|~>|>|<|=|~|\^)?(v?)([0-9xX*]+(?:\.[0-9xX*]+){0,2})$`)
+
+// prereleaseConstraint rewrites a constraint so that semver compares a
+// prerelease version with its bounds instead of rejecting it. A bound with
+// the same major, minor and patch as the version gets a prerelease just after
+// the version's, because the version is lower than that release. Any other
+// bound gets the lowest prerelease, -0, which doesn't change how it orders
+// against the version.
+func prereleaseConstraint(c *semver.Constraints, version *semver.Version) (*semver.Constraints, error) {
+	ors := strings.Split(c.String(), " || ")
+	for i, or := range ors {
+		ands := strings.Split(or, " ")
+		for j, and := range ands {
+			m := boundRegex.FindStringSubmatch(and)
+			if m == nil {
+				// The bound already has a prerelease or build metadata
+				continue
+			}
+			op, prefix, bound := m[1], m[2], m[3]
+			parts := strings.Split(bound, ".")
+
+			if isWildcard(parts[0]) {
+				// A wildcard major version, such as *, matches any version
+				switch op {
+				case "", "=", ">=", "=>", "~", "~>", "^":
+					ands[j] = ">=0.0.0-0"
+				}
+				continue
+			}
+
+			release := [3]uint64{}
+			for k, part := range parts {
+				release[k], _ = strconv.ParseUint(part, 10, 64) // wildcards are 0
+			}
+
+			pre := "0"
+			if release == [3]uint64{version.Major(), version.Minor(), version.Patch()} {
+				pre = version.Prerelease() + ".0"
+			}
+			ands[j] = op + prefix + bound + "-" + pre
+		}
+		ors[i] = strings.Join(ands, " ")
+	}
+	return semver.NewConstraint(strings.Join(ors, " || "))
+}
+
+func isWildcard(part string) bool {
+	return part == "x" || part == "X" || part == "*"
+}
@@ -3,6 +3,7 @@ package extensions
 import (
 	"testing"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/cnabio/cnab-go/bundle"
 
 	"github.com/stretchr/testify/assert"
@@ -59,9 +60,18 @@ func TestDependencySolver_ResolveVersion(t *testing.T) {
 		{name: "pinned version",
 			dep:         Dependency{Bundle: "mysql:5.7"},
 			wantVersion: "5.7"},
-		{name: "unimplemented range",
-			dep:       Dependency{Bundle: "mysql", Version: &DependencyVersion{Ranges: []string{"1 - 1.5"}}},
-			wantError: "not implemented"},
+		{name: "version range",
+			dep:         Dependency{Bundle: "getporterci/porter-test-with-versions", Version: &DependencyVersion{Ranges: []string{"1 - 1.5"}}},
+			wantVersion: "v1.2"},
+		{name: "version range with prereleases",
+			dep:         Dependency{Bundle: "getporterci/porter-test-with-versions", Version: &DependencyVersion{Ranges: []string{"1 - 1.5"}, AllowPrereleases: true}},
+			wantVersion: "v1.3-beta1"},
+		{name: "no version in range",
+			dep:       Dependency{Bundle: "getporterci/porter-test-with-versions", Version: &DependencyVersion{Ranges: []string{"2.x"}}},
+			wantError: "none of the tags"},
+		{name: "invalid version range",
+			dep:       Dependency{Bundle: "getporterci/porter-test-with-versions", Version: &DependencyVersion{Ranges: []string{"oops"}}},
+			wantError: "invalid version range"},
 		{name: "default tag to latest",
 			dep:         Dependency{Bundle: "getporterci/porter-test-only-latest"},
 			wantVersion: "latest"},
@@ -80,9 +90,9 @@ func TestDependencySolver_ResolveVersion(t *testing.T) {
 	}
 
 	for _, tc := range testcases {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			tc := tc
 
 			s := DependencySolver{}
 			version, err := s.ResolveVersion("mysql", tc.dep)
@@ -98,3 +108,152 @@ func TestDependencySolver_ResolveVersion(t *testing.T) {
 		})
 	}
 }
+
+type testTagLister struct {
+	tags             []string
+	repository       string
+	insecureRegistry bool
+}
+
+func (l *testTagLister) ListTags(repository string, insecureRegistry bool) ([]string, error) {
+	l.repository = repository
+	l.insecureRegistry = insecureRegistry
+	return l.tags, nil
+}
+
+func TestDependencySolver_ResolveVersion_Registry(t *testing.T) {
+	registry := &testTagLister{tags: []string{"v1.0.0", "v1.2.0", "v2.0.0"}}
+	s := DependencySolver{Registry: registry, InsecureRegistry: true}
+
+	dep := Dependency{Bundle: "localhost:5000/mysql", Version: &DependencyVersion{Ranges: []string{"1.x"}}}
+	version, err := s.ResolveVersion("mysql", dep)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0", version.Tag())
+	assert.Equal(t, "localhost:5000/mysql", registry.repository, "the tags should be listed with the registry")
+	assert.True(t, registry.insecureRegistry, "the insecure registry flag should be passed to the registry")
+}
+
+func TestFilterVersions(t *testing.T) {
+	t.Parallel()
+
+	tags := []string{"latest", "v1.0", "v1.2", "v1.3-beta1", "2.0.0", "stable"}
+
+	testcases := []struct {
+		name             string
+		tags             []string
+		ranges           []string
+		allowPrereleases bool
+		want             []string
+	}{
+		{name: "no ranges", want: []string{"v1.0", "v1.2", "2.0.0"}},
+		{name: "no ranges with prereleases", allowPrereleases: true, want: []string{"v1.0", "v1.2", "v1.3-beta1", "2.0.0"}},
+		{name: "single range", ranges: []string{"^1.1"}, want: []string{"v1.2"}},
+		{name: "single range with prereleases", ranges: []string{"^1.1"}, allowPrereleases: true, want: []string{"v1.2", "v1.3-beta1"}},
+		{name: "multiple ranges", ranges: []string{"1.0", ">= 2"}, want: []string{"v1.0", "2.0.0"}},
+		{name: "no match", ranges: []string{"3.x"}, want: []string{}},
+		{name: "prerelease lower than the minimum", tags: []string{"v1.3.0", "v1.4.0-rc1", "v1.4.1-rc1"}, ranges: []string{">=1.4.0"}, allowPrereleases: true, want: []string{"v1.4.1-rc1"}},
+		{name: "prerelease lower than the maximum", tags: []string{"v1.4.0", "v1.5.0-beta1", "v1.5.0"}, ranges: []string{"<1.5"}, allowPrereleases: true, want: []string{"v1.4.0", "v1.5.0-beta1"}},
+		{name: "prerelease of a tilde range", tags: []string{"v1.4.0-rc1", "v1.4.2-rc1", "v1.5.0-beta1"}, ranges: []string{"~1.4"}, allowPrereleases: true, want: []string{"v1.4.2-rc1"}},
+		{name: "prerelease of a wildcard range", tags: []string{"v1.4.0-rc1", "v2.0.0"}, ranges: []string{"*"}, allowPrereleases: true, want: []string{"v1.4.0-rc1", "v2.0.0"}},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			constraints := make([]*semver.Constraints, 0, len(tc.ranges))
+			for _, r := range tc.ranges {
+				c, err := semver.NewConstraint(r)
+				require.NoError(t, err)
+				constraints = append(constraints, c)
+			}
+
+			tags := tags
+			if tc.tags != nil {
+				tags = tc.tags
+			}
+			versions := FilterVersions(tags, tc.allowPrereleases, constraints)
+			got := make([]string, 0, len(versions))
+			for _, v := range versions {
+				got = append(got, v.Original())
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDependencyLock_GetPullReference(t *testing.T) {
+	t.Parallel()
+
+	digest := "sha256:3abc67269f59e3ed824e811a1ff1ee64f0d44c0218efefada57a4bebc2d7ef6f"
+
+	testcases := []struct {
+		name      string
+		lock      DependencyLock
+		wantRef   string
+		wantError string
+	}{
+		{name: "no digest",
+			lock:    DependencyLock{Alias: "mysql", Reference: "getporter/mysql:v0.1.3"},
+			wantRef: "getporter/mysql:v0.1.3"},
+		{name: "digest",
+			lock:    DependencyLock{Alias: "mysql", Reference: "getporter/mysql:v0.1.3", Digest: digest},
+			wantRef: "getporter/mysql@" + digest},
+		{name: "invalid digest",
+			lock:      DependencyLock{Alias: "mysql", Reference: "getporter/mysql:v0.1.3", Digest: "oops"},
+			wantError: "invalid digest"},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ref, err := tc.lock.GetPullReference()
+			if tc.wantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantRef, ref)
+			}
+		})
+	}
+}
+
+func TestDependencyLock_IsSameRepository(t *testing.T) {
+	t.Parallel()
+
+	lock := DependencyLock{Alias: "mysql", Reference: "getporter/mysql:v0.1.3"}
+
+	testcases := []struct {
+		name      string
+		ref       string
+		wantSame  bool
+		wantError string
+	}{
+		{name: "same tag", ref: "getporter/mysql:v0.1.3", wantSame: true},
+		{name: "different tag", ref: "getporter/mysql:v0.2.0", wantSame: true},
+		{name: "digest", ref: "docker.io/getporter/mysql@sha256:3abc67269f59e3ed824e811a1ff1ee64f0d44c0218efefada57a4bebc2d7ef6f", wantSame: true},
+		{name: "different repository", ref: "getporter/postgres:v0.1.3", wantSame: false},
+		{name: "different registry", ref: "localhost:5000/getporter/mysql:v0.1.3", wantSame: false},
+		{name: "invalid reference", ref: "oops:", wantError: "error parsing bundle reference"},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			same, err := lock.IsSameRepository(tc.ref)
+			if tc.wantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantSame, same)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	cnabaction "github.com/cnabio/cnab-go/action"
 	"github.com/cnabio/cnab-go/bundle"
 
+	"get.porter.sh/porter/pkg/cnab/extensions"
 	"get.porter.sh/porter/pkg/config"
 	"get.porter.sh/porter/pkg/yaml"
 	"github.com/cnabio/cnab-go/action"
@@ -29,6 +30,16 @@ type ActionArguments struct {
 	// Either a filepath to the bundle or the name of the bundle.
 	BundlePath string
 
+	// PreviousClaimID is the id of a previous claim for the installation. When
+	// set, the bundle, bundle reference and parameters from that claim are used,
+	// instead of BundlePath, BundleReference and Params, for example to roll
+	// back a failed upgrade.
+	PreviousClaimID string
+
+	// BundleReference is the reference to the bundle in an OCI registry, in the
+	// format REGISTRY/NAME:TAG. It is recorded on the claim when set.
+	BundleReference string
+
 	// Additional files to copy into the bundle
 	// Target Path => File Contents
 	Files map[string]string
@@ -36,6 +47,10 @@ type ActionArguments struct {
 	// Params is the set of user-specified parameter values to pass to the bundle.
 	Params map[string]string
 
+	// ParameterSets are the names of the parameter sets that Params were loaded from.
+	// They are recorded on the claim.
+	ParameterSets []string
+
 	// Either a filepath to a credential file or the name of a set of a credentials.
 	CredentialIdentifiers []string
 
@@ -47,6 +62,29 @@ type ActionArguments struct {
 
 	// Give the bundle privileged access to the docker daemon.
 	AllowDockerHostAccess bool
+
+	// DependencyInstallations maps a dependency alias to the name of its installation.
+	// When a dependency is not in the map, the installation name is derived from
+	// the name of this installation and the dependency alias.
+	DependencyInstallations map[string]string
+
+	// BoundDependencies maps a dependency alias to an existing installation
+	// that is used for the dependency, instead of an installation that is
+	// managed by this bundle. They are recorded on the claim so that later
+	// actions use the same installations.
+	BoundDependencies map[string]string
+
+	// Labels to apply to the installation, which are recorded on the claim.
+	// When nil, the labels from the last claim for the installation are kept.
+	Labels map[string]string
+}
+
+// GetDependencyInstallation returns the name of the installation for a dependency of the bundle.
+func (args ActionArguments) GetDependencyInstallation(alias string) string {
+	if installation, ok := args.DependencyInstallations[alias]; ok {
+		return installation
+	}
+	return extensions.BuildPrerequisiteInstallationName(args.Installation, alias)
 }
 
 func (r *Runtime) ApplyConfig(args ActionArguments) action.OperationConfigs {
@@ -110,51 +148,7 @@ func (r *Runtime) AddRelocation(args ActionArguments) action.OperationConfigFunc
 }
 
 func (r *Runtime) Execute(args ActionArguments) error {
-	if args.Action == "" {
-		return errors.New("action is required")
-	}
-
-	var b bundle.Bundle
-	var err error
-
-	if args.BundlePath != "" {
-		b, err = r.ProcessBundle(args.BundlePath)
-		if err != nil {
-			return err
-		}
-	}
-
-	existingClaim, err := r.claims.ReadLastClaim(args.Installation)
-	if err != nil {
-		// Only install and stateless actions can execute without an initial installation
-		if !(args.Action == claim.ActionInstall || b.Actions[args.Action].Stateless) {
-			return errors.Wrapf(err, "could not load installation %s", args.Installation)
-		}
-	}
-
-	// If the user didn't override the bundle definition, use the one
-	// from the existing claim
-	if existingClaim.ID != "" && args.BundlePath == "" {
-		b = existingClaim.Bundle
-	}
-
-	params, err := r.loadParameters(b, args)
-	if err != nil {
-		return errors.Wrap(err, "invalid parameters")
-	}
-
-	var c claim.Claim
-	if existingClaim.ID == "" {
-		c, err = claim.New(args.Installation, args.Action, b, params)
-	} else {
-		c, err = existingClaim.NewClaim(args.Action, b, params)
-	}
-	if err != nil {
-		return err
-	}
-
-	// Validate the action we are about to perform
-	err = c.Validate()
+	c, err := r.NewClaim(args)
 	if err != nil {
 		return err
 	}
@@ -197,7 +191,7 @@ func (r *Runtime) Execute(args ActionArguments) error {
 		}
 	}
 
-	r.printDebugInfo(creds, params)
+	r.printDebugInfo(creds, c.Parameters)
 
 	opResult, result, err := a.Run(c, creds, r.ApplyConfig(args)...)
 
@@ -212,6 +206,80 @@ func (r *Runtime) Execute(args ActionArguments) error {
 	}
 }
 
+// NewClaim creates the claim for an action, using the bundle, parameters and
+// last claim of the installation. The claim is not saved.
+func (r *Runtime) NewClaim(args ActionArguments) (claim.Claim, error) {
+	if args.Action == "" {
+		return claim.Claim{}, errors.New("action is required")
+	}
+
+	var b bundle.Bundle
+	var err error
+
+	if args.BundlePath != "" {
+		b, err = r.ProcessBundle(args.BundlePath)
+		if err != nil {
+			return claim.Claim{}, err
+		}
+	}
+
+	existingClaim, err := r.claims.ReadLastClaim(args.Installation)
+	if err != nil {
+		// Only install and stateless actions can execute without an initial installation
+		if !(args.Action == claim.ActionInstall || b.Actions[args.Action].Stateless) {
+			return claim.Claim{}, errors.Wrapf(err, "could not load installation %s", args.Installation)
+		}
+	}
+
+	// If the user didn't override the bundle definition, use the one
+	// from the existing claim
+	if existingClaim.ID != "" && args.BundlePath == "" {
+		b = existingClaim.Bundle
+	}
+
+	var params map[string]interface{}
+	var previousClaim claim.Claim
+	if args.PreviousClaimID != "" {
+		previousClaim, err = r.claims.ReadClaim(args.PreviousClaimID)
+		if err != nil {
+			return claim.Claim{}, errors.Wrapf(err, "could not load claim %s", args.PreviousClaimID)
+		}
+		if previousClaim.Installation != args.Installation {
+			return claim.Claim{}, errors.Errorf("claim %s is for installation %s, not %s", previousClaim.ID, previousClaim.Installation, args.Installation)
+		}
+
+		// Reuse exactly what was used before, parameter sources and defaults have already been applied
+		b = previousClaim.Bundle
+		params = previousClaim.Parameters
+	} else {
+		params, err = r.loadParameters(b, args)
+		if err != nil {
+			return claim.Claim{}, errors.Wrap(err, "invalid parameters")
+		}
+	}
+
+	var c claim.Claim
+	if existingClaim.ID == "" {
+		c, err = claim.New(args.Installation, args.Action, b, params)
+	} else {
+		c, err = existingClaim.NewClaim(args.Action, b, params)
+	}
+	if err != nil {
+		return claim.Claim{}, err
+	}
+	// Only keep the reference from the last claim when the bundle isn't changing
+	if previousClaim.ID != "" {
+		c.BundleReference = previousClaim.BundleReference
+	} else if args.BundlePath != "" || args.BundleReference != "" {
+		c.BundleReference = args.BundleReference
+	}
+	c.Custom = setCustomClaimData(c.Custom, args)
+
+	// Validate the action we are about to perform
+	err = c.Validate()
+	return c, err
+}
+
 // appendFailedResult creates a failed result from the operation error and accumulates
 // the error(s).
 func (r *Runtime) appendFailedResult(opErr error, c claim.Claim) error {
@@ -16,10 +16,32 @@ func (r *Runtime) loadCredentials(b bundle.Bundle, args ActionArguments) (values
 		return nil, credentials.Validate(nil, b.Credentials, args.Action)
 	}
 
+	csets, err := r.loadCredentialSets(args)
+	if err != nil {
+		return nil, err
+	}
+
 	// The strategy here is "last one wins". We loop through each credential file and
 	// calculate its credentials. Then we insert them into the creds map in the order
 	// in which they were supplied on the CLI.
 	resolvedCredentials := valuesource.Set{}
+	for _, cset := range csets {
+		rc, err := r.credentials.ResolveAll(cset)
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range rc {
+			resolvedCredentials[k] = v
+		}
+	}
+	return resolvedCredentials, credentials.Validate(resolvedCredentials, b.Credentials, args.Action)
+}
+
+// loadCredentialSets loads the credential sets specified for the action, in
+// the order in which they were supplied on the CLI.
+func (r *Runtime) loadCredentialSets(args ActionArguments) ([]credentials.CredentialSet, error) {
+	csets := make([]credentials.CredentialSet, 0, len(args.CredentialIdentifiers))
 	for _, name := range args.CredentialIdentifiers {
 		var cset credentials.CredentialSet
 		var err error
@@ -31,17 +53,9 @@ func (r *Runtime) loadCredentials(b bundle.Bundle, args ActionArguments) (values
 		if err != nil {
 			return nil, err
 		}
-
-		rc, err := r.credentials.ResolveAll(cset)
-		if err != nil {
-			return nil, err
-		}
-
-		for k, v := range rc {
-			resolvedCredentials[k] = v
-		}
+		csets = append(csets, cset)
 	}
-	return resolvedCredentials, credentials.Validate(resolvedCredentials, b.Credentials, args.Action)
+	return csets, nil
 }
 
 // isPathy checks to see if a name looks like a path.
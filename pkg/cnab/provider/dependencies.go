@@ -0,0 +1,115 @@
+package cnabprovider
+
+import (
+	"encoding/json"
+
+	"get.porter.sh/porter/pkg/config"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/pkg/errors"
+)
+
+// porterClaimData is the data that Porter records in the custom section of a claim.
+type porterClaimData struct {
+	// BoundDependencies maps a dependency alias to the existing installation
+	// used for the dependency.
+	BoundDependencies map[string]string `json:"boundDependencies,omitempty"`
+
+	// CredentialSets are the names of the credential sets used for the action.
+	CredentialSets []string `json:"credentialSets,omitempty"`
+
+	// ParameterSets are the names of the parameter sets used for the action.
+	ParameterSets []string `json:"parameterSets,omitempty"`
+
+	// Labels are the labels applied to the installation.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// GetBoundDependencies returns the existing installations that were used for
+// the dependencies of the bundle, as recorded on the claim.
+func GetBoundDependencies(c claim.Claim) (map[string]string, error) {
+	data, err := loadPorterClaimData(c.Custom)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read the dependencies of installation %s", c.Installation)
+	}
+	return data.BoundDependencies, nil
+}
+
+// GetCredentialSets returns the names of the credential sets that were used
+// for the action, as recorded on the claim.
+func GetCredentialSets(c claim.Claim) ([]string, error) {
+	data, err := loadPorterClaimData(c.Custom)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read the credential sets of installation %s", c.Installation)
+	}
+	return data.CredentialSets, nil
+}
+
+// GetParameterSets returns the names of the parameter sets that were used
+// for the action, as recorded on the claim.
+func GetParameterSets(c claim.Claim) ([]string, error) {
+	data, err := loadPorterClaimData(c.Custom)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read the parameter sets of installation %s", c.Installation)
+	}
+	return data.ParameterSets, nil
+}
+
+// GetLabels returns the labels of the installation, as recorded on the claim.
+func GetLabels(c claim.Claim) (map[string]string, error) {
+	data, err := loadPorterClaimData(c.Custom)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read the labels of installation %s", c.Installation)
+	}
+	return data.Labels, nil
+}
+
+func loadPorterClaimData(custom interface{}) (porterClaimData, error) {
+	var data porterClaimData
+
+	customMap, ok := custom.(map[string]interface{})
+	if !ok {
+		return data, nil
+	}
+	raw, ok := customMap[config.CustomPorterKey]
+	if !ok {
+		return data, nil
+	}
+
+	// The custom data is untyped after it is loaded from storage, so round trip
+	// it through json to get it back into our struct
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return data, errors.Wrap(err, "could not marshal the custom porter data from the claim")
+	}
+	err = json.Unmarshal(b, &data)
+	return data, errors.Wrap(err, "could not unmarshal the custom porter data from the claim")
+}
+
+// setCustomClaimData records the porter specific data for an action in the
+// custom section of a claim.
+func setCustomClaimData(custom interface{}, args ActionArguments) interface{} {
+	// Copy the custom data, it is shared with the previous claim for the installation
+	customMap := make(map[string]interface{}, 1)
+	if existing, ok := custom.(map[string]interface{}); ok {
+		for k, v := range existing {
+			customMap[k] = v
+		}
+	}
+
+	// We own everything stored under our key, if the existing data can't be
+	// read it's safe to replace it
+	data, _ := loadPorterClaimData(customMap)
+	if args.BoundDependencies != nil {
+		data.BoundDependencies = args.BoundDependencies
+	}
+	// Labels are kept until they are replaced by another action
+	if args.Labels != nil {
+		data.Labels = args.Labels
+	}
+	// Credentials and parameters are specified for every action, so only keep what was used this time
+	data.CredentialSets = args.CredentialIdentifiers
+	data.ParameterSets = args.ParameterSets
+	customMap[config.CustomPorterKey] = data
+
+	return customMap
+}
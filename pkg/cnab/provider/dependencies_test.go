@@ -0,0 +1,59 @@
+package cnabprovider
+
+import (
+	"testing"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntime_BoundDependencies(t *testing.T) {
+	t.Parallel()
+
+	r := NewTestRuntime(t)
+	r.TestConfig.TestContext.AddTestFile("testdata/bundle.json", "bundle.json")
+
+	args := ActionArguments{
+		Action:            claim.ActionInstall,
+		Installation:      "mybuns",
+		BundlePath:        "bundle.json",
+		BundleReference:   "getporter/mybuns:v0.1.0",
+		BoundDependencies: map[string]string{"mysql": "shared-mysql"},
+	}
+	err := r.Execute(args)
+	require.NoError(t, err, "Install failed")
+
+	c, err := r.claims.ReadLastClaim(args.Installation)
+	require.NoError(t, err, "ReadLastClaim failed")
+	assert.Equal(t, "getporter/mybuns:v0.1.0", c.BundleReference, "the bundle reference should be recorded")
+	bound, err := GetBoundDependencies(c)
+	require.NoError(t, err, "GetBoundDependencies failed")
+	assert.Equal(t, map[string]string{"mysql": "shared-mysql"}, bound, "the bound dependencies should be recorded")
+
+	// The bindings are kept when they aren't specified
+	args.Action = claim.ActionUpgrade
+	args.BundlePath = ""
+	args.BundleReference = ""
+	args.BoundDependencies = nil
+	err = r.Execute(args)
+	require.NoError(t, err, "Upgrade failed")
+
+	c, err = r.claims.ReadLastClaim(args.Installation)
+	require.NoError(t, err, "ReadLastClaim failed")
+	assert.Equal(t, "getporter/mybuns:v0.1.0", c.BundleReference, "the bundle reference should be kept from the previous claim")
+	bound, err = GetBoundDependencies(c)
+	require.NoError(t, err, "GetBoundDependencies failed")
+	assert.Equal(t, map[string]string{"mysql": "shared-mysql"}, bound, "the bound dependencies should be kept from the previous claim")
+
+	// The bindings are cleared when an empty set is specified
+	args.BoundDependencies = map[string]string{}
+	err = r.Execute(args)
+	require.NoError(t, err, "Upgrade failed")
+
+	c, err = r.claims.ReadLastClaim(args.Installation)
+	require.NoError(t, err, "ReadLastClaim failed")
+	bound, err = GetBoundDependencies(c)
+	require.NoError(t, err, "GetBoundDependencies failed")
+	assert.Empty(t, bound, "the bound dependencies should be cleared")
+}
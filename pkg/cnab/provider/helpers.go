@@ -50,3 +50,10 @@ func (t *TestRuntime) Execute(args ActionArguments) error {
 	}
 	return t.Runtime.Execute(args)
 }
+
+func (t *TestRuntime) Plan(args ActionArguments) (ActionPlan, error) {
+	if args.Driver == "" {
+		args.Driver = debugDriver
+	}
+	return t.Runtime.Plan(args)
+}
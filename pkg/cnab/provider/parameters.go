@@ -113,7 +113,7 @@ func (r *Runtime) resolveParameterSources(bun bundle.Bundle, args ActionArgument
 				installation = args.Installation
 				outputName = source.OutputName
 			case extensions.DependencyOutputParameterSource:
-				installation = extensions.BuildPrerequisiteInstallationName(args.Installation, source.Dependency)
+				installation = args.GetDependencyInstallation(source.Dependency)
 				outputName = source.OutputName
 			}
 
@@ -0,0 +1,185 @@
+package cnabprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	cnabaction "github.com/cnabio/cnab-go/action"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/driver"
+	"github.com/cnabio/cnab-go/secrets/host"
+	"github.com/cnabio/cnab-to-oci/relocation"
+	"github.com/pkg/errors"
+)
+
+// MaskedValue replaces sensitive values in an action plan.
+const MaskedValue = "******"
+
+// ActionPlan describes everything that would be used to execute an action,
+// without executing it.
+type ActionPlan struct {
+	Action          string                 `json:"action" yaml:"action"`
+	Installation    string                 `json:"installation" yaml:"installation"`
+	Bundle          string                 `json:"bundle" yaml:"bundle"`
+	Version         string                 `json:"version" yaml:"version"`
+	BundleReference string                 `json:"bundleReference,omitempty" yaml:"bundleReference,omitempty"`
+	Driver          string                 `json:"driver" yaml:"driver"`
+	InvocationImage string                 `json:"invocationImage" yaml:"invocationImage"`
+	Images          map[string]string      `json:"images,omitempty" yaml:"images,omitempty"`
+	Parameters      map[string]interface{} `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Credentials     []PlannedCredential    `json:"credentials,omitempty" yaml:"credentials,omitempty"`
+	Files           []string               `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+// PlannedCredential describes where the value of a credential is loaded from.
+type PlannedCredential struct {
+	Name          string `json:"name" yaml:"name"`
+	CredentialSet string `json:"credentialSet" yaml:"credentialSet"`
+	Source        string `json:"source" yaml:"source"`
+	Value         string `json:"value" yaml:"value"`
+}
+
+// planningDriver captures the operation that would be executed, without running it.
+type planningDriver struct {
+	op *driver.Operation
+}
+
+func (d *planningDriver) Run(op *driver.Operation) (driver.OperationResult, error) {
+	d.op = op
+	return driver.OperationResult{}, nil
+}
+
+func (d *planningDriver) Handles(imageType string) bool {
+	return true
+}
+
+// Plan resolves the bundle, parameters, credentials and files that an action
+// would use, without executing the action. Sensitive values are masked.
+func (r *Runtime) Plan(args ActionArguments) (ActionPlan, error) {
+	c, err := r.NewClaim(args)
+	if err != nil {
+		return ActionPlan{}, err
+	}
+
+	creds, err := r.loadCredentials(c.Bundle, args)
+	if err != nil {
+		return ActionPlan{}, errors.Wrap(err, "could not load credentials")
+	}
+
+	plannedCreds, err := r.planCredentials(args)
+	if err != nil {
+		return ActionPlan{}, errors.Wrap(err, "could not load credentials")
+	}
+
+	// Build the operation the same way as when the action is executed,
+	// so that the plan includes everything that is mounted into the bundle
+	d := &planningDriver{}
+	a := cnabaction.New(d, r.claims)
+	_, _, err = a.Run(c, creds, r.ApplyConfig(args)...)
+	if err != nil {
+		return ActionPlan{}, errors.Wrapf(err, "could not plan the %s action", args.Action)
+	}
+
+	reloMap, err := r.loadRelocationMapping(args)
+	if err != nil {
+		return ActionPlan{}, err
+	}
+
+	plan := ActionPlan{
+		Action:          c.Action,
+		Installation:    c.Installation,
+		Bundle:          c.Bundle.Name,
+		Version:         c.Bundle.Version,
+		BundleReference: c.BundleReference,
+		Driver:          args.Driver,
+		InvocationImage: d.op.Image.Image,
+		Images:          make(map[string]string, len(c.Bundle.Images)),
+		Parameters:      make(map[string]interface{}, len(c.Parameters)),
+		Credentials:     plannedCreds,
+		Files:           make([]string, 0, len(d.op.Files)),
+	}
+
+	for name, img := range c.Bundle.Images {
+		if mapped, ok := reloMap[img.Image]; ok {
+			plan.Images[name] = mapped
+		} else {
+			plan.Images[name] = img.Image
+		}
+	}
+
+	for name, value := range c.Parameters {
+		if IsParameterSensitive(c.Bundle, name) {
+			value = MaskedValue
+		}
+		plan.Parameters[name] = value
+	}
+
+	for path := range d.op.Files {
+		plan.Files = append(plan.Files, path)
+	}
+	sort.Strings(plan.Files)
+
+	return plan, nil
+}
+
+// planCredentials determines where the value of each credential is loaded from.
+// When a credential is defined in multiple credential sets, the last one wins.
+func (r *Runtime) planCredentials(args ActionArguments) ([]PlannedCredential, error) {
+	csets, err := r.loadCredentialSets(args)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := make(map[string]PlannedCredential)
+	for _, cset := range csets {
+		for _, cred := range cset.Credentials {
+			source := cred.Source.Value
+			// Hard-coded values are the credential, not a pointer to it
+			if cred.Source.Key == host.SourceValue {
+				source = MaskedValue
+			}
+			lookup[cred.Name] = PlannedCredential{
+				Name:          cred.Name,
+				CredentialSet: cset.Name,
+				Source:        fmt.Sprintf("%s: %s", cred.Source.Key, source),
+				Value:         MaskedValue,
+			}
+		}
+	}
+
+	plannedCreds := make([]PlannedCredential, 0, len(lookup))
+	for _, cred := range lookup {
+		plannedCreds = append(plannedCreds, cred)
+	}
+	sort.Slice(plannedCreds, func(i, j int) bool {
+		return plannedCreds[i].Name < plannedCreds[j].Name
+	})
+
+	return plannedCreds, nil
+}
+
+func (r *Runtime) loadRelocationMapping(args ActionArguments) (relocation.ImageRelocationMap, error) {
+	if args.RelocationMapping == "" {
+		return nil, nil
+	}
+
+	b, err := r.FileSystem.ReadFile(args.RelocationMapping)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the relocation mapping")
+	}
+
+	var reloMap relocation.ImageRelocationMap
+	err = json.Unmarshal(b, &reloMap)
+	return reloMap, errors.Wrap(err, "unable to parse the relocation mapping")
+}
+
+// IsParameterSensitive determines if the value of a parameter should not be displayed.
+func IsParameterSensitive(bun bundle.Bundle, name string) bool {
+	param, ok := bun.Parameters[name]
+	if !ok {
+		return false
+	}
+	def, ok := bun.Definitions[param.Definition]
+	return ok && def.WriteOnly != nil && *def.WriteOnly
+}
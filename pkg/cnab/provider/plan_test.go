@@ -0,0 +1,129 @@
+package cnabprovider
+
+import (
+	"encoding/json"
+	"testing"
+
+	"get.porter.sh/porter/pkg/secrets"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/cnabio/cnab-go/secrets/host"
+	"github.com/cnabio/cnab-go/valuesource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntime_Plan(t *testing.T) {
+	t.Parallel()
+
+	r := NewTestRuntime(t)
+	r.TestConfig.TestContext.AddTestFile("testdata/relocation-mapping.json", "/relocation-mapping.json")
+	r.TestCredentials.TestSecrets.AddSecret("password", "mypassword")
+	r.TestCredentials.TestSecrets.AddSecret("token", "abc123")
+
+	writeOnly := true
+	bun := bundle.Bundle{
+		SchemaVersion: "v1.0.0",
+		Name:          "mybuns",
+		Version:       "1.0.0",
+		InvocationImages: []bundle.InvocationImage{
+			{BaseImage: bundle.BaseImage{Image: "technosophos/helloworld:0.1.0", ImageType: "docker"}},
+		},
+		Images: map[string]bundle.Image{
+			"app":  {BaseImage: bundle.BaseImage{Image: "gabrtv/microservice@sha256:cca460afa270d4c527981ef9ca4989346c56cf9b20217dcea37df1ece8120687", ImageType: "docker"}},
+			"test": {BaseImage: bundle.BaseImage{Image: "getporter/test:v1.0.0", ImageType: "docker"}},
+		},
+		Definitions: definition.Definitions{
+			"string":    {Type: "string"},
+			"sensitive": {Type: "string", WriteOnly: &writeOnly},
+		},
+		Parameters: map[string]bundle.Parameter{
+			"color":    {Definition: "string", Destination: &bundle.Location{EnvironmentVariable: "COLOR"}},
+			"db-pass":  {Definition: "sensitive", Destination: &bundle.Location{EnvironmentVariable: "DB_PASS"}},
+			"greeting": {Definition: "string", Destination: &bundle.Location{Path: "/cnab/app/greeting.txt"}},
+		},
+		Credentials: map[string]bundle.Credential{
+			"password": {Location: bundle.Location{EnvironmentVariable: "PASSWORD"}},
+			"token":    {Location: bundle.Location{Path: "/root/.token"}},
+		},
+	}
+	bunData, err := json.Marshal(bun)
+	require.NoError(t, err)
+	require.NoError(t, r.FileSystem.WriteFile("/bundle.json", bunData, 0644))
+
+	cs := credentials.NewCredentialSet("mycreds",
+		valuesource.Strategy{Name: "password", Source: valuesource.Source{Key: secrets.SourceSecret, Value: "password"}},
+		valuesource.Strategy{Name: "token", Source: valuesource.Source{Key: secrets.SourceSecret, Value: "token"}},
+	)
+	require.NoError(t, r.credentials.Save(cs))
+
+	args := ActionArguments{
+		Action:                claim.ActionInstall,
+		Installation:          "mybuns",
+		BundlePath:            "/bundle.json",
+		BundleReference:       "getporter/mybuns:v1.0.0",
+		Params:                map[string]string{"color": "blue", "db-pass": "topsecret", "greeting": "hello"},
+		CredentialIdentifiers: []string{"mycreds"},
+		RelocationMapping:     "/relocation-mapping.json",
+		Files:                 map[string]string{"/cnab/app/dependencies/mysql/bundle.json": "{}"},
+	}
+	plan, err := r.Plan(args)
+	require.NoError(t, err, "Plan failed")
+
+	assert.Equal(t, claim.ActionInstall, plan.Action)
+	assert.Equal(t, "mybuns", plan.Installation)
+	assert.Equal(t, "mybuns", plan.Bundle)
+	assert.Equal(t, "1.0.0", plan.Version)
+	assert.Equal(t, "getporter/mybuns:v1.0.0", plan.BundleReference)
+	assert.Equal(t, debugDriver, plan.Driver)
+	assert.Equal(t, "my.registry/helloworld:0.1.0", plan.InvocationImage, "the invocation image should be relocated")
+	assert.Equal(t, map[string]string{
+		"app":  "my.registry/microservice@sha256:cca460afa270d4c527981ef9ca4989346c56cf9b20217dcea37df1ece8120687",
+		"test": "getporter/test:v1.0.0",
+	}, plan.Images, "the images should be relocated")
+	assert.Equal(t, map[string]interface{}{"color": "blue", "db-pass": MaskedValue, "greeting": "hello"}, plan.Parameters, "sensitive parameters should be masked")
+	assert.Equal(t, []PlannedCredential{
+		{Name: "password", CredentialSet: "mycreds", Source: "secret: password", Value: MaskedValue},
+		{Name: "token", CredentialSet: "mycreds", Source: "secret: token", Value: MaskedValue},
+	}, plan.Credentials, "credential values should be masked")
+	assert.Equal(t, []string{
+		"/cnab/app/dependencies/mysql/bundle.json",
+		"/cnab/app/greeting.txt",
+		"/cnab/app/image-map.json",
+		"/cnab/app/relocation-mapping.json",
+		"/cnab/bundle.json",
+		"/cnab/claim.json",
+		"/root/.token",
+	}, plan.Files)
+
+	// Nothing should be recorded for the installation
+	_, err = r.claims.ReadLastClaim(args.Installation)
+	require.Error(t, err, "the plan should not save a claim")
+}
+
+func TestRuntime_planCredentials(t *testing.T) {
+	t.Parallel()
+
+	r := NewTestRuntime(t)
+
+	cs1 := credentials.NewCredentialSet("cs1",
+		valuesource.Strategy{Name: "password", Source: valuesource.Source{Key: secrets.SourceSecret, Value: "password"}},
+		valuesource.Strategy{Name: "token", Source: valuesource.Source{Key: host.SourceEnv, Value: "TOKEN"}},
+	)
+	require.NoError(t, r.credentials.Save(cs1))
+	cs2 := credentials.NewCredentialSet("cs2",
+		valuesource.Strategy{Name: "token", Source: valuesource.Source{Key: host.SourceValue, Value: "abc123"}},
+	)
+	require.NoError(t, r.credentials.Save(cs2))
+
+	args := ActionArguments{CredentialIdentifiers: []string{"cs1", "cs2"}}
+	creds, err := r.planCredentials(args)
+	require.NoError(t, err, "planCredentials failed")
+
+	assert.Equal(t, []PlannedCredential{
+		{Name: "password", CredentialSet: "cs1", Source: "secret: password", Value: MaskedValue},
+		{Name: "token", CredentialSet: "cs2", Source: "value: " + MaskedValue, Value: MaskedValue},
+	}, creds, "the last credential set should win and hard-coded values should be masked")
+}
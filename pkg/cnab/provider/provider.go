@@ -2,10 +2,17 @@ package cnabprovider
 
 import (
 	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
 )
 
 // CNABProvider is the interface Porter uses to communicate with the CNAB runtime
 type CNABProvider interface {
 	LoadBundle(bundleFile string) (bundle.Bundle, error)
 	Execute(arguments ActionArguments) error
+
+	// NewClaim creates the claim that an action would record, without executing it.
+	NewClaim(arguments ActionArguments) (claim.Claim, error)
+
+	// Plan resolves everything that would be used to execute an action, without executing it.
+	Plan(arguments ActionArguments) (ActionPlan, error)
 }
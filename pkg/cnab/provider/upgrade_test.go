@@ -54,4 +54,40 @@ func TestRuntime_Upgrade(t *testing.T) {
 		require.Error(t, err, "Upgrade should have failed")
 		assert.Contains(t, err.Error(), "Installation does not exist")
 	})
+	t.Run("previous claim", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewTestRuntime(t)
+		r.TestConfig.TestContext.AddTestFile("testdata/bundle.json", "bundle.json")
+
+		previousBundle := bundle.Bundle{Name: "mybuns", Version: "0.1.0"}
+		previousClaim, err := claim.New("mybuns", claim.ActionInstall, previousBundle, map[string]interface{}{"color": "blue"})
+		require.NoError(t, err, "New claim failed")
+		previousClaim.BundleReference = "getporter/mybuns:v0.1.0"
+		err = r.claims.SaveClaim(previousClaim)
+		require.NoError(t, err, "SaveClaim failed")
+
+		args := ActionArguments{
+			Action:          claim.ActionUpgrade,
+			Installation:    "mybuns",
+			PreviousClaimID: previousClaim.ID,
+		}
+		c, err := r.NewClaim(args)
+		require.NoError(t, err, "NewClaim failed")
+
+		assert.Equal(t, claim.ActionUpgrade, c.Action, "wrong action recorded")
+		assert.Equal(t, previousBundle, c.Bundle, "the bundle from the previous claim should be used")
+		assert.Equal(t, previousClaim.Parameters, c.Parameters, "the parameters from the previous claim should be used")
+		assert.Equal(t, previousClaim.BundleReference, c.BundleReference, "the bundle reference from the previous claim should be used")
+
+		otherClaim, err := claim.New("otherbuns", claim.ActionInstall, bundle.Bundle{}, nil)
+		require.NoError(t, err, "New claim failed")
+		err = r.claims.SaveClaim(otherClaim)
+		require.NoError(t, err, "SaveClaim failed")
+
+		args.Installation = "otherbuns"
+		_, err = r.NewClaim(args)
+		require.Error(t, err, "NewClaim should reject a claim from another installation")
+		assert.Contains(t, err.Error(), "is for installation mybuns, not otherbuns")
+	})
 }
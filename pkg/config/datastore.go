@@ -1,6 +1,10 @@
 package config
 
 import (
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
 	"github.com/pkg/errors"
 )
 
@@ -27,6 +31,75 @@ type Data struct {
 
 	// SecretSources defined in the configuration file.
 	SecretSources []SecretSource `mapstructure:"secrets"`
+
+	// TrustPolicies defined in the configuration file.
+	TrustPolicies []TrustPolicy `mapstructure:"trust"`
+
+	// Environments defined in the configuration file, that bundles are promoted to.
+	Environments []Environment `mapstructure:"environments"`
+
+	// CacheSize is the maximum size of the bundle cache, such as 500MiB.
+	// When empty, the size of the cache is not limited.
+	CacheSize string `mapstructure:"cache-size"`
+
+	// CacheTTL is how long a cached bundle tag is used before checking the
+	// registry to see if the tag was pushed again, such as 10m. When empty,
+	// the registry is checked every time that the tag is used.
+	CacheTTL string `mapstructure:"cache-ttl"`
+
+	// ClaimRetention limits how much of the history of each installation is kept.
+	ClaimRetention ClaimRetention `mapstructure:"claim-retention"`
+}
+
+// ClaimRetention is the retention policy for the claims of an installation.
+// A claim is kept when any of the limits keep it, and the most recent claim,
+// and the most recent successful claim, of an installation are always kept.
+type ClaimRetention struct {
+	// KeepLast is the number of the most recent claims to keep for each
+	// installation. When 0, the number of claims is not limited.
+	KeepLast int `mapstructure:"keep-last"`
+
+	// KeepDays is the number of days that claims are kept for. When 0, the age
+	// of claims is not limited.
+	KeepDays int `mapstructure:"keep-days"`
+
+	// AutoPrune removes the claims that are not kept after each action.
+	AutoPrune bool `mapstructure:"auto-prune"`
+}
+
+// Environment is a registry that bundles are promoted to, such as staging or
+// prod, and the policies that a bundle must meet to be promoted there.
+type Environment struct {
+	// Name of the environment, used with porter bundles promote --to.
+	Name string `mapstructure:"name"`
+
+	// Registry prefix that bundles are promoted to, such as
+	// prod.example.com/myorg.
+	Registry string `mapstructure:"registry"`
+
+	// AllowedTags are glob patterns, such as v*, that the tag of a promoted
+	// bundle must match. When empty, any tag is allowed.
+	AllowedTags []string `mapstructure:"allowed-tags"`
+
+	// MutableTags are glob patterns, such as latest, for tags that may be
+	// overwritten by a promotion. All other tags are immutable.
+	MutableTags []string `mapstructure:"mutable-tags"`
+
+	// Keys are paths to PEM encoded public keys. When set, a bundle must be
+	// signed by one of these keys before it is promoted.
+	Keys []string `mapstructure:"keys"`
+}
+
+// TrustPolicy is the set of public keys that are trusted to sign bundles from
+// a registry or repository.
+type TrustPolicy struct {
+	// Repository that the policy applies to, such as docker.io/getporter or
+	// docker.io/getporter/wordpress. Use * to apply the policy to every repository.
+	Repository string `mapstructure:"repository"`
+
+	// Keys are paths to PEM encoded public keys. Bundles must be signed by one
+	// of these keys, when there are no keys bundles are not allowed.
+	Keys []string `mapstructure:"keys"`
 }
 
 // SecretSource is the plugin stanza for secrets.
@@ -95,6 +168,98 @@ func (d *Data) GetSecretSource(name string) (SecretSource, error) {
 	return SecretSource{}, errors.New("secrets %q not defined")
 }
 
+// GetCacheSize returns the maximum size of the bundle cache in bytes,
+// or 0 when the size of the cache is not limited.
+func (d *Data) GetCacheSize() (int64, error) {
+	if d == nil || d.CacheSize == "" {
+		return 0, nil
+	}
+
+	size, err := humanize.ParseBytes(d.CacheSize)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid cache-size %s", d.CacheSize)
+	}
+	return int64(size), nil
+}
+
+// GetCacheTTL returns how long a cached bundle tag is used before checking
+// the registry to see if the tag was pushed again, or 0 when the registry is
+// always checked.
+func (d *Data) GetCacheTTL() (time.Duration, error) {
+	if d == nil || d.CacheTTL == "" {
+		return 0, nil
+	}
+
+	ttl, err := time.ParseDuration(d.CacheTTL)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid cache-ttl %s", d.CacheTTL)
+	}
+	if ttl < 0 {
+		return 0, errors.Errorf("invalid cache-ttl %s, the duration cannot be negative", d.CacheTTL)
+	}
+	return ttl, nil
+}
+
+// GetClaimRetention returns the retention policy for the claims of installations.
+func (d *Data) GetClaimRetention() (ClaimRetention, error) {
+	if d == nil {
+		return ClaimRetention{}, nil
+	}
+
+	r := d.ClaimRetention
+	if r.KeepLast < 0 {
+		return ClaimRetention{}, errors.Errorf("invalid claim-retention keep-last %d, it cannot be negative", r.KeepLast)
+	}
+	if r.KeepDays < 0 {
+		return ClaimRetention{}, errors.Errorf("invalid claim-retention keep-days %d, it cannot be negative", r.KeepDays)
+	}
+	return r, nil
+}
+
+// GetEnvironment returns the environment with the specified name.
+func (d *Data) GetEnvironment(name string) (Environment, error) {
+	if d != nil {
+		for _, env := range d.Environments {
+			if env.Name == name {
+				return env, nil
+			}
+		}
+	}
+
+	return Environment{}, errors.Errorf("environment %q is not defined in the porter config", name)
+}
+
+// GetTrustPolicy returns the most specific trust policy for a repository,
+// in the format REGISTRY/NAME, for example docker.io/getporter/wordpress.
+func (d *Data) GetTrustPolicy(repository string) (TrustPolicy, bool) {
+	var match TrustPolicy
+	found := false
+	if d == nil {
+		return match, found
+	}
+
+	for _, tp := range d.TrustPolicies {
+		if tp.Repository == "*" {
+			if !found {
+				match = tp
+				found = true
+			}
+			continue
+		}
+
+		prefix := strings.TrimSuffix(tp.Repository, "/")
+		if repository != prefix && !strings.HasPrefix(repository, prefix+"/") {
+			continue
+		}
+		if !found || match.Repository == "*" || len(prefix) > len(strings.TrimSuffix(match.Repository, "/")) {
+			match = tp
+			found = true
+		}
+	}
+
+	return match, found
+}
+
 // PluginConfig is a standardized config stanza that defines which plugin to
 // use and its custom configuration.
 type PluginConfig struct {
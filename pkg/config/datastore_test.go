@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -60,3 +61,102 @@ func TestData_SecretAccessors(t *testing.T) {
 	assert.Equal(t, "prod", source.Name, "SecretSource.Name returned the wrong value")
 	assert.Equal(t, "azure.keyvault", source.PluginSubKey, "SecretSource.PluginSubKey returned the wrong value")
 }
+
+func TestData_GetTrustPolicy(t *testing.T) {
+	d := Data{
+		TrustPolicies: []TrustPolicy{
+			{Repository: "*"},
+			{Repository: "docker.io/getporter", Keys: []string{"getporter.pub"}},
+			{Repository: "docker.io/getporter/wordpress/", Keys: []string{"wordpress.pub"}},
+			{Repository: "localhost:5000", Keys: []string{"local.pub"}},
+		},
+	}
+
+	testcases := []struct {
+		repository string
+		want       string
+	}{
+		{"docker.io/getporter/wordpress", "docker.io/getporter/wordpress/"},
+		{"docker.io/getporter/mysql", "docker.io/getporter"},
+		{"docker.io/getporterz/mysql", "*"},
+		{"localhost:5000/mysql", "localhost:5000"},
+		{"quay.io/someone/mysql", "*"},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.repository, func(t *testing.T) {
+			tp, ok := d.GetTrustPolicy(tc.repository)
+			require.True(t, ok, "expected a trust policy")
+			assert.Equal(t, tc.want, tp.Repository)
+		})
+	}
+
+	var empty Data
+	_, ok := empty.GetTrustPolicy("docker.io/getporter/wordpress")
+	assert.False(t, ok, "no trust policy should apply when none are configured")
+}
+
+func TestData_GetEnvironment(t *testing.T) {
+	d := Data{
+		Environments: []Environment{
+			{Name: "staging", Registry: "staging.example.com/porter"},
+			{Name: "prod", Registry: "prod.example.com/porter", AllowedTags: []string{"v*"}},
+		},
+	}
+
+	env, err := d.GetEnvironment("prod")
+	require.NoError(t, err, "GetEnvironment failed")
+	assert.Equal(t, "prod.example.com/porter", env.Registry)
+	assert.Equal(t, []string{"v*"}, env.AllowedTags)
+
+	_, err = d.GetEnvironment("dev")
+	require.EqualError(t, err, `environment "dev" is not defined in the porter config`)
+}
+
+func TestData_GetCacheSize(t *testing.T) {
+	var d *Data
+	size, err := d.GetCacheSize()
+	require.NoError(t, err, "GetCacheSize failed")
+	assert.Equal(t, int64(0), size, "the cache should be unlimited by default")
+
+	d = &Data{CacheSize: "500MiB"}
+	size, err = d.GetCacheSize()
+	require.NoError(t, err, "GetCacheSize failed")
+	assert.Equal(t, int64(500*1024*1024), size)
+
+	d = &Data{CacheSize: "lots"}
+	_, err = d.GetCacheSize()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid cache-size lots")
+}
+
+func TestData_GetCacheTTL(t *testing.T) {
+	var d *Data
+	ttl, err := d.GetCacheTTL()
+	require.NoError(t, err, "GetCacheTTL failed")
+	assert.Equal(t, time.Duration(0), ttl, "the registry should always be checked by default")
+
+	d = &Data{CacheTTL: "10m"}
+	ttl, err = d.GetCacheTTL()
+	require.NoError(t, err, "GetCacheTTL failed")
+	assert.Equal(t, 10*time.Minute, ttl)
+
+	d = &Data{CacheTTL: "-1h"}
+	_, err = d.GetCacheTTL()
+	require.EqualError(t, err, "invalid cache-ttl -1h, the duration cannot be negative")
+}
+
+func TestData_GetClaimRetention(t *testing.T) {
+	var d *Data
+	r, err := d.GetClaimRetention()
+	require.NoError(t, err, "GetClaimRetention failed")
+	assert.Equal(t, ClaimRetention{}, r, "every claim should be kept by default")
+
+	d = &Data{ClaimRetention: ClaimRetention{KeepLast: 10, KeepDays: 30, AutoPrune: true}}
+	r, err = d.GetClaimRetention()
+	require.NoError(t, err, "GetClaimRetention failed")
+	assert.Equal(t, d.ClaimRetention, r)
+
+	d = &Data{ClaimRetention: ClaimRetention{KeepDays: -1}}
+	_, err = d.GetClaimRetention()
+	require.EqualError(t, err, "invalid claim-retention keep-days -1, it cannot be negative")
+}
@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"get.porter.sh/porter/pkg/context"
 	"get.porter.sh/porter/pkg/yaml"
@@ -588,6 +589,10 @@ type Dependency struct {
 	Versions         []string          `yaml:"versions"`
 	AllowPrereleases bool              `yaml:"prereleases"`
 	Parameters       map[string]string `yaml:"parameters,omitempty"`
+
+	// Installation is the name of an existing installation to use for the
+	// dependency, instead of installing the dependency with the bundle.
+	Installation string `yaml:"installation,omitempty"`
 }
 
 func (d *Dependency) Validate(cxt *context.Context) error {
@@ -730,6 +735,73 @@ func (s Steps) Validate(m *Manifest) error {
 
 type Step struct {
 	Data map[string]interface{} `yaml:",inline"`
+
+	// Retry configures retrying the step when it fails.
+	Retry *StepRetry `yaml:"retry,omitempty"`
+}
+
+// DefaultStepBackoff is how long to wait before retrying a failed step, when
+// the step doesn't specify a backoff.
+const DefaultStepBackoff = time.Second
+
+// StepRetry configures retrying a step when it fails, for steps that call
+// flaky operations.
+type StepRetry struct {
+	// Attempts is the number of times to retry the step after it fails.
+	Attempts int `yaml:"attempts"`
+
+	// Backoff is how long to wait before the first retry, such as 10s. The
+	// wait doubles after each attempt.
+	Backoff string `yaml:"backoff,omitempty"`
+
+	// MaxBackoff is the longest to wait between attempts.
+	MaxBackoff string `yaml:"maxBackoff,omitempty"`
+}
+
+func (r *StepRetry) Validate() error {
+	if r.Attempts < 0 {
+		return errors.Errorf("invalid retry attempts %d, the value cannot be negative", r.Attempts)
+	}
+
+	if r.Backoff != "" {
+		if _, err := time.ParseDuration(r.Backoff); err != nil {
+			return errors.Wrapf(err, "invalid retry backoff %q", r.Backoff)
+		}
+	}
+
+	if r.MaxBackoff != "" {
+		if _, err := time.ParseDuration(r.MaxBackoff); err != nil {
+			return errors.Wrapf(err, "invalid retry maxBackoff %q", r.MaxBackoff)
+		}
+	}
+
+	return nil
+}
+
+// GetBackoff returns how long to wait before the specified retry attempt,
+// starting at 1.
+func (r *StepRetry) GetBackoff(attempt int) time.Duration {
+	backoff := DefaultStepBackoff
+	if r.Backoff != "" {
+		backoff, _ = time.ParseDuration(r.Backoff)
+	}
+
+	var maxBackoff time.Duration
+	if r.MaxBackoff != "" {
+		maxBackoff, _ = time.ParseDuration(r.MaxBackoff)
+	}
+
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if maxBackoff > 0 && backoff >= maxBackoff {
+			break
+		}
+	}
+
+	if maxBackoff > 0 && backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
 }
 
 func (s *Step) Validate(m *Manifest) error {
@@ -759,6 +831,12 @@ func (s *Step) Validate(m *Manifest) error {
 		return err
 	}
 
+	if s.Retry != nil {
+		if err := s.Retry.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid retry for the %s step", mixinType)
+		}
+	}
+
 	return nil
 }
 
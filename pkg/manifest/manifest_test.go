@@ -3,6 +3,7 @@ package manifest
 import (
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"get.porter.sh/porter/pkg/config"
 	"get.porter.sh/porter/pkg/context"
@@ -716,3 +717,58 @@ func TestParameterDefinition_UpdateApplyTo(t *testing.T) {
 		})
 	}
 }
+
+func TestStepRetry_Validate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		retry   StepRetry
+		wantErr string
+	}{
+		{name: "valid", retry: StepRetry{Attempts: 3, Backoff: "10s", MaxBackoff: "1m"}},
+		{name: "negative attempts", retry: StepRetry{Attempts: -1}, wantErr: "invalid retry attempts -1"},
+		{name: "invalid backoff", retry: StepRetry{Attempts: 1, Backoff: "soon"}, wantErr: `invalid retry backoff "soon"`},
+		{name: "invalid max backoff", retry: StepRetry{Attempts: 1, MaxBackoff: "10"}, wantErr: `invalid retry maxBackoff "10"`},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.retry.Validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestStepRetry_GetBackoff(t *testing.T) {
+	r := StepRetry{Attempts: 5}
+	assert.Equal(t, DefaultStepBackoff, r.GetBackoff(1), "the default backoff should be used")
+	assert.Equal(t, 2*DefaultStepBackoff, r.GetBackoff(2), "the backoff should double after each attempt")
+
+	r = StepRetry{Attempts: 5, Backoff: "10s", MaxBackoff: "30s"}
+	assert.Equal(t, 10*time.Second, r.GetBackoff(1))
+	assert.Equal(t, 20*time.Second, r.GetBackoff(2))
+	assert.Equal(t, 30*time.Second, r.GetBackoff(3), "the backoff should not exceed the max")
+	assert.Equal(t, 30*time.Second, r.GetBackoff(5), "the backoff should not exceed the max")
+}
+
+func TestUnmarshalManifest_StepRetry(t *testing.T) {
+	cxt := context.NewTestContext(t)
+	cxt.AddTestFile("testdata/porter-with-retry.yaml", config.Name)
+
+	m, err := LoadManifestFrom(cxt.Context, config.Name)
+	require.NoError(t, err, "could not load manifest")
+
+	require.Len(t, m.Install, 1)
+	step := m.Install[0]
+	assert.Equal(t, "exec", step.GetMixinName(), "the retry should not be treated as a mixin")
+	require.NotNil(t, step.Retry, "the retry should be loaded")
+	assert.Equal(t, StepRetry{Attempts: 3, Backoff: "5s"}, *step.Retry)
+
+	require.Len(t, m.CustomActions["status"], 1)
+	assert.Nil(t, m.CustomActions["status"][0].Retry)
+}
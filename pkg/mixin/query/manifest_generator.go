@@ -46,12 +46,13 @@ func (g ManifestGenerator) buildInputForMixin(mixinName string) BuildInput {
 	}
 
 	filterSteps := func(action string, steps manifest.Steps) {
-		mixinSteps := manifest.Steps{}
+		// Only pass the mixin's data, fields like retry are handled by porter
+		mixinSteps := []map[string]interface{}{}
 		for _, step := range steps {
 			if step.GetMixinName() != mixinName {
 				continue
 			}
-			mixinSteps = append(mixinSteps, step)
+			mixinSteps = append(mixinSteps, step.Data)
 		}
 		input.Actions[action] = mixinSteps
 	}
@@ -27,6 +27,35 @@ func (p *Porter) ExecuteAction(action BundleAction) error {
 		return err
 	}
 
+	if actionOpts.DryRun {
+		if err = p.checkRequiredChanges(action); err != nil {
+			return err
+		}
+		return p.planAction(action, deperator)
+	}
+
+	err = p.loadLayoutImages(actionOpts)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := p.lockInstallations(actionOpts, deperator)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer p.autoPruneInstallation(actionOpts.Name)
+
+	err = p.checkRequiredChanges(action)
+	if err != nil {
+		return err
+	}
+
+	rollbackTo, err := p.getRollbackClaim(action)
+	if err != nil {
+		return err
+	}
+
 	err = deperator.Execute()
 	if err != nil {
 		return err
@@ -39,5 +68,9 @@ func (p *Porter) ExecuteAction(action BundleAction) error {
 	deperator.PrepareRootActionArguments(&actionArgs)
 
 	fmt.Fprintf(p.Out, "%s %s...\n", action.GetActionVerb(), actionOpts.Name)
-	return p.CNAB.Execute(actionArgs)
+	err = p.CNAB.Execute(actionArgs)
+	if err != nil && rollbackTo != nil {
+		return p.rollbackUpgrade(actionArgs, *rollbackTo, err)
+	}
+	return err
 }
@@ -12,6 +12,11 @@ import (
 	"github.com/cnabio/cnab-go/imagestore"
 	"github.com/cnabio/cnab-go/imagestore/construction"
 	"github.com/docker/docker/pkg/archive"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pivotal/image-relocation/pkg/image"
 	"github.com/pkg/errors"
 )
 
@@ -19,6 +24,11 @@ import (
 type ArchiveOptions struct {
 	BundleActionOptions
 	ArchiveFile string
+
+	// BaseArchives are previously generated archives of the bundle. When set,
+	// a delta archive is generated that only contains the layers that are not
+	// in any of the base archives.
+	BaseArchives []string
 }
 
 // Validate performs validation on the publish options
@@ -34,12 +44,28 @@ func (o *ArchiveOptions) Validate(args []string, p *Porter) error {
 	if o.Reference == "" {
 		return errors.New("must provide a value for --reference of the form REGISTRY/bundle:tag")
 	}
+	if IsOCILayoutReference(o.Reference) {
+		return errors.New("the bundle is already in an OCI layout, only bundles in a registry can be archived")
+	}
+
+	for _, base := range o.BaseArchives {
+		if _, err := p.FileSystem.Stat(base); err != nil {
+			return errors.Wrapf(err, "unable to access --base %s", base)
+		}
+		if filepath.Clean(base) == filepath.Clean(o.ArchiveFile) {
+			return errors.Errorf("the archive %s cannot be its own --base", base)
+		}
+	}
+
 	return o.BundleActionOptions.Validate(args, p)
 }
 
 // Archive is a composite function that generates a CNAB thick bundle. It will pull the invocation image, and
 // any referenced images locally (if needed), export them to individual layers, generate a bundle.json and
-// then generate a gzipped tar archive containing the bundle.json and the images
+// then generate a gzipped tar archive containing the bundle.json and the images.
+//
+// The images are exported to a work directory next to the archive, FILENAME.partial, which is kept when
+// the archive fails so that running the command again resumes from the images that were already exported.
 func (p *Porter) Archive(opts ArchiveOptions) error {
 	dir := filepath.Dir(opts.ArchiveFile)
 	if _, err := p.Config.FileSystem.Stat(dir); os.IsNotExist(err) {
@@ -70,7 +96,11 @@ func (p *Porter) Archive(opts ArchiveOptions) error {
 		return err
 	}
 
-	dest, err := p.Config.FileSystem.OpenFile(opts.ArchiveFile, os.O_RDWR|os.O_CREATE, 0644)
+	dest, err := p.Config.FileSystem.OpenFile(opts.ArchiveFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.Wrapf(err, "could not create archive %s", opts.ArchiveFile)
+	}
+	defer dest.Close()
 
 	exp := &exporter{
 		fs:                    p.Config.FileSystem,
@@ -79,12 +109,15 @@ func (p *Porter) Archive(opts ArchiveOptions) error {
 		bundle:                bun,
 		destination:           dest,
 		imageStoreConstructor: ctor,
+		workDir:               opts.ArchiveFile + ".partial",
+		baseArchives:          opts.BaseArchives,
 	}
 	if err := exp.export(); err != nil {
+		fmt.Fprintf(p.Err, "The exported images were kept in %s, run the command again to resume the archive\n", exp.workDir)
 		return err
 	}
 
-	return nil
+	return p.FileSystem.RemoveAll(exp.workDir)
 }
 
 type exporter struct {
@@ -95,21 +128,25 @@ type exporter struct {
 	destination           io.Writer
 	imageStoreConstructor imagestore.Constructor
 	imageStore            imagestore.Store
+
+	// workDir is where the archive is assembled before it is compressed.
+	// It is kept between runs so that an interrupted archive can be resumed.
+	workDir string
+
+	// exported are the images that were exported by a previous run.
+	exported []v1.Descriptor
+
+	// baseArchives are the archives that a delta archive is generated against.
+	baseArchives []string
 }
 
 func (ex *exporter) export() error {
-
-	name := ex.bundle.Name + "-" + ex.bundle.Version
-	archiveDir, err := ex.fs.TempDir("", name)
-	if err != nil {
-		return err
-	}
-	if err := ex.fs.MkdirAll(archiveDir, 0644); err != nil {
+	archiveDir := ex.workDir
+	if err := ex.fs.MkdirAll(archiveDir, 0755); err != nil {
 		return err
 	}
-	defer ex.fs.RemoveAll(archiveDir)
 
-	to, err := ex.fs.OpenFile(filepath.Join(archiveDir, "bundle.json"), os.O_RDWR|os.O_CREATE, 0666)
+	to, err := ex.fs.OpenFile(filepath.Join(archiveDir, "bundle.json"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
 		return err
 	}
@@ -119,10 +156,20 @@ func (ex *exporter) export() error {
 		return errors.Wrap(err, "unable to write bundle.json in archive")
 	}
 
+	layoutDir := filepath.Join(archiveDir, archiveLayoutDir)
+	ex.exported, err = ex.findExportedImages(layoutDir)
+	if err != nil {
+		return errors.Wrapf(err, "could not resume from the images in %s, remove the directory to start over", archiveDir)
+	}
+
+	// Creating the image store resets the layout, so add back the images that were already exported
 	ex.imageStore, err = ex.imageStoreConstructor(imagestore.WithArchiveDir(archiveDir), imagestore.WithLogs(ex.logs))
 	if err != nil {
 		return fmt.Errorf("error creating artifacts: %s", err)
 	}
+	if err := restoreExportedImages(layoutDir, ex.exported); err != nil {
+		return fmt.Errorf("error creating artifacts: %s", err)
+	}
 
 	if err := ex.prepareArtifacts(ex.bundle); err != nil {
 		return fmt.Errorf("error preparing artifacts: %s", err)
@@ -132,11 +179,21 @@ func (ex *exporter) export() error {
 		return fmt.Errorf("error preparing artifacts: %s", err)
 	}
 
+	excludes, err := ex.prepareDelta(archiveDir)
+	if err != nil {
+		return err
+	}
+
 	tarOptions := &archive.TarOptions{
 		Compression:      archive.Gzip,
 		IncludeFiles:     []string{"."},
 		IncludeSourceDir: true,
 	}
+	if len(excludes) > 0 {
+		// Paths are prefixed with ./ when the source directory is included, which the exclude patterns can't match
+		tarOptions.IncludeSourceDir = false
+		tarOptions.ExcludePatterns = excludes
+	}
 	rc, err := archive.TarWithOptions(archiveDir, tarOptions)
 	if err != nil {
 		return err
@@ -189,6 +246,11 @@ func (ex *exporter) prepareArtifacts(bun bundle.Bundle) error {
 
 // addImage pulls an image, adds it to the artifacts/ directory, and verifies its digest
 func (ex *exporter) addImage(image bundle.BaseImage) error {
+	if desc, ok := ex.findExported(image.Image); ok {
+		fmt.Fprintf(ex.out, "Skipping image %s, it was already exported\n", image.Image)
+		return checkDigest(image, desc.Digest.String())
+	}
+
 	dig, err := ex.imageStore.Add(image.Image)
 	if err != nil {
 		return err
@@ -208,3 +270,191 @@ func checkDigest(image bundle.BaseImage, dig string) error {
 	}
 	return nil
 }
+
+// findExportedImages returns the images in the layout that were completely
+// exported by a previous run and can be reused, and removes any blobs that were
+// left behind by an image that was only partially exported or is exported again.
+func (ex *exporter) findExportedImages(layoutDir string) ([]v1.Descriptor, error) {
+	if _, err := ex.fs.Stat(filepath.Join(layoutDir, "index.json")); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	ii, err := layout.ImageIndexFromPath(layoutDir)
+	if err != nil {
+		return nil, err
+	}
+	im, err := ii.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var exported []v1.Descriptor
+	keep := map[v1.Hash]bool{}
+	for _, desc := range im.Manifests {
+		if !ex.canReuseExportedImage(desc) {
+			continue
+		}
+
+		blobs, err := ex.referencedBlobs(layoutDir, ii, desc)
+		if err != nil {
+			// The image will be exported again
+			continue
+		}
+		for _, blob := range blobs {
+			keep[blob] = true
+		}
+		exported = append(exported, desc)
+	}
+
+	blobsDir := filepath.Join(layoutDir, "blobs")
+	algorithms, err := ex.fs.ReadDir(blobsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return exported, nil
+		}
+		return nil, err
+	}
+	for _, alg := range algorithms {
+		files, err := ex.fs.ReadDir(filepath.Join(blobsDir, alg.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if keep[v1.Hash{Algorithm: alg.Name(), Hex: f.Name()}] {
+				continue
+			}
+			if err := ex.fs.Remove(filepath.Join(blobsDir, alg.Name(), f.Name())); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(exported) > 0 {
+		fmt.Fprintf(ex.out, "Resuming archive with %d images that were already exported\n", len(exported))
+	}
+	return exported, nil
+}
+
+// canReuseExportedImage determines if an image exported by a previous run has
+// the digest that the bundle expects. Images without a digest in the bundle are
+// exported again, since their tag may point to different content by now.
+func (ex *exporter) canReuseExportedImage(desc v1.Descriptor) bool {
+	images := make([]bundle.BaseImage, 0, len(ex.bundle.Images)+len(ex.bundle.InvocationImages))
+	for _, img := range ex.bundle.Images {
+		images = append(images, img.BaseImage)
+	}
+	for _, img := range ex.bundle.InvocationImages {
+		images = append(images, img.BaseImage)
+	}
+
+	for _, img := range images {
+		if img.Digest == "" || img.Digest != desc.Digest.String() {
+			continue
+		}
+		if _, ok := findImageDescriptor([]v1.Descriptor{desc}, img.Image); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// referencedBlobs returns the blobs used by an image or image index in the
+// layout, and returns an error when any of them are missing or incomplete.
+func (ex *exporter) referencedBlobs(layoutDir string, ii v1.ImageIndex, desc v1.Descriptor) ([]v1.Hash, error) {
+	if err := ex.checkBlob(layoutDir, desc.Digest, desc.Size); err != nil {
+		return nil, err
+	}
+	blobs := []v1.Hash{desc.Digest}
+
+	switch desc.MediaType {
+	case types.OCIImageIndex, types.DockerManifestList:
+		child, err := ii.ImageIndex(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		im, err := child.IndexManifest()
+		if err != nil {
+			return nil, err
+		}
+		for _, childDesc := range im.Manifests {
+			childBlobs, err := ex.referencedBlobs(layoutDir, child, childDesc)
+			if err != nil {
+				return nil, err
+			}
+			blobs = append(blobs, childBlobs...)
+		}
+	default:
+		img, err := ii.Image(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		m, err := img.Manifest()
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range append([]v1.Descriptor{m.Config}, m.Layers...) {
+			if err := ex.checkBlob(layoutDir, blob.Digest, blob.Size); err != nil {
+				return nil, err
+			}
+			blobs = append(blobs, blob.Digest)
+		}
+	}
+
+	return blobs, nil
+}
+
+// checkBlob returns an error when the blob is missing or was not completely written.
+func (ex *exporter) checkBlob(layoutDir string, digest v1.Hash, size int64) error {
+	info, err := ex.fs.Stat(filepath.Join(layoutDir, "blobs", digest.Algorithm, digest.Hex))
+	if err != nil {
+		return err
+	}
+	if info.Size() != size {
+		return errors.Errorf("blob %s is incomplete", digest)
+	}
+	return nil
+}
+
+// restoreExportedImages adds images that were exported by a previous run back to the layout index.
+func restoreExportedImages(layoutDir string, exported []v1.Descriptor) error {
+	if len(exported) == 0 {
+		return nil
+	}
+
+	lp, err := layout.FromPath(layoutDir)
+	if err != nil {
+		return err
+	}
+	for _, desc := range exported {
+		if err := lp.AppendDescriptor(desc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findExported returns the descriptor of an image that was exported by a previous run.
+func (ex *exporter) findExported(img string) (v1.Descriptor, bool) {
+	return findImageDescriptor(ex.exported, img)
+}
+
+// findImageDescriptor returns the descriptor for an image from the descriptors in an OCI layout index.
+func findImageDescriptor(descs []v1.Descriptor, img string) (v1.Descriptor, bool) {
+	name := normalizeImageName(img)
+	for _, desc := range descs {
+		if ref, ok := desc.Annotations[ocispec.AnnotationRefName]; ok && normalizeImageName(ref) == name {
+			return desc, true
+		}
+	}
+	return v1.Descriptor{}, false
+}
+
+// normalizeImageName returns the fully qualified name of an image, so that
+// docker.io/library/nginx and nginx are considered the same image.
+func normalizeImageName(img string) string {
+	n, err := image.NewName(img)
+	if err != nil {
+		return img
+	}
+	return n.String()
+}
@@ -0,0 +1,155 @@
+package porter
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/carolynvs/aferox"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+const (
+	// archiveLayoutDir is the location of the OCI layout with the bundle images in an archive.
+	archiveLayoutDir = "artifacts/layout"
+
+	// archiveDeltaFile is the location of the delta manifest in a delta archive.
+	archiveDeltaFile = "artifacts/delta.json"
+)
+
+// archiveDelta describes a delta archive, which only contains the layers
+// that are not in the base archives that it was generated from.
+type archiveDelta struct {
+	// Bases are the digests of the base archives.
+	Bases []string `json:"bases"`
+}
+
+// prepareDelta writes the delta manifest when the archive is generated from base archives,
+// and returns the paths of the blobs in the archive directory that are in the base archives.
+func (ex *exporter) prepareDelta(archiveDir string) ([]string, error) {
+	deltaFile := filepath.Join(archiveDir, archiveDeltaFile)
+	if len(ex.baseArchives) == 0 {
+		// Remove a delta manifest left behind by a previous run with --base
+		if err := ex.fs.Remove(deltaFile); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	var delta archiveDelta
+	baseBlobs := map[string]bool{}
+	for _, base := range ex.baseArchives {
+		dig, blobs, err := readArchiveBlobs(ex.fs, base)
+		if err != nil {
+			return nil, err
+		}
+		delta.Bases = append(delta.Bases, dig)
+		for blob := range blobs {
+			baseBlobs[blob] = true
+		}
+	}
+
+	data, err := json.MarshalIndent(delta, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal the delta manifest")
+	}
+	if err := ex.fs.WriteFile(deltaFile, data, 0644); err != nil {
+		return nil, errors.Wrap(err, "could not write the delta manifest")
+	}
+
+	var excludes []string
+	blobsDir := path.Join(archiveLayoutDir, "blobs")
+	algorithms, err := ex.fs.ReadDir(filepath.Join(archiveDir, blobsDir))
+	if err != nil {
+		return nil, err
+	}
+	for _, alg := range algorithms {
+		files, err := ex.fs.ReadDir(filepath.Join(archiveDir, blobsDir, alg.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if baseBlobs[alg.Name()+":"+f.Name()] {
+				excludes = append(excludes, path.Join(blobsDir, alg.Name(), f.Name()))
+			}
+		}
+	}
+
+	fmt.Fprintf(ex.out, "Generating a delta archive without the %d blobs that are in the base archives\n", len(excludes))
+	return excludes, nil
+}
+
+// readArchiveBlobs returns the digest of an archive and the digests of the
+// image blobs that it contains.
+func readArchiveBlobs(fs aferox.Aferox, archiveFile string) (string, map[string]bool, error) {
+	f, err := fs.Open(archiveFile)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "could not open archive %s", archiveFile)
+	}
+	defer f.Close()
+
+	digester := digest.Canonical.Digester()
+	r := io.TeeReader(f, digester.Hash())
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "could not read archive %s", archiveFile)
+	}
+	defer gz.Close()
+
+	blobs := map[string]bool{}
+	blobsDir := path.Join(archiveLayoutDir, "blobs") + "/"
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "could not read archive %s", archiveFile)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(hdr.Name)
+		if !strings.HasPrefix(name, blobsDir) {
+			continue
+		}
+		blob := strings.SplitN(strings.TrimPrefix(name, blobsDir), "/", 2)
+		if len(blob) == 2 {
+			blobs[blob[0]+":"+blob[1]] = true
+		}
+	}
+
+	// Read the rest of the file so that the digest includes the entire archive
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		return "", nil, errors.Wrapf(err, "could not read archive %s", archiveFile)
+	}
+
+	return digester.Digest().String(), blobs, nil
+}
+
+// readArchiveDelta reads the delta manifest from an extracted archive. Archives
+// that are not delta archives do not have a delta manifest.
+func readArchiveDelta(fs aferox.Aferox, archiveDir string) (archiveDelta, bool, error) {
+	data, err := fs.ReadFile(filepath.Join(archiveDir, archiveDeltaFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return archiveDelta{}, false, nil
+		}
+		return archiveDelta{}, false, err
+	}
+
+	var delta archiveDelta
+	err = json.Unmarshal(data, &delta)
+	return delta, true, errors.Wrap(err, "could not unmarshal the delta manifest")
+}
@@ -0,0 +1,161 @@
+package porter
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"get.porter.sh/porter/pkg/config"
+	"github.com/carolynvs/aferox"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestArchive writes a gzipped tar archive with the specified files.
+func writeTestArchive(t *testing.T, fs aferox.Aferox, archiveFile string, files map[string][]byte) {
+	f, err := fs.Create(archiveFile)
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, contents := range files {
+		err = tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg})
+		require.NoError(t, err)
+		_, err = tw.Write(contents)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+}
+
+func testArchiveBundle(t *testing.T, version string) []byte {
+	bun := bundle.Bundle{
+		SchemaVersion: "v1.0.0",
+		Name:          "mybuns",
+		Version:       version,
+		InvocationImages: []bundle.InvocationImage{
+			{BaseImage: bundle.BaseImage{Image: "getporter/mybuns:" + version, ImageType: "docker"}},
+		},
+		Definitions: definition.Definitions{
+			"porter-debug": {Type: "boolean"},
+		},
+		Parameters: map[string]bundle.Parameter{
+			"porter-debug": {Definition: "porter-debug", Destination: &bundle.Location{EnvironmentVariable: "PORTER_DEBUG"}},
+		},
+		Custom: map[string]interface{}{
+			config.CustomPorterKey: map[string]interface{}{"manifestDigest": "abc123"},
+		},
+	}
+	data, err := json.Marshal(bun)
+	require.NoError(t, err)
+	return data
+}
+
+func Test_readArchiveBlobs(t *testing.T) {
+	p := NewTestPorter(t)
+
+	writeTestArchive(t, p.FileSystem, "mybuns.tgz", map[string][]byte{
+		"./bundle.json":                      testArchiveBundle(t, "0.1.0"),
+		"./artifacts/layout/index.json":      []byte("{}"),
+		"./artifacts/layout/blobs/sha256/a1": []byte("layer1"),
+		"artifacts/layout/blobs/sha256/b2":   []byte("layer2"),
+	})
+
+	dig, blobs, err := readArchiveBlobs(p.FileSystem, "mybuns.tgz")
+	require.NoError(t, err)
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", dig)
+	assert.Equal(t, map[string]bool{"sha256:a1": true, "sha256:b2": true}, blobs)
+
+	dig2, _, err := readArchiveBlobs(p.FileSystem, "mybuns.tgz")
+	require.NoError(t, err)
+	assert.Equal(t, dig, dig2, "the archive digest should be stable")
+}
+
+func TestExporter_prepareDelta(t *testing.T) {
+	p := NewTestPorter(t)
+
+	writeTestArchive(t, p.FileSystem, "base.tgz", map[string][]byte{
+		"./bundle.json":                      testArchiveBundle(t, "0.1.0"),
+		"./artifacts/layout/blobs/sha256/a1": []byte("layer1"),
+	})
+	baseDigest, _, err := readArchiveBlobs(p.FileSystem, "base.tgz")
+	require.NoError(t, err)
+
+	archiveDir := "/mybuns.tgz.partial"
+	blobsDir := filepath.Join(archiveDir, archiveLayoutDir, "blobs/sha256")
+	require.NoError(t, p.FileSystem.MkdirAll(blobsDir, 0755))
+	require.NoError(t, p.FileSystem.WriteFile(filepath.Join(blobsDir, "a1"), []byte("layer1"), 0644))
+	require.NoError(t, p.FileSystem.WriteFile(filepath.Join(blobsDir, "c3"), []byte("layer3"), 0644))
+
+	t.Run("delta", func(t *testing.T) {
+		ex := &exporter{fs: p.FileSystem, out: p.Out, baseArchives: []string{"base.tgz"}}
+		excludes, err := ex.prepareDelta(archiveDir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"artifacts/layout/blobs/sha256/a1"}, excludes)
+
+		delta, isDelta, err := readArchiveDelta(p.FileSystem, archiveDir)
+		require.NoError(t, err)
+		assert.True(t, isDelta)
+		assert.Equal(t, []string{baseDigest}, delta.Bases)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		ex := &exporter{fs: p.FileSystem, out: p.Out}
+		excludes, err := ex.prepareDelta(archiveDir)
+		require.NoError(t, err)
+		assert.Empty(t, excludes)
+
+		_, isDelta, err := readArchiveDelta(p.FileSystem, archiveDir)
+		require.NoError(t, err)
+		assert.False(t, isDelta, "the delta manifest from the previous run should be removed")
+	})
+}
+
+func TestPorter_extractArchives(t *testing.T) {
+	p := NewTestPorter(t)
+	p.TestConfig.TestContext.UseFilesystem()
+
+	tmpDir, err := ioutil.TempDir("", "porter")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	fullArchive := filepath.Join(tmpDir, "mybuns-0.1.0.tgz")
+	writeTestArchive(t, p.FileSystem, fullArchive, map[string][]byte{
+		"./bundle.json":                      testArchiveBundle(t, "0.1.0"),
+		"./artifacts/layout/blobs/sha256/a1": []byte("layer1"),
+	})
+	fullDigest, _, err := readArchiveBlobs(p.FileSystem, fullArchive)
+	require.NoError(t, err)
+
+	deltaManifest, err := json.Marshal(archiveDelta{Bases: []string{fullDigest}})
+	require.NoError(t, err)
+	deltaArchive := filepath.Join(tmpDir, "mybuns-0.2.0.tgz")
+	writeTestArchive(t, p.FileSystem, deltaArchive, map[string][]byte{
+		"./bundle.json":                      testArchiveBundle(t, "0.2.0"),
+		"./artifacts/delta.json":             deltaManifest,
+		"./artifacts/layout/blobs/sha256/b2": []byte("layer2"),
+	})
+
+	t.Run("full and delta", func(t *testing.T) {
+		dir := filepath.Join(tmpDir, "chain")
+		bun, err := p.extractArchives(dir, []string{fullArchive, deltaArchive})
+		require.NoError(t, err)
+		assert.Equal(t, "0.2.0", bun.Version, "the bundle should be loaded from the last archive")
+		assert.FileExists(t, filepath.Join(dir, archiveLayoutDir, "blobs/sha256/a1"))
+		assert.FileExists(t, filepath.Join(dir, archiveLayoutDir, "blobs/sha256/b2"))
+	})
+
+	t.Run("missing base", func(t *testing.T) {
+		dir := filepath.Join(tmpDir, "delta")
+		_, err := p.extractArchives(dir, []string{deltaArchive})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is a delta archive, specify the archives it was generated from before it")
+	})
+}
@@ -0,0 +1,87 @@
+package porter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// ArchiveImportOptions are the options for importing a bundle archive into an OCI layout directory.
+type ArchiveImportOptions struct {
+	// ArchiveFiles are the archives to import, a full archive optionally
+	// followed by the delta archives that were generated from it.
+	ArchiveFiles []string
+
+	// Dir is the directory where the OCI layout is created.
+	Dir string
+}
+
+// Validate the archive import options.
+func (o *ArchiveImportOptions) Validate(args []string, p *Porter) error {
+	if len(args) == 0 {
+		return errors.New("the archive file to import is required")
+	}
+	o.ArchiveFiles = args
+
+	for _, archiveFile := range o.ArchiveFiles {
+		if _, err := p.FileSystem.Stat(archiveFile); err != nil {
+			return errors.Wrapf(err, "unable to access archive %s", archiveFile)
+		}
+	}
+
+	if o.Dir == "" {
+		return errors.New("--to-dir is required")
+	}
+	exists, err := p.FileSystem.Exists(o.Dir)
+	if err != nil {
+		return err
+	}
+	if exists {
+		empty, err := p.FileSystem.IsEmpty(o.Dir)
+		if err != nil {
+			return errors.Wrapf(err, "unable to access --to-dir %s", o.Dir)
+		}
+		if !empty {
+			return errors.Errorf("--to-dir %s must be an empty directory", o.Dir)
+		}
+	}
+
+	return nil
+}
+
+// ImportArchive expands a bundle archive into an OCI image layout directory,
+// with the bundle.json alongside the images, so that the bundle can be run
+// from the directory without a registry.
+func (p *Porter) ImportArchive(opts ArchiveImportOptions) error {
+	dir := p.FileSystem.Abs(opts.Dir)
+	if err := p.FileSystem.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "could not create --to-dir %s", dir)
+	}
+
+	bun, err := p.extractArchives(dir, opts.ArchiveFiles)
+	if err != nil {
+		return err
+	}
+
+	// Move the layout to the root of the directory so that it is an OCI layout
+	layoutDir := filepath.Join(dir, archiveLayoutDir)
+	entries, err := p.FileSystem.ReadDir(layoutDir)
+	if err != nil {
+		return errors.Wrapf(err, "the archive does not contain an OCI layout")
+	}
+	for _, entry := range entries {
+		err = p.FileSystem.Rename(filepath.Join(layoutDir, entry.Name()), filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return errors.Wrapf(err, "could not move %s into %s", entry.Name(), dir)
+		}
+	}
+	if err := p.FileSystem.RemoveAll(filepath.Join(dir, filepath.Dir(archiveLayoutDir))); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	fmt.Fprintf(p.Out, "Imported bundle %s version %s into %s. Use it with --reference %s%s\n",
+		bun.Name, bun.Version, dir, OCILayoutScheme, dir)
+	return nil
+}
@@ -0,0 +1,122 @@
+package porter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestLayoutArchive writes an archive of the mybuns bundle, with its
+// invocation image in the OCI layout.
+func writeTestLayoutArchive(t *testing.T, p *TestPorter, archiveFile string) v1.Image {
+	files, img := buildTestLayoutArchiveFiles(t)
+	writeTestArchive(t, p.FileSystem, archiveFile, files)
+	return img
+}
+
+// buildTestLayoutArchiveFiles returns the files in an archive of the mybuns
+// bundle, with its invocation image in the OCI layout.
+func buildTestLayoutArchiveFiles(t *testing.T) (map[string][]byte, v1.Image) {
+	layoutDir, err := ioutil.TempDir("", "porter")
+	require.NoError(t, err)
+	defer os.RemoveAll(layoutDir)
+
+	lp, err := layout.Write(layoutDir, empty.Index)
+	require.NoError(t, err)
+	img, err := random.Image(10, 1)
+	require.NoError(t, err)
+	err = lp.AppendImage(img, layout.WithAnnotations(map[string]string{ocispec.AnnotationRefName: "getporter/mybuns:0.1.0"}))
+	require.NoError(t, err)
+
+	files := map[string][]byte{
+		"./bundle.json": testArchiveBundle(t, "0.1.0"),
+	}
+	err = filepath.Walk(layoutDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(layoutDir, path)
+		if err != nil {
+			return err
+		}
+		files["./"+filepath.Join(archiveLayoutDir, rel)], err = ioutil.ReadFile(path)
+		return err
+	})
+	require.NoError(t, err)
+
+	return files, img
+}
+
+func TestArchiveImportOptions_Validate(t *testing.T) {
+	p := NewTestPorter(t)
+	p.FileSystem.WriteFile("mybuns.tgz", []byte("mybuns"), 0644)
+	p.FileSystem.WriteFile("full/bundle.json", []byte("{}"), 0644)
+	p.FileSystem.MkdirAll("empty", 0755)
+
+	testcases := []struct {
+		name      string
+		args      []string
+		dir       string
+		wantError string
+	}{
+		{"no args", nil, "mybuns", "the archive file to import is required"},
+		{"missing archive", []string{"missing.tgz"}, "mybuns", "unable to access archive missing.tgz: open /missing.tgz: file does not exist"},
+		{"no dir", []string{"mybuns.tgz"}, "", "--to-dir is required"},
+		{"dir not empty", []string{"mybuns.tgz"}, "full", "--to-dir full must be an empty directory"},
+		{"empty dir", []string{"mybuns.tgz"}, "empty", ""},
+		{"new dir", []string{"mybuns.tgz"}, "mybuns", ""},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := ArchiveImportOptions{Dir: tc.dir}
+			err := opts.Validate(tc.args, p.Porter)
+			if tc.wantError != "" {
+				require.EqualError(t, err, tc.wantError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPorter_ImportArchive(t *testing.T) {
+	p := NewTestPorter(t)
+	_, home := p.TestConfig.TestContext.UseFilesystem()
+	p.TestConfig.SetHomeDir(home)
+
+	tmpDir, err := ioutil.TempDir("", "porter")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	archiveFile := filepath.Join(tmpDir, "mybuns.tgz")
+	img := writeTestLayoutArchive(t, p, archiveFile)
+
+	opts := ArchiveImportOptions{Dir: filepath.Join(tmpDir, "mybuns")}
+	err = opts.Validate([]string{archiveFile}, p.Porter)
+	require.NoError(t, err)
+	err = p.ImportArchive(opts)
+	require.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(opts.Dir, "bundle.json"))
+	assert.FileExists(t, filepath.Join(opts.Dir, "index.json"))
+	assert.FileExists(t, filepath.Join(opts.Dir, "oci-layout"))
+	assert.NoDirExists(t, filepath.Join(opts.Dir, "artifacts"), "the layout should be moved to the root of the directory")
+	assert.Contains(t, p.TestConfig.TestContext.GetOutput(), "Use it with --reference oci-layout://"+opts.Dir)
+
+	dig, err := img.Digest()
+	require.NoError(t, err)
+	images, err := findLayoutImages(opts.Dir, mustLoadTestArchiveBundle(t, p, opts.Dir))
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, dig.String(), images[0].Digest)
+}
@@ -1,8 +1,17 @@
 package porter
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -48,3 +57,116 @@ func TestArchive_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestArchive_Validate_Base(t *testing.T) {
+	p := NewTestPorter(t)
+	p.FileSystem.WriteFile("mybuns-v0.1.0.tgz", []byte("mybuns"), 0644)
+
+	opts := ArchiveOptions{}
+	opts.Reference = "myreg/mybuns:v0.2.0"
+	opts.BaseArchives = []string{"missing.tgz"}
+	err := opts.Validate([]string{"mybuns-v0.2.0.tgz"}, p.Porter)
+	require.EqualError(t, err, "unable to access --base missing.tgz: open /missing.tgz: file does not exist")
+
+	opts.BaseArchives = []string{"mybuns-v0.1.0.tgz"}
+	err = opts.Validate([]string{"mybuns-v0.1.0.tgz"}, p.Porter)
+	require.EqualError(t, err, "the archive mybuns-v0.1.0.tgz cannot be its own --base")
+
+	err = opts.Validate([]string{"mybuns-v0.2.0.tgz"}, p.Porter)
+	require.NoError(t, err)
+}
+
+func TestExporter_findExportedImages(t *testing.T) {
+	p := NewTestPorter(t)
+	p.TestConfig.TestContext.UseFilesystem()
+
+	layoutDir, err := ioutil.TempDir("", "porter")
+	require.NoError(t, err)
+	defer os.RemoveAll(layoutDir)
+
+	lp, err := layout.Write(layoutDir, empty.Index)
+	require.NoError(t, err)
+	img, err := random.Image(10, 2)
+	require.NoError(t, err)
+	err = lp.AppendImage(img, layout.WithAnnotations(map[string]string{ocispec.AnnotationRefName: "getporter/mybuns:v0.1.0"}))
+	require.NoError(t, err)
+
+	// Simulate a blob from an image that was only partially exported
+	partialBlob := filepath.Join(layoutDir, "blobs/sha256/abc123")
+	require.NoError(t, ioutil.WriteFile(partialBlob, []byte("partial"), 0644))
+
+	dig, err := img.Digest()
+	require.NoError(t, err)
+	bun := bundle.Bundle{
+		InvocationImages: []bundle.InvocationImage{
+			{BaseImage: bundle.BaseImage{Image: "getporter/mybuns:v0.1.0", Digest: dig.String()}},
+		},
+	}
+
+	ex := &exporter{fs: p.FileSystem, out: p.Out, bundle: bun}
+	exported, err := ex.findExportedImages(layoutDir)
+	require.NoError(t, err)
+	require.Len(t, exported, 1)
+	assert.NoFileExists(t, partialBlob, "blobs that are not used by an exported image should be removed")
+
+	ex.exported = exported
+	desc, ok := ex.findExported("docker.io/getporter/mybuns:v0.1.0")
+	require.True(t, ok, "the image name should be normalized")
+	assert.Equal(t, dig, desc.Digest)
+
+	t.Run("stale image", func(t *testing.T) {
+		staleBun := bundle.Bundle{
+			InvocationImages: []bundle.InvocationImage{
+				{BaseImage: bundle.BaseImage{Image: "getporter/mybuns:v0.1.0", Digest: "sha256:1111111111111111111111111111111111111111111111111111111111111111"}},
+			},
+		}
+		staleEx := &exporter{fs: p.FileSystem, out: p.Out, bundle: staleBun}
+		assert.False(t, staleEx.canReuseExportedImage(desc), "an image with a different digest than the bundle should be exported again")
+
+		staleBun.InvocationImages[0].Digest = ""
+		assert.False(t, staleEx.canReuseExportedImage(desc), "an image without a digest in the bundle should be exported again")
+
+		assert.True(t, ex.canReuseExportedImage(desc))
+	})
+
+	// Truncate a layer so that the image is exported again
+	layers, err := img.Layers()
+	require.NoError(t, err)
+	layerDigest, err := layers[0].Digest()
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(layoutDir, "blobs", layerDigest.Algorithm, layerDigest.Hex), []byte("trunc"), 0644))
+
+	exported, err = ex.findExportedImages(layoutDir)
+	require.NoError(t, err)
+	assert.Empty(t, exported, "an image with an incomplete layer should not be resumed")
+}
+
+func TestExporter_findExportedImages_NoDigest(t *testing.T) {
+	p := NewTestPorter(t)
+	p.TestConfig.TestContext.UseFilesystem()
+
+	layoutDir, err := ioutil.TempDir("", "porter")
+	require.NoError(t, err)
+	defer os.RemoveAll(layoutDir)
+
+	lp, err := layout.Write(layoutDir, empty.Index)
+	require.NoError(t, err)
+	img, err := random.Image(10, 1)
+	require.NoError(t, err)
+	err = lp.AppendImage(img, layout.WithAnnotations(map[string]string{ocispec.AnnotationRefName: "getporter/mybuns:v0.1.0"}))
+	require.NoError(t, err)
+
+	bun := bundle.Bundle{
+		InvocationImages: []bundle.InvocationImage{
+			{BaseImage: bundle.BaseImage{Image: "getporter/mybuns:v0.1.0"}},
+		},
+	}
+	ex := &exporter{fs: p.FileSystem, out: p.Out, bundle: bun}
+	exported, err := ex.findExportedImages(layoutDir)
+	require.NoError(t, err)
+	assert.Empty(t, exported, "an image without a digest in the bundle should be exported again")
+
+	dig, err := img.Digest()
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(layoutDir, "blobs", dig.Algorithm, dig.Hex), "the blobs of the stale image should be removed")
+}
@@ -0,0 +1,312 @@
+package porter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	configadapter "get.porter.sh/porter/pkg/cnab/config-adapter"
+	"get.porter.sh/porter/pkg/printer"
+	"github.com/cnabio/cnab-go/bundle"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+var (
+	ArchiveVerifyAllowedFormats = printer.Formats{printer.FormatTable, printer.FormatJson}
+	ArchiveVerifyDefaultFormat  = printer.FormatTable
+)
+
+const (
+	// ArchiveEntryOK is the status of an archive entry that is present and matches the bundle.
+	ArchiveEntryOK = "ok"
+
+	// ArchiveEntryMissing is the status of an archive entry that is not in the archive.
+	ArchiveEntryMissing = "missing"
+
+	// ArchiveEntryCorrupt is the status of an archive entry that doesn't match its digest or size.
+	ArchiveEntryCorrupt = "corrupt"
+
+	// ArchiveEntryInvalid is the status of a bundle.json that can't be used.
+	ArchiveEntryInvalid = "invalid"
+)
+
+// ArchiveVerifyOptions are the options for verifying a bundle archive.
+type ArchiveVerifyOptions struct {
+	printer.PrintOptions
+
+	// ArchiveFiles are the archives to verify, a full archive optionally
+	// followed by the delta archives that were generated from it.
+	ArchiveFiles []string
+}
+
+// Validate the archive verify options.
+func (o *ArchiveVerifyOptions) Validate(args []string, p *Porter) error {
+	if len(args) == 0 {
+		return errors.New("the archive file to verify is required")
+	}
+	o.ArchiveFiles = args
+
+	for _, archiveFile := range o.ArchiveFiles {
+		if _, err := p.FileSystem.Stat(archiveFile); err != nil {
+			return errors.Wrapf(err, "unable to access archive %s", archiveFile)
+		}
+	}
+
+	return o.PrintOptions.Validate(ArchiveVerifyDefaultFormat, ArchiveVerifyAllowedFormats)
+}
+
+// ArchiveVerification is the result of verifying the contents of a bundle archive.
+type ArchiveVerification struct {
+	Archive string         `json:"archive"`
+	Entries []ArchiveEntry `json:"entries"`
+}
+
+// IsValid determines if every entry in the archive was verified.
+func (v ArchiveVerification) IsValid() bool {
+	for _, entry := range v.Entries {
+		if entry.Status != ArchiveEntryOK {
+			return false
+		}
+	}
+	return true
+}
+
+// ArchiveEntry is the verification status of the bundle.json or an image in a bundle archive.
+type ArchiveEntry struct {
+	// Name of the entry, either bundle.json or the image reference.
+	Name string `json:"name"`
+
+	// Type of the entry: bundle, invocationImage or image.
+	Type string `json:"type"`
+
+	// Digest of the image in the archive.
+	Digest string `json:"digest,omitempty"`
+
+	// Status of the entry: ok, missing, corrupt or invalid.
+	Status string `json:"status"`
+
+	// Message explains why the entry isn't ok.
+	Message string `json:"message,omitempty"`
+}
+
+// VerifyArchive checks the contents of a bundle archive and prints the results.
+// An error is returned when the archive is not valid.
+func (p *Porter) VerifyArchive(opts ArchiveVerifyOptions) error {
+	v, err := p.verifyArchive(opts.ArchiveFiles)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Format {
+	case printer.FormatJson:
+		err = printer.PrintJson(p.Out, v)
+	case printer.FormatTable:
+		printEntryRow :=
+			func(v interface{}) []interface{} {
+				e, ok := v.(ArchiveEntry)
+				if !ok {
+					return nil
+				}
+				return []interface{}{e.Name, e.Type, e.Digest, e.Status, e.Message}
+			}
+		err = printer.PrintTable(p.Out, v.Entries, printEntryRow, "Name", "Type", "Digest", "Status", "Message")
+	default:
+		err = fmt.Errorf("invalid format: %s", opts.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !v.IsValid() {
+		return errors.Errorf("archive %s failed verification", v.Archive)
+	}
+	return nil
+}
+
+// verifyArchive extracts the archives and checks that the bundle.json can be
+// used, and that every image in the bundle is in the archive with the expected
+// content digest and size.
+func (p *Porter) verifyArchive(archiveFiles []string) (ArchiveVerification, error) {
+	v := ArchiveVerification{Archive: strings.Join(archiveFiles, ", ")}
+
+	dir, err := p.FileSystem.TempDir("", "porter")
+	if err != nil {
+		return v, errors.Wrap(err, "error creating temp directory for archive extraction")
+	}
+	defer p.FileSystem.RemoveAll(dir)
+
+	if err := p.unpackArchives(dir, archiveFiles); err != nil {
+		return v, err
+	}
+
+	bun, entry := p.verifyArchiveBundle(dir)
+	v.Entries = append(v.Entries, entry)
+	if bun == nil {
+		return v, nil
+	}
+
+	layoutDir := filepath.Join(dir, archiveLayoutDir)
+	var ii v1.ImageIndex
+	var descs []v1.Descriptor
+	if ii, err = layout.ImageIndexFromPath(layoutDir); err == nil {
+		var im *v1.IndexManifest
+		if im, err = ii.IndexManifest(); err == nil {
+			descs = im.Manifests
+		}
+	}
+	if err != nil && p.Debug {
+		fmt.Fprintf(p.Err, "Could not read the OCI layout from the archive: %s\n", err)
+	}
+
+	for _, img := range bun.InvocationImages {
+		v.Entries = append(v.Entries, p.verifyArchiveImage(layoutDir, ii, descs, "invocationImage", img.BaseImage))
+	}
+	imageKeys := make([]string, 0, len(bun.Images))
+	for key := range bun.Images {
+		imageKeys = append(imageKeys, key)
+	}
+	sort.Strings(imageKeys)
+	for _, key := range imageKeys {
+		v.Entries = append(v.Entries, p.verifyArchiveImage(layoutDir, ii, descs, "image", bun.Images[key].BaseImage))
+	}
+
+	return v, nil
+}
+
+// verifyArchiveBundle checks that the bundle.json in the archive parses and
+// was stamped by Porter. The bundle is nil when it could not be parsed.
+func (p *Porter) verifyArchiveBundle(dir string) (*bundle.Bundle, ArchiveEntry) {
+	entry := ArchiveEntry{Name: "bundle.json", Type: "bundle", Status: ArchiveEntryOK}
+
+	data, err := p.FileSystem.ReadFile(filepath.Join(dir, "bundle.json"))
+	if err != nil {
+		entry.Status = ArchiveEntryMissing
+		entry.Message = "the archive does not contain a bundle.json"
+		return nil, entry
+	}
+
+	bun, err := bundle.Unmarshal(data)
+	if err != nil {
+		entry.Status = ArchiveEntryInvalid
+		entry.Message = fmt.Sprintf("could not parse the bundle: %s", err)
+		return nil, entry
+	}
+	entry.Name = fmt.Sprintf("bundle.json (%s %s)", bun.Name, bun.Version)
+
+	if err := bun.Validate(); err != nil {
+		entry.Status = ArchiveEntryInvalid
+		entry.Message = fmt.Sprintf("invalid bundle: %s", err)
+		return bun, entry
+	}
+
+	stamp, err := configadapter.LoadStamp(*bun)
+	if err != nil || stamp.ManifestDigest == "" {
+		entry.Status = ArchiveEntryInvalid
+		entry.Message = "the bundle was not stamped by porter"
+	}
+	return bun, entry
+}
+
+// verifyArchiveImage checks that an image from the bundle is in the OCI layout
+// with the digest and size from the bundle, and that its blobs are intact.
+func (p *Porter) verifyArchiveImage(layoutDir string, ii v1.ImageIndex, descs []v1.Descriptor, imageType string, img bundle.BaseImage) ArchiveEntry {
+	entry := ArchiveEntry{Name: img.Image, Type: imageType, Status: ArchiveEntryOK}
+
+	desc, ok := findImageDescriptor(descs, img.Image)
+	if !ok {
+		entry.Status = ArchiveEntryMissing
+		entry.Message = "the image is not in the archive"
+		return entry
+	}
+	entry.Digest = desc.Digest.String()
+
+	if img.Digest != "" && img.Digest != entry.Digest {
+		entry.Status = ArchiveEntryCorrupt
+		entry.Message = fmt.Sprintf("the content digest does not match %s from the bundle", img.Digest)
+		return entry
+	}
+	if img.Size != 0 && img.Size != uint64(desc.Size) {
+		entry.Status = ArchiveEntryCorrupt
+		entry.Message = fmt.Sprintf("the size %d does not match %d from the bundle", desc.Size, img.Size)
+		return entry
+	}
+
+	entry.Status, entry.Message = p.verifyLayoutImage(layoutDir, ii, desc)
+	return entry
+}
+
+// verifyLayoutImage checks every blob of an image or image index in the layout.
+func (p *Porter) verifyLayoutImage(layoutDir string, ii v1.ImageIndex, desc v1.Descriptor) (string, string) {
+	if status, msg := p.verifyLayoutBlob(layoutDir, desc); status != ArchiveEntryOK {
+		return status, msg
+	}
+
+	switch desc.MediaType {
+	case types.OCIImageIndex, types.DockerManifestList:
+		child, err := ii.ImageIndex(desc.Digest)
+		if err != nil {
+			return ArchiveEntryCorrupt, fmt.Sprintf("could not read the image index %s: %s", desc.Digest, err)
+		}
+		im, err := child.IndexManifest()
+		if err != nil {
+			return ArchiveEntryCorrupt, fmt.Sprintf("could not read the image index %s: %s", desc.Digest, err)
+		}
+		for _, childDesc := range im.Manifests {
+			if status, msg := p.verifyLayoutImage(layoutDir, child, childDesc); status != ArchiveEntryOK {
+				return status, msg
+			}
+		}
+	default:
+		img, err := ii.Image(desc.Digest)
+		if err != nil {
+			return ArchiveEntryCorrupt, fmt.Sprintf("could not read the image manifest %s: %s", desc.Digest, err)
+		}
+		m, err := img.Manifest()
+		if err != nil {
+			return ArchiveEntryCorrupt, fmt.Sprintf("could not read the image manifest %s: %s", desc.Digest, err)
+		}
+		for _, blob := range append([]v1.Descriptor{m.Config}, m.Layers...) {
+			if status, msg := p.verifyLayoutBlob(layoutDir, blob); status != ArchiveEntryOK {
+				return status, msg
+			}
+		}
+	}
+
+	return ArchiveEntryOK, ""
+}
+
+// verifyLayoutBlob checks that a blob is in the layout with the expected digest and size.
+func (p *Porter) verifyLayoutBlob(layoutDir string, desc v1.Descriptor) (string, string) {
+	f, err := p.FileSystem.Open(filepath.Join(layoutDir, "blobs", desc.Digest.Algorithm, desc.Digest.Hex))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ArchiveEntryMissing, fmt.Sprintf("blob %s is not in the archive", desc.Digest)
+		}
+		return ArchiveEntryCorrupt, fmt.Sprintf("could not read blob %s: %s", desc.Digest, err)
+	}
+	defer f.Close()
+
+	dig, err := digest.Parse(desc.Digest.String())
+	if err != nil || !dig.Algorithm().Available() {
+		return ArchiveEntryCorrupt, fmt.Sprintf("unsupported digest %s", desc.Digest)
+	}
+	digester := dig.Algorithm().Digester()
+	size, err := io.Copy(digester.Hash(), f)
+	if err != nil {
+		return ArchiveEntryCorrupt, fmt.Sprintf("could not read blob %s: %s", desc.Digest, err)
+	}
+	if size != desc.Size {
+		return ArchiveEntryCorrupt, fmt.Sprintf("blob %s is %d bytes but should be %d", desc.Digest, size, desc.Size)
+	}
+	if digester.Digest() != dig {
+		return ArchiveEntryCorrupt, fmt.Sprintf("blob %s does not match its digest", desc.Digest)
+	}
+	return ArchiveEntryOK, ""
+}
@@ -0,0 +1,137 @@
+package porter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveVerifyOptions_Validate(t *testing.T) {
+	p := NewTestPorter(t)
+	p.FileSystem.WriteFile("mybuns.tgz", []byte("mybuns"), 0644)
+
+	opts := ArchiveVerifyOptions{}
+	err := opts.Validate(nil, p.Porter)
+	require.EqualError(t, err, "the archive file to verify is required")
+
+	err = opts.Validate([]string{"missing.tgz"}, p.Porter)
+	require.EqualError(t, err, "unable to access archive missing.tgz: open /missing.tgz: file does not exist")
+
+	opts.RawFormat = "yaml"
+	err = opts.Validate([]string{"mybuns.tgz"}, p.Porter)
+	require.EqualError(t, err, "invalid format: yaml")
+
+	opts.RawFormat = "json"
+	err = opts.Validate([]string{"mybuns.tgz"}, p.Porter)
+	require.NoError(t, err)
+}
+
+func TestPorter_VerifyArchive(t *testing.T) {
+	p := NewTestPorter(t)
+	p.TestConfig.TestContext.UseFilesystem()
+
+	tmpDir, err := ioutil.TempDir("", "porter")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	// updateBundle modifies the bundle.json in the archive files
+	updateBundle := func(t *testing.T, files map[string][]byte, update func(bun *bundle.Bundle)) {
+		bun, err := bundle.Unmarshal(files["./bundle.json"])
+		require.NoError(t, err)
+		update(bun)
+		files["./bundle.json"], err = json.Marshal(bun)
+		require.NoError(t, err)
+	}
+
+	verify := func(t *testing.T, files map[string][]byte) ArchiveVerification {
+		archiveFile := filepath.Join(tmpDir, "mybuns.tgz")
+		writeTestArchive(t, p.FileSystem, archiveFile, files)
+		v, err := p.verifyArchive([]string{archiveFile})
+		require.NoError(t, err)
+		return v
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		files, img := buildTestLayoutArchiveFiles(t)
+		dig, err := img.Digest()
+		require.NoError(t, err)
+		updateBundle(t, files, func(bun *bundle.Bundle) {
+			bun.InvocationImages[0].Digest = dig.String()
+		})
+
+		archiveFile := filepath.Join(tmpDir, "valid.tgz")
+		writeTestArchive(t, p.FileSystem, archiveFile, files)
+		opts := ArchiveVerifyOptions{}
+		require.NoError(t, opts.Validate([]string{archiveFile}, p.Porter))
+		err = p.VerifyArchive(opts)
+		require.NoError(t, err)
+
+		output := p.TestConfig.TestContext.GetOutput()
+		assert.Contains(t, output, "bundle.json (mybuns 0.1.0)")
+		assert.Contains(t, output, dig.String())
+	})
+
+	t.Run("not stamped", func(t *testing.T) {
+		files, _ := buildTestLayoutArchiveFiles(t)
+		updateBundle(t, files, func(bun *bundle.Bundle) {
+			bun.Custom = nil
+		})
+
+		v := verify(t, files)
+		assert.False(t, v.IsValid())
+		assert.Equal(t, ArchiveEntry{Name: "bundle.json (mybuns 0.1.0)", Type: "bundle", Status: ArchiveEntryInvalid, Message: "the bundle was not stamped by porter"}, v.Entries[0])
+	})
+
+	t.Run("missing image", func(t *testing.T) {
+		files, _ := buildTestLayoutArchiveFiles(t)
+		updateBundle(t, files, func(bun *bundle.Bundle) {
+			bun.Images = map[string]bundle.Image{
+				"app": {BaseImage: bundle.BaseImage{Image: "getporter/app:v1.0.0"}},
+			}
+		})
+
+		v := verify(t, files)
+		assert.False(t, v.IsValid())
+		require.Len(t, v.Entries, 3)
+		assert.Equal(t, ArchiveEntryOK, v.Entries[1].Status, "the invocation image should be ok")
+		assert.Equal(t, ArchiveEntry{Name: "getporter/app:v1.0.0", Type: "image", Status: ArchiveEntryMissing, Message: "the image is not in the archive"}, v.Entries[2])
+	})
+
+	t.Run("digest mismatch", func(t *testing.T) {
+		files, _ := buildTestLayoutArchiveFiles(t)
+		updateBundle(t, files, func(bun *bundle.Bundle) {
+			bun.InvocationImages[0].Digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+		})
+
+		v := verify(t, files)
+		require.Len(t, v.Entries, 2)
+		assert.Equal(t, ArchiveEntryCorrupt, v.Entries[1].Status)
+		assert.Contains(t, v.Entries[1].Message, "the content digest does not match")
+	})
+
+	t.Run("corrupt layer", func(t *testing.T) {
+		files, img := buildTestLayoutArchiveFiles(t)
+		layers, err := img.Layers()
+		require.NoError(t, err)
+		layerDigest, err := layers[0].Digest()
+		require.NoError(t, err)
+		layerFile := "./" + filepath.Join(archiveLayoutDir, "blobs", layerDigest.Algorithm, layerDigest.Hex)
+		require.Contains(t, files, layerFile)
+		files[layerFile][0]++
+
+		v := verify(t, files)
+		require.Len(t, v.Entries, 2)
+		assert.Equal(t, ArchiveEntryCorrupt, v.Entries[1].Status)
+		assert.Equal(t, "blob "+layerDigest.String()+" does not match its digest", v.Entries[1].Message)
+
+		delete(files, layerFile)
+		v = verify(t, files)
+		assert.Equal(t, ArchiveEntryMissing, v.Entries[1].Status)
+	})
+}
@@ -28,6 +28,12 @@ type BuildOptions struct {
 	contextOptions
 	metadataOpts
 	NoLint bool
+
+	// InsecureRegistry allows connecting to an unsecured registry when resolving dependencies.
+	InsecureRegistry bool
+
+	// UpdateLock resolves the dependencies again, instead of using the ones in porter.lock.
+	UpdateLock bool
 }
 
 func (o *BuildOptions) Validate(cxt *context.Context) error {
@@ -71,6 +77,12 @@ func (p *Porter) Build(opts BuildOptions) error {
 		}
 	}
 
+	// Resolve the dependencies to a specific bundle so that the same
+	// dependencies are used whenever the bundle is executed.
+	if err := p.lockDependencies(opts.InsecureRegistry, opts.UpdateLock); err != nil {
+		return errors.Wrap(err, "unable to lock dependencies")
+	}
+
 	// Build bundle so that resulting bundle.json is available for inclusion
 	// into the invocation image.
 	// Note: the content digest field on the invocation image section of the
@@ -152,6 +164,10 @@ func (p *Porter) buildBundle(invocationImage string, digest string) error {
 		return err
 	}
 
+	if err := p.embedDependencyLocks(&bun); err != nil {
+		return err
+	}
+
 	return p.writeBundle(bun)
 }
 
@@ -0,0 +1,211 @@
+package porter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"get.porter.sh/porter/pkg/cache"
+	"get.porter.sh/porter/pkg/printer"
+	dtprinter "github.com/carolynvs/datetime-printer"
+	"github.com/docker/distribution/reference"
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+)
+
+// CacheListOptions are the options for listing the bundles in the cache.
+type CacheListOptions struct {
+	printer.PrintOptions
+}
+
+// ListCache prints the bundles in the cache.
+func (p *Porter) ListCache(opts CacheListOptions) error {
+	entries, err := p.Cache.ListBundles()
+	if err != nil {
+		return err
+	}
+
+	switch opts.Format {
+	case printer.FormatJson:
+		return printer.PrintJson(p.Out, entries)
+	case printer.FormatYaml:
+		return printer.PrintYaml(p.Out, entries)
+	case printer.FormatTable:
+		// have every row use the same "now" starting ... NOW!
+		now := time.Now()
+		tp := dtprinter.DateTimePrinter{
+			Now: func() time.Time { return now },
+		}
+
+		row :=
+			func(v interface{}) []interface{} {
+				e, ok := v.(cache.Entry)
+				if !ok {
+					return nil
+				}
+				return []interface{}{e.DisplayName(), e.Digest, humanize.IBytes(uint64(e.Size)), tp.Format(e.LastUsed)}
+			}
+		return printer.PrintTable(p.Out, entries, row,
+			"TAG", "DIGEST", "SIZE", "LAST USED")
+	default:
+		return fmt.Errorf("invalid format: %s", opts.Format)
+	}
+}
+
+// CachePruneOptions are the options for removing unused bundles from the cache.
+type CachePruneOptions struct {
+	// OlderThan is how long since a bundle was last used before it is
+	// removed, such as 30d or 12h.
+	OlderThan string
+
+	// All removes every bundle from the cache.
+	All bool
+
+	olderThan time.Duration
+}
+
+// Validate the prune options.
+func (o *CachePruneOptions) Validate() error {
+	if o.All {
+		if o.OlderThan != "" {
+			return errors.New("--all and --older-than cannot be used together")
+		}
+		return nil
+	}
+	if o.OlderThan == "" {
+		return errors.New("either --older-than or --all is required")
+	}
+
+	var err error
+	o.olderThan, err = parseAge(o.OlderThan)
+	if err != nil {
+		return errors.Wrapf(err, "invalid --older-than %s", o.OlderThan)
+	}
+	return nil
+}
+
+// parseAge parses a duration that may also be specified in days, such as 30d.
+func parseAge(value string) (time.Duration, error) {
+	var age time.Duration
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return 0, errors.New("expected a duration such as 30d or 12h")
+		}
+		age = time.Duration(days) * 24 * time.Hour
+	} else {
+		var err error
+		age, err = time.ParseDuration(value)
+		if err != nil {
+			return 0, errors.New("expected a duration such as 30d or 12h")
+		}
+	}
+
+	if age < 0 {
+		return 0, errors.New("the duration cannot be negative")
+	}
+	return age, nil
+}
+
+// PruneCache removes the bundles from the cache that haven't been used recently.
+func (p *Porter) PruneCache(opts CachePruneOptions) error {
+	entries, err := p.Cache.ListBundles()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-opts.olderThan)
+	var reclaimed int64
+	for _, e := range entries {
+		if !opts.All && !e.LastUsed.Before(cutoff) {
+			continue
+		}
+
+		if err = p.Cache.RemoveBundle(e.ID); err != nil {
+			return err
+		}
+		reclaimed += e.Size
+		fmt.Fprintf(p.Out, "Removed %s\n", e.DisplayName())
+	}
+
+	fmt.Fprintf(p.Out, "Reclaimed %s\n", humanize.IBytes(uint64(reclaimed)))
+	return nil
+}
+
+// CacheRemoveOptions are the options for removing a bundle from the cache.
+type CacheRemoveOptions struct {
+	// Reference to the bundle, or the cache ID of a bundle without a tag.
+	Reference string
+}
+
+// Validate the remove options.
+func (o *CacheRemoveOptions) Validate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("the bundle reference to remove from the cache is required")
+	}
+	if len(args) > 1 {
+		return errors.Errorf("only one bundle reference may be specified but multiple were received: %s", args)
+	}
+	o.Reference = args[0]
+	return nil
+}
+
+// RemoveFromCache removes a bundle from the cache.
+func (p *Porter) RemoveFromCache(opts CacheRemoveOptions) error {
+	entries, err := p.Cache.ListBundles()
+	if err != nil {
+		return err
+	}
+
+	removed := false
+	for _, e := range entries {
+		if !matchesCacheEntry(e, opts.Reference) {
+			continue
+		}
+
+		if err = p.Cache.RemoveBundle(e.ID); err != nil {
+			return err
+		}
+		removed = true
+		fmt.Fprintf(p.Out, "Removed %s\n", e.DisplayName())
+	}
+
+	if !removed {
+		return errors.Errorf("bundle %s is not in the cache", opts.Reference)
+	}
+	return nil
+}
+
+// matchesCacheEntry determines if a cached bundle has the reference, or its
+// normalized equivalent, as one of its tags or as its digest reference, or
+// has the reference as its ID.
+func matchesCacheEntry(e cache.Entry, ref string) bool {
+	if e.ID == ref {
+		return true
+	}
+
+	want, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return false
+	}
+	want = reference.TagNameOnly(want)
+
+	refs := e.Tags
+	if e.Repository != "" {
+		refs = append(refs, e.Repository+"@"+e.Digest)
+	}
+	for _, r := range refs {
+		if r == ref {
+			return true
+		}
+		got, err := reference.ParseNormalizedNamed(r)
+		if err != nil {
+			continue
+		}
+		if reference.TagNameOnly(got).String() == want.String() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package porter
+
+import (
+	"testing"
+	"time"
+
+	"get.porter.sh/porter/pkg/cache"
+	"get.porter.sh/porter/pkg/printer"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachePruneOptions_Validate(t *testing.T) {
+	testcases := []struct {
+		olderThan string
+		all       bool
+		want      time.Duration
+		wantError string
+	}{
+		{olderThan: "", wantError: "either --older-than or --all is required"},
+		{olderThan: "", all: true, want: 0},
+		{olderThan: "30d", all: true, wantError: "--all and --older-than cannot be used together"},
+		{olderThan: "30d", want: 30 * 24 * time.Hour},
+		{olderThan: "12h", want: 12 * time.Hour},
+		{olderThan: "1w", wantError: "invalid --older-than 1w: expected a duration such as 30d or 12h"},
+		{olderThan: "-1h", wantError: "invalid --older-than -1h: the duration cannot be negative"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.olderThan, func(t *testing.T) {
+			opts := CachePruneOptions{OlderThan: tc.olderThan, All: tc.all}
+			err := opts.Validate()
+			if tc.wantError != "" {
+				require.EqualError(t, err, tc.wantError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, opts.olderThan)
+			}
+		})
+	}
+}
+
+func TestPorter_Cache(t *testing.T) {
+	setup := func(t *testing.T) *TestPorter {
+		p := NewTestPorter(t)
+		for _, tag := range []string{"getporter/porter-hello:v0.1.0", "localhost:5000/mybuns:v1.0.0"} {
+			_, err := p.Cache.StoreBundle(tag, "", bundle.Bundle{Name: "mybuns", Version: "0.1.0"}, nil)
+			require.NoError(t, err, "StoreBundle failed")
+		}
+		return p
+	}
+
+	cachedTags := func(t *testing.T, p *TestPorter) []string {
+		entries, err := p.Cache.ListBundles()
+		require.NoError(t, err, "ListBundles failed")
+		var tags []string
+		for _, e := range entries {
+			tags = append(tags, e.Tags...)
+		}
+		return tags
+	}
+
+	t.Run("list", func(t *testing.T) {
+		p := setup(t)
+		opts := CacheListOptions{}
+		opts.Format = printer.FormatJson
+		require.NoError(t, p.ListCache(opts))
+
+		gotOutput := p.TestConfig.TestContext.GetOutput()
+		assert.Contains(t, gotOutput, `"getporter/porter-hello:v0.1.0"`)
+		assert.Contains(t, gotOutput, `"localhost:5000/mybuns:v1.0.0"`)
+	})
+
+	t.Run("remove normalized reference", func(t *testing.T) {
+		p := setup(t)
+		opts := CacheRemoveOptions{}
+		require.NoError(t, opts.Validate([]string{"docker.io/getporter/porter-hello:v0.1.0"}))
+		require.NoError(t, p.RemoveFromCache(opts))
+
+		assert.Equal(t, []string{"localhost:5000/mybuns:v1.0.0"}, cachedTags(t, p))
+		assert.Contains(t, p.TestConfig.TestContext.GetOutput(), "Removed getporter/porter-hello:v0.1.0")
+	})
+
+	t.Run("remove missing bundle", func(t *testing.T) {
+		p := setup(t)
+		opts := CacheRemoveOptions{Reference: "getporter/porter-hello:v0.2.0"}
+		err := p.RemoveFromCache(opts)
+		require.EqualError(t, err, "bundle getporter/porter-hello:v0.2.0 is not in the cache")
+	})
+
+	t.Run("prune older than", func(t *testing.T) {
+		p := setup(t)
+		opts := CachePruneOptions{OlderThan: "1h"}
+		require.NoError(t, opts.Validate())
+		require.NoError(t, p.PruneCache(opts))
+		assert.Len(t, cachedTags(t, p), 2, "recently used bundles should not be pruned")
+
+		opts = CachePruneOptions{All: true}
+		require.NoError(t, opts.Validate())
+		require.NoError(t, p.PruneCache(opts))
+		assert.Empty(t, cachedTags(t, p), "every bundle should be pruned")
+	})
+}
+
+func TestMatchesCacheEntry(t *testing.T) {
+	e := cache.Entry{ID: "abc123", Tags: []string{"getporter/porter-hello"}}
+	assert.True(t, matchesCacheEntry(e, "abc123"))
+	assert.True(t, matchesCacheEntry(e, "getporter/porter-hello"))
+	assert.True(t, matchesCacheEntry(e, "docker.io/getporter/porter-hello:latest"))
+	assert.False(t, matchesCacheEntry(e, "getporter/porter-hello:v0.1.0"))
+	assert.False(t, matchesCacheEntry(cache.Entry{ID: "abc123"}, "getporter/porter-hello"))
+}
@@ -1,17 +1,38 @@
 package porter
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-to-oci/relocation"
 	"github.com/docker/distribution/reference"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
 )
 
+// DefaultCopyConcurrency is the default number of images that are copied at
+// the same time when relocating images.
+const DefaultCopyConcurrency = 4
+
 type CopyOpts struct {
 	Source           string
 	Destination      string
 	InsecureRegistry bool
+
+	// RelocateImages copies every image referenced by the bundle into the
+	// destination registry and organization.
+	RelocateImages bool
+
+	// RelocationMapping is the path to write the mapping of the original images
+	// to the relocated images.
+	RelocationMapping string
+
+	// Concurrency is the maximum number of images copied at the same time.
+	Concurrency int
 }
 
 // Validate performs validation logic on the options specified for a bundle copy
@@ -26,6 +47,12 @@ func (c *CopyOpts) Validate() error {
 	if isCopyDigestReference(source) && isCopyReferenceOnly(c.Destination) {
 		return errors.New("--destination must be tagged reference when --source is digested reference")
 	}
+	if c.RelocationMapping != "" && !c.RelocateImages {
+		return errors.New("--relocation-mapping can only be used with --relocate-images")
+	}
+	if c.RelocateImages && c.Concurrency < 1 {
+		return errors.New("--concurrency must be at least 1")
+	}
 	return nil
 }
 
@@ -56,13 +83,163 @@ func generateNewBundleRef(source, dest string) string {
 func (p *Porter) CopyBundle(c *CopyOpts) error {
 	destinationRef := generateNewBundleRef(c.Source, c.Destination)
 	fmt.Fprintf(p.Out, "Beginning bundle copy to %s. This may take some time.\n", destinationRef)
-	bun, _, err := p.Registry.PullBundle(c.Source, c.InsecureRegistry)
+	bun, rm, err := p.Registry.PullBundle(c.Source, c.InsecureRegistry)
 	if err != nil {
 		return errors.Wrap(err, "unable to pull bundle before copying")
 	}
-	_, err = p.Registry.PushBundle(bun, destinationRef, c.InsecureRegistry)
+
+	return p.copyBundle(bun, rm, destinationRef, c)
+}
+
+// copyBundle pushes a bundle that was pulled from the source to the destination,
+// relocating its images first when requested.
+func (p *Porter) copyBundle(bun bundle.Bundle, rm *relocation.ImageRelocationMap, destinationRef string, c *CopyOpts) error {
+	if !c.RelocateImages {
+		_, err := p.Registry.PushBundle(bun, destinationRef, c.InsecureRegistry)
+		if err != nil {
+			return errors.Wrap(err, "unable to copy bundle to new location")
+		}
+		return nil
+	}
+
+	reloMap, err := p.relocateImages(bun, rm, destinationRef, c)
+	if err != nil {
+		return err
+	}
+
+	if c.RelocationMapping != "" {
+		data, err := json.MarshalIndent(reloMap, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "could not marshal the relocation mapping")
+		}
+		if err = p.FileSystem.WriteFile(c.RelocationMapping, data, 0644); err != nil {
+			return errors.Wrapf(err, "could not write the relocation mapping to %s", c.RelocationMapping)
+		}
+	}
+
+	// Push the bundle unmodified, resolving its images from the relocated
+	// images so that the original registries aren't needed after the copy
+	_, err = p.Registry.PushRelocatedBundle(bun, destinationRef, reloMap, c.InsecureRegistry)
 	if err != nil {
 		return errors.Wrap(err, "unable to copy bundle to new location")
 	}
 	return nil
 }
+
+// relocatedImage is an image from a bundle that is copied to a new location.
+type relocatedImage struct {
+	// Image is the original image reference from the bundle.
+	Image string
+
+	// Digest is the content digest of the image from the bundle.
+	Digest string
+
+	// Source is where the image is copied from.
+	Source string
+
+	// Destination is where the image is copied to.
+	Destination string
+}
+
+// relocateImages copies the invocation images and images from a bundle into the
+// registry and organization of the destination bundle reference, and returns
+// the mapping of the original images to the digested references of the copied images.
+func (p *Porter) relocateImages(bun bundle.Bundle, rm *relocation.ImageRelocationMap, destinationRef string, c *CopyOpts) (relocation.ImageRelocationMap, error) {
+	var images []relocatedImage
+	addImage := func(img bundle.BaseImage) error {
+		for _, existing := range images {
+			if existing.Image == img.Image {
+				return nil
+			}
+		}
+
+		destination, err := getRelocatedImageName(img.Image, destinationRef)
+		if err != nil {
+			return err
+		}
+
+		// Copy from where the image was relocated, if the bundle was already relocated
+		source := img.Image
+		if rm != nil {
+			if relocated, ok := (*rm)[img.Image]; ok {
+				source = relocated
+			}
+		}
+
+		images = append(images, relocatedImage{Image: img.Image, Digest: img.Digest, Source: source, Destination: destination})
+		return nil
+	}
+	for _, img := range bun.InvocationImages {
+		if err := addImage(img.BaseImage); err != nil {
+			return nil, err
+		}
+	}
+	imageKeys := make([]string, 0, len(bun.Images))
+	for key := range bun.Images {
+		imageKeys = append(imageKeys, key)
+	}
+	sort.Strings(imageKeys)
+	for _, key := range imageKeys {
+		if err := addImage(bun.Images[key].BaseImage); err != nil {
+			return nil, err
+		}
+	}
+
+	fmt.Fprintf(p.Out, "Relocating %d images with a concurrency of %d...\n", len(images), c.Concurrency)
+
+	var mu sync.Mutex
+	reloMap := make(relocation.ImageRelocationMap, len(images))
+	sem := make(chan struct{}, c.Concurrency)
+	g := errgroup.Group{}
+	for _, ri := range images {
+		img := ri // Force img to be in the go routine's closure below
+		g.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			digest, err := p.Registry.CopyImage(img.Source, img.Destination, c.InsecureRegistry)
+			if err != nil {
+				return errors.Wrapf(err, "unable to relocate image %s", img.Image)
+			}
+			if img.Digest != "" && digest != img.Digest {
+				return errors.Errorf("the relocated image %s has digest %s but the bundle expects %s", img.Destination, digest, img.Digest)
+			}
+
+			relocated, err := p.rewriteImageWithDigest(img.Destination, digest)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			reloMap[img.Image] = relocated
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	for _, img := range images {
+		fmt.Fprintf(p.Out, "Relocated image %s to %s\n", img.Image, reloMap[img.Image])
+	}
+	return reloMap, nil
+}
+
+// getRelocatedImageName returns the location of an image in the registry and
+// organization of the bundle reference, keeping the tag of the original image.
+func getRelocatedImageName(origImg string, bundleRef string) (string, error) {
+	newImgName, err := getNewImageNameFromBundleReference(origImg, bundleRef)
+	if err != nil {
+		return "", err
+	}
+
+	origRef, err := reference.ParseNormalizedNamed(origImg)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid image reference %s", origImg)
+	}
+	if tagged, ok := origRef.(reference.Tagged); ok {
+		return fmt.Sprintf("%s:%s", newImgName.Name(), tagged.Tag()), nil
+	}
+	return newImgName.Name(), nil
+}
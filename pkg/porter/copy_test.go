@@ -1,11 +1,16 @@
 package porter
 
 import (
+	"encoding/json"
 	"fmt"
+	"sync"
 	"testing"
 
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-to-oci/relocation"
 	"github.com/docker/distribution/reference"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func makeNamed(ref string) reference.Named {
@@ -136,6 +141,38 @@ func TestValidateCopyArgs(t *testing.T) {
 			true,
 			"--destination must be tagged reference when --source is digested reference",
 		},
+		{
+			"relocation mapping without relocate images",
+			CopyOpts{
+				Source:            "deislabs/mybuns:v0.1.0",
+				Destination:       "blah.acr.io",
+				RelocationMapping: "relocation-mapping.json",
+			},
+			true,
+			"--relocation-mapping can only be used with --relocate-images",
+		},
+		{
+			"relocate images without concurrency",
+			CopyOpts{
+				Source:         "deislabs/mybuns:v0.1.0",
+				Destination:    "blah.acr.io",
+				RelocateImages: true,
+			},
+			true,
+			"--concurrency must be at least 1",
+		},
+		{
+			"relocate images",
+			CopyOpts{
+				Source:            "deislabs/mybuns:v0.1.0",
+				Destination:       "blah.acr.io",
+				RelocateImages:    true,
+				RelocationMapping: "relocation-mapping.json",
+				Concurrency:       DefaultCopyConcurrency,
+			},
+			false,
+			"",
+		},
 	}
 
 	for _, test := range tests {
@@ -185,3 +222,103 @@ func TestCopyGenerateBundleRef(t *testing.T) {
 		assert.Equal(t, test.Expected, newRef, fmt.Sprintf("%s: expected %s got %s", test.Name, test.Expected, newRef))
 	}
 }
+
+func Test_getRelocatedImageName(t *testing.T) {
+	testcases := []struct {
+		image string
+		want  string
+	}{
+		{"getporter/mybuns-installer:v0.1.0", "mirror.example.com/porter/mybuns-installer:v0.1.0"},
+		{"docker.io/library/nginx@sha256:a808aa4e3508d7129742eefda938249574447cce5403dc12d4cbbfe7f4f31e58", "mirror.example.com/porter/nginx"},
+		{"localhost:5000/mysql", "mirror.example.com/porter/mysql"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.image, func(t *testing.T) {
+			got, err := getRelocatedImageName(tc.image, "mirror.example.com/porter/mybuns:v0.1.0")
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPorter_CopyBundle_RelocateImages(t *testing.T) {
+	const (
+		installerDigest = "sha256:a808aa4e3508d7129742eefda938249574447cce5403dc12d4cbbfe7f4f31e58"
+		mysqlDigest     = "sha256:bb9b47bb07e8c2f62ea1f617351739b35264f8a6121d79e989cd4e81743afe0a"
+	)
+	bun := bundle.Bundle{
+		Name:    "mybuns",
+		Version: "0.1.0",
+		InvocationImages: []bundle.InvocationImage{
+			{BaseImage: bundle.BaseImage{Image: "getporter/mybuns-installer:v0.1.0", Digest: installerDigest}},
+		},
+		Images: map[string]bundle.Image{
+			"mysql": {BaseImage: bundle.BaseImage{Image: "mysql:5.7", Digest: mysqlDigest}},
+		},
+	}
+
+	p := NewTestPorter(t)
+	p.TestRegistry.MockPullBundle = func(tag string, insecureRegistry bool) (bundle.Bundle, *relocation.ImageRelocationMap, error) {
+		// The installer was already relocated into the bundle repository
+		return bun, &relocation.ImageRelocationMap{
+			"getporter/mybuns-installer:v0.1.0": "getporter/mybuns@" + installerDigest,
+		}, nil
+	}
+	var mu sync.Mutex
+	copied := map[string]string{}
+	p.TestRegistry.MockCopyImage = func(source string, destination string, insecureRegistry bool) (string, error) {
+		assert.True(t, insecureRegistry)
+		mu.Lock()
+		defer mu.Unlock()
+		copied[source] = destination
+		if source == "mysql:5.7" {
+			return mysqlDigest, nil
+		}
+		return installerDigest, nil
+	}
+	var pushedMap relocation.ImageRelocationMap
+	p.TestRegistry.MockPushRelocatedBundle = func(pushedBun bundle.Bundle, tag string, reloMap relocation.ImageRelocationMap, insecureRegistry bool) (*relocation.ImageRelocationMap, error) {
+		assert.Equal(t, bun, pushedBun, "the bundle should be pushed unmodified")
+		assert.Equal(t, "mirror.example.com/porter/mybuns:v0.1.0", tag)
+		pushedMap = reloMap
+		return nil, nil
+	}
+
+	opts := &CopyOpts{
+		Source:            "getporter/mybuns:v0.1.0",
+		Destination:       "mirror.example.com/porter",
+		InsecureRegistry:  true,
+		RelocateImages:    true,
+		RelocationMapping: "relocation-mapping.json",
+		Concurrency:       1,
+	}
+	require.NoError(t, opts.Validate())
+	require.NoError(t, p.CopyBundle(opts))
+
+	assert.Equal(t, map[string]string{
+		"getporter/mybuns@" + installerDigest: "mirror.example.com/porter/mybuns-installer:v0.1.0",
+		"mysql:5.7":                           "mirror.example.com/porter/mysql:5.7",
+	}, copied, "the images should be copied from their relocated location when available")
+
+	wantMap := relocation.ImageRelocationMap{
+		"getporter/mybuns-installer:v0.1.0": "mirror.example.com/porter/mybuns-installer@" + installerDigest,
+		"mysql:5.7":                         "mirror.example.com/porter/mysql@" + mysqlDigest,
+	}
+	assert.Equal(t, wantMap, pushedMap)
+
+	data, err := p.FileSystem.ReadFile("relocation-mapping.json")
+	require.NoError(t, err)
+	var gotMap relocation.ImageRelocationMap
+	require.NoError(t, json.Unmarshal(data, &gotMap))
+	assert.Equal(t, wantMap, gotMap)
+
+	t.Run("digest mismatch", func(t *testing.T) {
+		p.TestRegistry.MockCopyImage = func(source string, destination string, insecureRegistry bool) (string, error) {
+			return "sha256:0000000000000000000000000000000000000000000000000000000000000000", nil
+		}
+		err := p.CopyBundle(opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "but the bundle expects")
+	})
+}
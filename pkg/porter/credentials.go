@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	cnabprovider "get.porter.sh/porter/pkg/cnab/provider"
 	"get.porter.sh/porter/pkg/context"
 	"get.porter.sh/porter/pkg/editor"
 	"get.porter.sh/porter/pkg/generator"
@@ -27,13 +28,28 @@ type CredentialEditOptions struct {
 	Name string
 }
 
-// ListCredentials lists saved credential sets.
+// ListCredentials lists saved credential sets. When a label selector is
+// specified, only the credential sets used by the selected installations are listed.
 func (p *Porter) ListCredentials(opts ListOptions) error {
 	creds, err := p.Credentials.ReadAll()
 	if err != nil {
 		return err
 	}
 
+	if !opts.selector.Empty() {
+		used, err := p.selectInstallationSets(opts.selector, cnabprovider.GetCredentialSets)
+		if err != nil {
+			return err
+		}
+		selected := make([]credentials.CredentialSet, 0, len(used))
+		for _, cs := range creds {
+			if used[cs.Name] {
+				selected = append(selected, cs)
+			}
+		}
+		creds = selected
+	}
+
 	switch opts.Format {
 	case printer.FormatJson:
 		return printer.PrintJson(p.Out, creds)
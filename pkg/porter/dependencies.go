@@ -1,7 +1,9 @@
 package porter
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"get.porter.sh/porter/pkg/cnab/extensions"
@@ -29,14 +31,25 @@ type dependencyExecutioner struct {
 	parentAction BundleAction
 	parentOpts   *BundleActionOptions
 	parentArgs   cnabprovider.ActionArguments
-	deps         []*queuedDependency
+
+	// deps is every dependency in the graph, direct and transitive, in the
+	// order that they should be installed.
+	deps []*queuedDependency
+
+	// rootDeps are the direct dependencies of the root bundle.
+	rootDeps map[string]*queuedDependency
+
+	// graph is a lookup of the resolved dependencies by their dependency key.
+	graph map[string]*queuedDependency
+
+	// boundDeps maps the direct dependencies that were bound to an existing
+	// installation from the command line, either for this action or a previous
+	// one, to the name of the installation.
+	boundDeps map[string]string
 }
 
 func newDependencyExecutioner(p *Porter, action string) *dependencyExecutioner {
-	resolver := BundleResolver{
-		Cache:    p.Cache,
-		Registry: p.Registry,
-	}
+	resolver := p.newBundleResolver()
 	return &dependencyExecutioner{
 		porter:   p,
 		Action:   action,
@@ -53,8 +66,24 @@ type queuedDependency struct {
 	CNABFile   string
 	Parameters map[string]string
 
+	// Installation is the name of the installation for the dependency.
+	Installation string
+
+	// Existing indicates that the dependency uses an existing installation,
+	// which is not managed by the bundle that depends upon it.
+	Existing bool
+
+	// Dependencies of this dependency, by alias.
+	Dependencies map[string]*queuedDependency
+
 	outputs claim.Outputs
 
+	// bundle definition of the dependency
+	bundle bundle.Bundle
+
+	// manifest of the dependency, only set for bundles built by porter
+	manifest *manifest.Manifest
+
 	// cache of the CNAB file contents
 	cnabFileContents []byte
 
@@ -71,112 +100,422 @@ func (e *dependencyExecutioner) Prepare(parentAction BundleAction) error {
 	}
 	e.parentArgs = parentArgs
 
-	err = e.identifyDependencies()
+	bun, err := e.loadParentBundle()
 	if err != nil {
 		return err
 	}
 
-	for _, dep := range e.deps {
-		err := e.prepareDependency(dep)
+	locks, err := e.identifyDependencies(bun)
+	if err != nil {
+		return err
+	}
+
+	e.boundDeps, err = e.loadBoundDependencies()
+	if err != nil {
+		return err
+	}
+
+	e.deps = make([]*queuedDependency, 0, len(locks))
+	e.graph = make(map[string]*queuedDependency, len(locks))
+	e.rootDeps = make(map[string]*queuedDependency, len(locks))
+	for _, lock := range locks {
+		dep, err := e.resolveDependencyGraph(lock, e.parentArgs.Installation, e.Manifest, nil)
 		if err != nil {
 			return err
 		}
+		e.rootDeps[lock.Alias] = dep
+	}
+
+	for alias, installation := range e.parentOpts.parsedDependencyInstallations {
+		if _, ok := e.rootDeps[alias]; !ok {
+			return errors.Errorf("invalid --dependency-installation %s=%s, the bundle does not have a dependency named %s", alias, installation, alias)
+		}
 	}
 
 	return nil
 }
 
+// listInstallations returns the root installation and the installations of
+// every dependency in the graph, sorted by name. Prepare must be called first.
+func (e *dependencyExecutioner) listInstallations() []string {
+	names := map[string]struct{}{e.parentArgs.Installation: {}}
+	for _, dep := range e.deps {
+		names[dep.Installation] = struct{}{}
+	}
+
+	installations := make([]string, 0, len(names))
+	for name := range names {
+		installations = append(installations, name)
+	}
+	sort.Strings(installations)
+	return installations
+}
+
 func (e *dependencyExecutioner) Execute() error {
 	if e.deps == nil {
 		return errors.New("Prepare must be called before Execute")
 	}
 
-	// executeDependency the requested action against all of the dependencies
-	for _, dep := range e.deps {
-		err := e.executeDependency(dep)
-		if err != nil {
-			return err
+	// executeDependency the requested action against all of the dependencies.
+	// Dependencies are installed before the bundles that depend upon them,
+	// and uninstalled after them.
+	reverse := e.Action == claim.ActionUninstall
+	return executeDependencyGraph(e.deps, reverse, e.parentOpts.MaxParallelDependencies, e.executeDependency)
+}
+
+// executeDependencyGraph runs execute against each dependency in the graph.
+// A dependency is executed once all of the dependencies that it relies upon
+// have completed, and when reverse is true, once all of the dependencies that
+// rely upon it have completed. Independent dependencies are executed
+// concurrently, up to the specified limit, otherwise they are executed in the
+// order that they were queued.
+//
+// After a failure no more dependencies are started, though any that are already
+// running are allowed to finish.
+func executeDependencyGraph(deps []*queuedDependency, reverse bool, limit int, execute func(dep *queuedDependency) error) error {
+	if limit < 1 {
+		limit = 1
+	}
+
+	queue := make([]*queuedDependency, len(deps))
+	waitFor := make(map[*queuedDependency][]*queuedDependency, len(deps))
+	for i, dep := range deps {
+		if reverse {
+			queue[len(deps)-1-i] = dep
+		} else {
+			queue[i] = dep
+		}
+
+		for _, child := range dep.Dependencies {
+			if reverse {
+				waitFor[child] = append(waitFor[child], dep)
+			} else {
+				waitFor[dep] = append(waitFor[dep], child)
+			}
 		}
 	}
 
-	return nil
+	type result struct {
+		dep *queuedDependency
+		err error
+	}
+	results := make(chan result, len(queue))
+	started := make(map[*queuedDependency]bool, len(queue))
+	finished := make(map[*queuedDependency]bool, len(queue))
+	isReady := func(dep *queuedDependency) bool {
+		for _, d := range waitFor[dep] {
+			if !finished[d] {
+				return false
+			}
+		}
+		return true
+	}
+
+	var execErrs error
+	running := 0
+	for {
+		if execErrs == nil {
+			for _, dep := range queue {
+				if running >= limit {
+					break
+				}
+				if started[dep] || !isReady(dep) {
+					continue
+				}
+
+				started[dep] = true
+				running++
+				go func(dep *queuedDependency) {
+					results <- result{dep: dep, err: execute(dep)}
+				}(dep)
+			}
+		}
+
+		if running == 0 {
+			break
+		}
+
+		r := <-results
+		running--
+		finished[r.dep] = true
+		if r.err != nil {
+			execErrs = multierror.Append(execErrs, r.err)
+		}
+	}
+
+	if execErrs != nil {
+		if merr, ok := execErrs.(*multierror.Error); ok && len(merr.Errors) == 1 {
+			return merr.Errors[0]
+		}
+	}
+	return execErrs
 }
 
 // PrepareRootActionArguments uses information about the dependencies of a bundle to prepare
 // the execution of the root operation.
 func (e *dependencyExecutioner) PrepareRootActionArguments(args *cnabprovider.ActionArguments) {
+	addDependencyActionArguments(args, e.rootDeps)
+
+	// Remember which dependencies were bound to an existing installation
+	// from the command line, so that they are used by later actions too
+	args.BoundDependencies = make(map[string]string, len(e.boundDeps))
+	for alias, installation := range e.boundDeps {
+		if _, ok := e.rootDeps[alias]; ok {
+			args.BoundDependencies[alias] = installation
+		}
+	}
+
+	removeDependencyParameters(args.Params)
+}
+
+// removeDependencyParameters removes parameters for dependencies, in the
+// format ALIAS#PARAMETER, leaving only the parameters for the root bundle.
+func removeDependencyParameters(params map[string]string) {
+	for key := range params {
+		if strings.Contains(key, "#") {
+			delete(params, key)
+		}
+	}
+}
+
+// addDependencyActionArguments sets the files and installation names needed
+// by a bundle to use its dependencies.
+func addDependencyActionArguments(args *cnabprovider.ActionArguments, deps map[string]*queuedDependency) {
 	if args.Files == nil {
-		args.Files = make(map[string]string, 2*len(e.deps))
+		args.Files = make(map[string]string, 2*len(deps))
+	}
+	if args.DependencyInstallations == nil {
+		args.DependencyInstallations = make(map[string]string, len(deps))
 	}
 
 	// Define files necessary for dependencies that need to be copied into the bundle
 	// args.Files is a map of target path to file contents
-	for _, dep := range e.deps {
+	for alias, dep := range deps {
 		// Copy the dependency bundle.json
-		target := runtime.GetDependencyDefinitionPath(dep.Alias)
+		target := runtime.GetDependencyDefinitionPath(alias)
 		args.Files[target] = string(dep.cnabFileContents)
-	}
 
-	// Remove parameters for dependencies
-	for key := range args.Params {
-		if strings.Contains(key, "#") {
-			delete(args.Params, key)
-		}
+		args.DependencyInstallations[alias] = dep.Installation
 	}
 }
 
-func (e *dependencyExecutioner) identifyDependencies() error {
-	// Load parent CNAB bundle definition
-	var bun bundle.Bundle
+// loadParentBundle loads the bundle definition of the root bundle.
+func (e *dependencyExecutioner) loadParentBundle() (bundle.Bundle, error) {
 	if e.parentOpts.CNABFile != "" {
-		bundle, err := e.CNAB.LoadBundle(e.parentOpts.CNABFile)
-		if err != nil {
-			return err
-		}
-		bun = bundle
+		return e.CNAB.LoadBundle(e.parentOpts.CNABFile)
 	} else if e.parentOpts.Reference != "" {
 		cachedBundle, err := e.Resolver.Resolve(e.parentOpts.BundlePullOptions)
 		if err != nil {
-			return errors.Wrapf(err, "could not resolve bundle")
+			return bundle.Bundle{}, errors.Wrapf(err, "could not resolve bundle")
 		}
 
-		bun = cachedBundle.Bundle
+		return cachedBundle.Bundle, nil
 	} else if e.parentOpts.Name != "" {
 		c, err := e.Claims.ReadLastClaim(e.parentOpts.Name)
 		if err != nil {
-			return err
+			return bundle.Bundle{}, err
+		}
+
+		return c.Bundle, nil
+	}
+
+	// If we hit here, there is a bug somewhere
+	return bundle.Bundle{}, errors.New("identifyDependencies failed to load the bundle because no bundle was specified. Please report this bug to https://github.com/getporter/porter/issues/new/choose")
+}
+
+// loadBoundDependencies determines which direct dependencies are bound to an
+// existing installation from the command line. Bindings specified for this
+// action take precedence over those recorded when the installation was last modified.
+func (e *dependencyExecutioner) loadBoundDependencies() (map[string]string, error) {
+	boundDeps := make(map[string]string, len(e.parentOpts.parsedDependencyInstallations))
+
+	if c, err := e.Claims.ReadLastClaim(e.parentArgs.Installation); err == nil {
+		recorded, err := cnabprovider.GetBoundDependencies(c)
+		if err != nil {
+			return nil, err
+		}
+		for alias, installation := range recorded {
+			boundDeps[alias] = installation
+		}
+	}
+
+	for alias, installation := range e.parentOpts.parsedDependencyInstallations {
+		boundDeps[alias] = installation
+	}
+
+	return boundDeps, nil
+}
+
+// getBoundInstallation returns the name of the existing installation that
+// should be used for a dependency. Returns an empty string when the dependency
+// should be installed with the bundle that depends upon it.
+func (e *dependencyExecutioner) getBoundInstallation(alias string, parentManifest *manifest.Manifest, isDirect bool) string {
+	// Bindings from the command line only apply to direct dependencies
+	if isDirect {
+		if installation, ok := e.boundDeps[alias]; ok {
+			return installation
+		}
+	}
+
+	// dependencies:
+	//   - name: DEP
+	//     installation: INSTALLATION
+	if parentManifest != nil {
+		for _, manifestDep := range parentManifest.Dependencies {
+			if manifestDep.Name == alias {
+				return manifestDep.Installation
+			}
 		}
+	}
+
+	return ""
+}
 
-		bun = c.Bundle
-	} else {
-		// If we hit here, there is a bug somewhere
-		return errors.New("identifyDependencies failed to load the bundle because no bundle was specified. Please report this bug to https://github.com/getporter/porter/issues/new/choose")
+// identifyDependencies determines which bundle should be used for each of the
+// direct dependencies of a bundle.
+func (e *dependencyExecutioner) identifyDependencies(bun bundle.Bundle) ([]extensions.DependencyLock, error) {
+	// Prefer the dependencies that were locked when the bundle was built
+	locks := getDependencyLocks(bun)
+	if locks == nil {
+		solver := e.porter.newDependencySolver(e.parentOpts.InsecureRegistry)
+		var err error
+		locks, err = solver.ResolveDependencies(bun)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	solver := &extensions.DependencySolver{}
-	locks, err := solver.ResolveDependencies(bun)
+	if e.Debug {
+		for _, lock := range locks {
+			if lock.Digest != "" {
+				fmt.Fprintf(e.Err, "Using locked dependency %s at %s@%s\n", lock.Alias, lock.Reference, lock.Digest)
+			} else {
+				fmt.Fprintf(e.Err, "Resolved dependency %s to %s\n", lock.Alias, lock.Reference)
+			}
+		}
+	}
+
+	return locks, nil
+}
+
+// getDependencyKey uniquely identifies a dependency in the graph. Dependencies
+// that use the same alias and bundle are shared, no matter where they are in the graph.
+func getDependencyKey(lock extensions.DependencyLock) string {
+	ref, err := lock.GetPullReference()
 	if err != nil {
-		return err
+		ref = lock.Reference
+	}
+	return lock.Alias + "=" + ref
+}
+
+// resolveDependencyGraph prepares a dependency and all of its dependencies, recursively.
+// Dependencies are queued in e.deps after their own dependencies, so that the
+// queue is ordered topologically.
+func (e *dependencyExecutioner) resolveDependencyGraph(lock extensions.DependencyLock, parentInstallation string, parentManifest *manifest.Manifest, path []*queuedDependency) (*queuedDependency, error) {
+	key := getDependencyKey(lock)
+
+	// Detect cycles, where a dependency depends upon itself further down the graph
+	for i, parent := range path {
+		if getDependencyKey(parent.DependencyLock) == key {
+			aliases := make([]string, 0, len(path)-i+1)
+			for _, d := range path[i:] {
+				aliases = append(aliases, d.Alias)
+			}
+			aliases = append(aliases, lock.Alias)
+			return nil, errors.Errorf("dependency cycle detected: %s", strings.Join(aliases, " -> "))
+		}
 	}
 
-	e.deps = make([]*queuedDependency, len(locks))
-	for i, lock := range locks {
+	isDirect := len(path) == 0
+	boundInstallation := e.getBoundInstallation(lock.Alias, parentManifest, isDirect)
+
+	// Diamond dependencies, where multiple bundles depend on the same bundle, share a single installation
+	if dep, ok := e.graph[key]; ok {
 		if e.Debug {
-			fmt.Fprintf(e.Out, "Resolved dependency %s to %s\n", lock.Alias, lock.Reference)
+			fmt.Fprintf(e.Err, "Dependency %s (%s) is shared, using installation %s\n", lock.Alias, lock.Reference, dep.Installation)
+		}
+		if dep.Existing != (boundInstallation != "") || (dep.Existing && dep.Installation != boundInstallation) {
+			return nil, errors.Errorf("conflicting installations were specified for the shared dependency %s", lock.Alias)
 		}
-		e.deps[i] = &queuedDependency{
+		if dep.Existing {
+			return dep, nil
+		}
+		err := e.applyDependencyParameters(dep, parentManifest, isDirect)
+		return dep, err
+	}
+
+	// Use the existing installation as-is, without resolving its dependencies
+	// or applying parameters, since it isn't managed by this bundle
+	if boundInstallation != "" {
+		dep := &queuedDependency{
 			DependencyLock: lock,
+			Installation:   boundInstallation,
+			Existing:       true,
 		}
+		err := e.prepareExistingDependency(dep)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.Debug {
+			fmt.Fprintf(e.Err, "Dependency %s (%s) is bound to the existing installation %s\n", lock.Alias, lock.Reference, boundInstallation)
+		}
+
+		e.graph[key] = dep
+		e.deps = append(e.deps, dep)
+		return dep, nil
 	}
 
-	return nil
+	dep := &queuedDependency{
+		DependencyLock: lock,
+		Installation:   extensions.BuildPrerequisiteInstallationName(parentInstallation, lock.Alias),
+	}
+	err := e.prepareDependency(dep)
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.applyDependencyParameters(dep, parentManifest, isDirect)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve the dependencies of the dependency
+	childLocks := getDependencyLocks(dep.bundle)
+	if childLocks == nil {
+		solver := e.porter.newDependencySolver(e.parentOpts.InsecureRegistry)
+		childLocks, err = solver.ResolveDependencies(dep.bundle)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error resolving the dependencies of %s", dep.Alias)
+		}
+	}
+
+	dep.Dependencies = make(map[string]*queuedDependency, len(childLocks))
+	for _, childLock := range childLocks {
+		child, err := e.resolveDependencyGraph(childLock, dep.Installation, dep.manifest, append(path, dep))
+		if err != nil {
+			return nil, err
+		}
+		dep.Dependencies[childLock.Alias] = child
+	}
+
+	if e.Debug && len(path) > 0 {
+		fmt.Fprintf(e.Err, "Resolved transitive dependency %s to %s\n", dep.Alias, dep.Reference)
+	}
+
+	e.graph[key] = dep
+	e.deps = append(e.deps, dep)
+	return dep, nil
 }
 
 func (e *dependencyExecutioner) prepareDependency(dep *queuedDependency) error {
 	// Pull the dependency
-	var err error
+	pullRef, err := dep.GetPullReference()
+	if err != nil {
+		return err
+	}
 	pullOpts := BundlePullOptions{
-		Reference:        dep.Reference,
+		Reference:        pullRef,
 		InsecureRegistry: e.parentOpts.InsecureRegistry,
 		Force:            e.parentOpts.Force,
 	}
@@ -186,6 +525,8 @@ func (e *dependencyExecutioner) prepareDependency(dep *queuedDependency) error {
 	}
 	dep.CNABFile = cachedDep.BundlePath
 	dep.RelocationMapping = cachedDep.RelocationFilePath
+	dep.bundle = cachedDep.Bundle
+	dep.manifest = cachedDep.Manifest
 
 	err = cachedDep.Bundle.Validate()
 	if err != nil {
@@ -198,33 +539,97 @@ func (e *dependencyExecutioner) prepareDependency(dep *queuedDependency) error {
 		return errors.Wrapf(err, "error reading %s", dep.CNABFile)
 	}
 
+	return nil
+}
+
+// prepareExistingDependency checks that an existing installation can be used
+// for a dependency, and loads the bundle definition from its claim.
+func (e *dependencyExecutioner) prepareExistingDependency(dep *queuedDependency) error {
+	c, err := e.Claims.ReadLastClaim(dep.Installation)
+	if err != nil {
+		return errors.Wrapf(err, "could not load installation %s for dependency %s", dep.Installation, dep.Alias)
+	}
+
+	// The root bundle is allowed to uninstall when the existing installation
+	// is in a bad state, since the dependency is skipped anyway
+	if e.Action != claim.ActionUninstall {
+		if c.Action == claim.ActionUninstall {
+			return errors.Errorf("installation %s cannot be used for dependency %s because it has been uninstalled", dep.Installation, dep.Alias)
+		}
+
+		result, err := e.Claims.ReadLastResult(c.ID)
+		if err != nil {
+			return errors.Wrapf(err, "could not load the status of installation %s for dependency %s", dep.Installation, dep.Alias)
+		}
+		if result.Status != claim.StatusSucceeded {
+			return errors.Errorf("installation %s cannot be used for dependency %s because its last action (%s) did not succeed: %s", dep.Installation, dep.Alias, c.Action, result.Status)
+		}
+	}
+
+	// Make sure that the installation is of the same bundle as the dependency
+	if c.BundleReference == "" {
+		return errors.Errorf("installation %s cannot be used for dependency %s because it was not installed from a bundle reference, so it cannot be verified that it is an installation of %s", dep.Installation, dep.Alias, dep.Reference)
+	}
+	sameBundle, err := dep.IsSameRepository(c.BundleReference)
+	if err != nil {
+		return err
+	}
+	if !sameBundle {
+		return errors.Errorf("installation %s cannot be used for dependency %s because it is an installation of %s, not %s", dep.Installation, dep.Alias, c.BundleReference, dep.Reference)
+	}
+
+	dep.bundle = c.Bundle
+	dep.cnabFileContents, err = json.Marshal(c.Bundle)
+	return errors.Wrapf(err, "could not marshal the bundle for installation %s", dep.Installation)
+}
+
+// applyDependencyParameters sets parameter overrides for a dependency that
+// are defined by the bundle that depends upon it. Parameters set on the command
+// line only apply to direct dependencies of the root bundle.
+func (e *dependencyExecutioner) applyDependencyParameters(dep *queuedDependency, parentManifest *manifest.Manifest, isDirect bool) error {
 	// Make a lookup of which parameters are defined in the dependent bundle
 	depParams := map[string]struct{}{}
-	for paramName := range cachedDep.Bundle.Parameters {
+	for paramName := range dep.bundle.Parameters {
 		depParams[paramName] = struct{}{}
 	}
 
+	setParam := func(paramName string, value string) error {
+		if dep.Parameters == nil {
+			dep.Parameters = make(map[string]string, 1)
+		}
+		if existing, ok := dep.Parameters[paramName]; ok && existing != value {
+			return errors.Errorf("conflicting values were specified for the parameter %s on the shared dependency %s", paramName, dep.Alias)
+		}
+		dep.Parameters[paramName] = value
+		return nil
+	}
+
 	// Handle any parameter overrides for the dependency defined in porter.yaml
 	// dependencies:
 	//   - name: DEP
 	//     parameters:
 	//       PARAM: VALUE
-	for _, manifestDep := range e.Manifest.Dependencies {
-		if manifestDep.Name == dep.Alias {
-			for paramName, value := range manifestDep.Parameters {
-				// Make sure the parameter is defined in the bundle
-				if _, ok := depParams[paramName]; !ok {
-					return errors.Errorf("invalid dependencies.%s.parameters entry, %s is not a parameter defined in that bundle", dep.Alias, paramName)
-				}
-
-				if dep.Parameters == nil {
-					dep.Parameters = make(map[string]string, 1)
+	if parentManifest != nil {
+		for _, manifestDep := range parentManifest.Dependencies {
+			if manifestDep.Name == dep.Alias {
+				for paramName, value := range manifestDep.Parameters {
+					// Make sure the parameter is defined in the bundle
+					if _, ok := depParams[paramName]; !ok {
+						return errors.Errorf("invalid dependencies.%s.parameters entry, %s is not a parameter defined in that bundle", dep.Alias, paramName)
+					}
+
+					if err := setParam(paramName, value); err != nil {
+						return err
+					}
 				}
-				dep.Parameters[paramName] = value
 			}
 		}
 	}
 
+	if !isDirect {
+		return nil
+	}
+
 	// Handle any parameter overrides for the dependency defined on the command line
 	// --param DEP#PARAM=VALUE
 	for key, value := range e.parentArgs.Params {
@@ -237,6 +642,7 @@ func (e *dependencyExecutioner) prepareDependency(dep *queuedDependency) error {
 				return errors.Errorf("invalid --param %s, %s is not a parameter defined in the bundle %s", key, paramName, dep.Alias)
 			}
 
+			// Parameters from the command line take precedence over the manifest
 			if dep.Parameters == nil {
 				dep.Parameters = make(map[string]string, 1)
 			}
@@ -249,10 +655,27 @@ func (e *dependencyExecutioner) prepareDependency(dep *queuedDependency) error {
 }
 
 func (e *dependencyExecutioner) executeDependency(dep *queuedDependency) error {
+	// Existing installations are only used for their outputs, they are never modified
+	if dep.Existing {
+		if e.Action == claim.ActionUninstall {
+			fmt.Fprintf(e.Out, "Skipping dependency %s, it uses the existing installation %s\n", dep.Alias, dep.Installation)
+			return nil
+		}
+
+		fmt.Fprintf(e.Out, "Using the existing installation %s for dependency %s\n", dep.Installation, dep.Alias)
+		outputs, err := e.Claims.ReadLastOutputs(dep.Installation)
+		if err != nil {
+			return errors.Wrapf(err, "could not read the outputs of installation %s for dependency %s", dep.Installation, dep.Alias)
+		}
+		dep.outputs = outputs
+		return nil
+	}
+
 	depArgs := cnabprovider.ActionArguments{
 		Action:            e.parentArgs.Action,
 		BundlePath:        dep.CNABFile,
-		Installation:      extensions.BuildPrerequisiteInstallationName(e.parentArgs.Installation, dep.Alias),
+		BundleReference:   dep.Reference,
+		Installation:      dep.Installation,
 		Driver:            e.parentArgs.Driver,
 		Params:            dep.Parameters,
 		RelocationMapping: dep.RelocationMapping,
@@ -260,6 +683,7 @@ func (e *dependencyExecutioner) executeDependency(dep *queuedDependency) error {
 		// For now, assume it's okay to give the dependency the same credentials as the parent
 		CredentialIdentifiers: e.parentArgs.CredentialIdentifiers,
 	}
+	addDependencyActionArguments(&depArgs, dep.Dependencies)
 
 	// Determine if we're working with UninstallOptions, to inform deletion and
 	// error handling, etc.
@@ -1,9 +1,18 @@
 package porter
 
 import (
+	"encoding/json"
+	"sync"
 	"testing"
+	"time"
 
+	"get.porter.sh/porter/pkg/cnab/extensions"
+	cnabprovider "get.porter.sh/porter/pkg/cnab/provider"
+	"get.porter.sh/porter/pkg/config"
+	"github.com/cnabio/cnab-go/bundle"
 	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-to-oci/relocation"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -32,3 +41,328 @@ func TestDependencyExecutioner_ExecuteBeforePrepare(t *testing.T) {
 	err = e.Execute()
 	require.NoError(t, err, "execute should not fail when we have called prepare")
 }
+
+// newTestDependencyBundle creates a bundle that depends upon the specified bundles, by alias.
+func newTestDependencyBundle(name string, sequence []string, deps map[string]string) bundle.Bundle {
+	bun := bundle.Bundle{
+		SchemaVersion: "v1.0.0",
+		Name:          name,
+		Version:       "0.1.0",
+		InvocationImages: []bundle.InvocationImage{
+			{BaseImage: bundle.BaseImage{Image: "localhost:5000/" + name + ":v0.1.0", ImageType: "docker"}},
+		},
+		Custom: map[string]interface{}{},
+	}
+
+	if len(deps) > 0 {
+		requires := make(map[string]extensions.Dependency, len(deps))
+		for alias, ref := range deps {
+			requires[alias] = extensions.Dependency{Name: alias, Bundle: ref}
+		}
+		bun.Custom[extensions.DependenciesExtensionKey] = extensions.Dependencies{
+			Sequence: sequence,
+			Requires: requires,
+		}
+	}
+
+	return bun
+}
+
+func TestDependencyExecutioner_TransitiveDependencies(t *testing.T) {
+	// root -> b -> d
+	//      -> c -> d
+	registry := map[string]bundle.Bundle{
+		"localhost:5000/b:v1": newTestDependencyBundle("b", nil, map[string]string{"d": "localhost:5000/d:v1"}),
+		"localhost:5000/c:v1": newTestDependencyBundle("c", nil, map[string]string{"d": "localhost:5000/d:v1"}),
+		"localhost:5000/d:v1": newTestDependencyBundle("d", nil, nil),
+	}
+	root := newTestDependencyBundle("root", []string{"b", "c"}, map[string]string{
+		"b": "localhost:5000/b:v1",
+		"c": "localhost:5000/c:v1",
+	})
+
+	prepare := func(t *testing.T, action string) (*TestPorter, *dependencyExecutioner) {
+		p := NewTestPorter(t)
+		p.TestRegistry.MockPullBundle = func(tag string, insecureRegistry bool) (bundle.Bundle, *relocation.ImageRelocationMap, error) {
+			bun, ok := registry[tag]
+			if !ok {
+				return bundle.Bundle{}, nil, errors.Errorf("bundle %s not found", tag)
+			}
+			return bun, nil, nil
+		}
+
+		rootData, err := json.Marshal(root)
+		require.NoError(t, err)
+		require.NoError(t, p.FileSystem.WriteFile("/root.json", rootData, 0644))
+
+		opts := NewInstallOptions()
+		opts.Name = "root"
+		opts.CNABFile = "/root.json"
+
+		e := newDependencyExecutioner(p.Porter, action)
+		err = e.Prepare(opts)
+		require.NoError(t, err, "Prepare failed")
+		return p, e
+	}
+
+	t.Run("graph", func(t *testing.T) {
+		_, e := prepare(t, claim.ActionInstall)
+
+		require.Len(t, e.deps, 3, "the shared dependency should only be queued once")
+		assert.Equal(t, "d", e.deps[0].Alias, "transitive dependencies should be installed first")
+		assert.Equal(t, "root-b-d", e.deps[0].Installation)
+		assert.Equal(t, "b", e.deps[1].Alias)
+		assert.Equal(t, "root-b", e.deps[1].Installation)
+		assert.Equal(t, "c", e.deps[2].Alias)
+		assert.Equal(t, "root-c", e.deps[2].Installation)
+
+		assert.Same(t, e.deps[1].Dependencies["d"], e.deps[2].Dependencies["d"], "the diamond dependency should be shared")
+		assert.Len(t, e.rootDeps, 2, "only direct dependencies should be passed to the root bundle")
+	})
+
+	t.Run("root action arguments", func(t *testing.T) {
+		_, e := prepare(t, claim.ActionInstall)
+
+		var args cnabprovider.ActionArguments
+		e.PrepareRootActionArguments(&args)
+
+		assert.Len(t, args.Files, 2)
+		assert.Equal(t, map[string]string{"b": "root-b", "c": "root-c"}, args.DependencyInstallations)
+	})
+
+	t.Run("uninstall in reverse order", func(t *testing.T) {
+		p, e := prepare(t, claim.ActionUninstall)
+
+		err := e.Execute()
+		require.NoError(t, err, "Execute failed")
+
+		gotOutput := p.TestConfig.TestContext.GetOutput()
+		assert.Regexp(t, "(?s)Executing dependency c.*Executing dependency b.*Executing dependency d", gotOutput)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		registry["localhost:5000/d:v1"] = newTestDependencyBundle("d", nil, map[string]string{"b": "localhost:5000/b:v1"})
+		defer func() {
+			registry["localhost:5000/d:v1"] = newTestDependencyBundle("d", nil, nil)
+		}()
+
+		p := NewTestPorter(t)
+		p.TestRegistry.MockPullBundle = func(tag string, insecureRegistry bool) (bundle.Bundle, *relocation.ImageRelocationMap, error) {
+			return registry[tag], nil, nil
+		}
+		rootData, err := json.Marshal(root)
+		require.NoError(t, err)
+		require.NoError(t, p.FileSystem.WriteFile("/root.json", rootData, 0644))
+
+		opts := NewInstallOptions()
+		opts.Name = "root"
+		opts.CNABFile = "/root.json"
+
+		e := newDependencyExecutioner(p.Porter, claim.ActionInstall)
+		err = e.Prepare(opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "dependency cycle detected: b -> d -> b")
+	})
+}
+
+func TestDependencyExecutioner_ExistingInstallation(t *testing.T) {
+	// root -> b -> d
+	//      -> c -> d
+	registry := map[string]bundle.Bundle{
+		"localhost:5000/b:v1": newTestDependencyBundle("b", nil, map[string]string{"d": "localhost:5000/d:v1"}),
+		"localhost:5000/c:v1": newTestDependencyBundle("c", nil, map[string]string{"d": "localhost:5000/d:v1"}),
+		"localhost:5000/d:v1": newTestDependencyBundle("d", nil, nil),
+	}
+	root := newTestDependencyBundle("root", []string{"b", "c"}, map[string]string{
+		"b": "localhost:5000/b:v1",
+		"c": "localhost:5000/c:v1",
+	})
+
+	newTestPorter := func(t *testing.T) *TestPorter {
+		p := NewTestPorter(t)
+		p.TestRegistry.MockPullBundle = func(tag string, insecureRegistry bool) (bundle.Bundle, *relocation.ImageRelocationMap, error) {
+			bun, ok := registry[tag]
+			if !ok {
+				return bundle.Bundle{}, nil, errors.Errorf("bundle %s not found", tag)
+			}
+			return bun, nil, nil
+		}
+
+		rootData, err := json.Marshal(root)
+		require.NoError(t, err)
+		require.NoError(t, p.FileSystem.WriteFile("/root.json", rootData, 0644))
+		return p
+	}
+
+	// createInstallation saves an installation of the b bundle
+	createInstallation := func(p *TestPorter, name string, bundleRef string, status string) {
+		c, err := claim.New(name, claim.ActionInstall, registry["localhost:5000/b:v1"], nil)
+		require.NoError(t, err)
+		c.BundleReference = bundleRef
+		require.NoError(t, p.TestClaims.SaveClaim(c))
+		r := p.TestClaims.CreateResult(c, status)
+		p.TestClaims.CreateOutput(c, r, "connstr", []byte("mysql://shared"))
+	}
+
+	prepare := func(p *TestPorter, action string, dependencyInstallations ...string) (*dependencyExecutioner, error) {
+		opts := NewInstallOptions()
+		opts.Name = "root"
+		opts.CNABFile = "/root.json"
+		opts.DependencyInstallations = dependencyInstallations
+		require.NoError(t, opts.parseDependencyInstallations())
+
+		e := newDependencyExecutioner(p.Porter, action)
+		err := e.Prepare(opts)
+		return e, err
+	}
+
+	t.Run("bound from the command line", func(t *testing.T) {
+		p := newTestPorter(t)
+		createInstallation(p, "shared-b", "localhost:5000/b:v2", claim.StatusSucceeded)
+
+		e, err := prepare(p, claim.ActionInstall, "b=shared-b")
+		require.NoError(t, err, "Prepare failed")
+
+		b := e.rootDeps["b"]
+		assert.True(t, b.Existing, "b should use the existing installation")
+		assert.Equal(t, "shared-b", b.Installation)
+		assert.Empty(t, b.Dependencies, "the dependencies of an existing installation should not be resolved")
+
+		var args cnabprovider.ActionArguments
+		e.PrepareRootActionArguments(&args)
+		assert.Equal(t, map[string]string{"b": "shared-b", "c": "root-c"}, args.DependencyInstallations)
+		assert.Equal(t, map[string]string{"b": "shared-b"}, args.BoundDependencies, "the binding should be recorded on the root installation")
+
+		err = e.Execute()
+		require.NoError(t, err, "Execute failed")
+		gotOutput := p.TestConfig.TestContext.GetOutput()
+		assert.Contains(t, gotOutput, "Using the existing installation shared-b for dependency b")
+		assert.NotContains(t, gotOutput, "Executing dependency b")
+		_, ok := b.outputs.GetByName("connstr")
+		assert.True(t, ok, "the outputs of the existing installation should be loaded")
+	})
+
+	t.Run("bound from a previous action", func(t *testing.T) {
+		p := newTestPorter(t)
+		createInstallation(p, "shared-b", "localhost:5000/b:v1", claim.StatusSucceeded)
+		rootClaim, err := claim.New("root", claim.ActionInstall, root, nil)
+		require.NoError(t, err)
+		rootClaim.Custom = map[string]interface{}{
+			config.CustomPorterKey: map[string]interface{}{
+				"boundDependencies": map[string]interface{}{"b": "shared-b"},
+			},
+		}
+		require.NoError(t, p.TestClaims.SaveClaim(rootClaim))
+
+		e, err := prepare(p, claim.ActionUninstall)
+		require.NoError(t, err, "Prepare failed")
+		assert.True(t, e.rootDeps["b"].Existing, "b should use the existing installation")
+
+		err = e.Execute()
+		require.NoError(t, err, "Execute failed")
+		gotOutput := p.TestConfig.TestContext.GetOutput()
+		assert.Contains(t, gotOutput, "Skipping dependency b, it uses the existing installation shared-b")
+		assert.NotContains(t, gotOutput, "Executing dependency b")
+	})
+
+	t.Run("unknown dependency", func(t *testing.T) {
+		p := newTestPorter(t)
+
+		_, err := prepare(p, claim.ActionInstall, "oops=shared-b")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the bundle does not have a dependency named oops")
+	})
+
+	t.Run("different bundle", func(t *testing.T) {
+		p := newTestPorter(t)
+		createInstallation(p, "shared-b", "localhost:5000/mysql:v1", claim.StatusSucceeded)
+
+		_, err := prepare(p, claim.ActionInstall, "b=shared-b")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "installation shared-b cannot be used for dependency b because it is an installation of localhost:5000/mysql:v1")
+	})
+
+	t.Run("failed installation", func(t *testing.T) {
+		p := newTestPorter(t)
+		createInstallation(p, "shared-b", "localhost:5000/b:v1", claim.StatusFailed)
+
+		_, err := prepare(p, claim.ActionInstall, "b=shared-b")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "installation shared-b cannot be used for dependency b because its last action (install) did not succeed")
+	})
+}
+
+func TestExecuteDependencyGraph(t *testing.T) {
+	// a -> c
+	// b
+	c := &queuedDependency{DependencyLock: extensions.DependencyLock{Alias: "c"}}
+	a := &queuedDependency{DependencyLock: extensions.DependencyLock{Alias: "a"}, Dependencies: map[string]*queuedDependency{"c": c}}
+	b := &queuedDependency{DependencyLock: extensions.DependencyLock{Alias: "b"}}
+	deps := []*queuedDependency{c, a, b}
+
+	t.Run("serial", func(t *testing.T) {
+		var order []string
+		err := executeDependencyGraph(deps, false, 1, func(dep *queuedDependency) error {
+			order = append(order, dep.Alias)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c", "a", "b"}, order)
+	})
+
+	t.Run("serial reverse", func(t *testing.T) {
+		var order []string
+		err := executeDependencyGraph(deps, true, 1, func(dep *queuedDependency) error {
+			order = append(order, dep.Alias)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b", "a", "c"}, order)
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		var mu sync.Mutex
+		var order []string
+
+		// b and c are independent, so they should be running at the same time
+		var started sync.WaitGroup
+		started.Add(2)
+		bothStarted := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(bothStarted)
+		}()
+
+		err := executeDependencyGraph(deps, false, 2, func(dep *queuedDependency) error {
+			if dep.Alias == "b" || dep.Alias == "c" {
+				started.Done()
+				select {
+				case <-bothStarted:
+				case <-time.After(5 * time.Second):
+					return errors.Errorf("%s was not executed concurrently", dep.Alias)
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, dep.Alias)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Len(t, order, 3)
+		assert.Equal(t, "a", order[2], "a should wait for its dependency c to complete")
+	})
+
+	t.Run("stop after failure", func(t *testing.T) {
+		var order []string
+		err := executeDependencyGraph(deps, false, 1, func(dep *queuedDependency) error {
+			order = append(order, dep.Alias)
+			if dep.Alias == "c" {
+				return errors.New("oops")
+			}
+			return nil
+		})
+		require.EqualError(t, err, "oops")
+		assert.Equal(t, []string{"c"}, order, "no more dependencies should start after a failure")
+	})
+}
@@ -0,0 +1,293 @@
+package porter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	cnabprovider "get.porter.sh/porter/pkg/cnab/provider"
+	"get.porter.sh/porter/pkg/parameters"
+	"get.porter.sh/porter/pkg/printer"
+	"get.porter.sh/porter/pkg/signing"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/pkg/errors"
+)
+
+var (
+	DiffAllowedFormats = printer.Formats{printer.FormatTable, printer.FormatJson, printer.FormatYaml}
+	DiffDefaultFormat  = printer.FormatTable
+)
+
+const (
+	// sensitiveDiffValue is displayed instead of the current value of a sensitive parameter.
+	sensitiveDiffValue = "(sensitive)"
+
+	// changedSensitiveDiffValue is displayed instead of the proposed value of a sensitive parameter.
+	changedSensitiveDiffValue = "(sensitive, changed)"
+)
+
+var _ BundleAction = NewInstallationDiffOptions()
+
+// InstallationDiffOptions are the options for comparing an installation with
+// a proposed upgrade.
+type InstallationDiffOptions struct {
+	*BundleActionOptions
+	printer.PrintOptions
+}
+
+func NewInstallationDiffOptions() InstallationDiffOptions {
+	return InstallationDiffOptions{BundleActionOptions: &BundleActionOptions{}}
+}
+
+func (o InstallationDiffOptions) GetAction() string {
+	return claim.ActionUpgrade
+}
+
+func (o InstallationDiffOptions) GetActionVerb() string {
+	return "upgrading"
+}
+
+// Validate prepares for comparing an installation and validates the args/options.
+func (o *InstallationDiffOptions) Validate(args []string, p *Porter) error {
+	err := o.BundleActionOptions.Validate(args, p)
+	if err != nil {
+		return err
+	}
+
+	return o.PrintOptions.Validate(DiffDefaultFormat, DiffAllowedFormats)
+}
+
+// InstallationDiff is the set of changes between the last successful action
+// of an installation and a proposed action.
+type InstallationDiff struct {
+	Installation string               `json:"installation" yaml:"installation"`
+	Changes      []InstallationChange `json:"changes" yaml:"changes"`
+}
+
+// HasChanges determines if the proposed action is different from the last
+// successful action.
+func (d InstallationDiff) HasChanges() bool {
+	return len(d.Changes) > 0
+}
+
+// InstallationChange is a single value that is different between the last
+// successful action of an installation and a proposed action.
+type InstallationChange struct {
+	Field    string `json:"field" yaml:"field"`
+	Current  string `json:"current" yaml:"current"`
+	Proposed string `json:"proposed" yaml:"proposed"`
+}
+
+// PrintInstallationDiff prints the changes that an upgrade would make to an installation.
+func (p *Porter) PrintInstallationDiff(opts InstallationDiffOptions) error {
+	diff, err := p.DiffInstallation(opts)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Format {
+	case printer.FormatJson:
+		return printer.PrintJson(p.Out, diff)
+	case printer.FormatYaml:
+		return printer.PrintYaml(p.Out, diff)
+	case printer.FormatTable:
+		if !diff.HasChanges() {
+			fmt.Fprintf(p.Out, "No changes to installation %s\n", diff.Installation)
+			return nil
+		}
+
+		printChangeRow :=
+			func(v interface{}) []interface{} {
+				c, ok := v.(InstallationChange)
+				if !ok {
+					return nil
+				}
+				return []interface{}{c.Field, c.Current, c.Proposed}
+			}
+		return printer.PrintTable(p.Out, diff.Changes, printChangeRow, "Field", "Current", "Proposed")
+	default:
+		return fmt.Errorf("invalid format: %s", opts.Format)
+	}
+}
+
+// DiffInstallation compares the last successful action of an installation
+// with an upgrade using the specified options.
+func (p *Porter) DiffInstallation(opts InstallationDiffOptions) (InstallationDiff, error) {
+	err := p.prepullBundleByReference(opts.BundleActionOptions)
+	if err != nil {
+		return InstallationDiff{}, errors.Wrap(err, "unable to pull bundle before comparing the installation")
+	}
+
+	err = p.ensureLocalBundleIsUpToDate(opts.bundleFileOptions)
+	if err != nil {
+		return InstallationDiff{}, err
+	}
+
+	return p.diffInstallation(opts)
+}
+
+// diffInstallation compares the last successful action of an installation with
+// the claim that the specified action would create. The bundle must already be
+// pulled and built.
+func (p *Porter) diffInstallation(action BundleAction) (InstallationDiff, error) {
+	opts := action.GetOptions()
+
+	current, err := p.readLastSuccessfulClaim(opts.Name)
+	if err != nil {
+		return InstallationDiff{}, err
+	}
+
+	args, err := p.BuildActionArgs(action)
+	if err != nil {
+		return InstallationDiff{}, err
+	}
+	removeDependencyParameters(args.Params)
+
+	proposed, err := p.CNAB.NewClaim(args)
+	if err != nil {
+		return InstallationDiff{}, errors.Wrapf(err, "could not determine the proposed changes to installation %s", opts.Name)
+	}
+
+	return diffClaims(current, proposed)
+}
+
+// readLastSuccessfulClaim returns the most recent claim for an installation
+// that completed successfully.
+func (p *Porter) readLastSuccessfulClaim(installation string) (claim.Claim, error) {
+	claims, err := p.Claims.ReadAllClaims(installation)
+	if err != nil {
+		return claim.Claim{}, errors.Wrapf(err, "could not read the claims for installation %s", installation)
+	}
+
+	// Claims are sorted from oldest to newest
+	for i := len(claims) - 1; i >= 0; i-- {
+		c := claims[i]
+		result, err := p.Claims.ReadLastResult(c.ID)
+		if err != nil {
+			// The action may not have finished, or the results were removed
+			continue
+		}
+		if result.Status == claim.StatusSucceeded {
+			return c, nil
+		}
+	}
+
+	return claim.Claim{}, errors.Errorf("installation %s does not have a successful action to compare against", installation)
+}
+
+// diffClaims returns the changes between two claims for an installation.
+// Sensitive parameter values are not displayed, only whether they changed.
+func diffClaims(current claim.Claim, proposed claim.Claim) (InstallationDiff, error) {
+	diff := InstallationDiff{
+		Installation: proposed.Installation,
+		Changes:      []InstallationChange{},
+	}
+	addChange := func(field string, currentValue string, proposedValue string) {
+		if currentValue != proposedValue {
+			diff.Changes = append(diff.Changes, InstallationChange{
+				Field:    field,
+				Current:  currentValue,
+				Proposed: proposedValue,
+			})
+		}
+	}
+
+	addChange("bundle.reference", current.BundleReference, proposed.BundleReference)
+	addChange("bundle.version", current.Bundle.Version, proposed.Bundle.Version)
+
+	currentDigest, err := signing.DigestBundle(current.Bundle)
+	if err != nil {
+		return InstallationDiff{}, err
+	}
+	proposedDigest, err := signing.DigestBundle(proposed.Bundle)
+	if err != nil {
+		return InstallationDiff{}, err
+	}
+	addChange("bundle.digest", currentDigest, proposedDigest)
+
+	currentCreds, err := cnabprovider.GetCredentialSets(current)
+	if err != nil {
+		return InstallationDiff{}, err
+	}
+	proposedCreds, err := cnabprovider.GetCredentialSets(proposed)
+	if err != nil {
+		return InstallationDiff{}, err
+	}
+	addChange("credentialSets", strings.Join(currentCreds, ", "), strings.Join(proposedCreds, ", "))
+
+	names := make(map[string]struct{}, len(current.Parameters)+len(proposed.Parameters))
+	for name := range current.Parameters {
+		if !parameters.IsInternal(name, current.Bundle) {
+			names[name] = struct{}{}
+		}
+	}
+	for name := range proposed.Parameters {
+		if !parameters.IsInternal(name, proposed.Bundle) {
+			names[name] = struct{}{}
+		}
+	}
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	for _, name := range sortedNames {
+		sensitive := cnabprovider.IsParameterSensitive(current.Bundle, name) ||
+			cnabprovider.IsParameterSensitive(proposed.Bundle, name)
+
+		currentValue, err := formatDiffValue(current.Parameters, name)
+		if err != nil {
+			return InstallationDiff{}, err
+		}
+		proposedValue, err := formatDiffValue(proposed.Parameters, name)
+		if err != nil {
+			return InstallationDiff{}, err
+		}
+		if sensitive {
+			currentValue, proposedValue = redactDiffValues(currentValue, proposedValue)
+		}
+		addChange("parameters."+name, currentValue, proposedValue)
+	}
+
+	return diff, nil
+}
+
+// formatDiffValue formats a parameter value so that it can be compared.
+func formatDiffValue(params map[string]interface{}, name string) (string, error) {
+	value, ok := params[name]
+	if !ok {
+		return "", nil
+	}
+
+	var formatted string
+	if s, ok := value.(string); ok {
+		formatted = s
+	} else {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", errors.Wrapf(err, "could not format the value of parameter %s", name)
+		}
+		formatted = string(b)
+	}
+
+	return formatted, nil
+}
+
+// redactDiffValues replaces the values of a sensitive parameter so that the
+// diff only shows whether the value changed. Nothing derived from the value,
+// such as a hash, is displayed because it could be used to guess the value.
+func redactDiffValues(currentValue string, proposedValue string) (string, string) {
+	if currentValue == proposedValue {
+		return "", ""
+	}
+
+	redact := func(value string, redacted string) string {
+		if value == "" {
+			return ""
+		}
+		return redacted
+	}
+	return redact(currentValue, sensitiveDiffValue), redact(proposedValue, changedSensitiveDiffValue)
+}
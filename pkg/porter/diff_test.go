@@ -0,0 +1,178 @@
+package porter
+
+import (
+	"testing"
+	"time"
+
+	"get.porter.sh/porter/pkg/claims"
+	"get.porter.sh/porter/pkg/parameters"
+	"get.porter.sh/porter/pkg/secrets"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/cnabio/cnab-go/valuesource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// installWordpress installs the wordpress bundle from the test cache with the debug driver.
+func installWordpress(t *testing.T, p *TestPorter) {
+	cacheDir, _ := p.Cache.GetCacheDir()
+	p.TestConfig.TestContext.AddTestDirectory("testdata/cache", cacheDir)
+
+	cs := credentials.NewCredentialSet("wordpress",
+		valuesource.Strategy{
+			Name: "kubeconfig",
+			Source: valuesource.Source{
+				Key:   secrets.SourceSecret,
+				Value: "kubeconfig",
+			},
+		})
+	p.TestCredentials.TestSecrets.AddSecret("kubeconfig", "abc123")
+	err := p.Credentials.Save(cs)
+	require.NoError(t, err, "Credentials.Save failed")
+
+	opts := NewInstallOptions()
+	opts.Driver = DebugDriver
+	opts.Reference = "localhost:5000/wordpress:v0.1.3"
+	opts.CredentialIdentifiers = []string{"wordpress"}
+	opts.Params = []string{"wordpress-password=mypassword"}
+	err = opts.Validate(nil, p.Porter)
+	require.NoError(t, err, "Validate install options failed")
+
+	err = p.InstallBundle(opts)
+	require.NoError(t, err, "InstallBundle failed")
+}
+
+func TestPorter_DiffInstallation(t *testing.T) {
+	p := NewTestPorter(t)
+	installWordpress(t, p)
+
+	t.Run("no changes", func(t *testing.T) {
+		opts := NewInstallationDiffOptions()
+		opts.Driver = DebugDriver
+		opts.Reference = "localhost:5000/wordpress:v0.1.3"
+		opts.CredentialIdentifiers = []string{"wordpress"}
+		opts.Params = []string{"wordpress-password=mypassword"}
+		err := opts.Validate(nil, p.Porter)
+		require.NoError(t, err, "Validate diff options failed")
+
+		diff, err := p.DiffInstallation(opts)
+		require.NoError(t, err, "DiffInstallation failed")
+		assert.Equal(t, "wordpress", diff.Installation)
+		assert.False(t, diff.HasChanges(), "the same upgrade should not have any changes, got %v", diff.Changes)
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		opts := NewInstallationDiffOptions()
+		opts.Driver = DebugDriver
+		opts.Reference = "localhost:5000/wordpress:v0.1.3"
+		opts.Params = []string{"wordpress-password=newpassword", "wordpress-name=mywordpress", "mysql#database-name=mydb"}
+		err := opts.Validate(nil, p.Porter)
+		require.NoError(t, err, "Validate diff options failed")
+
+		diff, err := p.DiffInstallation(opts)
+		require.NoError(t, err, "DiffInstallation failed")
+		require.Len(t, diff.Changes, 3, "dependency parameters should not be compared")
+		assert.Equal(t, InstallationChange{Field: "credentialSets", Current: "wordpress", Proposed: ""}, diff.Changes[0])
+		assert.Equal(t, InstallationChange{Field: "parameters.wordpress-name", Current: "porter-ci-wordpress", Proposed: "mywordpress"}, diff.Changes[1])
+		assert.Equal(t, "parameters.wordpress-password", diff.Changes[2].Field)
+		assert.Equal(t, "(sensitive)", diff.Changes[2].Current, "sensitive parameters should not be displayed")
+		assert.Equal(t, "(sensitive, changed)", diff.Changes[2].Proposed, "sensitive parameters should not be displayed")
+	})
+}
+
+func TestPorter_UpgradeBundle_RequireChanges(t *testing.T) {
+	p := NewTestPorter(t)
+	installWordpress(t, p)
+
+	opts := NewUpgradeOptions()
+	opts.Driver = DebugDriver
+	opts.Reference = "localhost:5000/wordpress:v0.1.3"
+	opts.CredentialIdentifiers = []string{"wordpress"}
+	opts.Params = []string{"wordpress-password=mypassword"}
+	opts.RequireChanges = true
+	err := opts.Validate(nil, p.Porter)
+	require.NoError(t, err, "Validate upgrade options failed")
+
+	// The installation is compared while it is locked, so that it can't change before the upgrade
+	p.TestClaims.CreateLock("wordpress", "someone@else", time.Hour)
+	err = p.UpgradeBundle(opts)
+	require.Error(t, err)
+	assert.True(t, claims.IsInstallationLocked(err), "the lock should be acquired before the installation is compared, got %v", err)
+	require.NoError(t, p.Claims.ForceUnlockInstallation("wordpress"))
+
+	err = p.UpgradeBundle(opts)
+	require.EqualError(t, err, "installation wordpress has not changed since its last successful action, there is nothing to upgrade")
+
+	opts.Params = []string{"wordpress-password=newpassword"}
+	err = opts.Validate(nil, p.Porter)
+	require.NoError(t, err, "Validate upgrade options failed")
+
+	err = p.UpgradeBundle(opts)
+	require.NoError(t, err, "UpgradeBundle should run when there are changes")
+}
+
+func Test_diffClaims(t *testing.T) {
+	writeOnly := true
+	bun := bundle.Bundle{
+		Name:    "mybuns",
+		Version: "1.0.0",
+		Definitions: definition.Definitions{
+			"number":    {Type: "integer"},
+			"sensitive": {Type: "string", WriteOnly: &writeOnly},
+			"internal":  {Type: "string", Comment: parameters.PorterInternal},
+		},
+		Parameters: map[string]bundle.Parameter{
+			"replicas":    {Definition: "number"},
+			"password":    {Definition: "sensitive"},
+			"porter-data": {Definition: "internal"},
+		},
+	}
+	current, err := claim.New("mybuns", claim.ActionInstall, bun, map[string]interface{}{
+		"replicas":    1,
+		"password":    "secret",
+		"porter-data": "abc",
+	})
+	require.NoError(t, err)
+	current.BundleReference = "getporter/mybuns:v1.0.0"
+
+	upgradedBun := bun
+	upgradedBun.Version = "1.1.0"
+	proposed, err := current.NewClaim(claim.ActionUpgrade, upgradedBun, map[string]interface{}{
+		"replicas":    float64(2),
+		"password":    "secret",
+		"porter-data": "def",
+	})
+	require.NoError(t, err)
+	proposed.BundleReference = "getporter/mybuns:v1.1.0"
+
+	diff, err := diffClaims(current, proposed)
+	require.NoError(t, err, "diffClaims failed")
+
+	require.Len(t, diff.Changes, 4, "unchanged sensitive values and internal parameters should not be included, got %v", diff.Changes)
+	assert.Equal(t, InstallationChange{Field: "bundle.reference", Current: "getporter/mybuns:v1.0.0", Proposed: "getporter/mybuns:v1.1.0"}, diff.Changes[0])
+	assert.Equal(t, InstallationChange{Field: "bundle.version", Current: "1.0.0", Proposed: "1.1.0"}, diff.Changes[1])
+	assert.Equal(t, "bundle.digest", diff.Changes[2].Field)
+	assert.Equal(t, InstallationChange{Field: "parameters.replicas", Current: "1", Proposed: "2"}, diff.Changes[3])
+}
+
+func Test_redactDiffValues(t *testing.T) {
+	testcases := []struct {
+		name, current, proposed, wantCurrent, wantProposed string
+	}{
+		{name: "unchanged", current: "secret", proposed: "secret"},
+		{name: "changed", current: "secret", proposed: "newsecret", wantCurrent: "(sensitive)", wantProposed: "(sensitive, changed)"},
+		{name: "added", proposed: "secret", wantProposed: "(sensitive, changed)"},
+		{name: "removed", current: "secret", wantCurrent: "(sensitive)"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotCurrent, gotProposed := redactDiffValues(tc.current, tc.proposed)
+			assert.Equal(t, tc.wantCurrent, gotCurrent)
+			assert.Equal(t, tc.wantProposed, gotProposed)
+		})
+	}
+}
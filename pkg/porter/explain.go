@@ -170,7 +170,7 @@ func (p *Porter) Explain(o ExplainOpts) error {
 	bundle, err := p.CNAB.LoadBundle(o.CNABFile)
 	// Print Bundle Details
 
-	pb, err := generatePrintable(bundle, o.Action)
+	pb, err := generatePrintable(bundle, o.Action, p.newDependencySolver(o.InsecureRegistry))
 	if err != nil {
 		return errors.Wrap(err, "unable to print bundle")
 	}
@@ -190,7 +190,7 @@ func (p *Porter) printBundleExplain(o ExplainOpts, pb *PrintableBundle) error {
 	}
 }
 
-func generatePrintable(bun bundle.Bundle, action string) (*PrintableBundle, error) {
+func generatePrintable(bun bundle.Bundle, action string, solver *extensions.DependencySolver) (*PrintableBundle, error) {
 	var stamp configadapter.Stamp
 
 	stamp, err := configadapter.LoadStamp(bun)
@@ -277,7 +277,6 @@ func generatePrintable(bun bundle.Bundle, action string) (*PrintableBundle, erro
 	}
 	sort.Sort(SortPrintableOutput(outputs))
 
-	solver := &extensions.DependencySolver{}
 	deps, err := solver.ResolveDependencies(bun)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error executing dependencies")
@@ -125,7 +125,7 @@ func TestExplain_generateTable(t *testing.T) {
 	p.TestConfig.TestContext.AddTestFile("testdata/explain/params-bundle.json", "params-bundle.json")
 	b, err := p.CNAB.LoadBundle("params-bundle.json")
 
-	pb, err := generatePrintable(b, "")
+	pb, err := generatePrintable(b, "", &extensions.DependencySolver{})
 	require.NoError(t, err)
 	opts := ExplainOpts{}
 	opts.RawFormat = "table"
@@ -146,7 +146,7 @@ func TestExplain_generateJSON(t *testing.T) {
 	p.TestConfig.TestContext.AddTestFile("testdata/explain/params-bundle.json", "params-bundle.json")
 	b, err := p.CNAB.LoadBundle("params-bundle.json")
 
-	pb, err := generatePrintable(b, "")
+	pb, err := generatePrintable(b, "", &extensions.DependencySolver{})
 	require.NoError(t, err)
 	opts := ExplainOpts{}
 	opts.RawFormat = "json"
@@ -167,7 +167,7 @@ func TestExplain_generateYAML(t *testing.T) {
 	p.TestConfig.TestContext.AddTestFile("testdata/explain/params-bundle.json", "params-bundle.json")
 	b, err := p.CNAB.LoadBundle("params-bundle.json")
 
-	pb, err := generatePrintable(b, "")
+	pb, err := generatePrintable(b, "", &extensions.DependencySolver{})
 	require.NoError(t, err)
 
 	opts := ExplainOpts{}
@@ -217,7 +217,7 @@ func TestExplain_generatePrintableBundleParams(t *testing.T) {
 		},
 	}
 
-	pb, err := generatePrintable(bun, "")
+	pb, err := generatePrintable(bun, "", &extensions.DependencySolver{})
 	require.NoError(t, err)
 
 	require.Equal(t, 2, len(pb.Parameters), "expected 2 parameters")
@@ -269,7 +269,7 @@ func TestExplain_generatePrintableBundleParamsWithAction(t *testing.T) {
 	}
 
 	t.Run("action applies", func(t *testing.T) {
-		pb, err := generatePrintable(bun, "install")
+		pb, err := generatePrintable(bun, "install", &extensions.DependencySolver{})
 		require.NoError(t, err)
 
 		require.Equal(t, 2, len(pb.Parameters), "expected 2 parameters")
@@ -284,7 +284,7 @@ func TestExplain_generatePrintableBundleParamsWithAction(t *testing.T) {
 	})
 
 	t.Run("action does not apply", func(t *testing.T) {
-		pb, err := generatePrintable(bun, "upgrade")
+		pb, err := generatePrintable(bun, "upgrade", &extensions.DependencySolver{})
 		require.NoError(t, err)
 
 		require.Equal(t, 1, len(pb.Parameters), "expected only 1 parameter since debug parameter doesn't apply to upgrade command")
@@ -292,7 +292,7 @@ func TestExplain_generatePrintableBundleParamsWithAction(t *testing.T) {
 	})
 
 	t.Run("all actions", func(t *testing.T) {
-		pb, err := generatePrintable(bun, "")
+		pb, err := generatePrintable(bun, "", &extensions.DependencySolver{})
 		require.NoError(t, err)
 
 		require.Equal(t, 2, len(pb.Parameters), "expected 2 parameters")
@@ -333,7 +333,7 @@ func TestExplain_generatePrintableBundleOutputs(t *testing.T) {
 		},
 	}
 
-	pb, err := generatePrintable(bun, "")
+	pb, err := generatePrintable(bun, "", &extensions.DependencySolver{})
 	require.NoError(t, err)
 
 	require.Equal(t, 2, len(pb.Outputs), "expected someoutput to be included because the action is unset")
@@ -352,12 +352,12 @@ func TestExplain_generatePrintableBundleOutputs(t *testing.T) {
 	assert.Equal(t, 0, len(pb.Actions))
 
 	// Check outputs for install action
-	pb, err = generatePrintable(bun, "install")
+	pb, err = generatePrintable(bun, "install", &extensions.DependencySolver{})
 	require.NoError(t, err)
 	assert.Equal(t, 2, len(pb.Outputs),"expected someoutput to be included")
 
 	// Check outputs for upgrade action action (someoutput doesn't apply)
-	pb, err = generatePrintable(bun, "upgrade")
+	pb, err = generatePrintable(bun, "upgrade", &extensions.DependencySolver{})
 	require.NoError(t, err)
 	assert.Equal(t, 1, len(pb.Outputs), "expected someoutput to be excluded by its applyTo")
 }
@@ -384,7 +384,7 @@ func TestExplain_generatePrintableBundleCreds(t *testing.T) {
 	}
 
 	t.Run("action applies", func(t *testing.T) {
-		pb, err := generatePrintable(bun, "install")
+		pb, err := generatePrintable(bun, "install", &extensions.DependencySolver{})
 		require.NoError(t, err)
 
 		require.Equal(t, 2, len(pb.Credentials), "expected 2 credentials")
@@ -399,7 +399,7 @@ func TestExplain_generatePrintableBundleCreds(t *testing.T) {
 	})
 
 	t.Run("action does not apply", func(t *testing.T) {
-		pb, err := generatePrintable(bun, "upgrade")
+		pb, err := generatePrintable(bun, "upgrade", &extensions.DependencySolver{})
 		require.NoError(t, err)
 
 		require.Equal(t, 1, len(pb.Credentials), "expected only 1 credential since kubeconfig credential doesn't apply to upgrade command")
@@ -407,7 +407,7 @@ func TestExplain_generatePrintableBundleCreds(t *testing.T) {
 	})
 
 	t.Run("all actions", func(t *testing.T) {
-		pb, err := generatePrintable(bun, "")
+		pb, err := generatePrintable(bun, "", &extensions.DependencySolver{})
 		require.NoError(t, err)
 
 		require.Equal(t, 2, len(pb.Credentials), "expected 2 credentials")
@@ -439,7 +439,7 @@ func TestExplain_generatePrintableBundlePorterVersion(t *testing.T) {
 		},
 	}
 
-	pb, err := generatePrintable(bun, "")
+	pb, err := generatePrintable(bun, "", &extensions.DependencySolver{})
 	assert.NoError(t, err)
 
 	assert.Equal(t, "v0.30.0", pb.PorterVersion)
@@ -455,7 +455,7 @@ func TestExplain_generatePrintableBundlePorterVersionNonPorterBundle(t *testing.
 		},
 	}
 
-	pb, err := generatePrintable(bun, "")
+	pb, err := generatePrintable(bun, "", &extensions.DependencySolver{})
 	assert.NoError(t, err)
 
 	assert.Equal(t, "", pb.PorterVersion)
@@ -491,7 +491,7 @@ func TestExplain_generatePrintableBundleDependencies(t *testing.T) {
 		},
 	}
 
-	pd, err := generatePrintable(bun, "")
+	pd, err := generatePrintable(bun, "", &extensions.DependencySolver{})
 	assert.NoError(t, err)
 	assert.Equal(t, 3, len(pd.Dependencies))
 	assert.Equal(t, 0, len(pd.Parameters))
@@ -506,7 +506,7 @@ func TestExplain_generateJSONForDependencies(t *testing.T) {
 	p.TestConfig.TestContext.AddTestFile("testdata/explain/dependencies-bundle.json", "dependencies-bundle.json")
 	b, err := p.CNAB.LoadBundle("dependencies-bundle.json")
 
-	pb, err := generatePrintable(b, "")
+	pb, err := generatePrintable(b, "", &extensions.DependencySolver{})
 	require.NoError(t, err)
 	opts := ExplainOpts{}
 	opts.RawFormat = "json"
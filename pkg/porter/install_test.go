@@ -1,13 +1,17 @@
 package porter
 
 import (
+	"encoding/json"
 	"testing"
 
+	cnabprovider "get.porter.sh/porter/pkg/cnab/provider"
 	"get.porter.sh/porter/pkg/context"
 	"get.porter.sh/porter/pkg/manifest"
+	"get.porter.sh/porter/pkg/printer"
 
 	"get.porter.sh/porter/pkg/secrets"
 
+	"github.com/cnabio/cnab-go/claim"
 	"github.com/cnabio/cnab-go/credentials"
 	"github.com/cnabio/cnab-go/valuesource"
 	"github.com/stretchr/testify/assert"
@@ -145,3 +149,63 @@ func TestPorter_InstallBundle_WithDepsFromTag(t *testing.T) {
 	err = p.InstallBundle(opts)
 	require.NoError(t, err, "InstallBundle failed")
 }
+
+func TestPorter_InstallBundle_DryRun(t *testing.T) {
+	p := NewTestPorter(t)
+
+	cacheDir, _ := p.Cache.GetCacheDir()
+	p.TestConfig.TestContext.AddTestDirectory("testdata/cache", cacheDir)
+
+	cs := credentials.NewCredentialSet("wordpress",
+		valuesource.Strategy{
+			Name: "kubeconfig",
+			Source: valuesource.Source{
+				Key:   secrets.SourceSecret,
+				Value: "kubeconfig",
+			},
+		})
+	p.TestCredentials.TestSecrets.AddSecret("kubeconfig", "abc123")
+	err := p.Credentials.Save(cs)
+	require.NoError(t, err, "Credentials.Save failed")
+
+	opts := NewInstallOptions()
+	opts.Driver = DebugDriver
+	opts.Reference = "localhost:5000/wordpress:v0.1.3"
+	opts.CredentialIdentifiers = []string{"wordpress"}
+	opts.Params = []string{"wordpress-password=mypassword", "mysql#database-name=mydb"}
+	opts.DryRun = true
+	opts.DryRunFormat.RawFormat = string(printer.FormatJson)
+	err = opts.Validate(nil, p.Porter)
+	require.NoError(t, err, "Validate install options failed")
+
+	err = p.InstallBundle(opts)
+	require.NoError(t, err, "InstallBundle failed")
+
+	var plan ActionPlan
+	err = json.Unmarshal([]byte(p.TestConfig.TestContext.GetOutput()), &plan)
+	require.NoError(t, err, "the plan should be printed as json")
+
+	assert.Equal(t, claim.ActionInstall, plan.Action)
+	assert.Equal(t, "wordpress", plan.Installation)
+	assert.Equal(t, "localhost:5000/wordpress:v0.1.3", plan.BundleReference)
+	assert.Equal(t, "localhost:5000/wordpress-installer:v0.1.3", plan.InvocationImage)
+	assert.Equal(t, cnabprovider.MaskedValue, plan.Parameters["wordpress-password"], "sensitive parameters should be masked")
+	assert.Equal(t, []cnabprovider.PlannedCredential{
+		{Name: "kubeconfig", CredentialSet: "wordpress", Source: "secret: kubeconfig", Value: cnabprovider.MaskedValue},
+	}, plan.Credentials)
+	assert.Contains(t, plan.Files, "/cnab/app/dependencies/mysql/bundle.json")
+	assert.Equal(t, []PlannedDependency{
+		{
+			Alias:        "mysql",
+			Reference:    "localhost:5000/mysql:v0.1.3",
+			Installation: "wordpress-mysql",
+			Parameters:   map[string]string{"database-name": "mydb", "mysql-user": "wordpress"},
+		},
+	}, plan.Dependencies)
+
+	// Nothing should have been installed
+	_, err = p.Claims.ReadLastClaim("wordpress")
+	require.Error(t, err, "the root bundle should not be installed")
+	_, err = p.Claims.ReadLastClaim("wordpress-mysql")
+	require.Error(t, err, "the dependency should not be installed")
+}
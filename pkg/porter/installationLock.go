@@ -0,0 +1,117 @@
+package porter
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"get.porter.sh/porter/pkg/claims"
+)
+
+// DefaultLockDuration is how long an installation stays locked by an action,
+// after which the lock expires in case the action was interrupted. The lock
+// is renewed while the action is running.
+const DefaultLockDuration = time.Hour
+
+// lockRenewalInterval is how often the locks held by an action are renewed.
+var lockRenewalInterval = DefaultLockDuration / 3
+
+// lockInstallations locks the installation, and every dependency installation
+// in its graph, for the duration of an action, so that concurrent actions
+// can't modify the same installations. The installations are locked in a
+// stable order and the locks are renewed until the returned function is
+// called to release them.
+func (p *Porter) lockInstallations(opts *BundleActionOptions, deperator *dependencyExecutioner) (func(), error) {
+	installations := deperator.listInstallations()
+	locked := make([]string, 0, len(installations))
+	unlock := func() {
+		for i := len(locked) - 1; i >= 0; i-- {
+			p.unlockInstallation(locked[i])
+		}
+	}
+
+	for _, installation := range installations {
+		err := p.lockInstallation(installation, opts.ForceUnlock)
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		locked = append(locked, installation)
+	}
+
+	stopRenewal := p.renewInstallationLocks(locked, lockRenewalInterval, DefaultLockDuration)
+	return func() {
+		stopRenewal()
+		unlock()
+	}, nil
+}
+
+// lockInstallation locks an installation. When forceUnlock is set, any
+// existing lock on the installation is removed first.
+func (p *Porter) lockInstallation(installation string, forceUnlock bool) error {
+	if forceUnlock {
+		lock, err := p.Claims.ReadInstallationLock(installation)
+		if err != nil && err != claims.ErrLockNotFound {
+			return err
+		}
+		if err == nil {
+			fmt.Fprintf(p.Err, "Removing the lock on installation %s held by %s\n", installation, lock.Owner)
+			if err = p.Claims.ForceUnlockInstallation(installation); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := p.Claims.LockInstallation(installation, getCurrentUser(), DefaultLockDuration)
+	return err
+}
+
+// renewInstallationLocks periodically extends the locks on the installations
+// until the returned function is called.
+func (p *Porter) renewInstallationLocks(installations []string, interval time.Duration, duration time.Duration) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				for _, installation := range installations {
+					if _, err := p.Claims.RenewInstallationLock(installation, duration); err != nil {
+						fmt.Fprintf(p.Err, "warning: unable to renew the lock on installation %s: %s\n", installation, err)
+					}
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// unlockInstallation releases a lock acquired by lockInstallation.
+func (p *Porter) unlockInstallation(installation string) {
+	if err := p.Claims.UnlockInstallation(installation); err != nil {
+		fmt.Fprintf(p.Err, "warning: unable to unlock installation %s: %s\n", installation, err)
+	}
+}
+
+// getCurrentUser identifies who is running porter, in the format USER@HOST.
+func getCurrentUser() string {
+	username := "unknown"
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s@%s", username, host)
+}
@@ -0,0 +1,121 @@
+package porter
+
+import (
+	"testing"
+	"time"
+
+	"get.porter.sh/porter/pkg/claims"
+	"get.porter.sh/porter/pkg/secrets"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/cnabio/cnab-go/valuesource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPorter_InstallBundle_Locked(t *testing.T) {
+	p := NewTestPorter(t)
+
+	cacheDir, _ := p.Cache.GetCacheDir()
+	p.TestConfig.TestContext.AddTestDirectory("testdata/cache", cacheDir)
+
+	cs := credentials.NewCredentialSet("wordpress",
+		valuesource.Strategy{
+			Name: "kubeconfig",
+			Source: valuesource.Source{
+				Key:   secrets.SourceSecret,
+				Value: "kubeconfig",
+			},
+		})
+	p.TestCredentials.TestSecrets.AddSecret("kubeconfig", "abc123")
+	err := p.Credentials.Save(cs)
+	require.NoError(t, err, "Credentials.Save failed")
+
+	p.TestClaims.CreateLock("wordpress", "someone@else", time.Hour)
+
+	opts := NewInstallOptions()
+	opts.Driver = DebugDriver
+	opts.Reference = "localhost:5000/wordpress:v0.1.3"
+	opts.CredentialIdentifiers = []string{"wordpress"}
+	opts.Params = []string{"wordpress-password=mypassword"}
+	err = opts.Validate(nil, p.Porter)
+	require.NoError(t, err, "Validate install options failed")
+
+	err = p.InstallBundle(opts)
+	require.Error(t, err, "InstallBundle should fail when the installation is locked")
+	assert.True(t, claims.IsInstallationLocked(err), "expected a locked error, got %v", err)
+	assert.Contains(t, err.Error(), "installation wordpress is locked by someone@else")
+
+	_, err = p.Claims.ReadLastClaim("wordpress-mysql")
+	require.Error(t, err, "the dependencies should not be installed when the installation is locked")
+
+	opts.ForceUnlock = true
+	err = p.InstallBundle(opts)
+	require.NoError(t, err, "InstallBundle should remove the lock with --force-unlock")
+	assert.Contains(t, p.TestConfig.TestContext.GetError(), "Removing the lock on installation wordpress held by someone@else")
+
+	_, err = p.Claims.ReadInstallationLock("wordpress")
+	assert.Equal(t, claims.ErrLockNotFound, err, "the lock should be released after the action")
+}
+
+func TestPorter_InstallBundle_DependencyLocked(t *testing.T) {
+	p := NewTestPorter(t)
+
+	cacheDir, _ := p.Cache.GetCacheDir()
+	p.TestConfig.TestContext.AddTestDirectory("testdata/cache", cacheDir)
+
+	cs := credentials.NewCredentialSet("wordpress",
+		valuesource.Strategy{
+			Name: "kubeconfig",
+			Source: valuesource.Source{
+				Key:   secrets.SourceSecret,
+				Value: "kubeconfig",
+			},
+		})
+	p.TestCredentials.TestSecrets.AddSecret("kubeconfig", "abc123")
+	err := p.Credentials.Save(cs)
+	require.NoError(t, err, "Credentials.Save failed")
+
+	p.TestClaims.CreateLock("wordpress-mysql", "someone@else", time.Hour)
+
+	opts := NewInstallOptions()
+	opts.Driver = DebugDriver
+	opts.Reference = "localhost:5000/wordpress:v0.1.3"
+	opts.CredentialIdentifiers = []string{"wordpress"}
+	opts.Params = []string{"wordpress-password=mypassword"}
+	err = opts.Validate(nil, p.Porter)
+	require.NoError(t, err, "Validate install options failed")
+
+	err = p.InstallBundle(opts)
+	require.Error(t, err, "InstallBundle should fail when a dependency installation is locked")
+	assert.True(t, claims.IsInstallationLocked(err), "expected a locked error, got %v", err)
+	assert.Contains(t, err.Error(), "installation wordpress-mysql is locked by someone@else")
+
+	_, err = p.Claims.ReadInstallationLock("wordpress")
+	assert.Equal(t, claims.ErrLockNotFound, err, "the locks acquired before the failure should be released")
+
+	opts.ForceUnlock = true
+	err = p.InstallBundle(opts)
+	require.NoError(t, err, "InstallBundle should remove the locks on the dependencies with --force-unlock")
+
+	for _, installation := range []string{"wordpress", "wordpress-mysql"} {
+		_, err = p.Claims.ReadInstallationLock(installation)
+		assert.Equal(t, claims.ErrLockNotFound, err, "the lock on %s should be released after the action", installation)
+	}
+}
+
+func TestPorter_renewInstallationLocks(t *testing.T) {
+	p := NewTestPorter(t)
+
+	lock, err := p.Claims.LockInstallation("mybuns", "me@localhost", time.Minute)
+	require.NoError(t, err)
+
+	stop := p.renewInstallationLocks([]string{"mybuns"}, time.Millisecond, time.Hour)
+	time.Sleep(50 * time.Millisecond)
+	stop()
+
+	renewed, err := p.Claims.ReadInstallationLock("mybuns")
+	require.NoError(t, err)
+	assert.Equal(t, lock.ID, renewed.ID, "the same lock should be kept")
+	assert.True(t, renewed.Expires.After(lock.Expires), "the lock should be renewed while the action runs")
+	assert.Empty(t, p.TestConfig.TestContext.GetError())
+}
@@ -0,0 +1,139 @@
+package porter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// labelSelectorOperators are the supported comparisons in a label selector,
+// longest first so that == is matched before =.
+var labelSelectorOperators = []string{"!=", "==", "="}
+
+// parseLabels parses labels in the format KEY=VALUE.
+func parseLabels(values []string) (map[string]string, error) {
+	labels := make(map[string]string, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("invalid label %s, it must be in the format KEY=VALUE", value)
+		}
+		key := strings.TrimSpace(parts[0])
+		if err := validateLabelKey(key); err != nil {
+			return nil, errors.Wrapf(err, "invalid label %s", value)
+		}
+		labels[key] = strings.TrimSpace(parts[1])
+	}
+	return labels, nil
+}
+
+func validateLabelKey(key string) error {
+	if key == "" {
+		return errors.New("the label key cannot be empty")
+	}
+	if strings.ContainsAny(key, "=!, \t") {
+		return errors.Errorf("the label key %q cannot contain =, !, commas or whitespace", key)
+	}
+	return nil
+}
+
+// formatLabels formats labels as a sorted, comma separated list of KEY=VALUE.
+func formatLabels(labels map[string]string) string {
+	formatted := make([]string, 0, len(labels))
+	for k, v := range labels {
+		formatted = append(formatted, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(formatted)
+	return strings.Join(formatted, ",")
+}
+
+// LabelSelector filters installations by their labels. All requirements
+// must match for an installation to be selected.
+type LabelSelector []labelRequirement
+
+// labelRequirement is a single comparison in a label selector.
+type labelRequirement struct {
+	Key      string
+	Operator string
+	Value    string
+}
+
+// ParseLabelSelector parses a comma separated list of requirements:
+//
+//	KEY=VALUE or KEY==VALUE: the label is set to the value.
+//	KEY!=VALUE: the label is not set to the value, or is not set.
+//	KEY: the label is set.
+//	!KEY: the label is not set.
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	var s LabelSelector
+	if strings.TrimSpace(selector) == "" {
+		return s, nil
+	}
+
+	for _, raw := range strings.Split(selector, ",") {
+		raw = strings.TrimSpace(raw)
+		req, err := parseLabelRequirement(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid label selector %s", selector)
+		}
+		s = append(s, req)
+	}
+	return s, nil
+}
+
+func parseLabelRequirement(raw string) (labelRequirement, error) {
+	for _, op := range labelSelectorOperators {
+		if i := strings.Index(raw, op); i >= 0 {
+			req := labelRequirement{
+				Key:      strings.TrimSpace(raw[:i]),
+				Operator: op,
+				Value:    strings.TrimSpace(raw[i+len(op):]),
+			}
+			if req.Operator == "==" {
+				req.Operator = "="
+			}
+			return req, validateLabelKey(req.Key)
+		}
+	}
+
+	if strings.HasPrefix(raw, "!") {
+		req := labelRequirement{Key: strings.TrimSpace(raw[1:]), Operator: "!"}
+		return req, validateLabelKey(req.Key)
+	}
+
+	req := labelRequirement{Key: raw}
+	return req, validateLabelKey(req.Key)
+}
+
+// Empty determines if the selector doesn't have any requirements, and selects everything.
+func (s LabelSelector) Empty() bool {
+	return len(s) == 0
+}
+
+// Matches determines if the labels satisfy every requirement of the selector.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		value, ok := labels[req.Key]
+		switch req.Operator {
+		case "=":
+			if !ok || value != req.Value {
+				return false
+			}
+		case "!=":
+			if ok && value == req.Value {
+				return false
+			}
+		case "!":
+			if ok {
+				return false
+			}
+		default:
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
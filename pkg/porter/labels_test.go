@@ -0,0 +1,62 @@
+package porter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseLabels(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		labels, err := parseLabels([]string{"team=payments", " env = staging ", "empty="})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"team": "payments", "env": "staging", "empty": ""}, labels)
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+		_, err := parseLabels([]string{"team"})
+		require.EqualError(t, err, "invalid label team, it must be in the format KEY=VALUE")
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := parseLabels([]string{"=payments"})
+		require.EqualError(t, err, "invalid label =payments: the label key cannot be empty")
+	})
+}
+
+func TestParseLabelSelector(t *testing.T) {
+	testcases := []struct {
+		selector string
+		labels   map[string]string
+		want     bool
+	}{
+		{"", nil, true},
+		{"team=payments", map[string]string{"team": "payments"}, true},
+		{"team==payments", map[string]string{"team": "payments"}, true},
+		{"team=payments", map[string]string{"team": "web"}, false},
+		{"team=payments", nil, false},
+		{"env!=prod", map[string]string{"env": "staging"}, true},
+		{"env!=prod", nil, true},
+		{"env!=prod", map[string]string{"env": "prod"}, false},
+		{"team", map[string]string{"team": "payments"}, true},
+		{"team", nil, false},
+		{"!team", nil, true},
+		{"!team", map[string]string{"team": "payments"}, false},
+		{"team=payments, env!=prod", map[string]string{"team": "payments", "env": "staging"}, true},
+		{"team=payments,env!=prod", map[string]string{"team": "payments", "env": "prod"}, false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.selector, func(t *testing.T) {
+			s, err := ParseLabelSelector(tc.selector)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, s.Matches(tc.labels))
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseLabelSelector("team=payments,=prod")
+		require.EqualError(t, err, "invalid label selector team=payments,=prod: the label key cannot be empty")
+	})
+}
@@ -1,7 +1,10 @@
 package porter
 
 import (
+	"strings"
+
 	cnabprovider "get.porter.sh/porter/pkg/cnab/provider"
+	"get.porter.sh/porter/pkg/printer"
 	"github.com/pkg/errors"
 )
 
@@ -23,11 +26,62 @@ type BundleActionOptions struct {
 	sharedOptions
 	BundlePullOptions
 	AllowAccessToDockerHost bool
+
+	// MaxParallelDependencies is the maximum number of independent dependencies
+	// that may be executed at the same time.
+	MaxParallelDependencies int
+
+	// DependencyInstallations binds dependencies of the bundle to existing
+	// installations, in the format ALIAS=INSTALLATION.
+	DependencyInstallations []string
+
+	// parsedDependencyInstallations is the parsed set of DependencyInstallations,
+	// mapping the dependency alias to the installation name.
+	parsedDependencyInstallations map[string]string
+
+	// DryRun prints everything that the action would use, instead of executing it.
+	DryRun bool
+
+	// DryRunFormat is the format used to print the plan when DryRun is set.
+	DryRunFormat printer.PrintOptions
+
+	// ForceUnlock removes an existing lock on the installation before
+	// executing the action.
+	ForceUnlock bool
+
+	// Labels to apply to the installation, in the format KEY=VALUE.
+	Labels []string
+
+	// parsedLabels is the parsed set of Labels. When no labels are specified,
+	// it is nil and the existing labels of the installation are kept.
+	parsedLabels map[string]string
 }
 
 func (o *BundleActionOptions) Validate(args []string, porter *Porter) error {
 	o.checkForDeprecatedTagValue()
 
+	if o.MaxParallelDependencies < 0 {
+		return errors.Errorf("invalid --max-parallel-dependencies %d, the value cannot be negative", o.MaxParallelDependencies)
+	}
+
+	if err := o.parseDependencyInstallations(); err != nil {
+		return err
+	}
+
+	if len(o.Labels) > 0 {
+		labels, err := parseLabels(o.Labels)
+		if err != nil {
+			return err
+		}
+		o.parsedLabels = labels
+	}
+
+	if o.DryRun {
+		if err := o.DryRunFormat.Validate(DryRunDefaultFormat, DryRunAllowedFormats); err != nil {
+			return err
+		}
+	}
+
 	if o.Reference != "" {
 		// Ignore anything set based on the bundle directory we are in, go off of the tag
 		o.File = ""
@@ -42,6 +96,19 @@ func (o *BundleActionOptions) Validate(args []string, porter *Porter) error {
 	return o.sharedOptions.Validate(args, porter)
 }
 
+// parseDependencyInstallations parses the dependency bindings in DependencyInstallations.
+func (o *BundleActionOptions) parseDependencyInstallations() error {
+	o.parsedDependencyInstallations = make(map[string]string, len(o.DependencyInstallations))
+	for _, value := range o.DependencyInstallations {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return errors.Errorf("invalid --dependency-installation %s, it must be in the format ALIAS=INSTALLATION", value)
+		}
+		o.parsedDependencyInstallations[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return nil
+}
+
 func (o *BundleActionOptions) GetOptions() *BundleActionOptions {
 	return o
 }
@@ -54,11 +121,13 @@ func (p *Porter) BuildActionArgs(action BundleAction) (cnabprovider.ActionArgume
 		Action:                action.GetAction(),
 		Installation:          opts.Name,
 		BundlePath:            opts.CNABFile,
+		BundleReference:       opts.Reference,
 		Params:                make(map[string]string, len(opts.combinedParameters)),
 		CredentialIdentifiers: make([]string, len(opts.CredentialIdentifiers)),
 		Driver:                opts.Driver,
 		RelocationMapping:     opts.RelocationMapping,
 		AllowDockerHostAccess: opts.AllowAccessToDockerHost,
+		Labels:                opts.parsedLabels,
 	}
 
 	err := opts.LoadParameters(p)
@@ -72,6 +141,10 @@ func (p *Porter) BuildActionArgs(action BundleAction) (cnabprovider.ActionArgume
 		args.Params[k] = v
 	}
 	copy(args.CredentialIdentifiers, opts.CredentialIdentifiers)
+	if len(opts.ParameterSets) > 0 {
+		args.ParameterSets = make([]string, len(opts.ParameterSets))
+		copy(args.ParameterSets, opts.ParameterSets)
+	}
 
 	return args, nil
 }
@@ -84,6 +157,14 @@ func (p *Porter) prepullBundleByReference(opts *BundleActionOptions) error {
 		return nil
 	}
 
+	if IsOCILayoutReference(opts.Reference) {
+		ref, err := p.normalizeOCILayoutReference(opts.Reference)
+		if err != nil {
+			return err
+		}
+		opts.Reference = ref
+	}
+
 	cachedBundle, err := p.PullBundle(opts.BundlePullOptions)
 	if err != nil {
 		return errors.Wrapf(err, "unable to pull bundle %s", opts.Reference)
@@ -185,3 +185,23 @@ func TestManifestIgnoredWithTag(t *testing.T) {
 		require.NoError(t, err, "Validate failed")
 	})
 }
+
+func TestBundleActionOptions_parseDependencyInstallations(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		opts := BundleActionOptions{DependencyInstallations: []string{"mysql=shared-mysql", " redis = shared-redis "}}
+
+		err := opts.parseDependencyInstallations()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"mysql": "shared-mysql", "redis": "shared-redis"}, opts.parsedDependencyInstallations)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, value := range []string{"mysql", "=shared-mysql", "mysql="} {
+			opts := BundleActionOptions{DependencyInstallations: []string{value}}
+
+			err := opts.parseDependencyInstallations()
+			require.Error(t, err, "%s should be invalid", value)
+			assert.Contains(t, err.Error(), "it must be in the format ALIAS=INSTALLATION")
+		}
+	})
+}
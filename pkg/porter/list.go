@@ -8,6 +8,7 @@ import (
 
 	"github.com/cnabio/cnab-go/claim"
 
+	cnabprovider "get.porter.sh/porter/pkg/cnab/provider"
 	"get.porter.sh/porter/pkg/printer"
 	dtprinter "github.com/carolynvs/datetime-printer"
 	"github.com/pkg/errors"
@@ -16,6 +17,24 @@ import (
 // ListOptions represent generic options for use by Porter's list commands
 type ListOptions struct {
 	printer.PrintOptions
+
+	// Labels is a label selector that filters the results by the labels of
+	// installations, for example team=payments,env!=prod.
+	Labels string
+
+	// selector is the parsed Labels.
+	selector LabelSelector
+}
+
+// Validate the list options.
+func (o *ListOptions) Validate() error {
+	selector, err := ParseLabelSelector(o.Labels)
+	if err != nil {
+		return err
+	}
+	o.selector = selector
+
+	return o.ParseFormat()
 }
 
 // DisplayInstallation holds a subset of pertinent values to be listed from installation data
@@ -26,6 +45,7 @@ type DisplayInstallation struct {
 	Modified time.Time
 	Action   string
 	Status   string
+	Labels   map[string]string `json:",omitempty" yaml:",omitempty"`
 
 	Outputs DisplayOutputs
 	History []InstallationAction
@@ -45,6 +65,11 @@ func NewDisplayInstallation(installation claim.Installation) (DisplayInstallatio
 		installTime = c.Created
 	}
 
+	labels, err := cnabprovider.GetLabels(c)
+	if err != nil {
+		return DisplayInstallation{}, err
+	}
+
 	history := make([]InstallationAction, len(installation.Claims))
 	for i, hc := range installation.Claims {
 		hasLogs, ok := hc.HasLogs()
@@ -67,6 +92,7 @@ func NewDisplayInstallation(installation claim.Installation) (DisplayInstallatio
 		Modified: c.Created,
 		Action:   c.Action,
 		Status:   installation.GetLastStatus(),
+		Labels:   labels,
 		History:  history,
 	}, nil
 }
@@ -93,8 +119,8 @@ type InstallationAction struct {
 	HasLogs   string
 }
 
-// ListInstallations lists installed bundles.
-func (p *Porter) ListInstallations() (DisplayInstallations, error) {
+// ListInstallations lists installed bundles whose labels match the selector.
+func (p *Porter) ListInstallations(selector LabelSelector) (DisplayInstallations, error) {
 	installations, err := p.Claims.ReadAllInstallationStatus()
 	if err != nil {
 		return nil, errors.Wrap(err, "could not list installations")
@@ -106,6 +132,9 @@ func (p *Porter) ListInstallations() (DisplayInstallations, error) {
 		if err != nil {
 			return nil, err
 		}
+		if !selector.Matches(displayInstallation.Labels) {
+			continue
+		}
 		displayInstallations = append(displayInstallations, displayInstallation)
 	}
 	sort.Sort(sort.Reverse(displayInstallations))
@@ -113,9 +142,59 @@ func (p *Porter) ListInstallations() (DisplayInstallations, error) {
 	return displayInstallations, nil
 }
 
+// selectInstallationClaims returns the last claim of each installation whose
+// labels match the selector, sorted by installation name.
+func (p *Porter) selectInstallationClaims(selector LabelSelector) ([]claim.Claim, error) {
+	installations, err := p.Claims.ReadAllInstallationStatus()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not list installations")
+	}
+
+	var claims []claim.Claim
+	for _, installation := range installations {
+		c, err := installation.GetLastClaim()
+		if err != nil {
+			return nil, err
+		}
+		labels, err := cnabprovider.GetLabels(c)
+		if err != nil {
+			return nil, err
+		}
+		if selector.Matches(labels) {
+			claims = append(claims, c)
+		}
+	}
+
+	sort.Slice(claims, func(i, j int) bool {
+		return claims[i].Installation < claims[j].Installation
+	})
+	return claims, nil
+}
+
+// selectInstallationSets returns the names of the credential or parameter
+// sets used by the last action of each installation whose labels match the selector.
+func (p *Porter) selectInstallationSets(selector LabelSelector, getSets func(claim.Claim) ([]string, error)) (map[string]bool, error) {
+	claims, err := p.selectInstallationClaims(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, c := range claims {
+		sets, err := getSets(c)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range sets {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
 // PrintInstallations prints installed bundles.
 func (p *Porter) PrintInstallations(opts ListOptions) error {
-	displayInstallations, err := p.ListInstallations()
+	displayInstallations, err := p.ListInstallations(opts.selector)
 	if err != nil {
 		return err
 	}
@@ -4,8 +4,13 @@ import (
 	"testing"
 
 	"get.porter.sh/porter/pkg/claims"
+	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/printer"
 	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
 	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -51,3 +56,106 @@ func TestNewDisplayInstallation(t *testing.T) {
 		require.Equal(t, claim.StatusSucceeded, di.Status, "invalid last status")
 	})
 }
+
+func TestPorter_ListInstallations_Labels(t *testing.T) {
+	p := NewTestPorter(t)
+	installWordpress(t, p)
+
+	upgrade := func(labels ...string) {
+		opts := NewUpgradeOptions()
+		opts.Driver = DebugDriver
+		opts.Reference = "localhost:5000/wordpress:v0.1.3"
+		opts.CredentialIdentifiers = []string{"wordpress"}
+		opts.Params = []string{"wordpress-password=mypassword"}
+		opts.Labels = labels
+		err := opts.Validate(nil, p.Porter)
+		require.NoError(t, err, "Validate upgrade options failed")
+
+		err = p.UpgradeBundle(opts)
+		require.NoError(t, err, "UpgradeBundle failed")
+	}
+	listNames := func(selector string) []string {
+		s, err := ParseLabelSelector(selector)
+		require.NoError(t, err)
+		installations, err := p.ListInstallations(s)
+		require.NoError(t, err, "ListInstallations failed")
+		names := make([]string, len(installations))
+		for i, installation := range installations {
+			names[i] = installation.Name
+		}
+		return names
+	}
+
+	upgrade("team=payments", "env=staging")
+	assert.Equal(t, []string{"wordpress"}, listNames("team=payments,env!=prod"))
+	assert.Equal(t, []string{"wordpress-mysql"}, listNames("!team"), "labels should not be applied to dependencies")
+	assert.Empty(t, listNames("env=prod"))
+
+	upgrade()
+	assert.Equal(t, []string{"wordpress"}, listNames("team=payments"), "labels should be kept when they are not specified")
+
+	upgrade("team=web")
+	assert.Equal(t, []string{"wordpress"}, listNames("team=web"))
+	assert.Empty(t, listNames("env=staging"), "specifying labels should replace the existing labels")
+
+	t.Run("credentials", func(t *testing.T) {
+		err := p.Credentials.Save(credentials.NewCredentialSet("unused"))
+		require.NoError(t, err, "Credentials.Save failed")
+
+		opts := ListOptions{Labels: "team=web"}
+		opts.RawFormat = string(printer.FormatYaml)
+		require.NoError(t, opts.Validate())
+		err = p.ListCredentials(opts)
+		require.NoError(t, err, "ListCredentials failed")
+
+		gotOutput := p.TestConfig.TestContext.GetOutput()
+		assert.Contains(t, gotOutput, "name: wordpress")
+		assert.NotContains(t, gotOutput, "name: unused", "credential sets not used by the selected installations should not be listed")
+	})
+}
+
+func TestPorter_ListBundleOutputs_Labels(t *testing.T) {
+	p := NewTestPorter(t)
+
+	b := bundle.Bundle{
+		Definitions: definition.Definitions{
+			"foo": &definition.Schema{Type: "string"},
+		},
+		Outputs: map[string]bundle.Output{
+			"foo": {Definition: "foo"},
+		},
+	}
+	createInstallation := func(name string, labels map[string]interface{}) {
+		c := p.TestClaims.CreateClaim(name, claim.ActionInstall, b, nil)
+		c.Custom = map[string]interface{}{
+			config.CustomPorterKey: map[string]interface{}{"labels": labels},
+		}
+		err := p.TestClaims.SaveClaim(c)
+		require.NoError(t, err, "SaveClaim failed")
+		r := p.TestClaims.CreateResult(c, claim.StatusSucceeded)
+		p.TestClaims.CreateOutput(c, r, "foo", []byte(name+"-output"))
+	}
+	createInstallation("app1", map[string]interface{}{"team": "payments"})
+	createInstallation("app2", map[string]interface{}{"team": "payments"})
+	createInstallation("app3", map[string]interface{}{"team": "web"})
+
+	opts := OutputListOptions{Labels: "team=payments"}
+	opts.RawFormat = string(printer.FormatTable)
+	err := opts.Validate(nil, p.Context)
+	require.NoError(t, err, "Validate failed")
+
+	outputs, err := p.ListBundleOutputs(&opts)
+	require.NoError(t, err, "ListBundleOutputs failed")
+	assert.Equal(t, DisplayOutputs{
+		{Installation: "app1", Name: "foo", Type: "string", Value: "app1-output"},
+		{Installation: "app2", Name: "foo", Type: "string", Value: "app2-output"},
+	}, outputs)
+
+	err = p.printOutputsTable(outputs)
+	require.NoError(t, err)
+	assert.Contains(t, p.TestConfig.TestContext.GetOutput(), "Installation")
+
+	opts = OutputListOptions{Labels: "team=payments"}
+	err = opts.Validate([]string{"app1"}, p.Context)
+	require.EqualError(t, err, "cannot specify both an installation and --label")
+}
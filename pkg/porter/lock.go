@@ -0,0 +1,215 @@
+package porter
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"get.porter.sh/porter/pkg/build"
+	configadapter "get.porter.sh/porter/pkg/cnab/config-adapter"
+	"get.porter.sh/porter/pkg/cnab/extensions"
+	"get.porter.sh/porter/pkg/config"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
+)
+
+// DependencyLockFileSchemaVersion is the current version of the porter.lock file format.
+const DependencyLockFileSchemaVersion = "1.0.0"
+
+// DependencyLockFile represents the porter.lock file, which records the
+// dependency bundles that were resolved when the bundle was built.
+type DependencyLockFile struct {
+	// SchemaVersion of the lock file.
+	SchemaVersion string `json:"schemaVersion"`
+
+	// Dependencies resolved for the bundle, in the order that they are executed.
+	Dependencies []LockedDependency `json:"dependencies"`
+}
+
+// LockedDependency is a dependency recorded in porter.lock, along with how it
+// was defined in the manifest when it was resolved.
+type LockedDependency struct {
+	extensions.DependencyLock
+
+	// Bundle is the bundle of the dependency defined in the manifest.
+	Bundle string `json:"bundle,omitempty"`
+
+	// Version is the allowed versions of the dependency defined in the manifest.
+	Version *extensions.DependencyVersion `json:"version,omitempty"`
+}
+
+// isLockedFor determines if the dependency was resolved for the same
+// definition of the dependency in the manifest.
+func (l LockedDependency) isLockedFor(dep extensions.Dependency) bool {
+	return l.Alias == dep.Name && l.Bundle == dep.Bundle && reflect.DeepEqual(l.Version, dep.Version)
+}
+
+// getDependencyLockFilePath returns the location of the porter.lock file,
+// which is kept next to the porter manifest.
+func (p *Porter) getDependencyLockFilePath() string {
+	return filepath.Join(filepath.Dir(p.Manifest.ManifestPath), build.LOCK_FILE)
+}
+
+// newDependencySolver creates a solver that lists the tags of dependencies with Porter's registry client.
+func (p *Porter) newDependencySolver(insecureRegistry bool) *extensions.DependencySolver {
+	return &extensions.DependencySolver{Registry: p.Registry, InsecureRegistry: insecureRegistry}
+}
+
+// lockDependencies resolves the dependencies defined in the manifest to a
+// specific bundle reference and digest, and saves them to porter.lock.
+// Dependencies that are already in porter.lock, and have not changed in the
+// manifest since they were locked, are kept as-is unless update is set.
+func (p *Porter) lockDependencies(insecureRegistry bool, update bool) error {
+	lockPath := p.getDependencyLockFilePath()
+
+	converter := configadapter.NewManifestConverter(p.Context, p.Manifest, nil, nil)
+	deps := converter.GenerateDependencies()
+	if deps == nil {
+		// Remove the lock file left behind when the bundle used to have dependencies
+		if exists, _ := p.FileSystem.Exists(lockPath); exists {
+			return errors.Wrapf(p.FileSystem.Remove(lockPath), "could not remove %s", lockPath)
+		}
+		return nil
+	}
+
+	current, err := p.readDependencyLockFile()
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]LockedDependency, len(current.Dependencies))
+	for _, lock := range current.Dependencies {
+		existing[lock.Alias] = lock
+	}
+
+	solver := p.newDependencySolver(insecureRegistry)
+	orderedDeps := deps.ListBySequence()
+	locks := make([]LockedDependency, 0, len(orderedDeps))
+	for _, dep := range orderedDeps {
+		// Reuse the locked dependency so that it only changes when requested
+		if lock, ok := existing[dep.Name]; ok && !update && lock.isLockedFor(dep) {
+			locks = append(locks, lock)
+			continue
+		}
+
+		ref, err := solver.ResolveVersion(dep.Name, dep)
+		if err != nil {
+			return err
+		}
+		lock := LockedDependency{
+			DependencyLock: extensions.DependencyLock{
+				Alias:     dep.Name,
+				Reference: reference.FamiliarString(ref),
+			},
+			Bundle:  dep.Bundle,
+			Version: dep.Version,
+		}
+
+		lock.Digest, err = p.Registry.GetBundleDigest(lock.Reference, insecureRegistry)
+		if err != nil {
+			return errors.Wrapf(err, "could not lock dependency %s", lock.Alias)
+		}
+
+		if p.Debug {
+			fmt.Fprintf(p.Err, "Locked dependency %s to %s@%s\n", lock.Alias, lock.Reference, lock.Digest)
+		}
+		locks = append(locks, lock)
+	}
+
+	if current.SchemaVersion == DependencyLockFileSchemaVersion && reflect.DeepEqual(current.Dependencies, locks) {
+		return nil
+	}
+
+	lockFile := DependencyLockFile{
+		SchemaVersion: DependencyLockFileSchemaVersion,
+		Dependencies:  locks,
+	}
+	data, err := json.MarshalIndent(lockFile, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the dependency lock file")
+	}
+
+	err = p.FileSystem.WriteFile(lockPath, data, 0644)
+	return errors.Wrapf(err, "could not write %s", lockPath)
+}
+
+// readDependencyLockFile loads porter.lock, returning an empty lock file when it doesn't exist.
+func (p *Porter) readDependencyLockFile() (DependencyLockFile, error) {
+	var lockFile DependencyLockFile
+	lockPath := p.getDependencyLockFilePath()
+	if exists, _ := p.FileSystem.Exists(lockPath); !exists {
+		return lockFile, nil
+	}
+
+	data, err := p.FileSystem.ReadFile(lockPath)
+	if err != nil {
+		return lockFile, errors.Wrapf(err, "could not read %s", lockPath)
+	}
+
+	err = json.Unmarshal(data, &lockFile)
+	return lockFile, errors.Wrapf(err, "could not parse %s", lockPath)
+}
+
+// readDependencyLocks loads the dependency locks from porter.lock.
+// Returns nil when there is no lock file.
+func (p *Porter) readDependencyLocks() ([]extensions.DependencyLock, error) {
+	lockFile, err := p.readDependencyLockFile()
+	if err != nil || lockFile.Dependencies == nil {
+		return nil, err
+	}
+
+	locks := make([]extensions.DependencyLock, 0, len(lockFile.Dependencies))
+	for _, lock := range lockFile.Dependencies {
+		locks = append(locks, lock.DependencyLock)
+	}
+	return locks, nil
+}
+
+// embedDependencyLocks records the dependencies from porter.lock in the porter stamp
+// of the bundle, so that the same dependency bundles are used when it is executed.
+func (p *Porter) embedDependencyLocks(bun *bundle.Bundle) error {
+	locks, err := p.readDependencyLocks()
+	if err != nil || locks == nil {
+		return err
+	}
+
+	// Make sure that the lock file isn't stale
+	wantAliases := make([]string, 0, len(p.Manifest.Dependencies))
+	for _, dep := range p.Manifest.Dependencies {
+		wantAliases = append(wantAliases, dep.Name)
+	}
+	gotAliases := make([]string, 0, len(locks))
+	for _, lock := range locks {
+		gotAliases = append(gotAliases, lock.Alias)
+	}
+	sort.Strings(wantAliases)
+	sort.Strings(gotAliases)
+	if fmt.Sprint(wantAliases) != fmt.Sprint(gotAliases) {
+		return errors.Errorf("%s is out-of-date with the dependencies defined in the porter manifest, run porter build to update it", p.getDependencyLockFilePath())
+	}
+
+	stamp, err := configadapter.LoadStamp(*bun)
+	if err != nil {
+		return err
+	}
+	stamp.Dependencies = locks
+	bun.Custom[config.CustomPorterKey] = stamp
+
+	return nil
+}
+
+// getDependencyLocks returns the dependency locks embedded in a bundle when
+// it was built. Returns nil when the bundle does not have locked dependencies.
+func getDependencyLocks(bun bundle.Bundle) []extensions.DependencyLock {
+	if !configadapter.IsPorterBundle(bun) {
+		return nil
+	}
+
+	stamp, err := configadapter.LoadStamp(bun)
+	if err != nil {
+		return nil
+	}
+	return stamp.Dependencies
+}
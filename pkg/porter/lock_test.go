@@ -0,0 +1,118 @@
+package porter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"get.porter.sh/porter/pkg/build"
+	"get.porter.sh/porter/pkg/cnab"
+	"get.porter.sh/porter/pkg/cnab/extensions"
+	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/manifest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDependencyDigest = "sha256:3abc67269f59e3ed824e811a1ff1ee64f0d44c0218efefada57a4bebc2d7ef6f"
+
+func TestPorter_lockDependencies(t *testing.T) {
+	p := NewTestPorter(t)
+	p.TestConfig.TestContext.AddTestFile("../../build/testdata/bundles/wordpress/porter.yaml", config.Name)
+	p.TestRegistry.MockGetBundleDigest = func(tag string, insecureRegistry bool) (string, error) {
+		return testDependencyDigest, nil
+	}
+
+	err := p.LoadManifest()
+	require.NoError(t, err)
+
+	err = p.lockDependencies(false, false)
+	require.NoError(t, err, "lockDependencies failed")
+
+	data, err := p.FileSystem.ReadFile(build.LOCK_FILE)
+	require.NoError(t, err, "the lock file was not written")
+
+	var lockFile DependencyLockFile
+	require.NoError(t, json.Unmarshal(data, &lockFile))
+	assert.Equal(t, DependencyLockFileSchemaVersion, lockFile.SchemaVersion)
+	require.Len(t, lockFile.Dependencies, 1)
+	assert.Equal(t, "mysql", lockFile.Dependencies[0].Alias)
+	assert.Equal(t, "localhost:5000/mysql:v0.1.3", lockFile.Dependencies[0].Reference)
+	assert.Equal(t, testDependencyDigest, lockFile.Dependencies[0].Digest)
+
+	t.Run("embedded in bundle", func(t *testing.T) {
+		err = p.buildBundle("foo", "")
+		require.NoError(t, err)
+
+		bun, err := cnab.LoadBundle(p.Context, build.LOCAL_BUNDLE)
+		require.NoError(t, err)
+		locks := getDependencyLocks(bun)
+		assert.Equal(t, []extensions.DependencyLock{lockFile.Dependencies[0].DependencyLock}, locks)
+	})
+
+	t.Run("stale lock file", func(t *testing.T) {
+		p.Manifest.Dependencies = append(p.Manifest.Dependencies, &manifest.Dependency{
+			Name:      "redis",
+			Reference: "localhost:5000/redis:v1.0.0",
+		})
+
+		err = p.buildBundle("foo", "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "out-of-date")
+	})
+
+	t.Run("lock file removed", func(t *testing.T) {
+		p.Manifest.Dependencies = nil
+
+		err = p.lockDependencies(false, false)
+		require.NoError(t, err)
+
+		exists, _ := p.FileSystem.Exists(build.LOCK_FILE)
+		assert.False(t, exists, "the lock file should be removed when there are no dependencies")
+	})
+}
+
+func TestPorter_lockDependencies_Reuse(t *testing.T) {
+	p := NewTestPorter(t)
+	p.TestConfig.TestContext.AddTestFile("../../build/testdata/bundles/wordpress/porter.yaml", config.Name)
+	p.TestRegistry.MockGetBundleDigest = func(tag string, insecureRegistry bool) (string, error) {
+		return testDependencyDigest, nil
+	}
+
+	err := p.LoadManifest()
+	require.NoError(t, err)
+	require.NoError(t, p.lockDependencies(false, false))
+
+	readLocks := func() []extensions.DependencyLock {
+		locks, err := p.readDependencyLocks()
+		require.NoError(t, err)
+		return locks
+	}
+
+	// Simulate being offline, or the tag being moved
+	p.TestRegistry.MockGetBundleDigest = func(tag string, insecureRegistry bool) (string, error) {
+		return "", errors.New("registry unavailable")
+	}
+	err = p.lockDependencies(false, false)
+	require.NoError(t, err, "the locked dependencies should be reused without contacting the registry")
+	assert.Equal(t, testDependencyDigest, readLocks()[0].Digest)
+
+	err = p.lockDependencies(false, true)
+	require.EqualError(t, err, "could not lock dependency mysql: registry unavailable", "the dependencies should be resolved again when an update is requested")
+
+	const newDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	p.TestRegistry.MockGetBundleDigest = func(tag string, insecureRegistry bool) (string, error) {
+		return newDigest, nil
+	}
+	require.NoError(t, p.lockDependencies(false, true))
+	assert.Equal(t, newDigest, readLocks()[0].Digest, "the lock should be updated")
+
+	p.Manifest.Dependencies[0].Reference = "localhost:5000/mysql:v0.1.4"
+	p.TestRegistry.MockGetBundleDigest = func(tag string, insecureRegistry bool) (string, error) {
+		return testDependencyDigest, nil
+	}
+	require.NoError(t, p.lockDependencies(false, false))
+	locks := readLocks()
+	assert.Equal(t, "localhost:5000/mysql:v0.1.4", locks[0].Reference, "a dependency that changed in the manifest should be resolved again")
+	assert.Equal(t, testDependencyDigest, locks[0].Digest)
+}
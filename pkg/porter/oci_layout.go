@@ -0,0 +1,144 @@
+package porter
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"get.porter.sh/porter/pkg/cache"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-to-oci/relocation"
+	"github.com/docker/distribution/reference"
+	"github.com/pivotal/image-relocation/pkg/image"
+	"github.com/pivotal/image-relocation/pkg/registry/ggcr"
+	"github.com/pkg/errors"
+)
+
+// OCILayoutScheme is the prefix of a bundle reference to a directory created
+// by porter archive import, for example oci-layout:///srv/bundles/mybuns.
+const OCILayoutScheme = "oci-layout://"
+
+// IsOCILayoutReference determines if the bundle reference is to an OCI layout directory.
+func IsOCILayoutReference(ref string) bool {
+	return strings.HasPrefix(ref, OCILayoutScheme)
+}
+
+// getOCILayoutDir returns the directory of an OCI layout bundle reference.
+func getOCILayoutDir(ref string) string {
+	return strings.TrimPrefix(ref, OCILayoutScheme)
+}
+
+// layoutImage is an invocation image in an OCI layout directory.
+type layoutImage struct {
+	// Image is the name of the image in the bundle.
+	Image string
+
+	// Digest of the image in the layout.
+	Digest string
+
+	// LocalTag is the tag of the image when it is loaded into the Docker image cache.
+	LocalTag string
+}
+
+// normalizeOCILayoutReference makes the directory of an OCI layout reference
+// absolute, so that the bundle is cached by its location.
+func (p *Porter) normalizeOCILayoutReference(ref string) (string, error) {
+	dir := p.FileSystem.Abs(getOCILayoutDir(ref))
+	if _, err := p.FileSystem.Stat(filepath.Join(dir, "bundle.json")); err != nil {
+		return "", errors.Wrapf(err, "%s is not a bundle imported with porter archive import", dir)
+	}
+	return OCILayoutScheme + dir, nil
+}
+
+// pullBundleFromLayout loads a bundle from an OCI layout directory into the
+// cache, with a relocation map that uses the invocation images from the layout.
+func (p *Porter) pullBundleFromLayout(ref string) (cache.CachedBundle, error) {
+	dir := getOCILayoutDir(ref)
+	data, err := p.FileSystem.ReadFile(filepath.Join(dir, "bundle.json"))
+	if err != nil {
+		return cache.CachedBundle{}, errors.Wrapf(err, "could not read the bundle from %s", dir)
+	}
+	bun, err := bundle.Unmarshal(data)
+	if err != nil {
+		return cache.CachedBundle{}, errors.Wrapf(err, "could not parse the bundle from %s", dir)
+	}
+
+	images, err := findLayoutImages(dir, *bun)
+	if err != nil {
+		return cache.CachedBundle{}, err
+	}
+	reloMap := relocation.ImageRelocationMap{}
+	for _, img := range images {
+		reloMap[img.Image] = img.LocalTag
+	}
+
+	return p.Cache.StoreBundle(ref, "", *bun, &reloMap)
+}
+
+// findLayoutImages finds the invocation images of the bundle in an OCI layout directory.
+func findLayoutImages(dir string, bun bundle.Bundle) ([]layoutImage, error) {
+	layout, err := ggcr.NewRegistryClient().ReadLayout(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read the OCI layout at %s", dir)
+	}
+
+	images := make([]layoutImage, 0, len(bun.InvocationImages))
+	for _, invImg := range bun.InvocationImages {
+		n, err := image.NewName(invImg.Image)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid invocation image %s", invImg.Image)
+		}
+		dig, err := layout.Find(n)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not find invocation image %s in the OCI layout at %s", invImg.Image, dir)
+		}
+
+		localTag, err := getLayoutImageTag(invImg.Image, dig.String())
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, layoutImage{Image: invImg.Image, Digest: dig.String(), LocalTag: localTag})
+	}
+	return images, nil
+}
+
+// getLayoutImageTag returns the tag used for an image from an OCI layout in the
+// Docker image cache. Loaded images aren't associated with their digest, so the
+// tag is derived from the digest instead.
+func getLayoutImageTag(img string, digest string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(img)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid image %s", img)
+	}
+
+	hex := strings.TrimPrefix(digest, "sha256:")
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return fmt.Sprintf("%s:oci-layout-%s", reference.FamiliarName(named), hex), nil
+}
+
+// loadLayoutImages loads the invocation images of a bundle from an OCI layout
+// directory into Docker, so that the docker driver doesn't pull them from a registry.
+func (p *Porter) loadLayoutImages(opts *BundleActionOptions) error {
+	if !IsOCILayoutReference(opts.Reference) || opts.Driver != DockerDriver {
+		return nil
+	}
+
+	bun, err := p.CNAB.LoadBundle(opts.CNABFile)
+	if err != nil {
+		return err
+	}
+
+	dir := getOCILayoutDir(opts.Reference)
+	images, err := findLayoutImages(dir, bun)
+	if err != nil {
+		return err
+	}
+	for _, img := range images {
+		if err := p.Registry.LoadImage(dir, img.Digest, img.LocalTag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package porter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-to-oci/relocation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustLoadTestArchiveBundle(t *testing.T, p *TestPorter, dir string) bundle.Bundle {
+	data, err := p.FileSystem.ReadFile(filepath.Join(dir, "bundle.json"))
+	require.NoError(t, err)
+	bun, err := bundle.Unmarshal(data)
+	require.NoError(t, err)
+	return *bun
+}
+
+func Test_getLayoutImageTag(t *testing.T) {
+	testcases := []struct {
+		image string
+		want  string
+	}{
+		{"getporter/mybuns:v0.1.0", "getporter/mybuns:oci-layout-0123456789ab"},
+		{"docker.io/library/nginx@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", "nginx:oci-layout-0123456789ab"},
+		{"localhost:5000/mybuns", "localhost:5000/mybuns:oci-layout-0123456789ab"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.image, func(t *testing.T) {
+			tag, err := getLayoutImageTag(tc.image, "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, tag)
+		})
+	}
+}
+
+func TestBundlePullOptions_validateReference_OCILayout(t *testing.T) {
+	opts := BundlePullOptions{Reference: "oci-layout:///srv/bundles/mybuns"}
+	require.NoError(t, opts.validateReference())
+
+	opts.Reference = OCILayoutScheme
+	require.EqualError(t, opts.validateReference(), "invalid value for --reference, specify the directory of the OCI layout, for example oci-layout:///srv/bundles/mybuns")
+}
+
+func TestPorter_InstallFromOCILayout(t *testing.T) {
+	p := NewTestPorter(t)
+	_, home := p.TestConfig.TestContext.UseFilesystem()
+	p.TestConfig.SetHomeDir(home)
+
+	tmpDir, err := ioutil.TempDir("", "porter")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	archiveFile := filepath.Join(tmpDir, "mybuns.tgz")
+	writeTestLayoutArchive(t, p, archiveFile)
+	importOpts := ArchiveImportOptions{Dir: filepath.Join(tmpDir, "mybuns")}
+	require.NoError(t, importOpts.Validate([]string{archiveFile}, p.Porter))
+	require.NoError(t, p.ImportArchive(importOpts))
+
+	images, err := findLayoutImages(importOpts.Dir, mustLoadTestArchiveBundle(t, p, importOpts.Dir))
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+
+	// Use a relative path to check that the reference is made absolute
+	p.FileSystem.Chdir(tmpDir)
+	ref := OCILayoutScheme + "mybuns"
+	wantRef := OCILayoutScheme + importOpts.Dir
+
+	t.Run("install", func(t *testing.T) {
+		opts := NewInstallOptions()
+		opts.Driver = DebugDriver
+		opts.Reference = ref
+		require.NoError(t, opts.Validate(nil, p.Porter))
+
+		err := p.InstallBundle(opts)
+		require.NoError(t, err, "InstallBundle failed")
+
+		c, err := p.Claims.ReadLastClaim("mybuns")
+		require.NoError(t, err)
+		assert.Equal(t, wantRef, c.BundleReference)
+
+		cachedBundle, ok, err := p.Cache.FindBundle(wantRef)
+		require.NoError(t, err)
+		require.True(t, ok, "the bundle should be cached")
+		data, err := p.FileSystem.ReadFile(cachedBundle.RelocationFilePath)
+		require.NoError(t, err)
+		var reloMap relocation.ImageRelocationMap
+		require.NoError(t, json.Unmarshal(data, &reloMap))
+		assert.Equal(t, relocation.ImageRelocationMap{"getporter/mybuns:0.1.0": images[0].LocalTag}, reloMap)
+	})
+
+	t.Run("load images", func(t *testing.T) {
+		var loaded []string
+		p.TestRegistry.MockLoadImage = func(layoutDir string, imageDigest string, tag string) error {
+			assert.Equal(t, importOpts.Dir, layoutDir)
+			assert.Equal(t, images[0].Digest, imageDigest)
+			loaded = append(loaded, tag)
+			return nil
+		}
+
+		opts := NewInstallOptions()
+		opts.Reference = ref
+		opts.Driver = DebugDriver
+		require.NoError(t, opts.Validate(nil, p.Porter))
+		require.NoError(t, p.prepullBundleByReference(opts.BundleActionOptions))
+
+		require.NoError(t, p.loadLayoutImages(opts.BundleActionOptions))
+		assert.Empty(t, loaded, "images should only be loaded for the docker driver")
+
+		opts.Driver = DockerDriver
+		require.NoError(t, p.loadLayoutImages(opts.BundleActionOptions))
+		assert.Equal(t, []string{images[0].LocalTag}, loaded)
+	})
+}
@@ -24,6 +24,13 @@ type OutputShowOptions struct {
 type OutputListOptions struct {
 	sharedOptions
 	printer.PrintOptions
+
+	// Labels is a label selector that lists the outputs of every installation
+	// with matching labels, instead of a single installation.
+	Labels string
+
+	// selector is the parsed Labels.
+	selector LabelSelector
 }
 
 // Validate validates the provided args, using the provided context,
@@ -58,6 +65,17 @@ func (o *OutputListOptions) Validate(args []string, cxt *context.Context) error
 		return err
 	}
 
+	o.selector, err = ParseLabelSelector(o.Labels)
+	if err != nil {
+		return err
+	}
+	if !o.selector.Empty() {
+		if o.Name != "" {
+			return errors.New("cannot specify both an installation and --label")
+		}
+		return o.ParseFormat()
+	}
+
 	// Attempt to derive installation name from context
 	err = o.sharedOptions.defaultBundleFiles(cxt)
 	if err != nil {
@@ -88,6 +106,10 @@ type DisplayOutput struct {
 	Name  string
 	Value string
 	Type  string
+
+	// Installation that the output belongs to, set when listing the outputs
+	// of multiple installations.
+	Installation string `json:",omitempty" yaml:",omitempty"`
 }
 
 type DisplayOutputs []DisplayOutput
@@ -125,6 +147,10 @@ func NewDisplayOutputs(bun bundle.Bundle, outputs claim.Outputs, format printer.
 // ListBundleOutputs lists the outputs for a given bundle according to the
 // provided display format
 func (p *Porter) ListBundleOutputs(opts *OutputListOptions) (DisplayOutputs, error) {
+	if !opts.selector.Empty() {
+		return p.listSelectedOutputs(opts)
+	}
+
 	err := p.applyDefaultOptions(&opts.sharedOptions)
 	if err != nil {
 		return nil, err
@@ -148,6 +174,30 @@ func (p *Porter) ListBundleOutputs(opts *OutputListOptions) (DisplayOutputs, err
 	return displayOutputs, nil
 }
 
+// listSelectedOutputs lists the outputs of every installation whose labels
+// match the selector.
+func (p *Porter) listSelectedOutputs(opts *OutputListOptions) (DisplayOutputs, error) {
+	claims, err := p.selectInstallationClaims(opts.selector)
+	if err != nil {
+		return nil, err
+	}
+
+	displayOutputs := DisplayOutputs{}
+	for _, c := range claims {
+		outputs, err := p.Claims.ReadLastOutputs(c.Installation)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, output := range NewDisplayOutputs(c.Bundle, outputs, opts.Format) {
+			output.Installation = c.Installation
+			displayOutputs = append(displayOutputs, output)
+		}
+	}
+
+	return displayOutputs, nil
+}
+
 func (p *Porter) PrintBundleOutputs(opts OutputListOptions) error {
 	outputs, err := p.ListBundleOutputs(&opts)
 	if err != nil {
@@ -186,10 +236,27 @@ func (p *Porter) printOutputsTable(outputs []DisplayOutput) error {
 	table.SetBorders(tablewriter.Border{Left: false, Right: false, Bottom: false, Top: true})
 	table.SetAutoFormatHeaders(false)
 
+	// Only include the installation when listing outputs from multiple installations
+	includeInstallation := false
+	for _, output := range outputs {
+		if output.Installation != "" {
+			includeInstallation = true
+			break
+		}
+	}
+
 	// Print the outputs table
-	table.SetHeader([]string{"Name", "Type", "Value"})
+	if includeInstallation {
+		table.SetHeader([]string{"Installation", "Name", "Type", "Value"})
+	} else {
+		table.SetHeader([]string{"Name", "Type", "Value"})
+	}
 	for _, output := range outputs {
-		table.Append([]string{output.Name, output.Type, output.Value})
+		if includeInstallation {
+			table.Append([]string{output.Installation, output.Name, output.Type, output.Value})
+		} else {
+			table.Append([]string{output.Name, output.Type, output.Value})
+		}
 	}
 	table.Render()
 
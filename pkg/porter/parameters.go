@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	cnabprovider "get.porter.sh/porter/pkg/cnab/provider"
 	"get.porter.sh/porter/pkg/context"
 	"get.porter.sh/porter/pkg/editor"
 	"get.porter.sh/porter/pkg/generator"
@@ -32,13 +33,28 @@ type ParameterEditOptions struct {
 	Name string
 }
 
-// ListParameters lists saved parameter sets.
+// ListParameters lists saved parameter sets. When a label selector is
+// specified, only the parameter sets used by the selected installations are listed.
 func (p *Porter) ListParameters(opts ListOptions) error {
 	params, err := p.Parameters.ReadAll()
 	if err != nil {
 		return err
 	}
 
+	if !opts.selector.Empty() {
+		used, err := p.selectInstallationSets(opts.selector, cnabprovider.GetParameterSets)
+		if err != nil {
+			return err
+		}
+		selected := make([]parameters.ParameterSet, 0, len(used))
+		for _, ps := range params {
+			if used[ps.Name] {
+				selected = append(selected, ps)
+			}
+		}
+		params = selected
+	}
+
 	switch opts.Format {
 	case printer.FormatJson:
 		return printer.PrintJson(p.Out, params)
@@ -0,0 +1,216 @@
+package porter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	cnabprovider "get.porter.sh/porter/pkg/cnab/provider"
+	"get.porter.sh/porter/pkg/printer"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/pkg/errors"
+)
+
+var (
+	DryRunAllowedFormats = printer.Formats{printer.FormatPlaintext, printer.FormatJson, printer.FormatYaml}
+	DryRunDefaultFormat  = printer.FormatPlaintext
+)
+
+// ActionPlan describes everything that an action would use, without executing it.
+type ActionPlan struct {
+	cnabprovider.ActionPlan `yaml:",inline"`
+
+	// Dependencies of the bundle, in the order that they would be executed.
+	Dependencies []PlannedDependency `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+}
+
+// PlannedDependency describes how a dependency of the bundle would be executed.
+type PlannedDependency struct {
+	Alias        string            `json:"alias" yaml:"alias"`
+	Reference    string            `json:"reference" yaml:"reference"`
+	Installation string            `json:"installation" yaml:"installation"`
+	Existing     bool              `json:"existing,omitempty" yaml:"existing,omitempty"`
+	DependsOn    []string          `json:"dependsOn,omitempty" yaml:"dependsOn,omitempty"`
+	Parameters   map[string]string `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// planAction resolves everything that the action would use and prints it,
+// without executing the action or any of its dependencies.
+func (p *Porter) planAction(action BundleAction, deperator *dependencyExecutioner) error {
+	opts := action.GetOptions()
+
+	actionArgs, err := p.BuildActionArgs(action)
+	if err != nil {
+		return err
+	}
+	deperator.PrepareRootActionArguments(&actionArgs)
+
+	rootPlan, err := p.CNAB.Plan(actionArgs)
+	if err != nil {
+		return err
+	}
+
+	plan := ActionPlan{
+		ActionPlan:   rootPlan,
+		Dependencies: deperator.planDependencies(),
+	}
+	return p.printActionPlan(opts.DryRunFormat, plan)
+}
+
+// planDependencies lists the dependencies in the order that they would be executed.
+func (e *dependencyExecutioner) planDependencies() []PlannedDependency {
+	deps := make([]PlannedDependency, 0, len(e.deps))
+	for _, dep := range e.deps {
+		ref, err := dep.GetPullReference()
+		if err != nil {
+			ref = dep.Reference
+		}
+
+		pd := PlannedDependency{
+			Alias:        dep.Alias,
+			Reference:    ref,
+			Installation: dep.Installation,
+			Existing:     dep.Existing,
+		}
+
+		for _, child := range dep.Dependencies {
+			pd.DependsOn = append(pd.DependsOn, child.Installation)
+		}
+		sort.Strings(pd.DependsOn)
+
+		if len(dep.Parameters) > 0 {
+			pd.Parameters = make(map[string]string, len(dep.Parameters))
+			for name, value := range dep.Parameters {
+				if cnabprovider.IsParameterSensitive(dep.bundle, name) {
+					value = cnabprovider.MaskedValue
+				}
+				pd.Parameters[name] = value
+			}
+		}
+
+		deps = append(deps, pd)
+	}
+
+	// Dependencies are uninstalled after the bundles that depend upon them
+	if e.Action == claim.ActionUninstall {
+		for i, j := 0, len(deps)-1; i < j; i, j = i+1, j-1 {
+			deps[i], deps[j] = deps[j], deps[i]
+		}
+	}
+
+	return deps
+}
+
+func (p *Porter) printActionPlan(opts printer.PrintOptions, plan ActionPlan) error {
+	switch opts.Format {
+	case printer.FormatJson:
+		return printer.PrintJson(p.Out, plan)
+	case printer.FormatYaml:
+		return printer.PrintYaml(p.Out, plan)
+	case printer.FormatPlaintext:
+		return p.printActionPlanText(plan)
+	default:
+		return fmt.Errorf("invalid format: %s", opts.Format)
+	}
+}
+
+func (p *Porter) printActionPlanText(plan ActionPlan) error {
+	fmt.Fprintf(p.Out, "Action: %s\n", plan.Action)
+	fmt.Fprintf(p.Out, "Installation: %s\n", plan.Installation)
+	fmt.Fprintf(p.Out, "Bundle: %s %s\n", plan.Bundle, plan.Version)
+	if plan.BundleReference != "" {
+		fmt.Fprintf(p.Out, "Bundle Reference: %s\n", plan.BundleReference)
+	}
+	fmt.Fprintf(p.Out, "Driver: %s\n", plan.Driver)
+	fmt.Fprintf(p.Out, "Invocation Image: %s\n", plan.InvocationImage)
+	fmt.Fprintln(p.Out, "")
+
+	if len(plan.Dependencies) > 0 {
+		fmt.Fprintln(p.Out, "Dependencies:")
+		printDependencyRow :=
+			func(v interface{}) []interface{} {
+				d, ok := v.(PlannedDependency)
+				if !ok {
+					return nil
+				}
+				existing := ""
+				if d.Existing {
+					existing = "existing"
+				}
+				return []interface{}{d.Alias, d.Installation, d.Reference, strings.Join(d.DependsOn, ", "), existing}
+			}
+		err := printer.PrintTable(p.Out, plan.Dependencies, printDependencyRow, "Alias", "Installation", "Reference", "Depends On", "Existing")
+		if err != nil {
+			return errors.Wrap(err, "unable to print dependencies table")
+		}
+		fmt.Fprintln(p.Out, "")
+	}
+
+	if len(plan.Images) > 0 {
+		fmt.Fprintln(p.Out, "Images:")
+		err := printer.PrintTable(p.Out, sortedPairs(plan.Images), printPairRow, "Name", "Image")
+		if err != nil {
+			return errors.Wrap(err, "unable to print images table")
+		}
+		fmt.Fprintln(p.Out, "")
+	}
+
+	if len(plan.Parameters) > 0 {
+		params := make(map[string]string, len(plan.Parameters))
+		for name, value := range plan.Parameters {
+			params[name] = fmt.Sprintf("%v", value)
+		}
+		fmt.Fprintln(p.Out, "Parameters:")
+		err := printer.PrintTable(p.Out, sortedPairs(params), printPairRow, "Name", "Value")
+		if err != nil {
+			return errors.Wrap(err, "unable to print parameters table")
+		}
+		fmt.Fprintln(p.Out, "")
+	}
+
+	if len(plan.Credentials) > 0 {
+		fmt.Fprintln(p.Out, "Credentials:")
+		printCredentialRow :=
+			func(v interface{}) []interface{} {
+				c, ok := v.(cnabprovider.PlannedCredential)
+				if !ok {
+					return nil
+				}
+				return []interface{}{c.Name, c.CredentialSet, c.Source, c.Value}
+			}
+		err := printer.PrintTable(p.Out, plan.Credentials, printCredentialRow, "Name", "Credential Set", "Source", "Value")
+		if err != nil {
+			return errors.Wrap(err, "unable to print credentials table")
+		}
+		fmt.Fprintln(p.Out, "")
+	}
+
+	if len(plan.Files) > 0 {
+		fmt.Fprintln(p.Out, "Files:")
+		for _, file := range plan.Files {
+			fmt.Fprintf(p.Out, "  %s\n", file)
+		}
+	}
+
+	return nil
+}
+
+// sortedPairs converts a map into a slice of key/value pairs, sorted by key.
+func sortedPairs(values map[string]string) [][2]string {
+	pairs := make([][2]string, 0, len(values))
+	for k, v := range values {
+		pairs = append(pairs, [2]string{k, v})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i][0] < pairs[j][0]
+	})
+	return pairs
+}
+
+func printPairRow(v interface{}) []interface{} {
+	pair, ok := v.([2]string)
+	if !ok {
+		return nil
+	}
+	return []interface{}{pair[0], pair[1]}
+}
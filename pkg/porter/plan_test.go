@@ -0,0 +1,67 @@
+package porter
+
+import (
+	"testing"
+
+	cnabprovider "get.porter.sh/porter/pkg/cnab/provider"
+	"get.porter.sh/porter/pkg/printer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPorter_printActionPlan(t *testing.T) {
+	plan := ActionPlan{
+		ActionPlan: cnabprovider.ActionPlan{
+			Action:          "install",
+			Installation:    "wordpress",
+			Bundle:          "wordpress",
+			Version:         "0.1.3",
+			BundleReference: "getporter/wordpress:v0.1.3",
+			Driver:          "docker",
+			InvocationImage: "getporter/wordpress-installer:v0.1.3",
+			Images:          map[string]string{"app": "getporter/wordpress-app:v1.0.0"},
+			Parameters:      map[string]interface{}{"wordpress-name": "mywordpress", "wordpress-password": cnabprovider.MaskedValue},
+			Credentials: []cnabprovider.PlannedCredential{
+				{Name: "kubeconfig", CredentialSet: "mycreds", Source: "path: /root/.kube/config", Value: cnabprovider.MaskedValue},
+			},
+			Files: []string{"/cnab/app/dependencies/mysql/bundle.json", "/cnab/bundle.json"},
+		},
+		Dependencies: []PlannedDependency{
+			{Alias: "mysql", Reference: "getporter/mysql:v0.1.3", Installation: "shared-mysql", Existing: true},
+		},
+	}
+
+	p := NewTestPorter(t)
+	err := p.printActionPlan(printer.PrintOptions{Format: printer.FormatPlaintext}, plan)
+	require.NoError(t, err)
+
+	wantOutput := `Action: install
+Installation: wordpress
+Bundle: wordpress 0.1.3
+Bundle Reference: getporter/wordpress:v0.1.3
+Driver: docker
+Invocation Image: getporter/wordpress-installer:v0.1.3
+
+Dependencies:
+Alias   Installation   Reference                Depends On   Existing
+mysql   shared-mysql   getporter/mysql:v0.1.3                existing
+
+Images:
+Name   Image
+app    getporter/wordpress-app:v1.0.0
+
+Parameters:
+Name                 Value
+wordpress-name       mywordpress
+wordpress-password   ******
+
+Credentials:
+Name         Credential Set   Source                     Value
+kubeconfig   mycreds          path: /root/.kube/config   ******
+
+Files:
+  /cnab/app/dependencies/mysql/bundle.json
+  /cnab/bundle.json
+`
+	assert.Equal(t, wantOutput, p.TestConfig.TestContext.GetOutput())
+}
@@ -11,6 +11,7 @@ import (
 	"get.porter.sh/porter/pkg/printer"
 	"get.porter.sh/porter/pkg/secrets/host"
 	"get.porter.sh/porter/pkg/storage/filesystem"
+	"get.porter.sh/porter/pkg/storage/sqlite"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
 	"github.com/olekukonko/tablewriter"
@@ -235,5 +236,6 @@ func getInternalPlugins(cfg *config.Config) map[string]func() plugin.Plugin {
 	return map[string]func() plugin.Plugin{
 		filesystem.PluginKey: func() plugin.Plugin { return filesystem.NewPlugin(*cfg) },
 		host.PluginKey:       func() plugin.Plugin { return host.NewPlugin() },
+		sqlite.PluginKey:     func() plugin.Plugin { return sqlite.NewPlugin(*cfg) },
 	}
 }
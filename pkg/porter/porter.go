@@ -17,7 +17,6 @@ import (
 	"get.porter.sh/porter/pkg/storage"
 	"get.porter.sh/porter/pkg/storage/pluginstore"
 	"get.porter.sh/porter/pkg/templates"
-	"github.com/cnabio/cnab-go/claim"
 )
 
 // Porter is the logic behind the porter client.
@@ -27,7 +26,7 @@ type Porter struct {
 	Cache       cache.BundleCache
 	Credentials credentials.CredentialProvider
 	Parameters  parameters.ParameterProvider
-	Claims      claim.Provider
+	Claims      claims.ClaimProvider
 	Registry    cnabtooci.RegistryProvider
 	Templates   *templates.Templates
 	Builder     BuildProvider
@@ -0,0 +1,231 @@
+package porter
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	cnabtooci "get.porter.sh/porter/pkg/cnab/cnab-to-oci"
+	"get.porter.sh/porter/pkg/config"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
+)
+
+// PromotionsKey is the key in the custom section of a bundle that records
+// the environments that the bundle was promoted to.
+const PromotionsKey = "sh.porter.promotions"
+
+// Promotion records that a bundle was promoted to an environment.
+type Promotion struct {
+	// Environment that the bundle was promoted to.
+	Environment string `json:"environment"`
+
+	// Source is the bundle reference that was promoted.
+	Source string `json:"source"`
+
+	// SourceDigest is the digest of the bundle manifest that was promoted.
+	SourceDigest string `json:"sourceDigest"`
+
+	// Time that the bundle was promoted.
+	Time time.Time `json:"time"`
+
+	// User that promoted the bundle, in the format USER@HOST.
+	User string `json:"user"`
+}
+
+// GetPromotions returns the promotions recorded in the custom section of a
+// bundle, starting with the first promotion.
+func GetPromotions(bun bundle.Bundle) ([]Promotion, error) {
+	data, ok := bun.Custom[PromotionsKey]
+	if !ok {
+		return nil, nil
+	}
+
+	dataB, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not marshal the %s custom section of bundle %s", PromotionsKey, bun.Name)
+	}
+
+	var promotions []Promotion
+	err = json.Unmarshal(dataB, &promotions)
+	return promotions, errors.Wrapf(err, "could not unmarshal the %s custom section of bundle %s", PromotionsKey, bun.Name)
+}
+
+// PromoteOptions are the options for promoting a bundle to an environment.
+type PromoteOptions struct {
+	// Source is the bundle reference to promote.
+	Source string
+
+	// Environment is the name of an environment from the Porter config.
+	Environment string
+
+	// Tag of the promoted bundle, defaults to the tag of the source bundle.
+	Tag string
+
+	InsecureRegistry bool
+
+	// RelocateImages copies the images referenced by the bundle into the
+	// registry of the environment.
+	RelocateImages bool
+
+	// Concurrency is the maximum number of images copied at the same time.
+	Concurrency int
+
+	// Sign the promoted bundle.
+	Sign bool
+
+	// SigningKey is the path to the private key used to sign the promoted bundle.
+	SigningKey string
+
+	env            config.Environment
+	destinationRef string
+}
+
+// Validate the promote options and the tag policy of the environment.
+func (o *PromoteOptions) Validate(args []string, p *Porter) error {
+	if len(args) == 0 {
+		return errors.New("the bundle reference to promote is required")
+	}
+	if len(args) > 1 {
+		return errors.Errorf("only one bundle reference may be specified but multiple were received: %s", args)
+	}
+	o.Source = args[0]
+
+	if IsOCILayoutReference(o.Source) {
+		return errors.New("bundles can only be promoted from a registry")
+	}
+	source, err := reference.ParseNormalizedNamed(o.Source)
+	if err != nil {
+		return errors.Wrapf(err, "invalid bundle reference %s, expected REGISTRY/bundle:tag", o.Source)
+	}
+
+	if o.Environment == "" {
+		return errors.New("--to is required")
+	}
+	o.env, err = p.Data.GetEnvironment(o.Environment)
+	if err != nil {
+		return err
+	}
+	if o.env.Registry == "" {
+		return errors.Errorf("the %s environment does not define a registry", o.env.Name)
+	}
+
+	if strings.ContainsAny(o.Tag, ":@") {
+		return errors.New("the --tag flag designates just the Docker tag portion of the promoted bundle reference")
+	}
+	if o.Tag == "" {
+		if isCopyDigestReference(source) {
+			return errors.New("--tag is required when promoting a bundle by digest")
+		}
+		o.Tag = reference.TagNameOnly(source).(reference.Tagged).Tag()
+	}
+	if len(o.env.AllowedTags) > 0 && !matchesTagPattern(o.Tag, o.env.AllowedTags) {
+		return errors.Errorf("tag %s is not allowed in the %s environment, allowed tags: %s",
+			o.Tag, o.env.Name, strings.Join(o.env.AllowedTags, ", "))
+	}
+	o.destinationRef = fmt.Sprintf("%s/%s:%s", strings.TrimSuffix(o.env.Registry, "/"), path.Base(reference.Path(source)), o.Tag)
+
+	if o.SigningKey != "" && !o.Sign {
+		return errors.New("--signing-key can only be used with --sign")
+	}
+	if o.RelocateImages && o.Concurrency < 1 {
+		return errors.New("--concurrency must be at least 1")
+	}
+
+	return nil
+}
+
+// matchesTagPattern determines if a tag matches any of the glob patterns.
+func matchesTagPattern(tag string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// PromoteBundle copies a bundle into the registry of an environment, after
+// checking the policies of the environment, and records the promotion in the
+// custom section of the promoted bundle.
+func (p *Porter) PromoteBundle(opts PromoteOptions) error {
+	// Load the key before promoting so that a bad key doesn't leave an unsigned bundle behind
+	var signer crypto.Signer
+	if opts.Sign {
+		var err error
+		signer, err = p.loadSigningKey(opts.SigningKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(p.Out, "Promoting bundle %s to the %s environment at %s\n", opts.Source, opts.env.Name, opts.destinationRef)
+
+	if !matchesTagPattern(opts.Tag, opts.env.MutableTags) {
+		_, err := p.Registry.GetBundleDigest(opts.destinationRef, opts.InsecureRegistry)
+		if err == nil {
+			return errors.Errorf("%s already exists and tag %s is immutable in the %s environment", opts.destinationRef, opts.Tag, opts.env.Name)
+		}
+		// Only promote when we know that the tag doesn't exist yet
+		if !cnabtooci.IsNotFound(err) {
+			return errors.Wrapf(err, "unable to check if %s already exists", opts.destinationRef)
+		}
+	}
+
+	sourceDigest, err := p.Registry.GetBundleDigest(opts.Source, opts.InsecureRegistry)
+	if err != nil {
+		return err
+	}
+	// Pull by digest so that the promoted bundle is the one recorded in the promotion, even if the tag is moved
+	bun, rm, err := p.Registry.PullBundle(getDigestReference(opts.Source, sourceDigest), opts.InsecureRegistry)
+	if err != nil {
+		return errors.Wrapf(err, "unable to pull bundle %s before promoting", opts.Source)
+	}
+
+	if len(opts.env.Keys) > 0 {
+		tp := config.TrustPolicy{Repository: fmt.Sprintf("the %s environment", opts.env.Name), Keys: opts.env.Keys}
+		if err = p.newBundleVerifier().VerifyWithPolicy(opts.Source, bun, tp, opts.InsecureRegistry); err != nil {
+			return errors.Wrapf(err, "bundle %s cannot be promoted to the %s environment", opts.Source, opts.env.Name)
+		}
+	}
+
+	promotions, err := GetPromotions(bun)
+	if err != nil {
+		return err
+	}
+	promotions = append(promotions, Promotion{
+		Environment:  opts.env.Name,
+		Source:       opts.Source,
+		SourceDigest: sourceDigest,
+		Time:         time.Now().UTC(),
+		User:         getCurrentUser(),
+	})
+	if bun.Custom == nil {
+		bun.Custom = make(map[string]interface{}, 1)
+	}
+	bun.Custom[PromotionsKey] = promotions
+
+	copyOpts := &CopyOpts{
+		Source:           opts.Source,
+		Destination:      opts.destinationRef,
+		InsecureRegistry: opts.InsecureRegistry,
+		RelocateImages:   opts.RelocateImages,
+		Concurrency:      opts.Concurrency,
+	}
+	if err = p.copyBundle(bun, rm, opts.destinationRef, copyOpts); err != nil {
+		return err
+	}
+
+	if opts.Sign {
+		if err = p.signBundle(opts.destinationRef, signer, opts.InsecureRegistry); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(p.Out, "Promoted bundle %s to %s\n", opts.Source, opts.destinationRef)
+	return nil
+}
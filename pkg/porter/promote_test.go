@@ -0,0 +1,213 @@
+package porter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/signing"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-to-oci/relocation"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPromoteConfig() *config.Data {
+	return &config.Data{
+		Environments: []config.Environment{
+			{Name: "staging", Registry: "staging.example.com/porter/", MutableTags: []string{"latest"}},
+			{Name: "prod", Registry: "prod.example.com/porter", AllowedTags: []string{"v*"}, Keys: []string{"keys/release.pub"}},
+			{Name: "broken"},
+		},
+	}
+}
+
+func TestPromoteOptions_Validate(t *testing.T) {
+	p := NewTestPorter(t)
+	p.Data = testPromoteConfig()
+
+	testcases := []struct {
+		name      string
+		args      []string
+		opts      PromoteOptions
+		wantDest  string
+		wantError string
+	}{
+		{name: "no args", opts: PromoteOptions{Environment: "staging"}, wantError: "the bundle reference to promote is required"},
+		{name: "too many args", args: []string{"a", "b"}, opts: PromoteOptions{Environment: "staging"}, wantError: "only one bundle reference may be specified but multiple were received: [a b]"},
+		{name: "missing --to", args: []string{"getporter/mybuns:v0.1.0"}, wantError: "--to is required"},
+		{name: "unknown environment", args: []string{"getporter/mybuns:v0.1.0"}, opts: PromoteOptions{Environment: "dev"}, wantError: `environment "dev" is not defined in the porter config`},
+		{name: "no registry", args: []string{"getporter/mybuns:v0.1.0"}, opts: PromoteOptions{Environment: "broken"}, wantError: "the broken environment does not define a registry"},
+		{name: "oci layout", args: []string{"oci-layout:///srv/mybuns"}, opts: PromoteOptions{Environment: "staging"}, wantError: "bundles can only be promoted from a registry"},
+		{name: "digest without tag", args: []string{"getporter/mybuns@sha256:a808aa4e3508d7129742eefda938249574447cce5403dc12d4cbbfe7f4f31e58"}, opts: PromoteOptions{Environment: "staging"}, wantError: "--tag is required when promoting a bundle by digest"},
+		{name: "tag not allowed", args: []string{"getporter/mybuns:latest"}, opts: PromoteOptions{Environment: "prod"}, wantError: "tag latest is not allowed in the prod environment, allowed tags: v*"},
+		{name: "signing key without sign", args: []string{"getporter/mybuns:v0.1.0"}, opts: PromoteOptions{Environment: "staging", SigningKey: "release.key"}, wantError: "--signing-key can only be used with --sign"},
+		{name: "source tag", args: []string{"getporter/mybuns:v0.1.0"}, opts: PromoteOptions{Environment: "staging"}, wantDest: "staging.example.com/porter/mybuns:v0.1.0"},
+		{name: "default tag", args: []string{"getporter/mybuns"}, opts: PromoteOptions{Environment: "staging"}, wantDest: "staging.example.com/porter/mybuns:latest"},
+		{name: "digest with tag", args: []string{"getporter/mybuns@sha256:a808aa4e3508d7129742eefda938249574447cce5403dc12d4cbbfe7f4f31e58"}, opts: PromoteOptions{Environment: "prod", Tag: "v0.1.0"}, wantDest: "prod.example.com/porter/mybuns:v0.1.0"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opts.Validate(tc.args, p.Porter)
+			if tc.wantError != "" {
+				require.EqualError(t, err, tc.wantError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantDest, tc.opts.destinationRef)
+			}
+		})
+	}
+}
+
+func TestPorter_PromoteBundle(t *testing.T) {
+	const sourceDigest = "sha256:a808aa4e3508d7129742eefda938249574447cce5403dc12d4cbbfe7f4f31e58"
+	bun := bundle.Bundle{
+		Name:             "mybuns",
+		Version:          "0.1.0",
+		InvocationImages: []bundle.InvocationImage{{BaseImage: bundle.BaseImage{Image: "getporter/mybuns-installer:v0.1.0", Digest: sourceDigest}}},
+	}
+	bundleDigest, err := signing.DigestBundle(bun)
+	require.NoError(t, err)
+
+	releasePriv, releasePub := signing.GenerateTestKey(t)
+	releaseKey, err := signing.ParsePrivateKey(releasePriv)
+	require.NoError(t, err)
+
+	setup := func(t *testing.T, existingTags ...string) *TestPorter {
+		p := NewTestPorter(t)
+		p.Data = testPromoteConfig()
+		home, err := p.GetHomeDir()
+		require.NoError(t, err)
+		require.NoError(t, p.FileSystem.WriteFile(filepath.Join(home, "keys/release.pub"), releasePub, 0644))
+
+		p.TestRegistry.MockGetBundleDigest = func(tag string, insecureRegistry bool) (string, error) {
+			if tag == "getporter/mybuns:v0.1.0" {
+				return sourceDigest, nil
+			}
+			for _, existing := range existingTags {
+				if tag == existing {
+					return "sha256:abc123", nil
+				}
+			}
+			return "", errors.Wrapf(errdefs.ErrNotFound, "unable to resolve the digest of %s", tag)
+		}
+		p.TestRegistry.MockPullBundle = func(tag string, insecureRegistry bool) (bundle.Bundle, *relocation.ImageRelocationMap, error) {
+			if tag != "getporter/mybuns@"+sourceDigest {
+				return bundle.Bundle{}, nil, errors.Errorf("the bundle should be pulled by its digest, got %s", tag)
+			}
+			return bun, nil, nil
+		}
+		return p
+	}
+
+	t.Run("records the promotion", func(t *testing.T) {
+		p := setup(t)
+		var pushed bundle.Bundle
+		var pushedTag string
+		p.TestRegistry.MockPushBundle = func(b bundle.Bundle, tag string, insecureRegistry bool) (*relocation.ImageRelocationMap, error) {
+			pushed = b
+			pushedTag = tag
+			return nil, nil
+		}
+
+		opts := PromoteOptions{Environment: "staging"}
+		require.NoError(t, opts.Validate([]string{"getporter/mybuns:v0.1.0"}, p.Porter))
+		require.NoError(t, p.PromoteBundle(opts))
+
+		assert.Equal(t, "staging.example.com/porter/mybuns:v0.1.0", pushedTag)
+		promotions, err := GetPromotions(pushed)
+		require.NoError(t, err)
+		require.Len(t, promotions, 1)
+		assert.Equal(t, "staging", promotions[0].Environment)
+		assert.Equal(t, "getporter/mybuns:v0.1.0", promotions[0].Source)
+		assert.Equal(t, sourceDigest, promotions[0].SourceDigest)
+		assert.NotEmpty(t, promotions[0].User)
+		assert.WithinDuration(t, time.Now(), promotions[0].Time, time.Minute)
+		assert.Empty(t, bun.Custom, "the source bundle should not be modified")
+		assert.Contains(t, p.TestConfig.TestContext.GetOutput(), "Promoted bundle getporter/mybuns:v0.1.0 to staging.example.com/porter/mybuns:v0.1.0")
+	})
+
+	t.Run("immutable tag", func(t *testing.T) {
+		p := setup(t, "staging.example.com/porter/mybuns:v0.1.0")
+
+		opts := PromoteOptions{Environment: "staging"}
+		require.NoError(t, opts.Validate([]string{"getporter/mybuns:v0.1.0"}, p.Porter))
+		err := p.PromoteBundle(opts)
+		require.EqualError(t, err, "staging.example.com/porter/mybuns:v0.1.0 already exists and tag v0.1.0 is immutable in the staging environment")
+	})
+
+	t.Run("unable to check the destination", func(t *testing.T) {
+		p := setup(t)
+		p.TestRegistry.MockGetBundleDigest = func(tag string, insecureRegistry bool) (string, error) {
+			return "", errors.New("pull access denied, repository does not exist or may require authorization")
+		}
+
+		opts := PromoteOptions{Environment: "staging"}
+		require.NoError(t, opts.Validate([]string{"getporter/mybuns:v0.1.0"}, p.Porter))
+		err := p.PromoteBundle(opts)
+		require.EqualError(t, err, "unable to check if staging.example.com/porter/mybuns:v0.1.0 already exists: pull access denied, repository does not exist or may require authorization",
+			"an immutable tag should not be overwritten when the registry can't tell us that it doesn't exist")
+	})
+
+	t.Run("mutable tag", func(t *testing.T) {
+		p := setup(t, "staging.example.com/porter/mybuns:latest")
+
+		opts := PromoteOptions{Environment: "staging", Tag: "latest"}
+		require.NoError(t, opts.Validate([]string{"getporter/mybuns:v0.1.0"}, p.Porter))
+		require.NoError(t, p.PromoteBundle(opts))
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		p := setup(t)
+
+		opts := PromoteOptions{Environment: "prod"}
+		require.NoError(t, opts.Validate([]string{"getporter/mybuns:v0.1.0"}, p.Porter))
+		err := p.PromoteBundle(opts)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "bundle getporter/mybuns:v0.1.0 cannot be promoted to the prod environment: the trust policy for the prod environment requires bundles to be signed")
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		p := setup(t)
+		sig, err := signing.Sign(releaseKey, bundleDigest)
+		require.NoError(t, err)
+		p.TestRegistry.MockPullSignature = func(tag string, insecureRegistry bool) (signing.Signature, error) {
+			return sig, nil
+		}
+
+		opts := PromoteOptions{Environment: "prod"}
+		require.NoError(t, opts.Validate([]string{"getporter/mybuns:v0.1.0"}, p.Porter))
+		require.NoError(t, p.PromoteBundle(opts))
+	})
+}
+
+func TestGetPromotions(t *testing.T) {
+	promoted := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	bun := bundle.Bundle{
+		Custom: map[string]interface{}{
+			PromotionsKey: []interface{}{
+				map[string]interface{}{
+					"environment":  "staging",
+					"source":       "getporter/mybuns:v0.1.0",
+					"sourceDigest": "sha256:abc123",
+					"time":         "2020-01-02T03:04:05Z",
+					"user":         "sally@example",
+				},
+			},
+		},
+	}
+
+	promotions, err := GetPromotions(bun)
+	require.NoError(t, err)
+	assert.Equal(t, []Promotion{
+		{Environment: "staging", Source: "getporter/mybuns:v0.1.0", SourceDigest: "sha256:abc123", Time: promoted, User: "sally@example"},
+	}, promotions)
+
+	promotions, err = GetPromotions(bundle.Bundle{})
+	require.NoError(t, err)
+	assert.Empty(t, promotions)
+}
@@ -0,0 +1,167 @@
+package porter
+
+import (
+	"fmt"
+	"time"
+
+	"get.porter.sh/porter/pkg/claims"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/pkg/errors"
+)
+
+// PruneOptions are the options for removing old claims from the history of installations.
+type PruneOptions struct {
+	// Name of the installation to prune. When empty, every installation is pruned.
+	Name string
+
+	// KeepLast overrides keep-last from the claim-retention config.
+	KeepLast int
+
+	// KeepDays overrides keep-days from the claim-retention config.
+	KeepDays int
+
+	// DryRun lists the claims that would be removed without removing them.
+	DryRun bool
+}
+
+func (o *PruneOptions) Validate(args []string) error {
+	if len(args) == 1 {
+		o.Name = args[0]
+	} else if len(args) > 1 {
+		return errors.Errorf("only one positional argument may be specified, the installation name, but multiple were received: %s", args)
+	}
+
+	if o.KeepLast < 0 {
+		return errors.Errorf("invalid --keep-last %d, it cannot be negative", o.KeepLast)
+	}
+	if o.KeepDays < 0 {
+		return errors.Errorf("invalid --keep-days %d, it cannot be negative", o.KeepDays)
+	}
+	return nil
+}
+
+// PruneInstallations removes the claims, and their results, outputs and logs,
+// that are not kept by the claim retention policy.
+func (p *Porter) PruneInstallations(opts PruneOptions) error {
+	retention, err := p.Data.GetClaimRetention()
+	if err != nil {
+		return err
+	}
+	if opts.KeepLast > 0 {
+		retention.KeepLast = opts.KeepLast
+	}
+	if opts.KeepDays > 0 {
+		retention.KeepDays = opts.KeepDays
+	}
+
+	policy := claims.NewRetentionPolicy(retention)
+	if policy.IsEmpty() {
+		return errors.New("no claim retention policy is configured, set keep-last or keep-days in the claim-retention section of the config file, or use --keep-last or --keep-days")
+	}
+
+	installations := []string{opts.Name}
+	if opts.Name == "" {
+		installations, err = p.Claims.ListInstallations()
+		if err != nil {
+			return errors.Wrap(err, "could not list installations")
+		}
+	}
+
+	for _, installation := range installations {
+		if !opts.DryRun {
+			// Don't remove claims while an action is running
+			_, err = p.Claims.LockInstallation(installation, getCurrentUser(), DefaultLockDuration)
+			if claims.IsInstallationLocked(err) {
+				fmt.Fprintf(p.Err, "Skipping installation %s: %s\n", installation, err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		pruned, err := p.pruneInstallation(installation, policy, opts.DryRun)
+		if !opts.DryRun {
+			p.unlockInstallation(installation)
+		}
+		p.printPrunedClaims(installation, pruned, opts.DryRun)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pruneInstallation removes the claims of an installation that are not kept
+// by the policy, returning the claims that were removed. The caller is
+// responsible for locking the installation.
+func (p *Porter) pruneInstallation(installation string, policy claims.RetentionPolicy, dryRun bool) ([]claim.Claim, error) {
+	i, err := p.Claims.ReadInstallation(installation)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read installation %s", installation)
+	}
+
+	outputClaims, err := claims.ListLastOutputClaims(p.Claims, i)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not determine the outputs of installation %s", installation)
+	}
+
+	prunable := policy.SelectPrunable(i, outputClaims, time.Now())
+	if dryRun {
+		return prunable, nil
+	}
+
+	for j, c := range prunable {
+		// Removes the results, outputs and logs of the claim too
+		err = p.Claims.DeleteClaim(c.ID)
+		if err != nil {
+			return prunable[:j], errors.Wrapf(err, "could not remove claim %s of installation %s", c.ID, installation)
+		}
+	}
+	return prunable, nil
+}
+
+func (p *Porter) printPrunedClaims(installation string, pruned []claim.Claim, dryRun bool) {
+	if len(pruned) == 0 {
+		fmt.Fprintf(p.Out, "Installation %s has no claims to remove\n", installation)
+		return
+	}
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	fmt.Fprintf(p.Out, "%s %d claims from installation %s:\n", verb, len(pruned), installation)
+	for _, c := range pruned {
+		fmt.Fprintf(p.Out, " - %s %s %s %s\n", c.ID, c.Action, c.Created.Format(time.RFC3339), c.GetStatus())
+	}
+}
+
+// autoPruneInstallation removes the claims of an installation that are not
+// kept by the claim retention policy after an action, when auto-prune is
+// enabled. The installation must be locked by the action.
+func (p *Porter) autoPruneInstallation(installation string) {
+	retention, err := p.Data.GetClaimRetention()
+	if err != nil {
+		fmt.Fprintf(p.Err, "warning: unable to prune installation %s: %s\n", installation, err)
+		return
+	}
+
+	policy := claims.NewRetentionPolicy(retention)
+	if !retention.AutoPrune || policy.IsEmpty() {
+		return
+	}
+
+	pruned, err := p.pruneInstallation(installation, policy, false)
+	if err != nil {
+		// The installation was deleted by the action
+		if errors.Cause(err) == claim.ErrInstallationNotFound {
+			return
+		}
+		fmt.Fprintf(p.Err, "warning: unable to prune installation %s: %s\n", installation, err)
+	}
+	if len(pruned) > 0 {
+		fmt.Fprintf(p.Out, "Removed %d old claims from installation %s\n", len(pruned), installation)
+	}
+}
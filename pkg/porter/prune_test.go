@@ -0,0 +1,173 @@
+package porter
+
+import (
+	"testing"
+	"time"
+
+	"get.porter.sh/porter/pkg/config"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createClaimHistory saves a claim and result for each status, and returns the claim ids in order.
+func createClaimHistory(p *TestPorter, installation string, statuses ...string) []string {
+	bun := bundle.Bundle{Name: installation, Version: "1.0.0"}
+	var ids []string
+	for _, status := range statuses {
+		c := p.TestClaims.CreateClaim(installation, claim.ActionUpgrade, bun, nil)
+		p.TestClaims.CreateResult(c, status)
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func listClaimIDs(t *testing.T, p *TestPorter, installation string) []string {
+	i, err := p.Claims.ReadInstallation(installation)
+	require.NoError(t, err)
+	var ids []string
+	for _, c := range i.Claims {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func TestPruneOptions_Validate(t *testing.T) {
+	opts := PruneOptions{}
+	require.NoError(t, opts.Validate([]string{"mybuns"}))
+	assert.Equal(t, "mybuns", opts.Name)
+
+	err := opts.Validate([]string{"a", "b"})
+	require.EqualError(t, err, "only one positional argument may be specified, the installation name, but multiple were received: [a b]")
+
+	opts = PruneOptions{KeepDays: -1}
+	require.EqualError(t, opts.Validate(nil), "invalid --keep-days -1, it cannot be negative")
+}
+
+func TestPorter_PruneInstallations(t *testing.T) {
+	p := NewTestPorter(t)
+	s := claim.StatusSucceeded
+	f := claim.StatusFailed
+	mybuns := createClaimHistory(p, "mybuns", s, s, f)
+	other := createClaimHistory(p, "other", s, s)
+
+	oldClaim, err := p.Claims.ReadClaim(mybuns[0])
+	require.NoError(t, err)
+	oldResult, err := p.Claims.ReadLastResult(oldClaim.ID)
+	require.NoError(t, err)
+	p.TestClaims.CreateOutput(oldClaim, oldResult, "password", []byte("abc123"))
+
+	// The output was regenerated by the next claim, so the old claim holds a stale value
+	newClaim, err := p.Claims.ReadClaim(mybuns[1])
+	require.NoError(t, err)
+	newResult, err := p.Claims.ReadLastResult(newClaim.ID)
+	require.NoError(t, err)
+	p.TestClaims.CreateOutput(newClaim, newResult, "password", []byte("def456"))
+
+	err = p.PruneInstallations(PruneOptions{KeepLast: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, mybuns[1:], listClaimIDs(t, p, "mybuns"), "the last claim and the last successful claim of mybuns should be kept")
+	assert.Equal(t, other[1:], listClaimIDs(t, p, "other"), "only the last claim of other should be kept")
+
+	_, err = p.Claims.ReadResult(oldResult.ID)
+	assert.Error(t, err, "the results of the pruned claim should be removed")
+	_, err = p.Claims.ReadOutput(oldClaim, oldResult, "password")
+	assert.Error(t, err, "the outputs of the pruned claim should be removed")
+	lastOutput, err := p.Claims.ReadLastOutput("mybuns", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "def456", string(lastOutput.Value), "the last value of the output should be kept")
+
+	gotOutput := p.TestConfig.TestContext.GetOutput()
+	assert.Contains(t, gotOutput, "Removed 1 claims from installation mybuns:\n - "+mybuns[0]+" upgrade")
+	assert.Contains(t, gotOutput, "Removed 1 claims from installation other:")
+
+	_, err = p.Claims.ReadInstallationLock("mybuns")
+	assert.Error(t, err, "the installation should be unlocked after it is pruned")
+}
+
+func TestPorter_PruneInstallations_KeepsOutputs(t *testing.T) {
+	p := NewTestPorter(t)
+	s := claim.StatusSucceeded
+	ids := createClaimHistory(p, "mybuns", s, s, s, s)
+
+	// The password is only generated by install, the upgrades don't change it
+	installClaim, err := p.Claims.ReadClaim(ids[0])
+	require.NoError(t, err)
+	installResult, err := p.Claims.ReadLastResult(installClaim.ID)
+	require.NoError(t, err)
+	p.TestClaims.CreateOutput(installClaim, installResult, "password", []byte("abc123"))
+
+	err = p.PruneInstallations(PruneOptions{KeepLast: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{ids[0], ids[3]}, listClaimIDs(t, p, "mybuns"), "the claim with the last value of the password should be kept")
+	output, err := p.Claims.ReadLastOutput("mybuns", "password")
+	require.NoError(t, err, "the password output should not be removed")
+	assert.Equal(t, "abc123", string(output.Value))
+}
+
+func TestPorter_PruneInstallations_DryRun(t *testing.T) {
+	p := NewTestPorter(t)
+	s := claim.StatusSucceeded
+	ids := createClaimHistory(p, "mybuns", s, s, s)
+
+	err := p.PruneInstallations(PruneOptions{Name: "mybuns", KeepLast: 1, DryRun: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, ids, listClaimIDs(t, p, "mybuns"), "no claims should be removed during a dry run")
+	gotOutput := p.TestConfig.TestContext.GetOutput()
+	assert.Contains(t, gotOutput, "Would remove 2 claims from installation mybuns:")
+	assert.Contains(t, gotOutput, ids[0])
+	assert.Contains(t, gotOutput, ids[1])
+}
+
+func TestPorter_PruneInstallations_Config(t *testing.T) {
+	p := NewTestPorter(t)
+	s := claim.StatusSucceeded
+	ids := createClaimHistory(p, "mybuns", s, s, s)
+
+	err := p.PruneInstallations(PruneOptions{})
+	require.EqualError(t, err, "no claim retention policy is configured, set keep-last or keep-days in the claim-retention section of the config file, or use --keep-last or --keep-days")
+
+	p.Data = &config.Data{ClaimRetention: config.ClaimRetention{KeepLast: 2}}
+	err = p.PruneInstallations(PruneOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, ids[1:], listClaimIDs(t, p, "mybuns"), "the policy from the config should be used")
+
+	err = p.PruneInstallations(PruneOptions{KeepLast: 1})
+	require.NoError(t, err)
+	assert.Equal(t, ids[2:], listClaimIDs(t, p, "mybuns"), "the flags should override the config")
+}
+
+func TestPorter_PruneInstallations_Locked(t *testing.T) {
+	p := NewTestPorter(t)
+	s := claim.StatusSucceeded
+	ids := createClaimHistory(p, "mybuns", s, s)
+	p.TestClaims.CreateLock("mybuns", "someone@else", time.Hour)
+
+	err := p.PruneInstallations(PruneOptions{KeepLast: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, ids, listClaimIDs(t, p, "mybuns"), "a locked installation should not be pruned")
+	assert.Contains(t, p.TestConfig.TestContext.GetError(), "Skipping installation mybuns: installation mybuns is locked by someone@else")
+}
+
+func TestPorter_AutoPruneInstallation(t *testing.T) {
+	p := NewTestPorter(t)
+	s := claim.StatusSucceeded
+	ids := createClaimHistory(p, "mybuns", s, s, s)
+
+	p.Data = &config.Data{ClaimRetention: config.ClaimRetention{KeepLast: 1}}
+	p.autoPruneInstallation("mybuns")
+	assert.Equal(t, ids, listClaimIDs(t, p, "mybuns"), "claims should not be pruned unless auto-prune is enabled")
+
+	p.Data.ClaimRetention.AutoPrune = true
+	p.autoPruneInstallation("mybuns")
+	assert.Equal(t, ids[2:], listClaimIDs(t, p, "mybuns"), "claims should be pruned when auto-prune is enabled")
+	assert.Contains(t, p.TestConfig.TestContext.GetOutput(), "Removed 2 old claims from installation mybuns")
+
+	p.autoPruneInstallation("missing")
+	assert.Empty(t, p.TestConfig.TestContext.GetError(), "a missing installation should be ignored")
+}
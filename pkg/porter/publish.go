@@ -2,7 +2,11 @@ package porter
 
 import (
 	"bytes"
+	"crypto"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
@@ -11,9 +15,10 @@ import (
 	portercontext "get.porter.sh/porter/pkg/context"
 	"github.com/cnabio/cnab-go/bundle"
 	"github.com/cnabio/cnab-go/bundle/loader"
-	"github.com/cnabio/cnab-go/packager"
 	"github.com/cnabio/cnab-to-oci/relocation"
 	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/opencontainers/go-digest"
 	"github.com/pivotal/image-relocation/pkg/image"
 	"github.com/pivotal/image-relocation/pkg/registry"
 	"github.com/pivotal/image-relocation/pkg/registry/ggcr"
@@ -25,17 +30,35 @@ import (
 type PublishOptions struct {
 	BundlePullOptions
 	bundleFileOptions
-	Tag         string
-	Registry    string
-	ArchiveFile string
+	Tag      string
+	Registry string
+
+	// ArchiveFiles are the archives to publish, a full archive optionally
+	// followed by the delta archives that were generated from it.
+	ArchiveFiles []string
+
+	// Sign the bundle after it is published.
+	Sign bool
+
+	// SigningKey is the path to the private key used to sign the bundle.
+	SigningKey string
+
+	// signer is the loaded SigningKey.
+	signer crypto.Signer
 }
 
+// DefaultSigningKey is the name of the private key in PORTER_HOME that is
+// used to sign bundles when a key is not specified.
+const DefaultSigningKey = "signing.key"
+
 // Validate performs validation on the publish options
 func (o *PublishOptions) Validate(cxt *portercontext.Context) error {
-	if o.ArchiveFile != "" {
-		// Verify the archive file can be accessed
-		if _, err := cxt.FileSystem.Stat(o.ArchiveFile); err != nil {
-			return errors.Wrapf(err, "unable to access --archive %s", o.ArchiveFile)
+	if len(o.ArchiveFiles) > 0 {
+		// Verify the archive files can be accessed
+		for _, archiveFile := range o.ArchiveFiles {
+			if _, err := cxt.FileSystem.Stat(archiveFile); err != nil {
+				return errors.Wrapf(err, "unable to access --archive %s", archiveFile)
+			}
 		}
 
 		if o.Reference == "" {
@@ -53,7 +76,14 @@ func (o *PublishOptions) Validate(cxt *portercontext.Context) error {
 		}
 	}
 
+	if o.SigningKey != "" && !o.Sign {
+		return errors.New("--signing-key can only be used with --sign")
+	}
+
 	if o.Reference != "" {
+		if IsOCILayoutReference(o.Reference) {
+			return errors.New("bundles can only be published to a registry, use porter archive import to import an archive into an OCI layout")
+		}
 		return o.validateReference()
 	}
 
@@ -84,7 +114,16 @@ func (p *Porter) Publish(opts PublishOptions) error {
 		}
 	}
 
-	if opts.ArchiveFile == "" {
+	// Load the key before publishing so that a bad key doesn't leave an unsigned bundle behind
+	if opts.Sign {
+		signer, err := p.loadSigningKey(opts.SigningKey)
+		if err != nil {
+			return err
+		}
+		opts.signer = signer
+	}
+
+	if len(opts.ArchiveFiles) == 0 {
 		return p.publishFromFile(opts)
 	}
 	return p.publishFromArchive(opts)
@@ -164,12 +203,21 @@ func (p *Porter) publishFromFile(opts PublishOptions) error {
 		return err
 	}
 
+	if opts.Sign {
+		err = p.signBundle(p.Manifest.Reference, opts.signer, opts.InsecureRegistry)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Perhaps we have a cached version of a bundle with the same reference, previously pulled
 	// If so, replace it, as it is most likely out-of-date per this publish
 	return p.refreshCachedBundle(bun, p.Manifest.Reference, rm)
 }
 
 // publishFromArchive (re-)publishes a bundle, provided by the archive file, using the provided tag.
+// When multiple archive files are provided, they are extracted in order on top of each other so that
+// delta archives are combined with the archives that they were generated from.
 //
 // After the bundle is extracted from the archive, we iterate through all of the images (invocation
 // and application) listed in the bundle, grab their digests by parsing the extracted
@@ -185,15 +233,13 @@ func (p *Porter) publishFromFile(opts PublishOptions) error {
 // this approach will need to be refactored, via preserving the original bundle and employing
 // a relocation mapping approach to associate the bundle's (old) images with the newly copied images.
 func (p *Porter) publishFromArchive(opts PublishOptions) error {
-	source := p.FileSystem.Abs(opts.ArchiveFile)
-	tmpDir, err := p.FileSystem.TempDir("", "porter")
+	extractedDir, err := p.FileSystem.TempDir("", "porter")
 	if err != nil {
 		return errors.Wrap(err, "error creating temp directory for archive extraction")
 	}
-	defer p.FileSystem.RemoveAll(tmpDir)
-	extractedDir := filepath.Join(tmpDir, strings.TrimSuffix(filepath.Base(source), ".tgz"))
+	defer p.FileSystem.RemoveAll(extractedDir)
 
-	bun, err := p.extractBundle(tmpDir, source)
+	bun, err := p.extractArchives(extractedDir, opts.ArchiveFiles)
 	if err != nil {
 		return err
 	}
@@ -202,9 +248,9 @@ func (p *Porter) publishFromArchive(opts PublishOptions) error {
 
 	// Use the ggcr client to read the extracted OCI Layout
 	client := ggcr.NewRegistryClient()
-	layout, err := client.ReadLayout(filepath.Join(extractedDir, "artifacts/layout"))
+	layout, err := client.ReadLayout(filepath.Join(extractedDir, archiveLayoutDir))
 	if err != nil {
-		return errors.Wrapf(err, "failed to parse OCI Layout from archive %s", opts.ArchiveFile)
+		return errors.Wrapf(err, "failed to parse OCI Layout from archive %s", strings.Join(opts.ArchiveFiles, ", "))
 	}
 
 	// Push updated images (renamed based on provided bundle tag) with same digests
@@ -247,30 +293,99 @@ func (p *Porter) publishFromArchive(opts PublishOptions) error {
 		return err
 	}
 
+	if opts.Sign {
+		err = p.signBundle(opts.Reference, opts.signer, opts.InsecureRegistry)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Perhaps we have a cached version of a bundle with the same tag, previously pulled
 	// If so, replace it, as it is most likely out-of-date per this publish
 	return p.refreshCachedBundle(bun, opts.Reference, rm)
 }
 
-// extractBundle extracts a bundle using the provided opts and returnsthe extracted bundle
-func (p *Porter) extractBundle(tmpDir, source string) (bundle.Bundle, error) {
-	if p.Debug {
-		fmt.Fprintf(p.Err, "Extracting bundle from archive %s...\n", source)
+// extractArchives extracts the archives in order into the same directory and
+// returns the bundle from the last archive. Delta archives must be preceded by
+// the archives that they were generated from.
+func (p *Porter) extractArchives(dir string, archiveFiles []string) (bundle.Bundle, error) {
+	if err := p.unpackArchives(dir, archiveFiles); err != nil {
+		return bundle.Bundle{}, err
 	}
 
 	l := loader.NewLoader()
-	imp := packager.NewImporter(source, tmpDir, l)
-	err := imp.Import()
+	bun, err := l.Load(filepath.Join(dir, "bundle.json"))
 	if err != nil {
-		return bundle.Bundle{}, errors.Wrapf(err, "failed to extract bundle from archive %s", source)
+		return bundle.Bundle{}, errors.Wrapf(err, "failed to load bundle from archive %s", archiveFiles[len(archiveFiles)-1])
+	}
+
+	return *bun, nil
+}
+
+// unpackArchives extracts the archives in order into the same directory,
+// checking that delta archives are preceded by the archives that they were generated from.
+func (p *Porter) unpackArchives(dir string, archiveFiles []string) error {
+	extracted := map[string]bool{}
+	for _, archiveFile := range archiveFiles {
+		source := p.FileSystem.Abs(archiveFile)
+		if p.Debug {
+			fmt.Fprintf(p.Err, "Extracting bundle from archive %s...\n", source)
+		}
+
+		// Only the last archive's delta manifest applies to the extracted files
+		err := p.FileSystem.Remove(filepath.Join(dir, archiveDeltaFile))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		dig, err := p.extractArchive(dir, source)
+		if err != nil {
+			return errors.Wrapf(err, "failed to extract bundle from archive %s", source)
+		}
+
+		delta, isDelta, err := readArchiveDelta(p.FileSystem, dir)
+		if err != nil {
+			return errors.Wrapf(err, "invalid delta archive %s", source)
+		}
+		if isDelta {
+			for _, base := range delta.Bases {
+				if !extracted[base] {
+					return errors.Errorf("archive %s is a delta archive, specify the archives it was generated from before it. Missing an archive with digest %s", archiveFile, base)
+				}
+			}
+		}
+		extracted[dig] = true
 	}
 
-	bun, err := l.Load(filepath.Join(tmpDir, strings.TrimSuffix(filepath.Base(source), ".tgz"), "bundle.json"))
+	return nil
+}
+
+// extractArchive extracts a gzipped tar archive into the directory and returns the digest of the archive.
+func (p *Porter) extractArchive(dir string, source string) (string, error) {
+	f, err := p.FileSystem.Open(source)
 	if err != nil {
-		return bundle.Bundle{}, errors.Wrapf(err, "failed to load bundle from archive %s", source)
+		return "", err
 	}
+	defer f.Close()
 
-	return *bun, nil
+	digester := digest.Canonical.Digester()
+	r := io.TeeReader(f, digester.Hash())
+
+	tarOptions := &archive.TarOptions{
+		Compression:      archive.Gzip,
+		IncludeFiles:     []string{"."},
+		IncludeSourceDir: true,
+		NoLchown:         true,
+	}
+	if err := archive.Untar(r, dir, tarOptions); err != nil {
+		return "", errors.Wrap(err, "untar failed")
+	}
+
+	// Read the rest of the file so that the digest includes the entire archive
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		return "", err
+	}
+	return digester.Digest().String(), nil
 }
 
 // pushUpdatedImage uses the provided layout to find the provided origImg,
@@ -384,7 +499,7 @@ func (p *Porter) rewriteImageWithDigest(InvocationImage string, digest string) (
 // refreshCachedBundle will store a bundle anew, if a bundle with the same tag is found in the cache
 func (p *Porter) refreshCachedBundle(bun bundle.Bundle, tag string, rm *relocation.ImageRelocationMap) error {
 	if _, found, _ := p.Cache.FindBundle(tag); found {
-		_, err := p.Cache.StoreBundle(tag, bun, rm)
+		_, err := p.Cache.StoreBundle(tag, "", bun, rm)
 		if err != nil {
 			fmt.Fprintf(p.Err, "warning: unable to update cache for bundle %s: %s\n", tag, err)
 		}
@@ -1,10 +1,13 @@
 package porter
 
 import (
+	"crypto"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"get.porter.sh/porter/pkg/cache"
+	"get.porter.sh/porter/pkg/signing"
 	"github.com/cnabio/cnab-go/bundle"
 	"github.com/cnabio/cnab-to-oci/relocation"
 	"github.com/pivotal/image-relocation/pkg/image"
@@ -40,7 +43,7 @@ func TestPublish_Validate_ArchivePath(t *testing.T) {
 	p := NewTestPorter(t)
 
 	opts := PublishOptions{
-		ArchiveFile: "mybuns.tgz",
+		ArchiveFiles: []string{"mybuns.tgz"},
 	}
 	err := opts.Validate(p.Context)
 	assert.EqualError(t, err, "unable to access --archive mybuns.tgz: open /mybuns.tgz: file does not exist")
@@ -199,7 +202,7 @@ func TestPublish_RefreshCachedBundle(t *testing.T) {
 	require.NoError(t, err, "should have not errored out if bundle does not yet exist in cache")
 
 	// Save bundle in cache
-	cachedBundle, err := p.Cache.StoreBundle(tag, bun, nil)
+	cachedBundle, err := p.Cache.StoreBundle(tag, "", bun, nil)
 	require.NoError(t, err, "should have successfully stored bundle")
 
 	// Get file mod time
@@ -230,7 +233,7 @@ func TestPublish_RefreshCachedBundle_OnlyWarning(t *testing.T) {
 		// force the bundle to be found
 		return cache.CachedBundle{}, true, nil
 	}
-	p.TestCache.StoreBundleMock = func(s string, b bundle.Bundle, relocationMap *relocation.ImageRelocationMap) (cachedBundle cache.CachedBundle, err error) {
+	p.TestCache.StoreBundleMock = func(s string, digest string, b bundle.Bundle, relocationMap *relocation.ImageRelocationMap) (cachedBundle cache.CachedBundle, err error) {
 		// sabotage the bundle refresh
 		return cache.CachedBundle{}, errors.New("error trying to store bundle")
 	}
@@ -241,3 +244,52 @@ func TestPublish_RefreshCachedBundle_OnlyWarning(t *testing.T) {
 	gotStderr := p.TestConfig.TestContext.GetError()
 	require.Equal(t, "warning: unable to update cache for bundle myreg/mybuns: error trying to store bundle\n", gotStderr)
 }
+
+func TestPublish_signBundle(t *testing.T) {
+	p := NewTestPorter(t)
+
+	priv, pub := signing.GenerateTestKey(t)
+	home, err := p.GetHomeDir()
+	require.NoError(t, err)
+	require.NoError(t, p.FileSystem.WriteFile(filepath.Join(home, DefaultSigningKey), priv, 0600))
+
+	// The published bundle is pulled back from the registry and signed
+	bun := bundle.Bundle{Name: "mybuns", Version: "0.1.0"}
+	p.TestRegistry.MockPullBundle = func(tag string, insecureRegistry bool) (bundle.Bundle, *relocation.ImageRelocationMap, error) {
+		return bun, nil, nil
+	}
+	var pushedSig signing.Signature
+	p.TestRegistry.MockPushSignature = func(tag string, sig signing.Signature, insecureRegistry bool) error {
+		assert.Equal(t, "myreg/mybuns:v0.1.0", tag)
+		pushedSig = sig
+		return nil
+	}
+
+	opts := PublishOptions{Sign: true}
+	opts.signer, err = p.loadSigningKey(opts.SigningKey)
+	require.NoError(t, err, "the default signing key should be loaded")
+
+	err = p.signBundle("myreg/mybuns:v0.1.0", opts.signer, opts.InsecureRegistry)
+	require.NoError(t, err, "signBundle failed")
+
+	bundleDigest, err := signing.DigestBundle(bun)
+	require.NoError(t, err)
+	pubKey, err := signing.ParsePublicKey(pub)
+	require.NoError(t, err)
+	err = signing.Verify(pushedSig, bundleDigest, []crypto.PublicKey{pubKey})
+	require.NoError(t, err, "the pushed signature should be valid")
+	assert.Contains(t, p.TestConfig.TestContext.GetOutput(), "Signed bundle myreg/mybuns:v0.1.0 with digest "+bundleDigest)
+}
+
+func TestPublish_Validate_SigningKey(t *testing.T) {
+	p := NewTestPorter(t)
+	p.TestConfig.TestContext.AddTestFile("testdata/porter.yaml", "porter.yaml")
+
+	opts := PublishOptions{SigningKey: "mykey.pem"}
+	err := opts.Validate(p.Context)
+	require.EqualError(t, err, "--signing-key can only be used with --sign")
+
+	opts = PublishOptions{Sign: true, SigningKey: "mykey.pem"}
+	err = opts.Validate(p.Context)
+	require.NoError(t, err, "validating should not have failed")
+}
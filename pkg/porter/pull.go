@@ -23,6 +23,13 @@ func (b *BundlePullOptions) checkForDeprecatedTagValue() {
 }
 
 func (b BundlePullOptions) validateReference() error {
+	if IsOCILayoutReference(b.Reference) {
+		if getOCILayoutDir(b.Reference) == "" {
+			return errors.Errorf("invalid value for --reference, specify the directory of the OCI layout, for example %s/srv/bundles/mybuns", OCILayoutScheme)
+		}
+		return nil
+	}
+
 	_, err := cnabtooci.ParseOCIReference(b.Reference)
 	if err != nil {
 		return errors.Wrap(err, "invalid value for --reference, specified value should be of the form REGISTRY/bundle:tag")
@@ -32,10 +39,16 @@ func (b BundlePullOptions) validateReference() error {
 
 // PullBundle looks for a given bundle tag in the bundle cache. If it is not found, it is
 // pulled and stored in the cache. The path to the cached bundle is returned.
+// Bundles in an OCI layout directory are always loaded from the directory.
 func (p *Porter) PullBundle(opts BundlePullOptions) (cache.CachedBundle, error) {
-	resolver := BundleResolver{
-		Cache:    p.Cache,
-		Registry: p.Registry,
+	if IsOCILayoutReference(opts.Reference) {
+		ref, err := p.normalizeOCILayoutReference(opts.Reference)
+		if err != nil {
+			return cache.CachedBundle{}, err
+		}
+		return p.pullBundleFromLayout(ref)
 	}
+
+	resolver := p.newBundleResolver()
 	return resolver.Resolve(opts)
 }
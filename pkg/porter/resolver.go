@@ -1,34 +1,189 @@
 package porter
 
 import (
+	"fmt"
+	"time"
+
 	"get.porter.sh/porter/pkg/cache"
 	cnabtooci "get.porter.sh/porter/pkg/cnab/cnab-to-oci"
+	"get.porter.sh/porter/pkg/config"
+	"github.com/docker/distribution/reference"
 	"github.com/pkg/errors"
 )
 
 type BundleResolver struct {
+	// Config is used to determine how long cached tags are trusted, and to
+	// print warnings. When nil, cached tags are always checked against the registry.
+	Config *config.Config
+
 	Cache    cache.BundleCache
 	Registry cnabtooci.RegistryProvider
+
+	// Verifier enforces the trust policies on bundles before they are cached.
+	// When nil, bundles are not verified.
+	Verifier *BundleVerifier
+}
+
+func (p *Porter) newBundleResolver() BundleResolver {
+	return BundleResolver{
+		Config:   p.Config,
+		Cache:    p.Cache,
+		Registry: p.Registry,
+		Verifier: p.newBundleVerifier(),
+	}
 }
 
 // Resolves a bundle from the cache, or pulls it and caches it
 // Returns the location of the bundle or an error
 func (r *BundleResolver) Resolve(opts BundlePullOptions) (cache.CachedBundle, error) {
-	if !opts.Force {
+	// Bundles with a trust policy are always pulled and verified, so that a
+	// bundle that was cached before the policy applied isn't used
+	_, trusted, err := r.Verifier.GetTrustPolicy(opts.Reference)
+	if err != nil {
+		return cache.CachedBundle{}, err
+	}
+
+	var digest string
+	if !opts.Force && !trusted {
 		cachedBundle, ok, err := r.Cache.FindBundle(opts.Reference)
 		if err != nil {
 			return cache.CachedBundle{}, errors.Wrapf(err, "unable to load bundle %s from cache", opts.Reference)
 		}
 		// If we found the bundle, return the path to the bundle.json
 		if ok {
-			return cachedBundle, nil
+			var current bool
+			current, digest = r.revalidate(cachedBundle, opts)
+			if current {
+				return cachedBundle, nil
+			}
+		}
+
+		// The tag may have moved to a bundle that is already cached
+		if digest != "" {
+			cachedBundle, ok, err := r.Cache.FindBundle(getDigestReference(opts.Reference, digest))
+			if err != nil {
+				return cache.CachedBundle{}, errors.Wrapf(err, "unable to load bundle %s from cache", opts.Reference)
+			}
+			if ok {
+				r.tagBundle(opts.Reference, digest)
+				cachedBundle.Tag = opts.Reference
+				return cachedBundle, nil
+			}
+		}
+	}
+
+	pullRef := opts.Reference
+	if !isDigestReference(opts.Reference) {
+		if digest == "" {
+			digest, err = r.Registry.GetBundleDigest(opts.Reference, opts.InsecureRegistry)
+			if err != nil {
+				r.debugf("Unable to resolve the digest of %s, the bundle will be cached by its tag: %s\n", opts.Reference, err)
+				digest = ""
+			}
+		}
+		// Pull by digest so that the pulled bundle is the one that we cache under that digest
+		if digest != "" {
+			pullRef = getDigestReference(opts.Reference, digest)
 		}
 	}
 
-	b, rMap, err := r.Registry.PullBundle(opts.Reference, opts.InsecureRegistry)
+	b, rMap, err := r.Registry.PullBundle(pullRef, opts.InsecureRegistry)
 	if err != nil {
 		return cache.CachedBundle{}, err
 	}
 
-	return r.Cache.StoreBundle(opts.Reference, b, rMap)
+	if trusted {
+		err = r.Verifier.Verify(opts.Reference, b, opts.InsecureRegistry)
+		if err != nil {
+			return cache.CachedBundle{}, err
+		}
+	}
+
+	return r.Cache.StoreBundle(opts.Reference, digest, b, rMap)
+}
+
+// revalidate determines if a cached bundle is still the bundle that its tag
+// references. Tags that were checked within the cache TTL are not checked
+// again. Otherwise the registry is asked for the digest of the tag, which is
+// returned when the cached bundle is out of date.
+func (r *BundleResolver) revalidate(cb cache.CachedBundle, opts BundlePullOptions) (bool, string) {
+	// Bundles referenced by their digest never change
+	if isDigestReference(opts.Reference) {
+		return true, ""
+	}
+
+	if cb.Digest != "" && time.Since(cb.Checked) < r.getCacheTTL() {
+		return true, ""
+	}
+
+	digest, err := r.Registry.GetBundleDigest(opts.Reference, opts.InsecureRegistry)
+	if err != nil {
+		r.warnf("WARNING: unable to check if bundle %s was pushed again, using the cached bundle: %s\n", opts.Reference, err)
+		return true, ""
+	}
+	if digest == "" || digest == cb.Digest {
+		if digest != "" {
+			r.tagBundle(opts.Reference, digest)
+		}
+		return true, ""
+	}
+
+	if cb.Digest == "" {
+		r.debugf("Bundle %s was cached without its digest, pulling it again\n", opts.Reference)
+	} else {
+		r.debugf("Bundle %s was pushed again, its digest changed from %s to %s\n", opts.Reference, cb.Digest, digest)
+	}
+	return false, digest
+}
+
+// tagBundle records that the registry confirmed the digest of a tag. The
+// cached bundle is still usable when the tag index can't be updated, so this
+// only prints a warning.
+func (r *BundleResolver) tagBundle(tag string, digest string) {
+	if err := r.Cache.TagBundle(tag, digest); err != nil {
+		r.warnf("WARNING: unable to update the cache index for %s: %s\n", tag, err)
+	}
+}
+
+func (r *BundleResolver) getCacheTTL() time.Duration {
+	if r.Config == nil {
+		return 0
+	}
+
+	ttl, err := r.Config.Data.GetCacheTTL()
+	if err != nil {
+		r.warnf("WARNING: %s, cached bundle tags are always checked against the registry\n", err)
+	}
+	return ttl
+}
+
+func (r *BundleResolver) warnf(format string, args ...interface{}) {
+	if r.Config != nil {
+		fmt.Fprintf(r.Config.Err, format, args...)
+	}
+}
+
+func (r *BundleResolver) debugf(format string, args ...interface{}) {
+	if r.Config != nil && r.Config.Debug {
+		fmt.Fprintf(r.Config.Err, format, args...)
+	}
+}
+
+// isDigestReference determines if a bundle reference is pinned to a digest.
+func isDigestReference(ref string) bool {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return false
+	}
+	return isCopyDigestReference(named)
+}
+
+// getDigestReference returns the reference to the bundle in the same
+// repository as the specified reference, pinned to the digest.
+func getDigestReference(ref string, digest string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ref
+	}
+	return fmt.Sprintf("%s@%s", reference.FamiliarName(named), digest)
 }
@@ -1,6 +1,7 @@
 package porter
 
 import (
+	"errors"
 	"testing"
 
 	"get.porter.sh/porter/pkg/cache"
@@ -9,6 +10,7 @@ import (
 	"github.com/cnabio/cnab-go/bundle"
 	"github.com/cnabio/cnab-to-oci/relocation"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBundleResolver_Resolve_ForcePull(t *testing.T) {
@@ -96,3 +98,95 @@ func TestBundleResolver_Resolve_CacheMiss(t *testing.T) {
 	assert.True(t, cacheSearched, "The cache should be searched when force is not specified")
 	assert.True(t, pulled, "The bundle should have been pulled because the bundle was not in the cache")
 }
+
+func TestBundleResolver_Resolve_Revalidate(t *testing.T) {
+	const (
+		tag     = "getporter/porter-hello:latest"
+		digest1 = "sha256:a808aa4e3508d7129742eefda938249574447cce5403dc12d4cbbfe7f4f31e58"
+		digest2 = "sha256:9a85b4bbbf3c4b1e9b0c19ea9cd09dcc07d6ea99e0ce1b7ad2bd0bd6f3a6ac9b"
+	)
+
+	setup := func(t *testing.T, registryDigest string, registryErr error) (BundleResolver, *config.TestConfig, *[]string) {
+		tc := config.NewTestConfig(t)
+		testReg := cnabtooci.NewTestRegistry()
+		resolver := BundleResolver{
+			Config:   tc.Config,
+			Cache:    cache.New(tc.Config),
+			Registry: testReg,
+		}
+
+		_, err := resolver.Cache.StoreBundle(tag, digest1, bundle.Bundle{Name: "porter-hello", Version: "0.1.0"}, nil)
+		require.NoError(t, err, "StoreBundle failed")
+
+		testReg.MockGetBundleDigest = func(ref string, insecureRegistry bool) (string, error) {
+			return registryDigest, registryErr
+		}
+		var pulled []string
+		testReg.MockPullBundle = func(ref string, insecureRegistry bool) (bundle.Bundle, *relocation.ImageRelocationMap, error) {
+			pulled = append(pulled, ref)
+			return bundle.Bundle{Name: "porter-hello", Version: "0.2.0"}, nil, nil
+		}
+		return resolver, tc, &pulled
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		resolver, _, pulled := setup(t, digest1, nil)
+
+		cb, err := resolver.Resolve(BundlePullOptions{Reference: tag})
+		require.NoError(t, err, "Resolve failed")
+		assert.Empty(t, *pulled, "the bundle should not be pulled when the digest is unchanged")
+		assert.Equal(t, "0.1.0", cb.Bundle.Version)
+	})
+
+	t.Run("pushed again", func(t *testing.T) {
+		resolver, _, pulled := setup(t, digest2, nil)
+
+		cb, err := resolver.Resolve(BundlePullOptions{Reference: tag})
+		require.NoError(t, err, "Resolve failed")
+		assert.Equal(t, []string{"getporter/porter-hello@" + digest2}, *pulled, "the bundle should be pulled by its new digest")
+		assert.Equal(t, "0.2.0", cb.Bundle.Version)
+		assert.Equal(t, digest2, cb.Digest)
+	})
+
+	t.Run("moved to a cached bundle", func(t *testing.T) {
+		resolver, _, pulled := setup(t, digest2, nil)
+		_, err := resolver.Cache.StoreBundle("getporter/porter-hello:v0.2.0", digest2, bundle.Bundle{Name: "porter-hello", Version: "0.2.0"}, nil)
+		require.NoError(t, err, "StoreBundle failed")
+
+		cb, err := resolver.Resolve(BundlePullOptions{Reference: tag})
+		require.NoError(t, err, "Resolve failed")
+		assert.Empty(t, *pulled, "the bundle should be used from the cache")
+		assert.Equal(t, "0.2.0", cb.Bundle.Version)
+		assert.Equal(t, tag, cb.Tag)
+	})
+
+	t.Run("within ttl", func(t *testing.T) {
+		resolver, tc, pulled := setup(t, "", errors.New("the registry should not be checked"))
+		tc.Data = &config.Data{CacheTTL: "1h"}
+
+		cb, err := resolver.Resolve(BundlePullOptions{Reference: tag})
+		require.NoError(t, err, "Resolve failed")
+		assert.Empty(t, *pulled)
+		assert.Equal(t, "0.1.0", cb.Bundle.Version)
+		assert.Empty(t, tc.TestContext.GetError())
+	})
+
+	t.Run("registry unavailable", func(t *testing.T) {
+		resolver, tc, pulled := setup(t, "", errors.New("connection refused"))
+
+		cb, err := resolver.Resolve(BundlePullOptions{Reference: tag})
+		require.NoError(t, err, "Resolve failed")
+		assert.Empty(t, *pulled, "the cached bundle should be used when the registry is unavailable")
+		assert.Equal(t, "0.1.0", cb.Bundle.Version)
+		assert.Contains(t, tc.TestContext.GetError(), "WARNING: unable to check if bundle getporter/porter-hello:latest was pushed again, using the cached bundle: connection refused")
+	})
+
+	t.Run("digest reference", func(t *testing.T) {
+		resolver, _, pulled := setup(t, "", errors.New("the registry should not be checked"))
+
+		cb, err := resolver.Resolve(BundlePullOptions{Reference: "getporter/porter-hello@" + digest1})
+		require.NoError(t, err, "Resolve failed")
+		assert.Empty(t, *pulled)
+		assert.Equal(t, "0.1.0", cb.Bundle.Version)
+	})
+}
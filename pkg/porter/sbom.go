@@ -0,0 +1,548 @@
+package porter
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"get.porter.sh/porter/pkg"
+	configadapter "get.porter.sh/porter/pkg/cnab/config-adapter"
+	"get.porter.sh/porter/pkg/cnab/extensions"
+	"get.porter.sh/porter/pkg/context"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SBOMFormatSPDX is the SPDX 2.2 JSON format.
+	SBOMFormatSPDX = "spdx"
+
+	// SBOMFormatCycloneDX is the CycloneDX 1.3 JSON format.
+	SBOMFormatCycloneDX = "cyclonedx"
+)
+
+// SBOMOptions are the options for generating a software bill of materials for a bundle.
+type SBOMOptions struct {
+	BundleActionOptions
+
+	// Format of the generated document: spdx or cyclonedx.
+	Format string
+}
+
+// Validate the sbom options. The bundle reference may be specified as a
+// positional argument instead of with --reference.
+func (o *SBOMOptions) Validate(args []string, cxt *context.Context) error {
+	switch len(args) {
+	case 0:
+	case 1:
+		o.Reference = args[0]
+	default:
+		return errors.Errorf("only one positional argument may be specified, the bundle reference, but multiple were received: %s", args)
+	}
+
+	o.checkForDeprecatedTagValue()
+
+	switch o.Format {
+	case "":
+		o.Format = SBOMFormatSPDX
+	case SBOMFormatSPDX, SBOMFormatCycloneDX:
+	default:
+		return errors.Errorf("invalid --format %s, allowed values are: %s, %s", o.Format, SBOMFormatSPDX, SBOMFormatCycloneDX)
+	}
+
+	if o.Reference != "" {
+		o.File = ""
+		o.CNABFile = ""
+
+		return o.validateReference()
+	}
+
+	return o.bundleFileOptions.Validate(cxt)
+}
+
+// bundleInventory is everything that went into a bundle, independent of the
+// format that it is printed in.
+type bundleInventory struct {
+	Name          string
+	Version       string
+	Description   string
+	Reference     string
+	Digest        string
+	PorterVersion string
+	Mixins        []inventoryMixin
+	Dependencies  []extensions.DependencyLock
+	Images        []inventoryImage
+}
+
+type inventoryMixin struct {
+	Name    string
+	Version string
+}
+
+type inventoryImage struct {
+	// Name of the image in the bundle, empty for invocation images.
+	Name string
+
+	// Image is where the image is pulled from, after relocation.
+	Image string
+
+	// Original location of the image, when it was relocated.
+	Original string
+
+	Digest          string
+	ImageType       string
+	InvocationImage bool
+}
+
+// GenerateSBOM prints a software bill of materials for a bundle, listing the
+// bundle, its dependencies, images and the mixins that it was built with.
+func (p *Porter) GenerateSBOM(opts SBOMOptions) error {
+	inv, err := p.getBundleInventory(opts)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+	switch opts.Format {
+	case SBOMFormatSPDX:
+		doc, err = generateSPDXDocument(inv, time.Now())
+	case SBOMFormatCycloneDX:
+		doc, err = generateCycloneDXDocument(inv, time.Now())
+	default:
+		return errors.Errorf("invalid format: %s", opts.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the sbom")
+	}
+	fmt.Fprintln(p.Out, string(data))
+	return nil
+}
+
+// getBundleInventory loads the bundle from a registry, or the local bundle,
+// and collects its contents.
+func (p *Porter) getBundleInventory(opts SBOMOptions) (bundleInventory, error) {
+	var bun bundle.Bundle
+	var reloMap map[string]string
+	inv := bundleInventory{}
+
+	if opts.Reference != "" {
+		cachedBundle, err := p.PullBundle(opts.BundlePullOptions)
+		if err != nil {
+			return bundleInventory{}, errors.Wrapf(err, "unable to pull bundle %s", opts.Reference)
+		}
+		bun = cachedBundle.Bundle
+		if cachedBundle.RelocationMap != nil {
+			reloMap = *cachedBundle.RelocationMap
+		}
+		inv.Reference = opts.Reference
+		inv.Digest = cachedBundle.Digest
+	} else {
+		err := p.applyDefaultOptions(&opts.sharedOptions)
+		if err != nil {
+			return bundleInventory{}, err
+		}
+		err = p.ensureLocalBundleIsUpToDate(opts.bundleFileOptions)
+		if err != nil {
+			return bundleInventory{}, err
+		}
+		bun, err = p.CNAB.LoadBundle(opts.CNABFile)
+		if err != nil {
+			return bundleInventory{}, errors.Wrap(err, "unable to load bundle")
+		}
+		if opts.RelocationMapping != "" {
+			reloBytes, err := p.FileSystem.ReadFile(opts.RelocationMapping)
+			if err != nil {
+				return bundleInventory{}, errors.Wrap(err, "unable to read provided relocation mapping")
+			}
+			err = json.Unmarshal(reloBytes, &reloMap)
+			if err != nil {
+				return bundleInventory{}, errors.Wrap(err, "unable to load provided relocation mapping")
+			}
+		}
+	}
+
+	inv.Name = bun.Name
+	inv.Version = bun.Version
+	inv.Description = bun.Description
+
+	if configadapter.IsPorterBundle(bun) {
+		stamp, err := configadapter.LoadStamp(bun)
+		if err != nil {
+			return bundleInventory{}, err
+		}
+		inv.PorterVersion = stamp.Version
+		for name, mixin := range stamp.Mixins {
+			inv.Mixins = append(inv.Mixins, inventoryMixin{Name: name, Version: mixin.Version})
+		}
+		sort.Slice(inv.Mixins, func(i, j int) bool {
+			return inv.Mixins[i].Name < inv.Mixins[j].Name
+		})
+		inv.Dependencies = stamp.Dependencies
+	}
+
+	// Bundles built before dependencies were locked are resolved now
+	if inv.Dependencies == nil {
+		solver := p.newDependencySolver(opts.InsecureRegistry)
+		deps, err := solver.ResolveDependencies(bun)
+		if err != nil {
+			return bundleInventory{}, errors.Wrap(err, "could not resolve the bundle dependencies")
+		}
+		inv.Dependencies = deps
+	}
+
+	invocationImages, images := handleInspectRelocate(bun, reloMap)
+	for _, img := range invocationImages {
+		inv.Images = append(inv.Images, inventoryImage{
+			Image:           img.Image,
+			Original:        img.Original,
+			Digest:          img.Digest,
+			ImageType:       img.ImageType,
+			InvocationImage: true,
+		})
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].Name < images[j].Name
+	})
+	for _, img := range images {
+		inv.Images = append(inv.Images, inventoryImage{
+			Name:      img.Name,
+			Image:     img.Image.Image,
+			Original:  img.Original,
+			Digest:    img.Digest,
+			ImageType: img.ImageType,
+		})
+	}
+
+	return inv, nil
+}
+
+// getOCIPackageURL returns the package url (purl) for an image or bundle in a
+// registry, for example pkg:oci/porter-hello@sha256%3A123?repository_url=docker.io/getporter/porter-hello&tag=v0.1.0
+func getOCIPackageURL(ref string, digest string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ""
+	}
+
+	path := reference.Path(named)
+	name := path[strings.LastIndex(path, "/")+1:]
+
+	if digest == "" {
+		if digested, ok := named.(reference.Digested); ok {
+			digest = digested.Digest().String()
+		}
+	}
+
+	purl := "pkg:oci/" + name
+	if digest != "" {
+		purl += "@" + strings.Replace(digest, ":", "%3A", 1)
+	}
+
+	qualifiers := []string{"repository_url=" + named.Name()}
+	if tagged, ok := named.(reference.Tagged); ok {
+		qualifiers = append(qualifiers, "tag="+tagged.Tag())
+	}
+	return purl + "?" + strings.Join(qualifiers, "&")
+}
+
+// splitDigest splits a digest such as sha256:123 into its algorithm and hash.
+func splitDigest(digest string) (string, string, bool) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// newUUID generates a random (version 4) UUID.
+func newUUID() (string, error) {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		return "", errors.Wrap(err, "could not generate a uuid")
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:]), nil
+}
+
+// SPDX document, https://spdx.github.io/spdx-spec/v2.2.2/
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	Description      string            `json:"description,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	SourceInfo       string            `json:"sourceInfo,omitempty"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+const spdxNoAssertion = "NOASSERTION"
+
+var spdxInvalidIDChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// getSPDXID returns an SPDX identifier, replacing characters that aren't allowed.
+func getSPDXID(kind string, name string) string {
+	return fmt.Sprintf("SPDXRef-%s-%s", kind, spdxInvalidIDChars.ReplaceAllString(name, "-"))
+}
+
+func newSPDXPackage(id string, name string, version string, location string, digest string) spdxPackage {
+	sp := spdxPackage{
+		SPDXID:           id,
+		Name:             name,
+		VersionInfo:      version,
+		DownloadLocation: spdxNoAssertion,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		CopyrightText:    spdxNoAssertion,
+	}
+	if location != "" {
+		sp.DownloadLocation = location
+		if purl := getOCIPackageURL(location, digest); purl != "" {
+			sp.ExternalRefs = []spdxExternalRef{
+				{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: purl},
+			}
+		}
+	}
+	if algorithm, hash, ok := splitDigest(digest); ok {
+		sp.Checksums = []spdxChecksum{
+			{Algorithm: strings.ToUpper(algorithm), ChecksumValue: hash},
+		}
+	}
+	return sp
+}
+
+func generateSPDXDocument(inv bundleInventory, now time.Time) (spdxDocument, error) {
+	id, err := newUUID()
+	if err != nil {
+		return spdxDocument{}, err
+	}
+
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.2",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              fmt.Sprintf("%s-%s", inv.Name, inv.Version),
+		DocumentNamespace: fmt.Sprintf("https://porter.sh/spdx/%s-%s-%s", inv.Name, inv.Version, id),
+		CreationInfo: spdxCreationInfo{
+			Created:  now.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: porter-" + pkg.Version},
+		},
+	}
+
+	bunPkg := newSPDXPackage(getSPDXID("Bundle", inv.Name), inv.Name, inv.Version, inv.Reference, inv.Digest)
+	bunPkg.Description = inv.Description
+	doc.Packages = append(doc.Packages, bunPkg)
+	doc.Relationships = append(doc.Relationships, spdxRelationship{
+		SPDXElementID: doc.SPDXID, RelationshipType: "DESCRIBES", RelatedSPDXElement: bunPkg.SPDXID,
+	})
+
+	for i, img := range inv.Images {
+		name := img.Name
+		if img.InvocationImage {
+			name = fmt.Sprintf("invocation-image-%d", i)
+		}
+		imgPkg := newSPDXPackage(getSPDXID("Image", name), img.Image, "", img.Image, img.Digest)
+		if img.Original != "" {
+			imgPkg.SourceInfo = "relocated from " + img.Original
+		}
+		doc.Packages = append(doc.Packages, imgPkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID: bunPkg.SPDXID, RelationshipType: "CONTAINS", RelatedSPDXElement: imgPkg.SPDXID,
+		})
+	}
+
+	for _, dep := range inv.Dependencies {
+		depPkg := newSPDXPackage(getSPDXID("Dependency", dep.Alias), dep.Alias, "", dep.Reference, dep.Digest)
+		doc.Packages = append(doc.Packages, depPkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID: bunPkg.SPDXID, RelationshipType: "DEPENDS_ON", RelatedSPDXElement: depPkg.SPDXID,
+		})
+	}
+
+	if inv.PorterVersion != "" {
+		porterPkg := newSPDXPackage("SPDXRef-Porter", "porter", inv.PorterVersion, "", "")
+		doc.Packages = append(doc.Packages, porterPkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID: porterPkg.SPDXID, RelationshipType: "BUILD_TOOL_OF", RelatedSPDXElement: bunPkg.SPDXID,
+		})
+	}
+
+	for _, mixin := range inv.Mixins {
+		mixinPkg := newSPDXPackage(getSPDXID("Mixin", mixin.Name), mixin.Name, mixin.Version, "", "")
+		doc.Packages = append(doc.Packages, mixinPkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{
+			SPDXElementID: mixinPkg.SPDXID, RelationshipType: "BUILD_TOOL_OF", RelatedSPDXElement: bunPkg.SPDXID,
+		})
+	}
+
+	return doc, nil
+}
+
+// CycloneDX document, https://cyclonedx.org/docs/1.3/json/
+type cycloneDXDocument struct {
+	BOMFormat    string                `json:"bomFormat"`
+	SpecVersion  string                `json:"specVersion"`
+	SerialNumber string                `json:"serialNumber"`
+	Version      int                   `json:"version"`
+	Metadata     cycloneDXMetadata     `json:"metadata"`
+	Components   []cycloneDXComponent  `json:"components"`
+	Dependencies []cycloneDXDependency `json:"dependencies"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Tools     []cycloneDXTool    `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXTool struct {
+	Vendor  string `json:"vendor"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type cycloneDXComponent struct {
+	BOMRef      string              `json:"bom-ref"`
+	Type        string              `json:"type"`
+	Name        string              `json:"name"`
+	Version     string              `json:"version,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Hashes      []cycloneDXHash     `json:"hashes,omitempty"`
+	PURL        string              `json:"purl,omitempty"`
+	Properties  []cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+func newCycloneDXComponent(bomRef string, componentType string, name string, version string, location string, digest string, kind string) cycloneDXComponent {
+	c := cycloneDXComponent{
+		BOMRef:     bomRef,
+		Type:       componentType,
+		Name:       name,
+		Version:    version,
+		Properties: []cycloneDXProperty{{Name: "porter:type", Value: kind}},
+	}
+	if location != "" {
+		c.PURL = getOCIPackageURL(location, digest)
+	}
+	if algorithm, hash, ok := splitDigest(digest); ok && algorithm == "sha256" {
+		c.Hashes = []cycloneDXHash{{Algorithm: "SHA-256", Content: hash}}
+	}
+	return c
+}
+
+func generateCycloneDXDocument(inv bundleInventory, now time.Time) (cycloneDXDocument, error) {
+	id, err := newUUID()
+	if err != nil {
+		return cycloneDXDocument{}, err
+	}
+
+	bun := newCycloneDXComponent("bundle:"+inv.Name, "application", inv.Name, inv.Version, inv.Reference, inv.Digest, "bundle")
+	bun.Description = inv.Description
+	if inv.PorterVersion != "" {
+		bun.Properties = append(bun.Properties, cycloneDXProperty{Name: "porter:version", Value: inv.PorterVersion})
+	}
+
+	doc := cycloneDXDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.3",
+		SerialNumber: "urn:uuid:" + id,
+		Version:      1,
+		Metadata: cycloneDXMetadata{
+			Timestamp: now.UTC().Format(time.RFC3339),
+			Tools:     []cycloneDXTool{{Vendor: "Porter", Name: "porter", Version: pkg.Version}},
+			Component: bun,
+		},
+		Components: []cycloneDXComponent{},
+	}
+
+	bunDeps := cycloneDXDependency{Ref: bun.BOMRef}
+	for _, img := range inv.Images {
+		kind := "image"
+		if img.InvocationImage {
+			kind = "invocation-image"
+		}
+		c := newCycloneDXComponent("image:"+img.Image, "container", img.Image, "", img.Image, img.Digest, kind)
+		if img.Original != "" {
+			c.Properties = append(c.Properties, cycloneDXProperty{Name: "porter:originalImage", Value: img.Original})
+		}
+		doc.Components = append(doc.Components, c)
+		bunDeps.DependsOn = append(bunDeps.DependsOn, c.BOMRef)
+	}
+
+	for _, dep := range inv.Dependencies {
+		c := newCycloneDXComponent("dependency:"+dep.Alias, "application", dep.Alias, "", dep.Reference, dep.Digest, "dependency")
+		doc.Components = append(doc.Components, c)
+		bunDeps.DependsOn = append(bunDeps.DependsOn, c.BOMRef)
+	}
+
+	for _, mixin := range inv.Mixins {
+		c := newCycloneDXComponent("mixin:"+mixin.Name, "application", mixin.Name, mixin.Version, "", "", "mixin")
+		doc.Components = append(doc.Components, c)
+		bunDeps.DependsOn = append(bunDeps.DependsOn, c.BOMRef)
+	}
+
+	doc.Dependencies = []cycloneDXDependency{bunDeps}
+	return doc, nil
+}
@@ -0,0 +1,190 @@
+package porter
+
+import (
+	"encoding/json"
+	"testing"
+
+	configadapter "get.porter.sh/porter/pkg/cnab/config-adapter"
+	"get.porter.sh/porter/pkg/cnab/extensions"
+	"get.porter.sh/porter/pkg/config"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-to-oci/relocation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSBOMDigest = "sha256:b8c6ce3e6c13b29e2a1a7d3dca3b6ec9fb0a7e2d8c4f0c0c52bfdb5e2ec1a3c4"
+
+func TestSBOMOptions_Validate(t *testing.T) {
+	testcases := []struct {
+		name      string
+		args      []string
+		opts      SBOMOptions
+		wantRef   string
+		wantError string
+	}{
+		{name: "positional reference", args: []string{"getporter/porter-hello:v0.1.0"}, wantRef: "getporter/porter-hello:v0.1.0"},
+		{name: "too many args", args: []string{"a", "b"}, wantError: "only one positional argument may be specified, the bundle reference, but multiple were received: [a b]"},
+		{name: "bad format", args: []string{"getporter/porter-hello:v0.1.0"}, opts: SBOMOptions{Format: "swid"}, wantError: "invalid --format swid, allowed values are: spdx, cyclonedx"},
+		{name: "bad reference", args: []string{"getporter/porter-hello:"}, wantError: "invalid value for --reference, specified value should be of the form REGISTRY/bundle:tag: invalid reference format"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewTestPorter(t)
+
+			err := tc.opts.Validate(tc.args, p.Context)
+			if tc.wantError != "" {
+				require.EqualError(t, err, tc.wantError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantRef, tc.opts.Reference)
+				assert.Equal(t, SBOMFormatSPDX, tc.opts.Format)
+			}
+		})
+	}
+}
+
+func TestGetOCIPackageURL(t *testing.T) {
+	assert.Equal(t, "pkg:oci/porter-hello@sha256%3A123?repository_url=docker.io/getporter/porter-hello&tag=v0.1.0",
+		getOCIPackageURL("getporter/porter-hello:v0.1.0", "sha256:123"))
+	assert.Equal(t, "pkg:oci/whalesay?repository_url=localhost:5000/whalesay&tag=latest",
+		getOCIPackageURL("localhost:5000/whalesay:latest", ""))
+	assert.Equal(t, "", getOCIPackageURL("INVALID", ""))
+}
+
+// setupSBOMBundle mocks pulling a porter bundle with images, a relocation
+// mapping and locked dependencies.
+func setupSBOMBundle(t *testing.T) *TestPorter {
+	p := NewTestPorter(t)
+	p.Data = &config.Data{}
+
+	stamp := configadapter.Stamp{
+		Version: "v0.38.0",
+		Mixins: map[string]configadapter.MixinRecord{
+			"helm3": {Version: "v0.1.14"},
+			"exec":  {Version: "v0.38.0"},
+		},
+		Dependencies: []extensions.DependencyLock{
+			{Alias: "mysql", Reference: "getporter/mysql:v0.1.0", Digest: "sha256:abc"},
+		},
+	}
+	bun := bundle.Bundle{
+		Name:        "wordpress",
+		Version:     "0.1.0",
+		Description: "WordPress with MySQL",
+		InvocationImages: []bundle.InvocationImage{
+			{BaseImage: bundle.BaseImage{Image: "getporter/wordpress-installer:v0.1.0", ImageType: "docker", Digest: "sha256:111"}},
+		},
+		Images: map[string]bundle.Image{
+			"wordpress": {BaseImage: bundle.BaseImage{Image: "bitnami/wordpress:5.5", ImageType: "docker", Digest: "sha256:222"}},
+		},
+		Custom: map[string]interface{}{
+			config.CustomPorterKey: stamp,
+		},
+	}
+
+	p.TestRegistry.MockGetBundleDigest = func(tag string, insecureRegistry bool) (string, error) {
+		return testSBOMDigest, nil
+	}
+	p.TestRegistry.MockPullBundle = func(tag string, insecureRegistry bool) (bundle.Bundle, *relocation.ImageRelocationMap, error) {
+		reloMap := relocation.ImageRelocationMap{
+			"getporter/wordpress-installer:v0.1.0": "example.com/wordpress@sha256:111",
+			"bitnami/wordpress:5.5":                "example.com/wordpress@sha256:222",
+		}
+		return bun, &reloMap, nil
+	}
+	return p
+}
+
+func TestPorter_GenerateSBOM_SPDX(t *testing.T) {
+	p := setupSBOMBundle(t)
+
+	opts := SBOMOptions{}
+	require.NoError(t, opts.Validate([]string{"example.com/wordpress:v0.1.0"}, p.Context))
+	require.NoError(t, p.GenerateSBOM(opts))
+
+	var doc spdxDocument
+	require.NoError(t, json.Unmarshal([]byte(p.TestConfig.TestContext.GetOutput()), &doc))
+
+	assert.Equal(t, "SPDX-2.2", doc.SPDXVersion)
+	assert.Equal(t, "wordpress-0.1.0", doc.Name)
+	assert.Contains(t, doc.DocumentNamespace, "https://porter.sh/spdx/wordpress-0.1.0-")
+
+	packages := make(map[string]spdxPackage, len(doc.Packages))
+	for _, pkg := range doc.Packages {
+		packages[pkg.SPDXID] = pkg
+	}
+	require.Len(t, packages, 7, "expected the bundle, 2 images, 1 dependency, porter and 2 mixins")
+
+	bun := packages["SPDXRef-Bundle-wordpress"]
+	assert.Equal(t, "0.1.0", bun.VersionInfo)
+	assert.Equal(t, []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: testSBOMDigest[len("sha256:"):]}}, bun.Checksums)
+	require.Len(t, bun.ExternalRefs, 1)
+	assert.Equal(t, "pkg:oci/wordpress@sha256%3A"+testSBOMDigest[len("sha256:"):]+"?repository_url=example.com/wordpress&tag=v0.1.0", bun.ExternalRefs[0].ReferenceLocator)
+
+	installer := packages["SPDXRef-Image-invocation-image-0"]
+	assert.Equal(t, "example.com/wordpress@sha256:111", installer.Name)
+	assert.Equal(t, "relocated from getporter/wordpress-installer:v0.1.0", installer.SourceInfo)
+	assert.Equal(t, []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: "111"}}, installer.Checksums)
+
+	wordpress := packages["SPDXRef-Image-wordpress"]
+	assert.Equal(t, "relocated from bitnami/wordpress:5.5", wordpress.SourceInfo)
+
+	mysql := packages["SPDXRef-Dependency-mysql"]
+	assert.Equal(t, "getporter/mysql:v0.1.0", mysql.DownloadLocation)
+
+	assert.Equal(t, "v0.38.0", packages["SPDXRef-Porter"].VersionInfo)
+	assert.Equal(t, "v0.1.14", packages["SPDXRef-Mixin-helm3"].VersionInfo)
+	assert.Equal(t, "v0.38.0", packages["SPDXRef-Mixin-exec"].VersionInfo)
+
+	assert.Contains(t, doc.Relationships, spdxRelationship{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: bun.SPDXID})
+	assert.Contains(t, doc.Relationships, spdxRelationship{SPDXElementID: bun.SPDXID, RelationshipType: "CONTAINS", RelatedSPDXElement: wordpress.SPDXID})
+	assert.Contains(t, doc.Relationships, spdxRelationship{SPDXElementID: bun.SPDXID, RelationshipType: "DEPENDS_ON", RelatedSPDXElement: mysql.SPDXID})
+	assert.Contains(t, doc.Relationships, spdxRelationship{SPDXElementID: "SPDXRef-Mixin-helm3", RelationshipType: "BUILD_TOOL_OF", RelatedSPDXElement: bun.SPDXID})
+}
+
+func TestPorter_GenerateSBOM_CycloneDX(t *testing.T) {
+	p := setupSBOMBundle(t)
+
+	opts := SBOMOptions{Format: SBOMFormatCycloneDX}
+	require.NoError(t, opts.Validate([]string{"example.com/wordpress:v0.1.0"}, p.Context))
+	require.NoError(t, p.GenerateSBOM(opts))
+
+	var doc cycloneDXDocument
+	require.NoError(t, json.Unmarshal([]byte(p.TestConfig.TestContext.GetOutput()), &doc))
+
+	assert.Equal(t, "CycloneDX", doc.BOMFormat)
+	assert.Equal(t, "1.3", doc.SpecVersion)
+	assert.Contains(t, doc.SerialNumber, "urn:uuid:")
+
+	bun := doc.Metadata.Component
+	assert.Equal(t, "wordpress", bun.Name)
+	assert.Equal(t, []cycloneDXHash{{Algorithm: "SHA-256", Content: testSBOMDigest[len("sha256:"):]}}, bun.Hashes)
+	assert.Contains(t, bun.Properties, cycloneDXProperty{Name: "porter:version", Value: "v0.38.0"})
+
+	var refs []string
+	for _, c := range doc.Components {
+		refs = append(refs, c.BOMRef)
+	}
+	wantRefs := []string{
+		"image:example.com/wordpress@sha256:111",
+		"image:example.com/wordpress@sha256:222",
+		"dependency:mysql",
+		"mixin:exec",
+		"mixin:helm3",
+	}
+	assert.Equal(t, wantRefs, refs)
+
+	installer := doc.Components[0]
+	assert.Equal(t, "container", installer.Type)
+	assert.Contains(t, installer.Properties, cycloneDXProperty{Name: "porter:type", Value: "invocation-image"})
+	assert.Contains(t, installer.Properties, cycloneDXProperty{Name: "porter:originalImage", Value: "getporter/wordpress-installer:v0.1.0"})
+
+	helm := doc.Components[4]
+	assert.Equal(t, "v0.1.14", helm.Version)
+
+	require.Len(t, doc.Dependencies, 1)
+	assert.Equal(t, bun.BOMRef, doc.Dependencies[0].Ref)
+	assert.Equal(t, wantRefs, doc.Dependencies[0].DependsOn)
+}
@@ -125,6 +125,9 @@ func (p *Porter) injectMixinSchemas(manifestSchema jsonSchema) (jsonSchema, erro
 
 		mixinEnumSchema = append(mixinEnumSchema, mixin)
 
+		// Allow each step to specify how porter should retry it
+		injectStepRetrySchema(mixinSchemaMap, append(coreActions, "invoke"))
+
 		// embed the entire mixin schema in the root
 		manifestSchema["mixin."+mixin] = mixinSchemaMap
 
@@ -176,6 +179,27 @@ func (p *Porter) injectMixinSchemas(manifestSchema jsonSchema) (jsonSchema, erro
 	return manifestSchema, err
 }
 
+// injectStepRetrySchema adds the retry field, which is handled by porter
+// instead of the mixin, to the step definitions of a mixin schema.
+func injectStepRetrySchema(mixinSchema jsonSchema, actions []string) {
+	definitions, ok := mixinSchema["definitions"].(jsonSchema)
+	if !ok {
+		return
+	}
+
+	for _, action := range actions {
+		stepSchema, ok := definitions[action+"Step"].(jsonSchema)
+		if !ok {
+			continue
+		}
+		stepProperties, ok := stepSchema["properties"].(jsonSchema)
+		if !ok {
+			continue
+		}
+		stepProperties["retry"] = jsonObject{"$ref": "#/definitions/stepRetry"}
+	}
+}
+
 func (p *Porter) GetReplacementSchema() (jsonSchema, error) {
 	home, err := p.GetHomeDir()
 	if err != nil {
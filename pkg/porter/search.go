@@ -0,0 +1,263 @@
+package porter
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	cnabtooci "get.porter.sh/porter/pkg/cnab/cnab-to-oci"
+	"get.porter.sh/porter/pkg/cnab/extensions"
+	"get.porter.sh/porter/pkg/printer"
+	"github.com/Masterminds/semver/v3"
+	dtprinter "github.com/carolynvs/datetime-printer"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-to-oci/relocation"
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
+)
+
+const (
+	// BundleSearchSortVersion sorts the tags by semantic version, newest first.
+	// Tags that are not semantic versions are sorted by name after the versions.
+	BundleSearchSortVersion = "version"
+
+	// BundleSearchSortCreated sorts the tags by when the invocation image was created, newest first.
+	BundleSearchSortCreated = "created"
+
+	// BundleSearchSortTag sorts the tags by name.
+	BundleSearchSortTag = "tag"
+)
+
+// BundleSearchOptions are the options for listing the bundles in a repository.
+type BundleSearchOptions struct {
+	printer.PrintOptions
+
+	// Repository to search, in the format REGISTRY/NAME.
+	Repository string
+
+	// Version is a semver constraint, such as ^1.2, that the tags must satisfy.
+	Version string
+
+	// IncludePrereleases includes prerelease versions when filtering by Version.
+	IncludePrereleases bool
+
+	// Sort order of the tags: version, created or tag.
+	Sort string
+
+	InsecureRegistry bool
+
+	constraint *semver.Constraints
+}
+
+// Validate the bundle search options.
+func (o *BundleSearchOptions) Validate(args []string) error {
+	switch len(args) {
+	case 0:
+		return errors.New("the repository to search is required, for example getporter/porter-hello")
+	case 1:
+		o.Repository = args[0]
+	default:
+		return errors.Errorf("only one positional argument may be specified, the repository, but multiple were received: %s", args)
+	}
+
+	ref, err := reference.ParseNormalizedNamed(o.Repository)
+	if err != nil {
+		return errors.Wrapf(err, "invalid repository %s, expected REGISTRY/NAME", o.Repository)
+	}
+	if !reference.IsNameOnly(ref) {
+		return errors.Errorf("invalid repository %s, specify the repository without a tag or digest", o.Repository)
+	}
+	o.Repository = ref.Name()
+
+	if o.Version != "" {
+		o.constraint, err = semver.NewConstraint(o.Version)
+		if err != nil {
+			return errors.Wrapf(err, "invalid --version %s", o.Version)
+		}
+	} else if o.IncludePrereleases {
+		return errors.New("--include-prereleases can only be used with --version")
+	}
+
+	switch o.Sort {
+	case "":
+		o.Sort = BundleSearchSortVersion
+	case BundleSearchSortVersion, BundleSearchSortCreated, BundleSearchSortTag:
+	default:
+		return errors.Errorf("invalid --sort %s, allowed values are: %s, %s, %s", o.Sort,
+			BundleSearchSortVersion, BundleSearchSortCreated, BundleSearchSortTag)
+	}
+
+	return o.ParseFormat()
+}
+
+// DisplayBundleTag is a tag in a repository and the metadata of the bundle that it references.
+type DisplayBundleTag struct {
+	Tag         string    `json:"tag" yaml:"tag"`
+	Name        string    `json:"name" yaml:"name"`
+	Version     string    `json:"version" yaml:"version"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+	Digest      string    `json:"digest" yaml:"digest"`
+	Created     time.Time `json:"created,omitempty" yaml:"created,omitempty"`
+
+	// version is the tag parsed as a semantic version, nil when it isn't one.
+	version *semver.Version
+}
+
+// SearchBundles lists the tags in a repository with the metadata of each bundle.
+func (p *Porter) SearchBundles(opts BundleSearchOptions) error {
+	tags, err := p.ListBundleTags(opts)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Format {
+	case printer.FormatJson:
+		return printer.PrintJson(p.Out, tags)
+	case printer.FormatYaml:
+		return printer.PrintYaml(p.Out, tags)
+	case printer.FormatTable:
+		// have every row use the same "now" starting ... NOW!
+		now := time.Now()
+		tp := dtprinter.DateTimePrinter{
+			Now: func() time.Time { return now },
+		}
+
+		row :=
+			func(v interface{}) []interface{} {
+				t, ok := v.(DisplayBundleTag)
+				if !ok {
+					return nil
+				}
+				created := ""
+				if !t.Created.IsZero() {
+					created = tp.Format(t.Created)
+				}
+				return []interface{}{t.Tag, t.Name, t.Version, t.Description, t.Digest, created}
+			}
+		return printer.PrintTable(p.Out, tags, row,
+			"TAG", "NAME", "VERSION", "DESCRIPTION", "DIGEST", "CREATED")
+	default:
+		return fmt.Errorf("invalid format: %s", opts.Format)
+	}
+}
+
+// ListBundleTags returns the tags in a repository that reference a bundle,
+// filtered and sorted by the search options.
+func (p *Porter) ListBundleTags(opts BundleSearchOptions) ([]DisplayBundleTag, error) {
+	tags, err := p.Registry.ListTags(opts.Repository, opts.InsecureRegistry)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.constraint != nil {
+		versions := extensions.FilterVersions(tags, opts.IncludePrereleases, []*semver.Constraints{opts.constraint})
+		tags = make([]string, 0, len(versions))
+		for _, v := range versions {
+			tags = append(tags, v.Original())
+		}
+	}
+
+	results := make([]DisplayBundleTag, 0, len(tags))
+	for _, tag := range tags {
+		version, _ := semver.NewVersion(tag)
+		ref := fmt.Sprintf("%s:%s", opts.Repository, tag)
+		result, ok, err := p.getBundleTag(ref, opts.InsecureRegistry)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		result.Tag = tag
+		result.version = version
+		results = append(results, result)
+	}
+
+	sortBundleTags(results, opts.Sort)
+	return results, nil
+}
+
+// getBundleTag pulls the bundle.json for a tag. Tags that don't reference a
+// bundle, such as bundle signatures, are skipped. Any other error, such as
+// failing to authenticate to the registry, is returned.
+func (p *Porter) getBundleTag(ref string, insecureRegistry bool) (DisplayBundleTag, bool, error) {
+	digest, err := p.Registry.GetBundleDigest(ref, insecureRegistry)
+	if err != nil {
+		// The tag was removed after the tags were listed
+		if cnabtooci.IsNotFound(err) {
+			if p.Debug {
+				fmt.Fprintf(p.Err, "Skipping %s: %s\n", ref, err)
+			}
+			return DisplayBundleTag{}, false, nil
+		}
+		return DisplayBundleTag{}, false, err
+	}
+
+	// Pull by digest so that the metadata is for the bundle with the digest that is displayed
+	bun, rm, err := p.Registry.PullBundle(getDigestReference(ref, digest), insecureRegistry)
+	if err != nil {
+		if cnabtooci.IsNotABundle(err) {
+			if p.Debug {
+				fmt.Fprintf(p.Err, "Skipping %s, it is not a bundle: %s\n", ref, err)
+			}
+			return DisplayBundleTag{}, false, nil
+		}
+		return DisplayBundleTag{}, false, errors.Wrapf(err, "unable to pull bundle %s", ref)
+	}
+
+	result := DisplayBundleTag{
+		Name:        bun.Name,
+		Version:     bun.Version,
+		Description: bun.Description,
+		Digest:      digest,
+	}
+
+	// The bundle.json doesn't record when it was built, use the invocation image instead
+	if len(bun.InvocationImages) > 0 {
+		img := getSearchImageReference(bun.InvocationImages[0].BaseImage, rm)
+		result.Created, err = p.Registry.GetImageCreated(img, insecureRegistry)
+		if err != nil && p.Debug {
+			fmt.Fprintf(p.Err, "Could not determine when %s was created: %s\n", ref, err)
+		}
+	}
+
+	return result, true, nil
+}
+
+// getSearchImageReference returns where an image from a bundle can be pulled,
+// preferring the relocated image and pinning it to its digest.
+func getSearchImageReference(img bundle.BaseImage, rm *relocation.ImageRelocationMap) string {
+	if rm != nil {
+		if relocated, ok := (*rm)[img.Image]; ok {
+			return relocated
+		}
+	}
+	if img.Digest != "" {
+		if named, err := reference.ParseNormalizedNamed(img.Image); err == nil {
+			return fmt.Sprintf("%s@%s", named.Name(), img.Digest)
+		}
+	}
+	return img.Image
+}
+
+// sortBundleTags sorts the tags in place.
+func sortBundleTags(tags []DisplayBundleTag, sortBy string) {
+	sort.SliceStable(tags, func(i, j int) bool {
+		a, b := tags[i], tags[j]
+		switch sortBy {
+		case BundleSearchSortCreated:
+			if !a.Created.Equal(b.Created) {
+				return a.Created.After(b.Created)
+			}
+		case BundleSearchSortVersion:
+			if a.version != nil && b.version != nil {
+				if !a.version.Equal(b.version) {
+					return a.version.GreaterThan(b.version)
+				}
+			} else if a.version != nil || b.version != nil {
+				return a.version != nil
+			}
+		}
+		return a.Tag < b.Tag
+	})
+}
@@ -0,0 +1,217 @@
+package porter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cnabtooci "get.porter.sh/porter/pkg/cnab/cnab-to-oci"
+	"get.porter.sh/porter/pkg/printer"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-to-oci/relocation"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundleSearchOptions_Validate(t *testing.T) {
+	testcases := []struct {
+		name      string
+		args      []string
+		opts      BundleSearchOptions
+		wantRepo  string
+		wantError string
+	}{
+		{name: "no args", wantError: "the repository to search is required, for example getporter/porter-hello"},
+		{name: "too many args", args: []string{"a", "b"}, wantError: "only one positional argument may be specified, the repository, but multiple were received: [a b]"},
+		{name: "tagged", args: []string{"getporter/porter-hello:v0.1.0"}, wantError: "invalid repository getporter/porter-hello:v0.1.0, specify the repository without a tag or digest"},
+		{name: "bad constraint", args: []string{"getporter/porter-hello"}, opts: BundleSearchOptions{Version: "not-a-version"}, wantError: "invalid --version not-a-version: improper constraint: not-a-version"},
+		{name: "prereleases without version", args: []string{"getporter/porter-hello"}, opts: BundleSearchOptions{IncludePrereleases: true}, wantError: "--include-prereleases can only be used with --version"},
+		{name: "bad sort", args: []string{"getporter/porter-hello"}, opts: BundleSearchOptions{Sort: "size"}, wantError: "invalid --sort size, allowed values are: version, created, tag"},
+		{name: "bad format", args: []string{"getporter/porter-hello"}, opts: BundleSearchOptions{PrintOptions: printer.PrintOptions{RawFormat: "toml"}}, wantError: "invalid format: toml"},
+		{name: "docker hub", args: []string{"getporter/porter-hello"}, opts: BundleSearchOptions{Version: "^0.1"}, wantRepo: "docker.io/getporter/porter-hello"},
+		{name: "registry", args: []string{"localhost:5000/porter-hello"}, wantRepo: "localhost:5000/porter-hello"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.opts.RawFormat == "" {
+				tc.opts.RawFormat = "table"
+			}
+			err := tc.opts.Validate(tc.args)
+			if tc.wantError != "" {
+				require.EqualError(t, err, tc.wantError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantRepo, tc.opts.Repository)
+				assert.Equal(t, BundleSearchSortVersion, tc.opts.Sort)
+			}
+		})
+	}
+}
+
+func TestPorter_ListBundleTags(t *testing.T) {
+	built := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	created := map[string]time.Time{
+		"v0.1.0":        built,
+		"v0.2.0-beta.1": built.Add(time.Hour),
+		"v0.2.0":        built.Add(2 * time.Hour),
+		"v1.0.0":        built.Add(-time.Hour),
+		"latest":        built.Add(3 * time.Hour),
+	}
+
+	setup := func(t *testing.T) *TestPorter {
+		p := NewTestPorter(t)
+		p.TestRegistry.MockListTags = func(repository string, insecureRegistry bool) ([]string, error) {
+			assert.Equal(t, "docker.io/getporter/porter-hello", repository)
+			return []string{"latest", "v0.1.0", "v0.2.0-beta.1", "v0.2.0", "v1.0.0", "sha256-abc123.sig"}, nil
+		}
+		p.TestRegistry.MockGetBundleDigest = func(tag string, insecureRegistry bool) (string, error) {
+			return "sha256:" + tag[len("docker.io/getporter/porter-hello:"):], nil
+		}
+		p.TestRegistry.MockPullBundle = func(ref string, insecureRegistry bool) (bundle.Bundle, *relocation.ImageRelocationMap, error) {
+			require.True(t, strings.HasPrefix(ref, "getporter/porter-hello@sha256:"), "the bundle should be pulled by digest")
+			version := ref[len("getporter/porter-hello@sha256:"):]
+			if _, ok := created[version]; !ok {
+				return bundle.Bundle{}, nil, cnabtooci.ErrNotABundle{Reference: ref, Reason: errors.New("invalid media type")}
+			}
+			return bundle.Bundle{
+				Name:             "porter-hello",
+				Version:          version,
+				Description:      "An example bundle",
+				InvocationImages: []bundle.InvocationImage{{BaseImage: bundle.BaseImage{Image: "getporter/porter-hello-installer:" + version}}},
+			}, nil, nil
+		}
+		p.TestRegistry.MockGetImageCreated = func(image string, insecureRegistry bool) (time.Time, error) {
+			return created[image[len("getporter/porter-hello-installer:"):]], nil
+		}
+		return p
+	}
+
+	getTags := func(results []DisplayBundleTag) []string {
+		tags := make([]string, len(results))
+		for i, r := range results {
+			tags[i] = r.Tag
+		}
+		return tags
+	}
+
+	testcases := []struct {
+		name     string
+		opts     BundleSearchOptions
+		wantTags []string
+	}{
+		{name: "sort by version", wantTags: []string{"v1.0.0", "v0.2.0", "v0.2.0-beta.1", "v0.1.0", "latest"}},
+		{name: "sort by created", opts: BundleSearchOptions{Sort: BundleSearchSortCreated}, wantTags: []string{"latest", "v0.2.0", "v0.2.0-beta.1", "v0.1.0", "v1.0.0"}},
+		{name: "sort by tag", opts: BundleSearchOptions{Sort: BundleSearchSortTag}, wantTags: []string{"latest", "v0.1.0", "v0.2.0", "v0.2.0-beta.1", "v1.0.0"}},
+		{name: "version constraint", opts: BundleSearchOptions{Version: "^0.2"}, wantTags: []string{"v0.2.0"}},
+		{name: "include prereleases", opts: BundleSearchOptions{Version: "<0.2", IncludePrereleases: true}, wantTags: []string{"v0.2.0-beta.1", "v0.1.0"}},
+		{name: "prerelease before the range", opts: BundleSearchOptions{Version: "^0.2", IncludePrereleases: true}, wantTags: []string{"v0.2.0"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := setup(t)
+			tc.opts.RawFormat = "table"
+			require.NoError(t, tc.opts.Validate([]string{"getporter/porter-hello"}))
+
+			results, err := p.ListBundleTags(tc.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantTags, getTags(results))
+		})
+	}
+
+	t.Run("bundle metadata", func(t *testing.T) {
+		p := setup(t)
+		opts := BundleSearchOptions{Version: "0.1.0"}
+		opts.RawFormat = "table"
+		require.NoError(t, opts.Validate([]string{"getporter/porter-hello"}))
+
+		results, err := p.ListBundleTags(opts)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "v0.1.0", results[0].Tag)
+		assert.Equal(t, "porter-hello", results[0].Name)
+		assert.Equal(t, "v0.1.0", results[0].Version)
+		assert.Equal(t, "An example bundle", results[0].Description)
+		assert.Equal(t, "sha256:v0.1.0", results[0].Digest)
+		assert.Equal(t, built, results[0].Created)
+	})
+
+	t.Run("deleted tag", func(t *testing.T) {
+		p := setup(t)
+		p.TestRegistry.MockGetBundleDigest = func(tag string, insecureRegistry bool) (string, error) {
+			if tag == "docker.io/getporter/porter-hello:latest" {
+				return "", errdefs.ErrNotFound
+			}
+			return "sha256:" + tag[len("docker.io/getporter/porter-hello:"):], nil
+		}
+		opts := BundleSearchOptions{}
+		opts.RawFormat = "table"
+		require.NoError(t, opts.Validate([]string{"getporter/porter-hello"}))
+
+		results, err := p.ListBundleTags(opts)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v1.0.0", "v0.2.0", "v0.2.0-beta.1", "v0.1.0"}, getTags(results), "a tag that was removed should be skipped")
+	})
+
+	t.Run("unauthorized digest", func(t *testing.T) {
+		p := setup(t)
+		p.TestRegistry.MockGetBundleDigest = func(tag string, insecureRegistry bool) (string, error) {
+			return "", errors.New("unauthorized: authentication required")
+		}
+		opts := BundleSearchOptions{}
+		opts.RawFormat = "table"
+		require.NoError(t, opts.Validate([]string{"getporter/porter-hello"}))
+
+		_, err := p.ListBundleTags(opts)
+		require.EqualError(t, err, "unauthorized: authentication required")
+	})
+
+	t.Run("unauthorized pull", func(t *testing.T) {
+		p := setup(t)
+		p.TestRegistry.MockPullBundle = func(ref string, insecureRegistry bool) (bundle.Bundle, *relocation.ImageRelocationMap, error) {
+			return bundle.Bundle{}, nil, errors.New("unauthorized: authentication required")
+		}
+		opts := BundleSearchOptions{Version: "0.1.0"}
+		opts.RawFormat = "table"
+		require.NoError(t, opts.Validate([]string{"getporter/porter-hello"}))
+
+		_, err := p.ListBundleTags(opts)
+		require.EqualError(t, err, "unable to pull bundle docker.io/getporter/porter-hello:v0.1.0: unauthorized: authentication required")
+	})
+}
+
+func TestPorter_SearchBundles_Json(t *testing.T) {
+	p := NewTestPorter(t)
+	p.TestRegistry.MockListTags = func(repository string, insecureRegistry bool) ([]string, error) {
+		return []string{"v0.1.0"}, nil
+	}
+	p.TestRegistry.MockGetBundleDigest = func(tag string, insecureRegistry bool) (string, error) {
+		return "sha256:abc123", nil
+	}
+	p.TestRegistry.MockPullBundle = func(tag string, insecureRegistry bool) (bundle.Bundle, *relocation.ImageRelocationMap, error) {
+		return bundle.Bundle{Name: "porter-hello", Version: "0.1.0"}, nil, nil
+	}
+
+	opts := BundleSearchOptions{}
+	opts.RawFormat = "json"
+	require.NoError(t, opts.Validate([]string{"getporter/porter-hello"}))
+	require.NoError(t, p.SearchBundles(opts))
+
+	gotOutput := p.TestConfig.TestContext.GetOutput()
+	assert.Contains(t, gotOutput, `"tag": "v0.1.0"`)
+	assert.Contains(t, gotOutput, `"name": "porter-hello"`)
+	assert.Contains(t, gotOutput, `"digest": "sha256:abc123"`)
+}
+
+func TestGetSearchImageReference(t *testing.T) {
+	img := bundle.BaseImage{Image: "getporter/installer:v0.1.0", Digest: "sha256:abc123"}
+	assert.Equal(t, "docker.io/getporter/installer@sha256:abc123", getSearchImageReference(img, nil))
+
+	rm := relocation.ImageRelocationMap{"getporter/installer:v0.1.0": "example.com/mirror/installer@sha256:abc123"}
+	assert.Equal(t, "example.com/mirror/installer@sha256:abc123", getSearchImageReference(img, &rm))
+
+	assert.Equal(t, "getporter/installer:v0.1.0", getSearchImageReference(bundle.BaseImage{Image: "getporter/installer:v0.1.0"}, nil))
+}
@@ -92,6 +92,9 @@ func (p *Porter) ShowInstallation(opts ShowOptions) error {
 		fmt.Fprintf(p.Out, "Name: %s\n", displayInstallation.Name)
 		fmt.Fprintf(p.Out, "Created: %s\n", tp.Format(displayInstallation.Created))
 		fmt.Fprintf(p.Out, "Modified: %s\n", tp.Format(displayInstallation.Modified))
+		if len(displayInstallation.Labels) > 0 {
+			fmt.Fprintf(p.Out, "Labels: %s\n", formatLabels(displayInstallation.Labels))
+		}
 
 		// Print outputs, if any
 		if len(displayInstallation.Outputs) > 0 {
@@ -0,0 +1,59 @@
+package porter
+
+import (
+	"crypto"
+	"fmt"
+	"path/filepath"
+
+	"get.porter.sh/porter/pkg/signing"
+	"github.com/pkg/errors"
+)
+
+// loadSigningKey reads the private key used to sign bundles, defaulting to
+// the signing key in PORTER_HOME.
+func (p *Porter) loadSigningKey(keyPath string) (crypto.Signer, error) {
+	if keyPath == "" {
+		home, err := p.GetHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		keyPath = filepath.Join(home, DefaultSigningKey)
+	}
+
+	data, err := p.FileSystem.ReadFile(keyPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read the signing key %s", keyPath)
+	}
+
+	key, err := signing.ParsePrivateKey(data)
+	return key, errors.Wrapf(err, "invalid signing key %s", keyPath)
+}
+
+// signBundle signs the published bundle and pushes the signature to the same
+// repository. The bundle is pulled back from the registry first, because the
+// registry may change the bundle.json when the bundle is pushed, and it must
+// be signed exactly as it is pulled by everyone else.
+func (p *Porter) signBundle(tag string, signer crypto.Signer, insecureRegistry bool) error {
+	bun, _, err := p.Registry.PullBundle(tag, insecureRegistry)
+	if err != nil {
+		return errors.Wrapf(err, "could not pull the published bundle %s to sign it", tag)
+	}
+
+	bundleDigest, err := signing.DigestBundle(bun)
+	if err != nil {
+		return err
+	}
+
+	sig, err := signing.Sign(signer, bundleDigest)
+	if err != nil {
+		return err
+	}
+
+	err = p.Registry.PushSignature(tag, sig, insecureRegistry)
+	if err != nil {
+		return errors.Wrapf(err, "could not push the signature for bundle %s", tag)
+	}
+
+	fmt.Fprintf(p.Out, "Signed bundle %s with digest %s\n", tag, bundleDigest)
+	return nil
+}
@@ -1,16 +1,29 @@
 package porter
 
 import (
-	"errors"
 	"fmt"
+
+	"get.porter.sh/porter/pkg/storage"
+	"github.com/pkg/errors"
 )
 
-func (p *Porter) MigrateStorage() error {
+// MigrateStorageOptions are the options for migrating Porter's data.
+type MigrateStorageOptions struct {
+	// DryRun lists the changes that the migration would make without changing any data.
+	DryRun bool
+}
+
+func (p *Porter) MigrateStorage(opts MigrateStorageOptions) error {
+	if opts.DryRun {
+		return p.planStorageMigration()
+	}
+
 	logfilePath, err := p.Storage.Migrate()
 
 	fmt.Fprintf(p.Out, "\nSaved migration logs to %s\n", logfilePath)
 
 	if err != nil {
+		fmt.Fprintln(p.Out, "To restore Porter's data to how it was before the migration, run porter storage rollback")
 		// The error has already been printed, don't return it otherwise it will be double printed
 		return errors.New("Migration failed!")
 	}
@@ -18,3 +31,149 @@ func (p *Porter) MigrateStorage() error {
 	fmt.Fprintln(p.Out, "Migration complete!")
 	return nil
 }
+
+func (p *Porter) planStorageMigration() error {
+	changes, err := p.Storage.PlanMigration()
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(p.Out, "Porter's data is up-to-date, no migration is required")
+		return nil
+	}
+
+	fmt.Fprintln(p.Out, "porter storage migrate would make the following changes:")
+	for _, change := range changes {
+		fmt.Fprintf(p.Out, " - %s\n", change)
+	}
+	return nil
+}
+
+// RollbackStorageOptions are the options for rolling back a migration of Porter's data.
+type RollbackStorageOptions struct {
+	// Snapshot is the path of the snapshot saved by the migration. Defaults to
+	// the most recent snapshot in PORTER_HOME.
+	Snapshot string
+}
+
+// RollbackStorage restores the data and schema from before a migration.
+func (p *Porter) RollbackStorage(opts RollbackStorageOptions) error {
+	snapshotPath, err := p.Storage.Rollback(opts.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(p.Out, "Restored Porter's data from %s\n", snapshotPath)
+	return nil
+}
+
+// EncryptStorage encrypts the existing data in the active storage with the
+// storage plugin's current encryption key.
+func (p *Porter) EncryptStorage() error {
+	count, err := p.Storage.Encrypt()
+	if err != nil {
+		if count > 0 {
+			return errors.WithMessagef(err, "encrypted %d items before the error", count)
+		}
+		return err
+	}
+
+	fmt.Fprintf(p.Out, "Encrypted %d items with the current encryption key\n", count)
+	return nil
+}
+
+// StorageExportOptions are the options for exporting Porter's data.
+type StorageExportOptions struct {
+	// To is the path of the archive to create.
+	To string
+}
+
+func (o *StorageExportOptions) Validate() error {
+	if o.To == "" {
+		return errors.New("--to is required, specify the path of the archive to create")
+	}
+	return nil
+}
+
+// ExportStorage writes the installations, credential sets and parameter sets
+// in the active storage to an archive.
+func (p *Porter) ExportStorage(opts StorageExportOptions) error {
+	f, err := p.FileSystem.Create(opts.To)
+	if err != nil {
+		return errors.Wrapf(err, "could not create %s", opts.To)
+	}
+
+	err = p.Storage.Export(f)
+	f.Close()
+	if err != nil {
+		// Don't leave a partial archive behind
+		p.FileSystem.Remove(opts.To)
+		return err
+	}
+
+	fmt.Fprintf(p.Out, "Exported Porter's data to %s\n", opts.To)
+	return nil
+}
+
+// StorageImportOptions are the options for importing Porter's data.
+type StorageImportOptions struct {
+	// File is the path of the archive to import.
+	File string
+
+	// Conflict is how records that are already in storage are handled: skip, overwrite or fail.
+	Conflict string
+}
+
+func (o *StorageImportOptions) Validate(args []string) error {
+	switch len(args) {
+	case 0:
+		return errors.New("the path of the archive to import is required")
+	case 1:
+		o.File = args[0]
+	default:
+		return errors.Errorf("only one positional argument may be specified, the archive, but multiple were received: %s", args)
+	}
+
+	switch o.Conflict {
+	case "":
+		o.Conflict = storage.ImportConflictSkip
+	case storage.ImportConflictSkip, storage.ImportConflictOverwrite, storage.ImportConflictFail:
+	default:
+		return errors.Errorf("invalid --conflict %s, allowed values are: %s, %s, %s", o.Conflict,
+			storage.ImportConflictSkip, storage.ImportConflictOverwrite, storage.ImportConflictFail)
+	}
+
+	return nil
+}
+
+// ImportStorage saves the installations, credential sets and parameter sets
+// from an archive to the active storage.
+func (p *Porter) ImportStorage(opts StorageImportOptions) error {
+	f, err := p.FileSystem.Open(opts.File)
+	if err != nil {
+		return errors.Wrapf(err, "could not open %s", opts.File)
+	}
+	defer f.Close()
+
+	summary, err := p.Storage.Import(f, opts.Conflict)
+	p.printImportSummary(summary)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(p.Out, "Imported Porter's data from %s\n", opts.File)
+	return nil
+}
+
+func (p *Porter) printImportSummary(summary storage.ImportSummary) {
+	for _, record := range summary.Imported {
+		fmt.Fprintf(p.Out, "Imported %s\n", record)
+	}
+	for _, record := range summary.Overwritten {
+		fmt.Fprintf(p.Out, "Overwrote %s\n", record)
+	}
+	for _, record := range summary.Skipped {
+		fmt.Fprintf(p.Out, "Skipped %s, it is already in storage\n", record)
+	}
+}
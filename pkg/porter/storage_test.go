@@ -0,0 +1,85 @@
+package porter
+
+import (
+	"testing"
+
+	"get.porter.sh/porter/pkg/storage"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageImportOptions_Validate(t *testing.T) {
+	testcases := []struct {
+		name         string
+		args         []string
+		opts         StorageImportOptions
+		wantConflict string
+		wantError    string
+	}{
+		{name: "default conflict", args: []string{"porter-data.tgz"}, wantConflict: storage.ImportConflictSkip},
+		{name: "overwrite", args: []string{"porter-data.tgz"}, opts: StorageImportOptions{Conflict: "overwrite"}, wantConflict: storage.ImportConflictOverwrite},
+		{name: "no args", wantError: "the path of the archive to import is required"},
+		{name: "too many args", args: []string{"a", "b"}, wantError: "only one positional argument may be specified, the archive, but multiple were received: [a b]"},
+		{name: "bad conflict", args: []string{"porter-data.tgz"}, opts: StorageImportOptions{Conflict: "merge"}, wantError: "invalid --conflict merge, allowed values are: skip, overwrite, fail"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.opts.Validate(tc.args)
+			if tc.wantError != "" {
+				require.EqualError(t, err, tc.wantError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "porter-data.tgz", tc.opts.File)
+				assert.Equal(t, tc.wantConflict, tc.opts.Conflict)
+			}
+		})
+	}
+}
+
+func TestPorter_ExportImportStorage(t *testing.T) {
+	src := NewTestPorter(t)
+	src.Storage = storage.NewManager(src.Config, crud.NewMockStore())
+	srcCreds := credentials.NewCredentialStore(src.Storage.(*storage.Manager))
+	require.NoError(t, srcCreds.Save(credentials.NewCredentialSet("mycreds")))
+
+	exportOpts := StorageExportOptions{To: "/porter-data.tgz"}
+	require.NoError(t, exportOpts.Validate())
+	require.NoError(t, src.ExportStorage(exportOpts))
+	assert.Contains(t, src.TestConfig.TestContext.GetOutput(), "Exported Porter's data to /porter-data.tgz")
+
+	archive, err := src.FileSystem.ReadFile("/porter-data.tgz")
+	require.NoError(t, err)
+
+	dest := NewTestPorter(t)
+	dest.Storage = storage.NewManager(dest.Config, crud.NewMockStore())
+	require.NoError(t, dest.FileSystem.WriteFile("/porter-data.tgz", archive, 0600))
+
+	importOpts := StorageImportOptions{}
+	require.NoError(t, importOpts.Validate([]string{"/porter-data.tgz"}))
+	require.NoError(t, dest.ImportStorage(importOpts))
+	assert.Contains(t, dest.TestConfig.TestContext.GetOutput(), "Imported credential set mycreds")
+
+	destCreds := credentials.NewCredentialStore(dest.Storage.(*storage.Manager))
+	_, err = destCreds.Read("mycreds")
+	require.NoError(t, err, "the credential set should have been imported")
+}
+
+func TestPorter_MigrateStorage_DryRun(t *testing.T) {
+	p := NewTestPorter(t)
+	mgr := storage.NewManager(p.Config, crud.NewMockStore())
+	p.Storage = mgr
+	require.NoError(t, mgr.GetDataStore().Save(credentials.ItemType, "", "mycreds", []byte(`{"name":"mycreds"}`)))
+
+	require.NoError(t, p.MigrateStorage(MigrateStorageOptions{DryRun: true}))
+
+	output := p.TestConfig.TestContext.GetOutput()
+	assert.Contains(t, output, "porter storage migrate would make the following changes:")
+	assert.Contains(t, output, " - credentials mycreds: set schemaVersion to "+string(credentials.DefaultSchemaVersion))
+
+	credB, err := mgr.GetDataStore().Read(credentials.ItemType, "mycreds")
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"mycreds"}`, string(credB), "a dry run should not change any data")
+}
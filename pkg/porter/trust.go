@@ -0,0 +1,116 @@
+package porter
+
+import (
+	"crypto"
+	"fmt"
+	"path/filepath"
+
+	cnabtooci "get.porter.sh/porter/pkg/cnab/cnab-to-oci"
+	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/signing"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/pkg/errors"
+)
+
+// BundleVerifier enforces the trust policies from the Porter config on
+// bundles that are pulled from a registry.
+type BundleVerifier struct {
+	*config.Config
+	Registry cnabtooci.RegistryProvider
+}
+
+// newBundleVerifier creates a verifier that uses the trust policies from the Porter config.
+func (p *Porter) newBundleVerifier() *BundleVerifier {
+	return &BundleVerifier{
+		Config:   p.Config,
+		Registry: p.Registry,
+	}
+}
+
+// GetTrustPolicy returns the trust policy that applies to the bundle reference.
+func (v *BundleVerifier) GetTrustPolicy(tag string) (config.TrustPolicy, bool, error) {
+	if v == nil {
+		return config.TrustPolicy{}, false, nil
+	}
+
+	ref, err := cnabtooci.ParseOCIReference(tag)
+	if err != nil {
+		return config.TrustPolicy{}, false, errors.Wrap(err, "invalid bundle tag format, expected REGISTRY/name:tag")
+	}
+
+	tp, ok := v.Data.GetTrustPolicy(ref.Name())
+	return tp, ok, nil
+}
+
+// Verify checks that the bundle was signed by a key that is trusted by the
+// trust policy for its reference. Bundles that don't have a trust policy
+// are not checked.
+func (v *BundleVerifier) Verify(tag string, bun bundle.Bundle, insecureRegistry bool) error {
+	tp, ok, err := v.GetTrustPolicy(tag)
+	if err != nil || !ok {
+		return err
+	}
+
+	return v.VerifyWithPolicy(tag, bun, tp, insecureRegistry)
+}
+
+// VerifyWithPolicy checks that the bundle was signed by a key that is trusted
+// by the specified trust policy.
+func (v *BundleVerifier) VerifyWithPolicy(tag string, bun bundle.Bundle, tp config.TrustPolicy, insecureRegistry bool) error {
+	if len(tp.Keys) == 0 {
+		return errors.Errorf("bundles from %s are not allowed by the trust policy for %s", tag, tp.Repository)
+	}
+
+	keys, err := v.loadTrustedKeys(tp)
+	if err != nil {
+		return err
+	}
+
+	sig, err := v.Registry.PullSignature(tag, insecureRegistry)
+	if err != nil {
+		return errors.Wrapf(err, "the trust policy for %s requires bundles to be signed", tp.Repository)
+	}
+
+	bundleDigest, err := signing.DigestBundle(bun)
+	if err != nil {
+		return err
+	}
+
+	err = signing.Verify(sig, bundleDigest, keys)
+	if err != nil {
+		return errors.Wrapf(err, "bundle %s failed signature verification", tag)
+	}
+
+	if v.Debug {
+		fmt.Fprintf(v.Err, "Verified the signature of bundle %s with digest %s\n", tag, bundleDigest)
+	}
+	return nil
+}
+
+// loadTrustedKeys reads the public keys for a trust policy. Relative paths
+// are resolved from PORTER_HOME.
+func (v *BundleVerifier) loadTrustedKeys(tp config.TrustPolicy) ([]crypto.PublicKey, error) {
+	home, err := v.GetHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]crypto.PublicKey, 0, len(tp.Keys))
+	for _, keyPath := range tp.Keys {
+		if !filepath.IsAbs(keyPath) {
+			keyPath = filepath.Join(home, keyPath)
+		}
+
+		data, err := v.FileSystem.ReadFile(keyPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read trusted key %s for %s", keyPath, tp.Repository)
+		}
+
+		key, err := signing.ParsePublicKey(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted key %s for %s", keyPath, tp.Repository)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,99 @@
+package porter
+
+import (
+	"crypto"
+	"path/filepath"
+	"testing"
+
+	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/signing"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-to-oci/relocation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPorter_PullBundle_TrustPolicy(t *testing.T) {
+	bun := bundle.Bundle{
+		Name:             "mybuns",
+		Version:          "1.0.0",
+		InvocationImages: []bundle.InvocationImage{{BaseImage: bundle.BaseImage{Image: "getporter/mybuns:v1.0.0", Digest: "sha256:abc123"}}},
+	}
+	bundleDigest, err := signing.DigestBundle(bun)
+	require.NoError(t, err, "DigestBundle failed")
+
+	trustedPriv, trustedPub := signing.GenerateTestKey(t)
+	trustedKey, err := signing.ParsePrivateKey(trustedPriv)
+	require.NoError(t, err)
+	otherPriv, _ := signing.GenerateTestKey(t)
+	otherKey, err := signing.ParsePrivateKey(otherPriv)
+	require.NoError(t, err)
+
+	setup := func(t *testing.T, signer crypto.Signer) *TestPorter {
+		p := NewTestPorter(t)
+		home, err := p.GetHomeDir()
+		require.NoError(t, err)
+		require.NoError(t, p.FileSystem.WriteFile(filepath.Join(home, "keys/myorg.pub"), trustedPub, 0644))
+		p.Data = &config.Data{
+			TrustPolicies: []config.TrustPolicy{
+				{Repository: "*"},
+				{Repository: "example.com/myorg", Keys: []string{"keys/myorg.pub"}},
+			},
+		}
+
+		p.TestRegistry.MockPullBundle = func(tag string, insecureRegistry bool) (bundle.Bundle, *relocation.ImageRelocationMap, error) {
+			return bun, nil, nil
+		}
+		if signer != nil {
+			sig, err := signing.Sign(signer, bundleDigest)
+			require.NoError(t, err, "Sign failed")
+			p.TestRegistry.MockPullSignature = func(tag string, insecureRegistry bool) (signing.Signature, error) {
+				return sig, nil
+			}
+		}
+		return p
+	}
+
+	t.Run("signed by a trusted key", func(t *testing.T) {
+		p := setup(t, trustedKey)
+
+		cb, err := p.PullBundle(BundlePullOptions{Reference: "example.com/myorg/mybuns:v1.0.0"})
+		require.NoError(t, err, "PullBundle failed")
+		assert.Equal(t, "mybuns", cb.Bundle.Name)
+	})
+
+	t.Run("signed by an untrusted key", func(t *testing.T) {
+		p := setup(t, otherKey)
+
+		_, err := p.PullBundle(BundlePullOptions{Reference: "example.com/myorg/mybuns:v1.0.0"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "bundle example.com/myorg/mybuns:v1.0.0 failed signature verification: the signature for bundle digest "+bundleDigest+" was not created by a trusted key")
+
+		_, ok, err := p.Cache.FindBundle("example.com/myorg/mybuns:v1.0.0")
+		require.NoError(t, err)
+		assert.False(t, ok, "a bundle that failed verification should not be cached")
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		p := setup(t, nil)
+
+		_, err := p.PullBundle(BundlePullOptions{Reference: "example.com/myorg/mybuns:v1.0.0"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the trust policy for example.com/myorg requires bundles to be signed")
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		p := setup(t, trustedKey)
+
+		_, err := p.PullBundle(BundlePullOptions{Reference: "example.com/someone/mybuns:v1.0.0"})
+		require.EqualError(t, err, "bundles from example.com/someone/mybuns:v1.0.0 are not allowed by the trust policy for *")
+	})
+
+	t.Run("no trust policy", func(t *testing.T) {
+		p := setup(t, nil)
+		p.Data = nil
+
+		_, err := p.PullBundle(BundlePullOptions{Reference: "example.com/someone/mybuns:v1.0.0"})
+		require.NoError(t, err, "bundles should not be verified when there isn't a trust policy")
+	})
+}
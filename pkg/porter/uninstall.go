@@ -83,6 +83,22 @@ func (p *Porter) UninstallBundle(opts UninstallOptions) error {
 		return err
 	}
 
+	if opts.DryRun {
+		return p.planAction(opts, deperator)
+	}
+
+	err = p.loadLayoutImages(opts.BundleActionOptions)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := p.lockInstallations(opts.BundleActionOptions, deperator)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer p.autoPruneInstallation(opts.Name)
+
 	actionArgs, err := p.BuildActionArgs(opts)
 	if err != nil {
 		return err
@@ -1,7 +1,11 @@
 package porter
 
 import (
+	"fmt"
+
+	cnabprovider "get.porter.sh/porter/pkg/cnab/provider"
 	"github.com/cnabio/cnab-go/claim"
+	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 )
 
@@ -11,10 +15,18 @@ var _ BundleAction = NewUpgradeOptions()
 // Porter handles defaulting any missing values.
 type UpgradeOptions struct {
 	*BundleActionOptions
+
+	// RequireChanges refuses to upgrade when nothing has changed since the
+	// last successful action of the installation.
+	RequireChanges bool
+
+	// RollbackOnFailure re-runs the bundle and parameters from the last
+	// successful action of the installation when the upgrade fails.
+	RollbackOnFailure bool
 }
 
 func NewUpgradeOptions() UpgradeOptions {
-	return UpgradeOptions{&BundleActionOptions{}}
+	return UpgradeOptions{BundleActionOptions: &BundleActionOptions{}}
 }
 
 func (o UpgradeOptions) GetAction() string {
@@ -40,3 +52,76 @@ func (p *Porter) UpgradeBundle(opts UpgradeOptions) error {
 
 	return p.ExecuteAction(opts)
 }
+
+// checkRequiredChanges stops an upgrade with --require-changes when the
+// installation has not changed since its last successful action. Call it while
+// the installation is locked, so that it can't change before the upgrade runs.
+func (p *Porter) checkRequiredChanges(action BundleAction) error {
+	opts, ok := action.(UpgradeOptions)
+	if !ok || !opts.RequireChanges {
+		return nil
+	}
+
+	diff, err := p.diffInstallation(opts)
+	if err != nil {
+		return err
+	}
+	if !diff.HasChanges() {
+		return errors.Errorf("installation %s has not changed since its last successful action, there is nothing to upgrade", opts.Name)
+	}
+	return nil
+}
+
+// getRollbackClaim returns the claim that the installation is rolled back to
+// when an upgrade fails, or nil when the action shouldn't be rolled back.
+func (p *Porter) getRollbackClaim(action BundleAction) (*claim.Claim, error) {
+	opts, ok := action.(UpgradeOptions)
+	if !ok || !opts.RollbackOnFailure {
+		return nil, nil
+	}
+
+	c, err := p.readLastSuccessfulClaim(opts.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to roll back the upgrade if it fails")
+	}
+	return &c, nil
+}
+
+// rollbackUpgrade re-runs the bundle and parameters from a previous claim after
+// an upgrade failed. Only the installation is rolled back, not its dependencies.
+func (p *Porter) rollbackUpgrade(args cnabprovider.ActionArguments, previous claim.Claim, upgradeErr error) error {
+	// Only roll back when the upgrade recorded a failed result, otherwise the
+	// bundle wasn't run and the installation is unchanged
+	failed, err := p.Claims.ReadLastClaim(args.Installation)
+	if err != nil || failed.ID == previous.ID {
+		return upgradeErr
+	}
+	result, err := p.Claims.ReadLastResult(failed.ID)
+	if err != nil || result.Status != claim.StatusFailed {
+		return upgradeErr
+	}
+
+	fmt.Fprintf(p.Out, "Upgrade failed, rolling back %s to version %s of the %s bundle...\n",
+		args.Installation, previous.Bundle.Version, previous.Bundle.Name)
+
+	args.BundlePath = ""
+	args.BundleReference = ""
+	args.Params = nil
+	args.PreviousClaimID = previous.ID
+	args.RelocationMapping = ""
+	if previous.BundleReference != "" {
+		cachedBundle, ok, err := p.Cache.FindBundle(previous.BundleReference)
+		if err == nil && ok {
+			args.RelocationMapping = cachedBundle.RelocationFilePath
+		}
+	}
+
+	err = p.CNAB.Execute(args)
+	if err != nil {
+		return multierror.Append(errors.Wrap(upgradeErr, "upgrade failed"),
+			errors.Wrapf(err, "rollback of installation %s failed", args.Installation))
+	}
+
+	return errors.Wrapf(upgradeErr, "upgrade failed and installation %s was rolled back to the bundle and parameters from claim %s",
+		args.Installation, previous.ID)
+}
@@ -0,0 +1,73 @@
+package porter
+
+import (
+	"testing"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPorter_rollbackUpgrade(t *testing.T) {
+	p := NewTestPorter(t)
+	installWordpress(t, p)
+
+	previous, err := p.readLastSuccessfulClaim("wordpress")
+	require.NoError(t, err, "readLastSuccessfulClaim failed")
+
+	opts := NewUpgradeOptions()
+	opts.Driver = DebugDriver
+	opts.Reference = "localhost:5000/wordpress:v0.1.3"
+	opts.CredentialIdentifiers = []string{"wordpress"}
+	opts.Params = []string{"wordpress-password=newpassword"}
+	opts.RollbackOnFailure = true
+	err = opts.Validate(nil, p.Porter)
+	require.NoError(t, err, "Validate upgrade options failed")
+
+	rollbackTo, err := p.getRollbackClaim(opts)
+	require.NoError(t, err, "getRollbackClaim failed")
+	require.NotNil(t, rollbackTo, "the upgrade should be rolled back to the install")
+	assert.Equal(t, previous.ID, rollbackTo.ID)
+
+	args, err := p.BuildActionArgs(opts)
+	require.NoError(t, err, "BuildActionArgs failed")
+	upgradeErr := errors.New("upgrade exploded")
+
+	t.Run("not rolled back when the bundle did not run", func(t *testing.T) {
+		err := p.rollbackUpgrade(args, previous, upgradeErr)
+		assert.Equal(t, upgradeErr, err, "the installation should not be rolled back when the upgrade didn't record a failed result")
+	})
+
+	t.Run("rolled back after a failed upgrade", func(t *testing.T) {
+		// Record a failed upgrade, as if the bundle had failed
+		failedParams := make(map[string]interface{}, len(previous.Parameters))
+		for k, v := range previous.Parameters {
+			failedParams[k] = v
+		}
+		failedParams["wordpress-password"] = "newpassword"
+		failed, err := previous.NewClaim(claim.ActionUpgrade, previous.Bundle, failedParams)
+		require.NoError(t, err, "NewClaim failed")
+		require.NoError(t, p.Claims.SaveClaim(failed), "SaveClaim failed")
+		result, err := failed.NewResult(claim.StatusFailed)
+		require.NoError(t, err, "NewResult failed")
+		require.NoError(t, p.Claims.SaveResult(result), "SaveResult failed")
+
+		err = p.rollbackUpgrade(args, previous, upgradeErr)
+		require.Error(t, err, "the upgrade error should still be returned after rolling back")
+		assert.Contains(t, err.Error(), "upgrade failed and installation wordpress was rolled back to the bundle and parameters from claim "+previous.ID)
+		assert.Equal(t, upgradeErr, errors.Cause(err))
+		assert.Contains(t, p.TestConfig.TestContext.GetOutput(), "Upgrade failed, rolling back wordpress to version 0.1.3 of the wordpress bundle...")
+
+		rollback, err := p.Claims.ReadLastClaim("wordpress")
+		require.NoError(t, err, "ReadLastClaim failed")
+		assert.NotEqual(t, failed.ID, rollback.ID, "the rollback should record a new claim")
+		assert.Equal(t, claim.ActionUpgrade, rollback.Action)
+		assert.Equal(t, previous.Parameters, rollback.Parameters, "the parameters from the install should be used")
+		assert.Equal(t, previous.BundleReference, rollback.BundleReference)
+
+		rollbackResult, err := p.Claims.ReadLastResult(rollback.ID)
+		require.NoError(t, err, "ReadLastResult failed")
+		assert.Equal(t, claim.StatusSucceeded, rollbackResult.Status)
+	})
+}
@@ -16,8 +16,14 @@ type ActionInput struct {
 //   ...
 // Solution from https://stackoverflow.com/a/42547226
 func (a *ActionInput) MarshalYAML() (interface{}, error) {
+	// Only pass the mixin's data, fields like retry are handled by porter
+	steps := make([]map[string]interface{}, len(a.Steps))
+	for i, step := range a.Steps {
+		steps[i] = step.Data
+	}
+
 	// encode the original
-	b, err := yaml.Marshal(a.Steps)
+	b, err := yaml.Marshal(steps)
 	if err != nil {
 		return nil, err
 	}
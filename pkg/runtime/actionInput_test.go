@@ -17,6 +17,7 @@ func TestActionInput_MarshalYAML(t *testing.T) {
 				"command": "echo hi",
 			},
 		},
+		Retry: &manifest.StepRetry{Attempts: 3},
 	}
 
 	input := &ActionInput{
@@ -30,5 +31,5 @@ func TestActionInput_MarshalYAML(t *testing.T) {
   - exec:
       command: echo hi
 `
-	assert.Equal(t, wantYaml, string(b))
+	assert.Equal(t, wantYaml, string(b), "only the mixin data should be passed to the mixin")
 }
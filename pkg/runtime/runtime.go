@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"get.porter.sh/porter/pkg/config"
 	"get.porter.sh/porter/pkg/context"
@@ -21,12 +22,16 @@ type PorterRuntime struct {
 	*context.Context
 	mixins          pkgmgmt.PackageManager
 	RuntimeManifest *RuntimeManifest
+
+	// sleep waits before a failed step is retried.
+	sleep func(time.Duration)
 }
 
 func NewPorterRuntime(cxt *context.Context, mixins pkgmgmt.PackageManager) *PorterRuntime {
 	return &PorterRuntime{
 		Context: cxt,
 		mixins:  mixins,
+		sleep:   time.Sleep,
 	}
 }
 
@@ -91,7 +96,7 @@ func (r *PorterRuntime) Execute(rm *RuntimeManifest) error {
 				Input:   string(inputBytes),
 				Runtime: true,
 			}
-			err = r.mixins.Run(r.Context, step.GetMixinName(), cmd)
+			err = r.runStep(step, cmd)
 			if err != nil {
 				return errors.Wrap(err, "mixin execution failed")
 			}
@@ -123,6 +128,27 @@ func (r *PorterRuntime) Execute(rm *RuntimeManifest) error {
 	return nil
 }
 
+// runStep executes a step with its mixin, retrying the step when it fails
+// according to the step's retry policy.
+func (r *PorterRuntime) runStep(step *manifest.Step, cmd pkgmgmt.CommandOptions) error {
+	attempts := 0
+	if step.Retry != nil {
+		attempts = step.Retry.Attempts
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = r.mixins.Run(r.Context, step.GetMixinName(), cmd)
+		if err == nil || attempt >= attempts {
+			return err
+		}
+
+		backoff := step.Retry.GetBackoff(attempt + 1)
+		fmt.Fprintf(r.Err, "step failed, retrying in %s (attempt %d of %d): %s\n", backoff, attempt+1, attempts, err)
+		r.sleep(backoff)
+	}
+}
+
 func (r *PorterRuntime) createOutputsDir() error {
 	// Ensure outputs directory exists
 	if err := r.FileSystem.MkdirAll(config.BundleOutputsDir, 0755); err != nil {
@@ -1,13 +1,17 @@
 package runtime
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"get.porter.sh/porter/pkg/config"
 	"get.porter.sh/porter/pkg/context"
 	"get.porter.sh/porter/pkg/manifest"
+	"get.porter.sh/porter/pkg/mixin"
+	"get.porter.sh/porter/pkg/pkgmgmt"
 	"github.com/cnabio/cnab-go/bundle/definition"
 	"github.com/cnabio/cnab-go/claim"
 	"github.com/stretchr/testify/assert"
@@ -304,3 +308,53 @@ func TestLoadImageMappingFilesGoodFiles(t *testing.T) {
 	assert.NotEmpty(t, reloMap)
 	assert.Equal(t, "mysql", bun.Name)
 }
+
+func TestPorterRuntime_runStep_Retry(t *testing.T) {
+	testcases := []struct {
+		name      string
+		failures  int
+		wantRuns  int
+		wantWaits []time.Duration
+		wantErr   bool
+	}{
+		{name: "succeeds after retrying", failures: 2, wantRuns: 3, wantWaits: []time.Duration{2 * time.Second, 4 * time.Second}},
+		{name: "fails after all attempts", failures: 5, wantRuns: 4, wantWaits: []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second}, wantErr: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewTestPorterRuntime(t)
+
+			var runs int
+			mixins := r.mixins.(*mixin.TestMixinProvider)
+			mixins.RunAssertions = []func(*context.Context, string, pkgmgmt.CommandOptions) error{
+				func(*context.Context, string, pkgmgmt.CommandOptions) error {
+					runs++
+					if runs <= tc.failures {
+						return errors.New("flaky operation failed")
+					}
+					return nil
+				},
+			}
+
+			var waits []time.Duration
+			r.sleep = func(d time.Duration) {
+				waits = append(waits, d)
+			}
+
+			step := &manifest.Step{
+				Data:  map[string]interface{}{"exec": map[string]interface{}{"description": "flaky"}},
+				Retry: &manifest.StepRetry{Attempts: 3, Backoff: "2s", MaxBackoff: "5s"},
+			}
+			err := r.runStep(step, pkgmgmt.CommandOptions{Command: claim.ActionInstall})
+			if tc.wantErr {
+				require.EqualError(t, err, "flaky operation failed")
+			} else {
+				require.NoError(t, err, "runStep failed")
+			}
+			assert.Equal(t, tc.wantRuns, runs, "unexpected number of runs")
+			assert.Equal(t, tc.wantWaits, waits, "unexpected backoff between attempts")
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// GenerateTestKey generates an Ed25519 key pair and returns the PEM encoded
+// private and public keys.
+func GenerateTestKey(t *testing.T) (privateKey []byte, publicKey []byte) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err, "could not generate a test key")
+	return EncodeTestKey(t, priv, priv.Public())
+}
+
+// EncodeTestKey PEM encodes a key pair.
+func EncodeTestKey(t *testing.T, priv crypto.PrivateKey, pub crypto.PublicKey) (privateKey []byte, publicKey []byte) {
+	privData, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err, "could not marshal the test private key")
+	pubData, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err, "could not marshal the test public key")
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privData}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubData})
+}
@@ -0,0 +1,142 @@
+package signing
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/pkg/errors"
+)
+
+// Signature is a detached signature over the digest of a bundle.json.
+type Signature struct {
+	// BundleDigest is the digest of the canonical bundle.json that was signed,
+	// in the format sha256:HEX.
+	BundleDigest string `json:"bundleDigest"`
+
+	// Signature over the BundleDigest.
+	Signature []byte `json:"signature"`
+}
+
+// DigestBundle calculates the digest of the canonical representation of a bundle.
+func DigestBundle(bun bundle.Bundle) (string, error) {
+	var buf bytes.Buffer
+	if _, err := bun.WriteTo(&buf); err != nil {
+		return "", errors.Wrapf(err, "could not calculate the digest of bundle %s", bun.Name)
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(buf.Bytes())), nil
+}
+
+// ParsePrivateKey parses a PEM encoded PKCS #8 private key. Ed25519, ECDSA
+// and RSA keys are supported.
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("the private key is not PEM encoded")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse the private key, it must be in PKCS #8 format")
+	}
+
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return k, nil
+	case *ecdsa.PrivateKey:
+		return k, nil
+	case *rsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, errors.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// ParsePublicKey parses a PEM encoded PKIX public key. Ed25519, ECDSA and
+// RSA keys are supported.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("the public key is not PEM encoded")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse the public key, it must be in PKIX format")
+	}
+
+	switch key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey:
+		return key, nil
+	default:
+		return nil, errors.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// Sign creates a detached signature over the digest of a bundle.
+func Sign(key crypto.Signer, bundleDigest string) (Signature, error) {
+	var sig []byte
+	var err error
+	switch key.(type) {
+	case ed25519.PrivateKey:
+		sig, err = key.Sign(rand.Reader, []byte(bundleDigest), crypto.Hash(0))
+	default:
+		hashed := sha256.Sum256([]byte(bundleDigest))
+		sig, err = key.Sign(rand.Reader, hashed[:], crypto.SHA256)
+	}
+	if err != nil {
+		return Signature{}, errors.Wrapf(err, "could not sign bundle digest %s", bundleDigest)
+	}
+
+	return Signature{
+		BundleDigest: bundleDigest,
+		Signature:    sig,
+	}, nil
+}
+
+// Verify checks that the signature was created by one of the keys and is for
+// the specified bundle digest.
+func Verify(sig Signature, bundleDigest string, keys []crypto.PublicKey) error {
+	if sig.BundleDigest != bundleDigest {
+		return errors.Errorf("the signature is for bundle digest %s but the bundle has digest %s", sig.BundleDigest, bundleDigest)
+	}
+
+	for _, key := range keys {
+		if verifySignature(key, []byte(sig.BundleDigest), sig.Signature) {
+			return nil
+		}
+	}
+	return errors.Errorf("the signature for bundle digest %s was not created by a trusted key", bundleDigest)
+}
+
+func verifySignature(key crypto.PublicKey, message []byte, sig []byte) bool {
+	hashed := sha256.Sum256(message)
+
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, message, sig)
+	case *ecdsa.PublicKey:
+		var esig struct {
+			R, S *big.Int
+		}
+		rest, err := asn1.Unmarshal(sig, &esig)
+		if err != nil || len(rest) > 0 {
+			return false
+		}
+		return ecdsa.Verify(k, hashed[:], esig.R, esig.S)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, hashed[:], sig) == nil
+	default:
+		return false
+	}
+}
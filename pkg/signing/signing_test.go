@@ -0,0 +1,74 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	testcases := map[string]func(t *testing.T) ([]byte, []byte){
+		"ed25519": GenerateTestKey,
+		"ecdsa": func(t *testing.T) ([]byte, []byte) {
+			return EncodeTestKey(t, ecdsaKey, ecdsaKey.Public())
+		},
+		"rsa": func(t *testing.T) ([]byte, []byte) {
+			return EncodeTestKey(t, rsaKey, rsaKey.Public())
+		},
+	}
+
+	bundleDigest, err := DigestBundle(bundle.Bundle{Name: "mybuns", Version: "1.0.0"})
+	require.NoError(t, err, "DigestBundle failed")
+
+	for name, generateKey := range testcases {
+		t.Run(name, func(t *testing.T) {
+			privData, pubData := generateKey(t)
+			priv, err := ParsePrivateKey(privData)
+			require.NoError(t, err, "ParsePrivateKey failed")
+			pub, err := ParsePublicKey(pubData)
+			require.NoError(t, err, "ParsePublicKey failed")
+
+			sig, err := Sign(priv, bundleDigest)
+			require.NoError(t, err, "Sign failed")
+			assert.Equal(t, bundleDigest, sig.BundleDigest)
+
+			err = Verify(sig, bundleDigest, []crypto.PublicKey{pub})
+			require.NoError(t, err, "the signature should be valid")
+
+			err = Verify(sig, "sha256:abc123", []crypto.PublicKey{pub})
+			require.EqualError(t, err, "the signature is for bundle digest "+bundleDigest+" but the bundle has digest sha256:abc123")
+
+			_, otherPubData := GenerateTestKey(t)
+			otherPub, err := ParsePublicKey(otherPubData)
+			require.NoError(t, err, "ParsePublicKey failed")
+			err = Verify(sig, bundleDigest, []crypto.PublicKey{otherPub})
+			require.EqualError(t, err, "the signature for bundle digest "+bundleDigest+" was not created by a trusted key")
+
+			sig.Signature[0] ^= 0xff
+			err = Verify(sig, bundleDigest, []crypto.PublicKey{pub})
+			require.Error(t, err, "a modified signature should not be valid")
+		})
+	}
+}
+
+func TestParsePrivateKey_Invalid(t *testing.T) {
+	_, err := ParsePrivateKey([]byte("not a key"))
+	require.EqualError(t, err, "the private key is not PEM encoded")
+
+	_, pub := GenerateTestKey(t)
+	_, err = ParsePrivateKey(pub)
+	require.Error(t, err, "a public key should not be accepted as a private key")
+	assert.Contains(t, err.Error(), "could not parse the private key")
+}
@@ -0,0 +1,611 @@
+package storage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"get.porter.sh/porter/pkg/config"
+	"github.com/Masterminds/semver/v3"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/cnabio/cnab-go/schema"
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/pkg/errors"
+)
+
+// ArchiveSchemaVersion is the version of the storage archive format created by Export.
+const ArchiveSchemaVersion = "1.0.0"
+
+// archiveManifestPath is the location of the manifest in the archive.
+const archiveManifestPath = "manifest.json"
+
+// parametersItemType is where parameter sets are persisted.
+// TODO (carolynvs): Use parameters.ItemType once parameter sets move to cnab-go, it causes a circular dependency at the moment.
+const parametersItemType = "parameters"
+
+const (
+	// ImportConflictSkip keeps the existing record when it is already in storage.
+	ImportConflictSkip = "skip"
+
+	// ImportConflictOverwrite replaces the existing record with the imported record.
+	ImportConflictOverwrite = "overwrite"
+
+	// ImportConflictFail stops the import, without importing any records, when any record is already in storage.
+	ImportConflictFail = "fail"
+)
+
+// ArchiveManifest describes the contents of a storage archive.
+type ArchiveManifest struct {
+	// SchemaVersion of the archive format.
+	SchemaVersion string `json:"schemaVersion"`
+
+	// Created is when the archive was exported.
+	Created time.Time `json:"created"`
+
+	// StorageSchema is the schema.json of the storage that was exported.
+	StorageSchema Schema `json:"storageSchema"`
+
+	// Items in the archive.
+	Items []ArchiveItem `json:"items"`
+}
+
+// ArchiveItem is a single item from storage in an archive.
+type ArchiveItem struct {
+	ItemType string `json:"itemType"`
+	Group    string `json:"group,omitempty"`
+	Name     string `json:"name"`
+
+	// Path of the item's data in the archive.
+	Path string `json:"path"`
+
+	data []byte
+}
+
+// ImportSummary lists the records that were imported, such as "installation mysql".
+type ImportSummary struct {
+	Imported    []string
+	Overwritten []string
+	Skipped     []string
+}
+
+// importRecord is a set of items that is imported, or skipped, together. An
+// installation includes its claims, results and outputs.
+type importRecord struct {
+	description string
+	itemType    string
+	name        string
+	items       []ArchiveItem
+}
+
+// Export writes all installations, credential sets and parameter sets to a
+// gzipped tar archive, along with the storage schema that they use.
+func (m *Manager) Export(w io.Writer) error {
+	// Reuse the same connection for the entire export
+	err := m.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	items, err := m.listExportItems()
+	if err != nil {
+		return err
+	}
+
+	manifest := ArchiveManifest{
+		SchemaVersion: ArchiveSchemaVersion,
+		Created:       time.Now(),
+		StorageSchema: m.schema,
+		Items:         items,
+	}
+	return writeArchive(w, manifest)
+}
+
+// listExportItems reads every item from storage. Installation locks are not
+// exported because they only apply to the storage that they were created in.
+func (m *Manager) listExportItems() ([]ArchiveItem, error) {
+	var items []ArchiveItem
+	readItems := func(itemType string, group string) ([]string, error) {
+		names, err := m.listItems(itemType, group)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			data, err := m.BackingStore.Read(itemType, name)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not read %s %s", itemType, name)
+			}
+			items = append(items, ArchiveItem{
+				ItemType: itemType,
+				Group:    group,
+				Name:     name,
+				Path:     path.Join("items", itemType, group, name),
+				data:     data,
+			})
+		}
+		return names, nil
+	}
+
+	installations, err := readItems(claim.ItemTypeInstallations, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, installation := range installations {
+		claimIDs, err := readItems(claim.ItemTypeClaims, installation)
+		if err != nil {
+			return nil, err
+		}
+		for _, claimID := range claimIDs {
+			resultIDs, err := readItems(claim.ItemTypeResults, claimID)
+			if err != nil {
+				return nil, err
+			}
+			for _, resultID := range resultIDs {
+				if _, err = readItems(claim.ItemTypeOutputs, resultID); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if _, err = readItems(credentials.ItemType, ""); err != nil {
+		return nil, err
+	}
+	if _, err = readItems(parametersItemType, ""); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// listItems lists the items of a type and group, treating a group that
+// doesn't exist as empty.
+func (m *Manager) listItems(itemType string, group string) ([]string, error) {
+	names, err := m.BackingStore.List(itemType, group)
+	if err != nil {
+		if strings.Contains(err.Error(), crud.ErrRecordDoesNotExist.Error()) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "could not list %s", itemType)
+	}
+	return names, nil
+}
+
+func writeArchive(w io.Writer, manifest ArchiveManifest) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestB, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the archive manifest")
+	}
+
+	writeFile := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(data)),
+			ModTime: manifest.Created,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return errors.Wrapf(err, "could not write %s to the archive", name)
+		}
+		_, err := tw.Write(data)
+		return errors.Wrapf(err, "could not write %s to the archive", name)
+	}
+
+	if err = writeFile(archiveManifestPath, manifestB); err != nil {
+		return err
+	}
+	for _, item := range manifest.Items {
+		if err = writeFile(item.Path, item.data); err != nil {
+			return err
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return errors.Wrap(err, "could not write the archive")
+	}
+	return errors.Wrap(gz.Close(), "could not write the archive")
+}
+
+// readArchive reads the manifest and the data for each item in an archive.
+func readArchive(r io.Reader) (ArchiveManifest, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return ArchiveManifest{}, errors.Wrap(err, "could not read the archive, it is not a gzipped tar file")
+	}
+	defer gz.Close()
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ArchiveManifest{}, errors.Wrap(err, "could not read the archive")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return ArchiveManifest{}, errors.Wrapf(err, "could not read %s from the archive", hdr.Name)
+		}
+		files[hdr.Name] = data
+	}
+
+	manifestB, ok := files[archiveManifestPath]
+	if !ok {
+		return ArchiveManifest{}, errors.Errorf("invalid archive, %s is missing", archiveManifestPath)
+	}
+
+	var manifest ArchiveManifest
+	if err = json.Unmarshal(manifestB, &manifest); err != nil {
+		return ArchiveManifest{}, errors.Wrapf(err, "could not parse %s in the archive", archiveManifestPath)
+	}
+	if manifest.SchemaVersion != ArchiveSchemaVersion {
+		return ArchiveManifest{}, errors.Errorf("unsupported archive schemaVersion %q, this version of Porter supports %s", manifest.SchemaVersion, ArchiveSchemaVersion)
+	}
+
+	for i, item := range manifest.Items {
+		data, ok := files[item.Path]
+		if !ok {
+			return ArchiveManifest{}, errors.Errorf("invalid archive, %s %s is missing from %s", item.ItemType, item.Name, item.Path)
+		}
+		manifest.Items[i].data = data
+	}
+
+	return manifest, nil
+}
+
+// Import saves the installations, credential sets and parameter sets from an
+// archive created by Export. When a record is already in storage, it is
+// handled with the conflict strategy: skip, overwrite or fail. Archives
+// exported with an older storage schema are migrated before they are
+// imported, and archives exported with a newer storage schema are rejected.
+func (m *Manager) Import(r io.Reader, conflict string) (ImportSummary, error) {
+	switch conflict {
+	case ImportConflictSkip, ImportConflictOverwrite, ImportConflictFail:
+	default:
+		return ImportSummary{}, errors.Errorf("invalid conflict strategy %q, allowed values are: %s, %s, %s",
+			conflict, ImportConflictSkip, ImportConflictOverwrite, ImportConflictFail)
+	}
+
+	manifest, err := readArchive(r)
+	if err != nil {
+		return ImportSummary{}, err
+	}
+
+	migrate, err := checkArchiveSchema(manifest.StorageSchema)
+	if err != nil {
+		return ImportSummary{}, err
+	}
+	if migrate {
+		manifest, err = m.migrateArchive(manifest)
+		if err != nil {
+			return ImportSummary{}, err
+		}
+	}
+
+	// Reuse the same connection for the entire import
+	err = m.Connect()
+	if err != nil {
+		return ImportSummary{}, err
+	}
+	defer m.Close()
+
+	records := groupImportRecords(manifest.Items)
+
+	summary := ImportSummary{}
+	var conflicts []string
+	exists := make([]bool, len(records))
+	for i, rec := range records {
+		exists[i], err = m.recordExists(rec)
+		if err != nil {
+			return ImportSummary{}, err
+		}
+		if exists[i] {
+			conflicts = append(conflicts, rec.description)
+		}
+	}
+	if len(conflicts) > 0 && conflict == ImportConflictFail {
+		return ImportSummary{}, errors.Errorf("the following records are already in storage, nothing was imported: %s", strings.Join(conflicts, ", "))
+	}
+
+	claimStore := claim.NewClaimStore(m.BackingStore, nil, nil)
+	for i, rec := range records {
+		if exists[i] {
+			if conflict == ImportConflictSkip {
+				summary.Skipped = append(summary.Skipped, rec.description)
+				continue
+			}
+
+			// Remove the claims, results and outputs of the existing installation
+			if rec.itemType == claim.ItemTypeInstallations {
+				if err = claimStore.DeleteInstallation(rec.name); err != nil {
+					return summary, errors.Wrapf(err, "could not remove the existing %s", rec.description)
+				}
+			}
+		}
+
+		if err = m.saveItems(rec.items); err != nil {
+			return summary, err
+		}
+
+		if exists[i] {
+			summary.Overwritten = append(summary.Overwritten, rec.description)
+		} else {
+			summary.Imported = append(summary.Imported, rec.description)
+		}
+	}
+
+	return summary, nil
+}
+
+// checkArchiveSchema compares the storage schema of an archive with the schema
+// used by this version of Porter. It returns true when the archive has an older
+// schema and must be migrated before it is imported, and an error when the
+// archive has a newer schema than this version of Porter supports.
+func checkArchiveSchema(archive Schema) (bool, error) {
+	current := currentSchema()
+	versions := []struct {
+		name    string
+		archive schema.Version
+		current schema.Version
+	}{
+		{name: "claims", archive: archive.Claims, current: current.Claims},
+		{name: "credentials", archive: archive.Credentials, current: current.Credentials},
+		{name: "parameters", archive: archive.Parameters, current: current.Parameters},
+	}
+
+	migrate := false
+	for _, v := range versions {
+		if v.archive == v.current {
+			continue
+		}
+
+		newer, err := isNewerSchemaVersion(v.archive, v.current)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid %s storage schema in the archive", v.name)
+		}
+		if newer {
+			return false, errors.Errorf("the archive was exported with a newer %s storage schema, %s, than this version of Porter supports, %s. Upgrade Porter to import it",
+				v.name, v.archive, v.current)
+		}
+		migrate = true
+	}
+	return migrate, nil
+}
+
+// isNewerSchemaVersion determines if a schema version, such as
+// cnab-claim-1.0.0-DRAFT+b5ed2f3, is newer than the current schema version.
+// Data saved before its schema was versioned doesn't have a version, and is older.
+func isNewerSchemaVersion(version schema.Version, current schema.Version) (bool, error) {
+	if version == "" {
+		return false, nil
+	}
+
+	parse := func(v schema.Version) (*semver.Version, error) {
+		sv, err := schema.GetSemver(string(v))
+		if err != nil {
+			return nil, err
+		}
+		return semver.NewVersion(string(sv))
+	}
+
+	got, err := parse(version)
+	if err != nil {
+		return false, err
+	}
+	want, err := parse(current)
+	if err != nil {
+		return false, err
+	}
+	return got.GreaterThan(want), nil
+}
+
+// migrateArchive migrates the items in an archive with an older storage
+// schema in a temporary in-memory store, and returns the archive with the
+// migrated items, so that it is imported like an archive with the current
+// schema.
+func (m *Manager) migrateArchive(manifest ArchiveManifest) (ArchiveManifest, error) {
+	// The migration logs and snapshot are saved to a temporary PORTER_HOME
+	// so that the migration can't be rolled back into the destination storage
+	home, err := m.FileSystem.TempDir("", "porter-import")
+	if err != nil {
+		return ArchiveManifest{}, errors.Wrap(err, "could not create a temporary directory to migrate the archive")
+	}
+
+	tmp := NewManager(newTempHomeConfig(m.Config, home), crud.NewMockStore())
+	err = tmp.saveItems(manifest.Items)
+	if err == nil {
+		err = tmp.saveSchema(manifest.StorageSchema)
+	}
+	if err != nil {
+		m.FileSystem.RemoveAll(home)
+		return ArchiveManifest{}, err
+	}
+
+	logfilePath, err := tmp.Migrate()
+	if err != nil {
+		// Keep the temporary directory so that the logs can be read
+		return ArchiveManifest{}, errors.Wrapf(err, "the archive could not be migrated to the current storage schema, nothing was imported, see the migration logs at %s", logfilePath)
+	}
+
+	err = tmp.Connect()
+	if err != nil {
+		return ArchiveManifest{}, err
+	}
+	items, err := tmp.listExportItems()
+	tmp.Close()
+	if err != nil {
+		return ArchiveManifest{}, err
+	}
+
+	if err = m.FileSystem.RemoveAll(home); err != nil {
+		fmt.Fprintf(m.Err, "WARNING: could not remove the temporary directory %s: %s\n", home, err)
+	}
+
+	manifest.StorageSchema = currentSchema()
+	manifest.Items = items
+	return manifest, nil
+}
+
+// newTempHomeConfig copies the configuration, using a different PORTER_HOME.
+func newTempHomeConfig(c *config.Config, home string) *config.Config {
+	cxt := *c.Context
+	env := c.EnvironMap()
+	cxt.Clearenv()
+	for k, v := range env {
+		cxt.Setenv(k, v)
+	}
+
+	tmp := *c
+	tmp.Context = &cxt
+	tmp.SetHomeDir(home)
+	return &tmp
+}
+
+// currentSchema is the storage schema used by this version of Porter.
+func currentSchema() Schema {
+	return Schema{
+		Claims:      schema.Version(claim.CNABSpecVersion),
+		Credentials: schema.Version(credentials.CNABSpecVersion),
+		Parameters:  schema.Version(ParameterSetCNABSpecVersion),
+	}
+}
+
+func (m *Manager) saveSchema(s Schema) error {
+	schemaB, err := json.Marshal(s)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal the storage schema")
+	}
+	err = m.BackingStore.Save("", "", "schema", schemaB)
+	return errors.Wrap(err, "could not save the storage schema")
+}
+
+func (m *Manager) saveItems(items []ArchiveItem) error {
+	for _, item := range items {
+		err := m.BackingStore.Save(item.ItemType, item.Group, item.Name, item.data)
+		if err != nil {
+			return errors.Wrapf(err, "could not save %s %s", item.ItemType, item.Name)
+		}
+	}
+	return nil
+}
+
+// recordExists determines if an installation, credential set or parameter set is already in storage.
+func (m *Manager) recordExists(rec importRecord) (bool, error) {
+	if rec.itemType == claim.ItemTypeInstallations {
+		claimIDs, err := m.listItems(claim.ItemTypeClaims, rec.name)
+		return len(claimIDs) > 0, err
+	}
+
+	_, err := m.BackingStore.Read(rec.itemType, rec.name)
+	if err != nil {
+		if strings.Contains(err.Error(), crud.ErrRecordDoesNotExist.Error()) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "could not check for an existing %s", rec.description)
+	}
+	return true, nil
+}
+
+// groupImportRecords groups the claims, results and outputs of each
+// installation with the installation, so that they are imported together.
+func groupImportRecords(items []ArchiveItem) []importRecord {
+	var records []importRecord
+	installations := map[string]int{}
+	getInstallation := func(name string) int {
+		i, ok := installations[name]
+		if !ok {
+			i = len(records)
+			installations[name] = i
+			records = append(records, importRecord{
+				description: "installation " + name,
+				itemType:    claim.ItemTypeInstallations,
+				name:        name,
+			})
+		}
+		return i
+	}
+
+	// Track which installation each claim and result belongs to
+	claimInstallations := map[string]int{}
+	resultInstallations := map[string]int{}
+	var unowned []ArchiveItem
+	for _, item := range items {
+		switch item.ItemType {
+		case claim.ItemTypeInstallations:
+			i := getInstallation(item.Name)
+			records[i].items = append(records[i].items, item)
+		case claim.ItemTypeClaims:
+			if item.Group == "" {
+				// Claims in the old format are not grouped by installation
+				unowned = append(unowned, item)
+				continue
+			}
+			i := getInstallation(item.Group)
+			claimInstallations[item.Name] = i
+			records[i].items = append(records[i].items, item)
+		case claim.ItemTypeResults:
+			i, ok := claimInstallations[item.Group]
+			if !ok {
+				unowned = append(unowned, item)
+				continue
+			}
+			resultInstallations[item.Name] = i
+			records[i].items = append(records[i].items, item)
+		case claim.ItemTypeOutputs:
+			i, ok := resultInstallations[item.Group]
+			if !ok {
+				unowned = append(unowned, item)
+				continue
+			}
+			records[i].items = append(records[i].items, item)
+		case credentials.ItemType:
+			records = append(records, importRecord{
+				description: "credential set " + item.Name,
+				itemType:    item.ItemType,
+				name:        item.Name,
+				items:       []ArchiveItem{item},
+			})
+		case parametersItemType:
+			records = append(records, importRecord{
+				description: "parameter set " + item.Name,
+				itemType:    item.ItemType,
+				name:        item.Name,
+				items:       []ArchiveItem{item},
+			})
+		default:
+			unowned = append(unowned, item)
+		}
+	}
+
+	for _, item := range unowned {
+		records = append(records, importRecord{
+			description: fmt.Sprintf("%s %s", item.ItemType, item.Name),
+			itemType:    item.ItemType,
+			name:        item.Name,
+			items:       []ArchiveItem{item},
+		})
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].description < records[j].description
+	})
+	return records
+}
@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/storage/filesystem"
+	"get.porter.sh/porter/pkg/storage/sqlite"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/cnabio/cnab-go/schema"
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/cnabio/cnab-go/valuesource"
+	hclog "github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newArchiveTestManager creates a manager for filesystem storage in a temporary PORTER_HOME.
+func newArchiveTestManager(t *testing.T) (*config.TestConfig, *Manager) {
+	c := config.NewTestConfig(t)
+	_, home := c.TestContext.UseFilesystem()
+	c.SetHomeDir(home)
+
+	dataStore := crud.NewBackingStore(filesystem.NewStore(*c.Config, hclog.NewNullLogger()))
+	return c, NewManager(c.Config, dataStore)
+}
+
+// saveTestInstallation saves an installation with a claim, result and output.
+func saveTestInstallation(t *testing.T, mgr *Manager, installation string, output string) claim.Claim {
+	bun := bundle.Bundle{
+		Definitions: map[string]*definition.Schema{
+			"output1": {Type: "string"},
+		},
+		Outputs: map[string]bundle.Output{
+			"output1": {Definition: "output1"},
+		},
+	}
+
+	claimStore := claim.NewClaimStore(mgr, nil, nil)
+	c, err := claim.New(installation, claim.ActionInstall, bun, nil)
+	require.NoError(t, err)
+	require.NoError(t, claimStore.SaveClaim(c))
+	r, err := c.NewResult(claim.StatusSucceeded)
+	require.NoError(t, err)
+	require.NoError(t, claimStore.SaveResult(r))
+	require.NoError(t, claimStore.SaveOutput(claim.NewOutput(c, r, "output1", []byte(output))))
+	return c
+}
+
+func saveTestCredentialSet(t *testing.T, mgr *Manager, name string, value string) {
+	credStore := credentials.NewCredentialStore(mgr)
+	cs := credentials.NewCredentialSet(name, valuesource.Strategy{
+		Name:   "password",
+		Source: valuesource.Source{Key: "value", Value: value},
+	})
+	require.NoError(t, credStore.Save(cs))
+}
+
+func exportTestData(t *testing.T) []byte {
+	c, src := newArchiveTestManager(t)
+	defer c.TestContext.Cleanup()
+
+	saveTestInstallation(t, src, "mysql", "mysql-output")
+	saveTestInstallation(t, src, "wordpress", "wordpress-output")
+	saveTestCredentialSet(t, src, "mycreds", "exported")
+	require.NoError(t, src.Save(parametersItemType, "", "myparams", []byte(`{"name":"myparams","schemaVersion":"1.0.0-DRAFT+TODO"}`)))
+	require.NoError(t, src.Save("locks", "", "mysql", []byte(`{}`)))
+
+	var archive bytes.Buffer
+	require.NoError(t, src.Export(&archive))
+	return archive.Bytes()
+}
+
+func TestManager_Export(t *testing.T) {
+	archive := exportTestData(t)
+
+	manifest, err := readArchive(bytes.NewReader(archive))
+	require.NoError(t, err)
+
+	assert.Equal(t, ArchiveSchemaVersion, manifest.SchemaVersion)
+	assert.Equal(t, currentSchema(), manifest.StorageSchema)
+
+	counts := map[string]int{}
+	for _, item := range manifest.Items {
+		counts[item.ItemType]++
+	}
+	wantCounts := map[string]int{
+		claim.ItemTypeInstallations: 2,
+		claim.ItemTypeClaims:        2,
+		claim.ItemTypeResults:       2,
+		claim.ItemTypeOutputs:       2,
+		credentials.ItemType:        1,
+		parametersItemType:          1,
+	}
+	assert.Equal(t, wantCounts, counts, "every item except the installation locks should be exported")
+}
+
+func TestManager_Import(t *testing.T) {
+	archive := exportTestData(t)
+
+	t.Run("into sqlite storage", func(t *testing.T) {
+		c := config.NewTestConfig(t)
+		_, home := c.TestContext.UseFilesystem()
+		c.SetHomeDir(home)
+		defer c.TestContext.Cleanup()
+		dest := NewManager(c.Config, sqlite.NewStore(*c.Config, hclog.NewNullLogger()))
+
+		summary, err := dest.Import(bytes.NewReader(archive), ImportConflictFail)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"credential set mycreds", "installation mysql", "installation wordpress", "parameter set myparams"}, summary.Imported)
+
+		claimStore := claim.NewClaimStore(dest, nil, nil)
+		installations, err := claimStore.ListInstallations()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mysql", "wordpress"}, installations)
+
+		output, err := claimStore.ReadLastOutput("wordpress", "output1")
+		require.NoError(t, err)
+		assert.Equal(t, "wordpress-output", string(output.Value))
+
+		cs, err := credentials.NewCredentialStore(dest).Read("mycreds")
+		require.NoError(t, err)
+		assert.Equal(t, "exported", cs.Credentials[0].Source.Value)
+
+		_, err = dest.Read(parametersItemType, "myparams")
+		require.NoError(t, err)
+	})
+
+	testcases := []struct {
+		conflict      string
+		wantError     string
+		wantOutput    string
+		wantCreds     string
+		wantImported  []string
+		wantSkipped   []string
+		wantOverwrote []string
+	}{
+		{conflict: ImportConflictSkip, wantOutput: "existing-output", wantCreds: "existing",
+			wantImported: []string{"installation wordpress", "parameter set myparams"},
+			wantSkipped:  []string{"credential set mycreds", "installation mysql"}},
+		{conflict: ImportConflictOverwrite, wantOutput: "mysql-output", wantCreds: "exported",
+			wantImported:  []string{"installation wordpress", "parameter set myparams"},
+			wantOverwrote: []string{"credential set mycreds", "installation mysql"}},
+		{conflict: ImportConflictFail, wantOutput: "existing-output", wantCreds: "existing",
+			wantError: "the following records are already in storage, nothing was imported: credential set mycreds, installation mysql"},
+	}
+	for _, tc := range testcases {
+		t.Run("conflict "+tc.conflict, func(t *testing.T) {
+			c, dest := newArchiveTestManager(t)
+			defer c.TestContext.Cleanup()
+
+			existing := saveTestInstallation(t, dest, "mysql", "existing-output")
+			saveTestCredentialSet(t, dest, "mycreds", "existing")
+
+			summary, err := dest.Import(bytes.NewReader(archive), tc.conflict)
+			if tc.wantError != "" {
+				require.EqualError(t, err, tc.wantError)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.wantImported, summary.Imported)
+			assert.Equal(t, tc.wantSkipped, summary.Skipped)
+			assert.Equal(t, tc.wantOverwrote, summary.Overwritten)
+
+			claimStore := claim.NewClaimStore(dest, nil, nil)
+			output, err := claimStore.ReadLastOutput("mysql", "output1")
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantOutput, string(output.Value))
+
+			i, err := claimStore.ReadInstallation("mysql")
+			require.NoError(t, err)
+			require.Len(t, i.Claims, 1, "the installation history should not be merged")
+			if tc.conflict == ImportConflictOverwrite {
+				assert.NotEqual(t, existing.ID, i.Claims[0].ID)
+			} else {
+				assert.Equal(t, existing.ID, i.Claims[0].ID)
+			}
+
+			cs, err := credentials.NewCredentialStore(dest).Read("mycreds")
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantCreds, cs.Credentials[0].Source.Value)
+		})
+	}
+}
+
+func TestManager_Import_Migrate(t *testing.T) {
+	const installation = "example-exec-outputs"
+
+	claimB, err := ioutil.ReadFile(filepath.Join("testdata/claims", "has-installation.json"))
+	require.NoError(t, err)
+
+	// An archive exported before claims were split into claims, results and outputs
+	var archive bytes.Buffer
+	err = writeArchive(&archive, ArchiveManifest{
+		SchemaVersion: ArchiveSchemaVersion,
+		Items: []ArchiveItem{
+			{ItemType: claim.ItemTypeClaims, Name: installation, Path: "items/claims/" + installation, data: claimB},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("empty storage", func(t *testing.T) {
+		c, dest := newArchiveTestManager(t)
+		defer c.TestContext.Cleanup()
+
+		summary, err := dest.Import(bytes.NewReader(archive.Bytes()), ImportConflictSkip)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"installation " + installation}, summary.Imported)
+		assert.Contains(t, c.TestContext.GetError(), "Migrating claims data")
+
+		home, err := c.GetHomeDir()
+		require.NoError(t, err)
+		files, err := c.FileSystem.ReadDir(home)
+		require.NoError(t, err)
+		for _, f := range files {
+			assert.NotContains(t, f.Name(), snapshotFileSuffix, "the archive should not be migrated in the destination storage")
+		}
+
+		claimStore := claim.NewClaimStore(dest, nil, nil)
+		lastClaim, err := claimStore.ReadLastClaim(installation)
+		require.NoError(t, err)
+		assert.Equal(t, installation, lastClaim.Installation)
+	})
+
+	t.Run("existing data", func(t *testing.T) {
+		c, dest := newArchiveTestManager(t)
+		defer c.TestContext.Cleanup()
+
+		saveTestCredentialSet(t, dest, "mycreds", "existing")
+
+		summary, err := dest.Import(bytes.NewReader(archive.Bytes()), ImportConflictFail)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"installation " + installation}, summary.Imported)
+
+		cs, err := credentials.NewCredentialStore(dest).Read("mycreds")
+		require.NoError(t, err)
+		assert.Equal(t, "existing", cs.Credentials[0].Source.Value, "the existing data should be kept")
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		c, dest := newArchiveTestManager(t)
+		defer c.TestContext.Cleanup()
+
+		existing := saveTestInstallation(t, dest, installation, "existing-output")
+
+		_, err := dest.Import(bytes.NewReader(archive.Bytes()), ImportConflictFail)
+		require.EqualError(t, err, "the following records are already in storage, nothing was imported: installation "+installation)
+
+		summary, err := dest.Import(bytes.NewReader(archive.Bytes()), ImportConflictSkip)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"installation " + installation}, summary.Skipped)
+
+		claimStore := claim.NewClaimStore(dest, nil, nil)
+		lastClaim, err := claimStore.ReadLastClaim(installation)
+		require.NoError(t, err)
+		assert.Equal(t, existing.ID, lastClaim.ID, "the existing installation should be kept")
+	})
+}
+
+func TestManager_Import_NewerSchema(t *testing.T) {
+	newer := currentSchema()
+	newer.Claims = "cnab-claim-2.0.0"
+
+	var archive bytes.Buffer
+	require.NoError(t, writeArchive(&archive, ArchiveManifest{SchemaVersion: ArchiveSchemaVersion, StorageSchema: newer}))
+
+	c, dest := newArchiveTestManager(t)
+	defer c.TestContext.Cleanup()
+
+	_, err := dest.Import(&archive, ImportConflictSkip)
+	require.EqualError(t, err, "the archive was exported with a newer claims storage schema, cnab-claim-2.0.0, than this version of Porter supports, "+claim.CNABSpecVersion+". Upgrade Porter to import it")
+}
+
+func TestCheckArchiveSchema(t *testing.T) {
+	testcases := []struct {
+		name        string
+		claims      schema.Version
+		wantMigrate bool
+		wantError   string
+	}{
+		{name: "current", claims: schema.Version(claim.CNABSpecVersion)},
+		{name: "unversioned", claims: "", wantMigrate: true},
+		{name: "older", claims: "cnab-claim-0.9.0", wantMigrate: true},
+		{name: "different build", claims: "cnab-claim-1.0.0-DRAFT", wantMigrate: true},
+		{name: "newer", claims: "cnab-claim-1.0.0", wantError: "the archive was exported with a newer claims storage schema"},
+		{name: "invalid", claims: "claims-v1", wantError: "invalid claims storage schema in the archive"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			archiveSchema := currentSchema()
+			archiveSchema.Claims = tc.claims
+
+			migrate, err := checkArchiveSchema(archiveSchema)
+			if tc.wantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantMigrate, migrate)
+			}
+		})
+	}
+}
+
+func TestReadArchive_UnsupportedVersion(t *testing.T) {
+	var archive bytes.Buffer
+	require.NoError(t, writeArchive(&archive, ArchiveManifest{SchemaVersion: "2.0.0"}))
+
+	_, err := readArchive(&archive)
+	require.EqualError(t, err, `unsupported archive schemaVersion "2.0.0", this version of Porter supports 1.0.0`)
+
+	manifestB, err := json.Marshal(ArchiveManifest{})
+	require.NoError(t, err)
+	_, err = readArchive(bytes.NewReader(manifestB))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "it is not a gzipped tar file")
+}
@@ -14,6 +14,13 @@ const PluginInterface = "storage"
 
 var _ plugin.Plugin = &Plugin{}
 
+// HasReadAll is implemented by stores that can read every item of a type and
+// group at once, instead of listing the items and reading them one at a time.
+type HasReadAll interface {
+	// ReadAll retrieves the items of the type and optional group.
+	ReadAll(itemType string, group string) ([][]byte, error)
+}
+
 // Plugin is a generic type of plugin for working with any implementation of a crud store.
 type Plugin struct {
 	Impl crud.Store
@@ -2,11 +2,13 @@ package crudstore
 
 import (
 	"net/rpc"
+	"strings"
 
 	"github.com/cnabio/cnab-go/utils/crud"
 )
 
 var _ crud.Store = &Client{}
+var _ HasReadAll = &Client{}
 
 type Client struct {
 	client *rpc.Client
@@ -42,6 +44,23 @@ func (g *Client) List(itemType string, group string) ([]string, error) {
 	return resp, err
 }
 
+// ReadAll retrieves the items of the type and optional group with a single
+// call to the plugin.
+func (g *Client) ReadAll(itemType string, group string) ([][]byte, error) {
+	var resp [][]byte
+	args := map[string]interface{}{
+		"itemType": itemType,
+		"group":    group,
+	}
+	err := g.client.Call("Plugin.ReadAll", args, &resp)
+	if err != nil && strings.Contains(err.Error(), "can't find method Plugin.ReadAll") {
+		// The plugin was built with an older version of Porter, list the
+		// items and read them one at a time instead
+		return crud.NewBackingStore(g).ReadAll(itemType, group)
+	}
+	return resp, err
+}
+
 func (g *Client) Save(itemType string, group string, name string, data []byte) error {
 	var resp interface{}
 	args := map[string]interface{}{
@@ -84,6 +103,17 @@ func (s *Server) List(args map[string]interface{}, resp *[]string) error {
 	return err
 }
 
+func (s *Server) ReadAll(args map[string]interface{}, resp *[][]byte) error {
+	store, ok := s.Impl.(HasReadAll)
+	if !ok {
+		store = crud.NewBackingStore(s.Impl)
+	}
+
+	var err error
+	*resp, err = store.ReadAll(args["itemType"].(string), args["group"].(string))
+	return err
+}
+
 func (s *Server) Save(args map[string]interface{}, resp *interface{}) error {
 	return s.Impl.Save(args["itemType"].(string), args["group"].(string), args["name"].(string), args["data"].([]byte))
 }
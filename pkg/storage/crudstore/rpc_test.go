@@ -0,0 +1,84 @@
+package crudstore
+
+import (
+	"net"
+	"net/rpc"
+	"testing"
+
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAllStore counts the calls to ReadAll.
+type readAllStore struct {
+	crud.MockStore
+	readAllCount int
+}
+
+func (s *readAllStore) ReadAll(itemType string, group string) ([][]byte, error) {
+	s.readAllCount++
+	return crud.NewBackingStore(s.MockStore).ReadAll(itemType, group)
+}
+
+// legacyServer is the server of a plugin built with an older version of Porter,
+// that doesn't implement ReadAll.
+type legacyServer struct {
+	impl *Server
+}
+
+func (s *legacyServer) List(args map[string]interface{}, resp *[]string) error {
+	return s.impl.List(args, resp)
+}
+
+func (s *legacyServer) Read(args map[string]interface{}, resp *[]byte) error {
+	return s.impl.Read(args, resp)
+}
+
+func newTestClient(t *testing.T, server interface{}) *Client {
+	s := rpc.NewServer()
+	require.NoError(t, s.RegisterName("Plugin", server))
+
+	clientConn, serverConn := net.Pipe()
+	go s.ServeConn(serverConn)
+	return &Client{client: rpc.NewClient(clientConn)}
+}
+
+func TestClient_ReadAll(t *testing.T) {
+	setup := func(t *testing.T) crud.MockStore {
+		store := crud.NewMockStore()
+		require.NoError(t, store.Save("items", "group1", "key1", []byte("value1")))
+		require.NoError(t, store.Save("items", "group1", "key2", []byte("value2")))
+		require.NoError(t, store.Save("items", "group2", "key3", []byte("value3")))
+		return store
+	}
+
+	t.Run("store reads all", func(t *testing.T) {
+		store := &readAllStore{MockStore: setup(t)}
+		client := newTestClient(t, &Server{Impl: store})
+		defer client.client.Close()
+
+		results, err := client.ReadAll("items", "group1")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, [][]byte{[]byte("value1"), []byte("value2")}, results)
+		assert.Equal(t, 1, store.readAllCount, "the store should read the items at once")
+	})
+
+	t.Run("store lists and reads", func(t *testing.T) {
+		client := newTestClient(t, &Server{Impl: setup(t)})
+		defer client.client.Close()
+
+		results, err := client.ReadAll("items", "group1")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, [][]byte{[]byte("value1"), []byte("value2")}, results)
+	})
+
+	t.Run("older plugin", func(t *testing.T) {
+		client := newTestClient(t, &legacyServer{impl: &Server{Impl: setup(t)}})
+		defer client.client.Close()
+
+		results, err := client.ReadAll("items", "group1")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, [][]byte{[]byte("value1"), []byte("value2")}, results)
+	})
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/pkg/errors"
+)
+
+// Encrypt saves every installation, credential set and parameter set, and the
+// schema, again so that a storage plugin that encrypts the data when it is
+// saved, such as the filesystem plugin with an encryption-key, encrypts the
+// existing data with its current key. This is used after encryption is enabled,
+// or the key is rotated. Returns the number of items that were saved.
+func (m *Manager) Encrypt() (int, error) {
+	// Reuse the same connection for every item
+	err := m.Connect()
+	if err != nil {
+		return 0, err
+	}
+	defer m.Close()
+
+	items, err := m.listExportItems()
+	if err != nil {
+		return 0, err
+	}
+
+	schemaB, err := m.BackingStore.Read("", "schema")
+	if err != nil && !strings.Contains(err.Error(), crud.ErrRecordDoesNotExist.Error()) {
+		return 0, errors.Wrap(err, "could not read storage schema document")
+	}
+	if err == nil {
+		items = append(items, ArchiveItem{Name: "schema", data: schemaB})
+	}
+
+	for i, item := range items {
+		err = m.BackingStore.Save(item.ItemType, item.Group, item.Name, item.data)
+		if err != nil {
+			return i, errors.Wrapf(err, "could not save %s %s", item.ItemType, item.Name)
+		}
+	}
+	return len(items), nil
+}
@@ -0,0 +1,52 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	"github.com/cnabio/cnab-go/valuesource"
+	"github.com/pkg/errors"
+)
+
+// PluginConfig is the configuration for the filesystem plugin, defined in the
+// config section of a storage entry in Porter's config file. Encryption keys
+// are resolved with the configured secrets plugin, like the sources of a
+// credential set, e.g. {env: PORTER_STORAGE_KEY}.
+type PluginConfig struct {
+	// EncryptionKey is the source of the key used to encrypt the data when it
+	// is saved. When it is not set, the data is saved unencrypted.
+	EncryptionKey *valuesource.Source `json:"encryption-key,omitempty"`
+
+	// PreviousEncryptionKeys are the sources of keys that were used before the
+	// encryption key was rotated. They are only used to decrypt existing data,
+	// until it is encrypted again with the current key by porter storage encrypt.
+	PreviousEncryptionKeys []valuesource.Source `json:"previous-encryption-keys,omitempty"`
+}
+
+// ReadPluginConfig parses the plugin configuration that Porter passes to the
+// plugin on stdin, which is empty when no configuration is defined.
+func ReadPluginConfig(r io.Reader) (PluginConfig, error) {
+	var cfg PluginConfig
+	if r == nil {
+		return cfg, nil
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return cfg, errors.Wrap(err, "could not read the filesystem plugin configuration")
+	}
+	if len(b) == 0 {
+		return cfg, nil
+	}
+
+	err = json.Unmarshal(b, &cfg)
+	if err != nil {
+		return cfg, errors.Wrap(err, "could not parse the filesystem plugin configuration")
+	}
+
+	if len(cfg.PreviousEncryptionKeys) > 0 && cfg.EncryptionKey == nil {
+		return cfg, errors.New("invalid filesystem plugin configuration, previous-encryption-keys requires encryption-key to be set")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,249 @@
+package filesystem
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// encryptedPrefix identifies data that was encrypted by the filesystem store.
+// It is followed by the parameters used to derive the key from the secret
+// (scrypt:N:r:p:salt), the id of the derived key, and then the base64 encoded
+// nonce and ciphertext, all separated by colons.
+const encryptedPrefix = "porter-encrypted:aes-256-gcm:"
+
+const (
+	// kdfName is the key derivation function recorded in the envelope.
+	kdfName = "scrypt"
+
+	// Cost parameters for new keys, the recommended values for interactive use.
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	// Upper bounds on the cost parameters accepted from an envelope, so that
+	// tampered data can't make porter spend minutes deriving a key.
+	maxScryptN = 1 << 20
+	maxScryptR = 32
+	maxScryptP = 16
+
+	saltSize = 16
+	keySize  = 32
+)
+
+// kdfParams are the parameters used to derive a key from a secret with scrypt.
+// They are stored alongside the encrypted data so that the cost can be raised
+// later without breaking data that was already encrypted.
+type kdfParams struct {
+	n, r, p int
+	salt    []byte
+}
+
+// newKDFParams generates parameters with a random salt.
+func newKDFParams() (kdfParams, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return kdfParams{}, errors.Wrap(err, "could not generate a salt")
+	}
+	return kdfParams{n: scryptN, r: scryptR, p: scryptP, salt: salt}, nil
+}
+
+// parseKDFParams reads the parameters from the fields of an envelope:
+// scrypt, N, r, p and salt.
+func parseKDFParams(fields []string) (kdfParams, error) {
+	if len(fields) != 5 || fields[0] != kdfName {
+		return kdfParams{}, errors.New("the encrypted data is malformed, unsupported key derivation parameters")
+	}
+
+	var params kdfParams
+	var err error
+	for i, v := range []*int{&params.n, &params.r, &params.p} {
+		*v, err = strconv.Atoi(fields[i+1])
+		if err != nil {
+			return kdfParams{}, errors.Wrap(err, "the encrypted data is malformed")
+		}
+	}
+	if params.n < 2 || params.n > maxScryptN || params.r < 1 || params.r > maxScryptR || params.p < 1 || params.p > maxScryptP {
+		return kdfParams{}, errors.Errorf("the encrypted data is malformed, the key derivation parameters N=%d r=%d p=%d are out of range", params.n, params.r, params.p)
+	}
+
+	params.salt, err = base64.StdEncoding.DecodeString(fields[4])
+	if err != nil || len(params.salt) == 0 {
+		return kdfParams{}, errors.New("the encrypted data is malformed, invalid salt")
+	}
+	return params, nil
+}
+
+func (p kdfParams) String() string {
+	return fmt.Sprintf("%s:%d:%d:%d:%s", kdfName, p.n, p.r, p.p, base64.StdEncoding.EncodeToString(p.salt))
+}
+
+// encryptionKey is a secret that keys are derived from. Derived keys are
+// cached by their parameters because scrypt is deliberately slow.
+type encryptionKey struct {
+	secret []byte
+
+	mu      sync.Mutex
+	derived map[string]*derivedKey
+}
+
+// derivedKey encrypts data with AES-256-GCM.
+type derivedKey struct {
+	params kdfParams
+
+	// id identifies the key that encrypted the data without revealing the key.
+	id   string
+	aead cipher.AEAD
+}
+
+func newEncryptionKey(secret string) (*encryptionKey, error) {
+	if secret == "" {
+		return nil, errors.New("the encryption key is empty")
+	}
+
+	return &encryptionKey{
+		secret:  []byte(secret),
+		derived: make(map[string]*derivedKey),
+	}, nil
+}
+
+// derive an AES-256 key from the secret with scrypt.
+func (k *encryptionKey) derive(params kdfParams) (*derivedKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if key, ok := k.derived[params.String()]; ok {
+		return key, nil
+	}
+
+	key, err := scrypt.Key(k.secret, params.salt, params.n, params.r, params.p, keySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not derive the encryption key")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create the encryption cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create the encryption cipher")
+	}
+
+	id := sha256.Sum256(key)
+	derived := &derivedKey{
+		params: params,
+		id:     hex.EncodeToString(id[:8]),
+		aead:   aead,
+	}
+	k.derived[params.String()] = derived
+	return derived, nil
+}
+
+// keyring encrypts data with the current key, and decrypts data with the key
+// that encrypted it, which may be a key from before the key was rotated.
+type keyring struct {
+	// keys to try when decrypting, starting with the current key.
+	keys []*encryptionKey
+
+	// params used to encrypt data with the current key, the salt is generated
+	// once per keyring so that the key is only derived once.
+	mu     sync.Mutex
+	params *kdfParams
+}
+
+func newKeyring(current *encryptionKey, previous ...*encryptionKey) *keyring {
+	return &keyring{keys: append([]*encryptionKey{current}, previous...)}
+}
+
+// currentKey derives the key used to encrypt new data.
+func (k *keyring) currentKey() (*derivedKey, error) {
+	k.mu.Lock()
+	if k.params == nil {
+		params, err := newKDFParams()
+		if err != nil {
+			k.mu.Unlock()
+			return nil, err
+		}
+		k.params = &params
+	}
+	params := *k.params
+	k.mu.Unlock()
+
+	return k.keys[0].derive(params)
+}
+
+func (k *keyring) encrypt(data []byte) ([]byte, error) {
+	key, err := k.currentKey()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "could not generate a nonce")
+	}
+
+	sealed := key.aead.Seal(nonce, nonce, data, nil)
+	envelope := strings.Join([]string{key.params.String(), key.id, base64.StdEncoding.EncodeToString(sealed)}, ":")
+	return []byte(encryptedPrefix + envelope), nil
+}
+
+func (k *keyring) decrypt(data []byte) ([]byte, error) {
+	envelope := strings.Split(string(bytes.TrimPrefix(data, []byte(encryptedPrefix))), ":")
+	if len(envelope) != 7 {
+		return nil, errors.New("the encrypted data is malformed")
+	}
+
+	params, err := parseKDFParams(envelope[:5])
+	if err != nil {
+		return nil, err
+	}
+
+	id := envelope[5]
+	var key *derivedKey
+	for _, candidate := range k.keys {
+		derived, err := candidate.derive(params)
+		if err != nil {
+			return nil, err
+		}
+		if derived.id == id {
+			key = derived
+			break
+		}
+	}
+	if key == nil {
+		return nil, errors.Errorf("the data was encrypted with a key (%s) that is not configured, add it to previous-encryption-keys", id)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(envelope[6])
+	if err != nil {
+		return nil, errors.Wrap(err, "the encrypted data is malformed")
+	}
+	nonceSize := key.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("the encrypted data is malformed")
+	}
+
+	plaintext, err := key.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decrypt the data")
+	}
+	return plaintext, nil
+}
+
+// isEncrypted determines if data was encrypted by the filesystem store.
+func isEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedPrefix))
+}
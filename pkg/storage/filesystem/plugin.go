@@ -2,6 +2,8 @@ package filesystem
 
 import (
 	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/secrets"
+	secretplugins "get.porter.sh/porter/pkg/secrets/pluginstore"
 	"get.porter.sh/porter/pkg/storage/crudstore"
 	"github.com/cnabio/cnab-go/utils/crud"
 	"github.com/hashicorp/go-hclog"
@@ -26,9 +28,20 @@ func NewPlugin(c config.Config) plugin.Plugin {
 		JSONFormat: true,
 	})
 
+	store := &Store{
+		Config: c,
+		logger: logger,
+		// Resolve the encryption keys with the configured secrets plugin
+		secrets: secrets.NewSecretStore(secretplugins.NewStore(&c)),
+	}
+
+	// Porter passes the plugin config on stdin, an invalid config is reported when porter connects
+	store.pluginConfig, store.configErr = ReadPluginConfig(c.In)
+
 	return &crudstore.Plugin{
 		Impl: &Plugin{
-			Store: NewStore(c, logger),
+			// Wrapping the store in a backing store so that its Connect is used.
+			Store: crud.NewBackingStore(store),
 		},
 	}
 }
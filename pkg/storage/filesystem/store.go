@@ -4,7 +4,9 @@ import (
 	"get.porter.sh/porter/pkg/config"
 	"github.com/cnabio/cnab-go/claim"
 	"github.com/cnabio/cnab-go/credentials"
+	cnabsecrets "github.com/cnabio/cnab-go/secrets"
 	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/cnabio/cnab-go/valuesource"
 	"github.com/hashicorp/go-hclog"
 	"github.com/pkg/errors"
 )
@@ -16,13 +18,33 @@ type Store struct {
 	crud.Store
 	config.Config
 	logger hclog.Logger
+
+	// pluginConfig is the configuration of the plugin, such as the encryption keys.
+	pluginConfig PluginConfig
+
+	// configErr is why the plugin configuration could not be read.
+	configErr error
+
+	// secrets resolves the encryption keys.
+	secrets cnabsecrets.Store
+
+	// keys encrypt the data when it is saved, nil when encryption is not configured.
+	keys *keyring
 }
 
 func NewStore(c config.Config, l hclog.Logger) crud.Store {
+	return NewStoreWithConfig(c, l, PluginConfig{}, nil)
+}
+
+// NewStoreWithConfig creates a filesystem store with the plugin configuration,
+// resolving any encryption keys with the secrets store.
+func NewStoreWithConfig(c config.Config, l hclog.Logger, pluginCfg PluginConfig, secrets cnabsecrets.Store) crud.Store {
 	// Wrapping ourselves in a backing store so that our Connect is used.
 	return crud.NewBackingStore(&Store{
-		Config: c,
-		logger: l,
+		Config:       c,
+		logger:       l,
+		pluginConfig: pluginCfg,
+		secrets:      secrets,
 	})
 }
 
@@ -31,6 +53,10 @@ func (s *Store) Connect() error {
 		return nil
 	}
 
+	if s.configErr != nil {
+		return s.configErr
+	}
+
 	home, err := s.Config.GetHomeDir()
 	if err != nil {
 		return errors.Wrap(err, "could not determine home directory for filesystem storage")
@@ -38,10 +64,87 @@ func (s *Store) Connect() error {
 
 	s.logger.Info("PORTER HOME: " + home)
 
+	if s.pluginConfig.EncryptionKey != nil {
+		s.keys, err = s.loadKeys()
+		if err != nil {
+			return err
+		}
+	}
+
 	s.Store = crud.NewFileSystemStore(home, NewFileExtensions())
 	return nil
 }
 
+// loadKeys resolves the current and previous encryption keys.
+func (s *Store) loadKeys() (*keyring, error) {
+	if s.secrets == nil {
+		return nil, errors.New("encryption is configured for the filesystem storage plugin but no secrets store is available to resolve the encryption key")
+	}
+
+	resolveKey := func(src valuesource.Source) (*encryptionKey, error) {
+		secret, err := s.secrets.Resolve(src.Key, src.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not resolve the encryption key from %s %q", src.Key, src.Value)
+		}
+		key, err := newEncryptionKey(secret)
+		return key, errors.Wrapf(err, "invalid encryption key from %s %q", src.Key, src.Value)
+	}
+
+	current, err := resolveKey(*s.pluginConfig.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make([]*encryptionKey, 0, len(s.pluginConfig.PreviousEncryptionKeys))
+	for _, src := range s.pluginConfig.PreviousEncryptionKeys {
+		key, err := resolveKey(src)
+		if err != nil {
+			return nil, err
+		}
+		previous = append(previous, key)
+	}
+
+	return newKeyring(current, previous...), nil
+}
+
+// Save an item, encrypting it first when encryption is configured.
+func (s *Store) Save(itemType string, group string, name string, data []byte) error {
+	if s.keys != nil {
+		var err error
+		data, err = s.keys.encrypt(data)
+		if err != nil {
+			return errors.Wrapf(err, "could not encrypt %s", describeItem(itemType, name))
+		}
+	}
+
+	return s.Store.Save(itemType, group, name, data)
+}
+
+// Read an item, decrypting it when it was encrypted. Data that was saved
+// before encryption was configured is returned as-is.
+func (s *Store) Read(itemType string, name string) ([]byte, error) {
+	data, err := s.Store.Read(itemType, name)
+	if err != nil || !isEncrypted(data) {
+		return data, err
+	}
+
+	if s.keys == nil {
+		return nil, errors.Errorf("%s is encrypted but no encryption-key is configured for the filesystem storage plugin", describeItem(itemType, name))
+	}
+
+	data, err = s.keys.decrypt(data)
+	return data, errors.Wrapf(err, "could not decrypt %s", describeItem(itemType, name))
+}
+
+// describeItem names an item in error messages, top level items such as the
+// schema document don't have an item type.
+func describeItem(itemType string, name string) string {
+	if itemType == "" {
+		return name
+	}
+	return itemType + " " + name
+}
+
 func NewFileExtensions() map[string]string {
 	ext := claim.NewClaimStoreFileExtensions()
 
@@ -51,6 +154,9 @@ func NewFileExtensions() map[string]string {
 	// TODO (carolynvs): change to parameters.ItemType once parameters move to cnab-go
 	ext["parameters"] = jsonExt
 
+	// Installation locks, see claims.ItemTypeLocks
+	ext["locks"] = jsonExt
+
 	// Handle top level files, like schema.json
 	ext[""] = jsonExt
 
@@ -0,0 +1,197 @@
+package filesystem
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/storage"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/cnabio/cnab-go/secrets/host"
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/cnabio/cnab-go/valuesource"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestConfig(t *testing.T) (*config.TestConfig, string) {
+	c := config.NewTestConfig(t)
+	_, home := c.TestContext.UseFilesystem()
+	c.SetHomeDir(home)
+	return c, home
+}
+
+// newEncryptedTestStore creates a store that resolves its keys from hard-coded values.
+func newEncryptedTestStore(c *config.TestConfig, key string, previousKeys ...string) crud.Store {
+	cfg := PluginConfig{
+		EncryptionKey: &valuesource.Source{Key: host.SourceValue, Value: key},
+	}
+	for _, k := range previousKeys {
+		cfg.PreviousEncryptionKeys = append(cfg.PreviousEncryptionKeys, valuesource.Source{Key: host.SourceValue, Value: k})
+	}
+	return NewStoreWithConfig(*c.Config, hclog.NewNullLogger(), cfg, &host.SecretStore{})
+}
+
+func TestStore_Encryption(t *testing.T) {
+	c, home := newTestConfig(t)
+	defer c.TestContext.Cleanup()
+
+	s := newEncryptedTestStore(c, "key1")
+	require.NoError(t, s.Save(credentials.ItemType, "", "mycreds", []byte(`{"password":"topsecret"}`)))
+
+	onDisk, err := c.FileSystem.ReadFile(filepath.Join(home, "credentials", "mycreds.json"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(onDisk), encryptedPrefix), "the data should be encrypted on disk")
+	assert.NotContains(t, string(onDisk), "topsecret")
+	assert.True(t, strings.HasPrefix(string(onDisk), encryptedPrefix+"scrypt:32768:8:1:"), "the key derivation parameters should be stored with the data")
+
+	data, err := s.Read(credentials.ItemType, "mycreds")
+	require.NoError(t, err)
+	assert.Equal(t, `{"password":"topsecret"}`, string(data), "the data should be decrypted when it is read")
+
+	all, err := s.(crud.ManagedStore).ReadAll(credentials.ItemType, "")
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`{"password":"topsecret"}`)}, all)
+
+	t.Run("no key configured", func(t *testing.T) {
+		_, err := NewStore(*c.Config, hclog.NewNullLogger()).Read(credentials.ItemType, "mycreds")
+		require.EqualError(t, err, "credentials mycreds is encrypted but no encryption-key is configured for the filesystem storage plugin")
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		_, err := newEncryptedTestStore(c, "key2").Read(credentials.ItemType, "mycreds")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "could not decrypt credentials mycreds: the data was encrypted with a key")
+		assert.Contains(t, err.Error(), "add it to previous-encryption-keys")
+	})
+
+	t.Run("rotated key", func(t *testing.T) {
+		rotated := newEncryptedTestStore(c, "key2", "key1")
+		data, err := rotated.Read(credentials.ItemType, "mycreds")
+		require.NoError(t, err)
+		assert.Equal(t, `{"password":"topsecret"}`, string(data), "data encrypted with a previous key should be decrypted")
+	})
+
+	t.Run("salted key", func(t *testing.T) {
+		other := newEncryptedTestStore(c, "key1")
+		require.NoError(t, other.Save(credentials.ItemType, "", "othercreds", []byte(`{"password":"topsecret"}`)))
+
+		otherOnDisk, err := c.FileSystem.ReadFile(filepath.Join(home, "credentials", "othercreds.json"))
+		require.NoError(t, err)
+		salt := func(data []byte) string { return strings.Split(string(data), ":")[6] }
+		id := func(data []byte) string { return strings.Split(string(data), ":")[7] }
+		assert.NotEqual(t, salt(onDisk), salt(otherOnDisk), "each store should derive its key with a new salt")
+		assert.NotEqual(t, id(onDisk), id(otherOnDisk), "the key id should depend on the salt")
+
+		data, err := s.Read(credentials.ItemType, "othercreds")
+		require.NoError(t, err)
+		assert.Equal(t, `{"password":"topsecret"}`, string(data), "data encrypted with a different salt should be decrypted")
+	})
+}
+
+func TestKeyring_Decrypt_InvalidParams(t *testing.T) {
+	key, err := newEncryptionKey("key1")
+	require.NoError(t, err)
+	k := newKeyring(key)
+
+	testcases := []struct {
+		name      string
+		envelope  string
+		wantError string
+	}{
+		{name: "missing fields", envelope: "abc:def",
+			wantError: "the encrypted data is malformed"},
+		{name: "unsupported kdf", envelope: "pbkdf2:1:8:1:c2FsdA==:abc:def",
+			wantError: "the encrypted data is malformed, unsupported key derivation parameters"},
+		{name: "expensive N", envelope: "scrypt:2097152:8:1:c2FsdA==:abc:def",
+			wantError: "the encrypted data is malformed, the key derivation parameters N=2097152 r=8 p=1 are out of range"},
+		{name: "invalid N", envelope: "scrypt:1000:8:1:c2FsdA==:abc:def",
+			wantError: "could not derive the encryption key: scrypt: N must be > 1 and a power of 2"},
+		{name: "missing salt", envelope: "scrypt:16:8:1::abc:def",
+			wantError: "the encrypted data is malformed, invalid salt"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := k.decrypt([]byte(encryptedPrefix + tc.envelope))
+			require.EqualError(t, err, tc.wantError)
+		})
+	}
+}
+
+func TestStore_Encryption_UnencryptedData(t *testing.T) {
+	c, _ := newTestConfig(t)
+	defer c.TestContext.Cleanup()
+
+	require.NoError(t, NewStore(*c.Config, hclog.NewNullLogger()).Save(credentials.ItemType, "", "mycreds", []byte(`{}`)))
+
+	data, err := newEncryptedTestStore(c, "key1").Read(credentials.ItemType, "mycreds")
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(data), "data saved before encryption was enabled should be read as-is")
+}
+
+func TestStore_Encryption_InvalidKey(t *testing.T) {
+	c, _ := newTestConfig(t)
+	defer c.TestContext.Cleanup()
+
+	s := newEncryptedTestStore(c, "")
+	err := s.Save(credentials.ItemType, "", "mycreds", []byte(`{}`))
+	require.EqualError(t, err, `invalid encryption key from value "": the encryption key is empty`)
+}
+
+func TestManager_Encrypt(t *testing.T) {
+	c, home := newTestConfig(t)
+	defer c.TestContext.Cleanup()
+
+	// Save data before encryption is enabled
+	mgr := storage.NewManager(c.Config, NewStore(*c.Config, hclog.NewNullLogger()))
+	credStore := credentials.NewCredentialStore(mgr)
+	require.NoError(t, credStore.Save(credentials.NewCredentialSet("mycreds")))
+
+	mgr = storage.NewManager(c.Config, newEncryptedTestStore(c, "key1"))
+	count, err := mgr.Encrypt()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "the credential set and schema should have been encrypted")
+
+	for _, file := range []string{"schema.json", "credentials/mycreds.json"} {
+		onDisk, err := c.FileSystem.ReadFile(filepath.Join(home, file))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(onDisk), encryptedPrefix), "%s should be encrypted", file)
+	}
+
+	_, err = credentials.NewCredentialStore(mgr).Read("mycreds")
+	require.NoError(t, err, "the encrypted data should be readable")
+}
+
+func TestReadPluginConfig(t *testing.T) {
+	testcases := []struct {
+		name      string
+		input     string
+		want      PluginConfig
+		wantError string
+	}{
+		{name: "no config"},
+		{name: "encryption", input: `{"encryption-key":{"env":"PORTER_STORAGE_KEY"},"previous-encryption-keys":[{"path":"/old-key"}]}`,
+			want: PluginConfig{
+				EncryptionKey:          &valuesource.Source{Key: "env", Value: "PORTER_STORAGE_KEY"},
+				PreviousEncryptionKeys: []valuesource.Source{{Key: "path", Value: "/old-key"}},
+			}},
+		{name: "previous keys only", input: `{"previous-encryption-keys":[{"path":"/old-key"}]}`,
+			wantError: "invalid filesystem plugin configuration, previous-encryption-keys requires encryption-key to be set"},
+		{name: "invalid json", input: `{`, wantError: "could not parse the filesystem plugin configuration: unexpected end of JSON input"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := ReadPluginConfig(strings.NewReader(tc.input))
+			if tc.wantError != "" {
+				require.EqualError(t, err, tc.wantError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.want, cfg)
+			}
+		})
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/storage/crudstore"
 	"github.com/cnabio/cnab-go/claim"
 	"github.com/cnabio/cnab-go/credentials"
 	"github.com/cnabio/cnab-go/schema"
@@ -42,6 +44,10 @@ type Manager struct {
 	// Allow the schema to be out-of-date, defaults to false. Prevents
 	// connections to underlying storage when the schema is out-of-date
 	allowOutOfDateSchema bool
+
+	// mu serializes access to the backing store, which does not support
+	// concurrent connections, e.g. when dependencies are executed in parallel.
+	mu sync.Mutex
 }
 
 // NewManager creates a storage manager for a backing datastore.
@@ -97,6 +103,9 @@ func (m *Manager) GetDataStore() crud.Store {
 }
 
 func (m *Manager) Count(itemType string, group string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	handleClose, err := m.HandleConnect()
 	defer handleClose()
 	if err != nil {
@@ -107,6 +116,9 @@ func (m *Manager) Count(itemType string, group string) (int, error) {
 }
 
 func (m *Manager) List(itemType string, group string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	handleClose, err := m.HandleConnect()
 	defer handleClose()
 	if err != nil {
@@ -117,6 +129,9 @@ func (m *Manager) List(itemType string, group string) ([]string, error) {
 }
 
 func (m *Manager) Save(itemType string, group string, name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	handleClose, err := m.HandleConnect()
 	defer handleClose()
 	if err != nil {
@@ -127,6 +142,9 @@ func (m *Manager) Save(itemType string, group string, name string, data []byte)
 }
 
 func (m *Manager) Read(itemType string, name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	handleClose, err := m.HandleConnect()
 	defer handleClose()
 	if err != nil {
@@ -137,16 +155,27 @@ func (m *Manager) Read(itemType string, name string) ([]byte, error) {
 }
 
 func (m *Manager) ReadAll(itemType string, group string) ([][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	handleClose, err := m.HandleConnect()
 	defer handleClose()
 	if err != nil {
 		return nil, err
 	}
 
+	// Let the datastore read the items at once when it can, e.g. a plugin
+	// reads them with a single call instead of a call per item
+	if store, ok := m.BackingStore.GetDataStore().(crudstore.HasReadAll); ok {
+		return store.ReadAll(itemType, group)
+	}
 	return m.BackingStore.ReadAll(itemType, group)
 }
 
 func (m *Manager) Delete(itemType string, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	handleClose, err := m.HandleConnect()
 	defer handleClose()
 	if err != nil {
@@ -185,6 +214,8 @@ func (m *Manager) MigrationRequired() bool {
 }
 
 // Migrate executes a migration on any/all of Porter's storage sub-systems.
+// The items that are migrated, and the schema, are saved to a snapshot in
+// PORTER_HOME before they are changed so that the migration can be rolled back.
 func (m *Manager) Migrate() (string, error) {
 	m.resetSchema()
 
@@ -206,7 +237,8 @@ func (m *Manager) Migrate() (string, error) {
 		return "", err
 	}
 
-	logfilePath := filepath.Join(home, fmt.Sprintf("%s-migrate.log", time.Now().Format("20060102150405")))
+	now := time.Now()
+	logfilePath := filepath.Join(home, fmt.Sprintf("%s-migrate.log", now.Format("20060102150405")))
 	logfile, err := m.FileSystem.Create(logfilePath)
 	if err != nil {
 		return "", errors.Wrapf(err, "error creating logfile for migration at %s", logfilePath)
@@ -214,6 +246,21 @@ func (m *Manager) Migrate() (string, error) {
 	defer logfile.Close()
 	w := io.MultiWriter(m.Err, logfile)
 
+	// Save the data that we are about to change so that the migration can be rolled back
+	changes, snapshotItems, err := m.planMigration()
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return logfilePath, err
+	}
+	if len(changes) > 0 {
+		snapshotPath, err := m.snapshotMigration(now, snapshotItems)
+		if err != nil {
+			fmt.Fprintln(w, err)
+			return logfilePath, err
+		}
+		fmt.Fprintf(w, "Saved a snapshot of the data to migrate to %s\n", snapshotPath)
+	}
+
 	var migrationErr *multierror.Error
 	if m.ShouldMigrateClaims() {
 		fmt.Fprintf(w, "Claims schema is out-of-date (want: %s got: %s)\n", claim.CNABSpecVersion, m.schema.Claims)
@@ -315,11 +362,7 @@ func (m *Manager) migrateClaims(w io.Writer) error {
 
 // writeSchema updates the schema with the most recent version then writes it to disk.
 func (m *Manager) writeSchema(w io.Writer) error {
-	m.schema = Schema{
-		Claims:      schema.Version(claim.CNABSpecVersion),
-		Credentials: schema.Version(credentials.CNABSpecVersion),
-		Parameters:  schema.Version(ParameterSetCNABSpecVersion),
-	}
+	m.schema = currentSchema()
 	schemaB, err := json.Marshal(m.schema)
 	if err != nil {
 		return errors.Wrap(err, "Unable to marshal storage schema file")
@@ -66,6 +66,21 @@ func (s *Store) Connect() error {
 	return nil
 }
 
+// ReadAll retrieves the items of the type and optional group with a single
+// call to the plugin.
+func (s *Store) ReadAll(itemType string, group string) ([][]byte, error) {
+	handleClose, err := s.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return nil, err
+	}
+
+	if store, ok := s.GetDataStore().(crudstore.HasReadAll); ok {
+		return store.ReadAll(itemType, group)
+	}
+	return s.BackingStore.ReadAll(itemType, group)
+}
+
 func (s *Store) Close() error {
 	if s.cleanup != nil {
 		s.cleanup()
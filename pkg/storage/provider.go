@@ -1,8 +1,28 @@
 package storage
 
+import "io"
+
 // StorageProvider handles high level functions over Porter's storage systems such as
 // migrating data formats.
 type StorageProvider interface {
 	// Migrate executes a migration on any/all of Porter's storage sub-systems.
 	Migrate() (string, error)
+
+	// PlanMigration lists the changes that Migrate would make.
+	PlanMigration() ([]MigrationChange, error)
+
+	// Rollback restores the data and schema from before a migration, using
+	// the snapshot saved by Migrate, and returns the snapshot used.
+	Rollback(snapshot string) (string, error)
+
+	// Export writes Porter's data to an archive.
+	Export(w io.Writer) error
+
+	// Import saves the data from an archive created by Export, handling
+	// records that are already in storage with the conflict strategy.
+	Import(r io.Reader, conflict string) (ImportSummary, error)
+
+	// Encrypt saves all of the data again so that it is encrypted with the
+	// storage plugin's current encryption key.
+	Encrypt() (int, error)
 }
@@ -0,0 +1,287 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/pkg/errors"
+)
+
+// snapshotFileSuffix is appended to the timestamp of a migration to name the
+// snapshot of the data that it changed, e.g. 20210102150405-migrate-snapshot.tgz.
+const snapshotFileSuffix = "-migrate-snapshot.tgz"
+
+// MigrationChange is a change to a single item in storage that is made by a migration.
+type MigrationChange struct {
+	ItemType    string
+	Name        string
+	Description string
+}
+
+func (c MigrationChange) String() string {
+	if c.Name == "" {
+		return fmt.Sprintf("%s: %s", c.ItemType, c.Description)
+	}
+	return fmt.Sprintf("%s %s: %s", c.ItemType, c.Name, c.Description)
+}
+
+// PlanMigration determines the changes that Migrate would make, without
+// changing anything in storage.
+func (m *Manager) PlanMigration() ([]MigrationChange, error) {
+	m.resetSchema()
+
+	// Let us call connect and not have it kick us out because the schema is out-of-date
+	m.allowOutOfDateSchema = true
+	defer func() {
+		m.allowOutOfDateSchema = false
+	}()
+
+	err := m.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	changes, _, err := m.planMigration()
+	return changes, err
+}
+
+// planMigration lists the changes that a migration makes, and the items that
+// it changes, as they are before the migration.
+func (m *Manager) planMigration() ([]MigrationChange, []ArchiveItem, error) {
+	var changes []MigrationChange
+	var items []ArchiveItem
+	planItems := func(itemType string, describe func(name string, data []byte) string) error {
+		names, err := m.listItems(itemType, "")
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			data, err := m.BackingStore.Read(itemType, name)
+			if err != nil {
+				if strings.Contains(err.Error(), crud.ErrRecordDoesNotExist.Error()) {
+					// Not a document, e.g. the claims directory of a migrated installation
+					continue
+				}
+				return errors.Wrapf(err, "could not read %s %s", itemType, name)
+			}
+
+			description := describe(name, data)
+			if description == "" {
+				continue
+			}
+			changes = append(changes, MigrationChange{ItemType: itemType, Name: name, Description: description})
+			items = append(items, ArchiveItem{
+				ItemType: itemType,
+				Name:     name,
+				Path:     path.Join("items", itemType, name),
+				data:     data,
+			})
+		}
+		return nil
+	}
+
+	if m.ShouldMigrateClaims() {
+		err := planItems(claim.ItemTypeClaims, func(name string, data []byte) string {
+			installation := getClaimInstallation(name, data)
+			description := fmt.Sprintf("split into the claims, results and outputs of installation %s", installation)
+			if getSchemaVersion(data) == "" && installation != name {
+				description = fmt.Sprintf("move claim.Name to claim.Installation and %s", description)
+			}
+			return description
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if m.ShouldMigrateCredentials() {
+		err := planItems(credentials.ItemType, func(name string, data []byte) string {
+			if getSchemaVersion(data) != "" {
+				return ""
+			}
+			return fmt.Sprintf("set schemaVersion to %s", credentials.DefaultSchemaVersion)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if m.ShouldMigrateParameters() {
+		err := planItems(parametersItemType, func(name string, data []byte) string {
+			if getSchemaVersion(data) != "" {
+				return ""
+			}
+			return fmt.Sprintf("set schemaVersion to %s", ParameterSetDefaultSchemaVersion)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if m.MigrationRequired() {
+		s := currentSchema()
+		changes = append(changes, MigrationChange{
+			ItemType:    "schema",
+			Description: fmt.Sprintf("update to claims %s, credentials %s, parameters %s", s.Claims, s.Credentials, s.Parameters),
+		})
+	}
+
+	return changes, items, nil
+}
+
+// getClaimInstallation returns the installation of a claim in the old
+// format, which may still use claim.Name instead of claim.Installation.
+func getClaimInstallation(name string, data []byte) string {
+	var peek struct {
+		Name         string `json:"name"`
+		Installation string `json:"installation"`
+	}
+	json.Unmarshal(data, &peek)
+
+	if peek.Installation != "" {
+		return peek.Installation
+	}
+	if peek.Name != "" {
+		return peek.Name
+	}
+	return name
+}
+
+// snapshotMigration saves the items that a migration changes, and the current
+// schema, to a snapshot in PORTER_HOME so that the migration can be rolled back.
+func (m *Manager) snapshotMigration(timestamp time.Time, items []ArchiveItem) (string, error) {
+	home, err := m.GetHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	manifest := ArchiveManifest{
+		SchemaVersion: ArchiveSchemaVersion,
+		Created:       timestamp,
+		StorageSchema: m.schema,
+		Items:         items,
+	}
+	var snapshot bytes.Buffer
+	if err = writeArchive(&snapshot, manifest); err != nil {
+		return "", err
+	}
+
+	snapshotPath := filepath.Join(home, timestamp.Format("20060102150405")+snapshotFileSuffix)
+	err = m.FileSystem.WriteFile(snapshotPath, snapshot.Bytes(), 0600)
+	return snapshotPath, errors.Wrapf(err, "could not save the migration snapshot to %s", snapshotPath)
+}
+
+// findLatestSnapshot returns the most recent migration snapshot in PORTER_HOME.
+func (m *Manager) findLatestSnapshot() (string, error) {
+	home, err := m.GetHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	files, err := m.FileSystem.ReadDir(home)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not list the migration snapshots in %s", home)
+	}
+
+	// The files are sorted by name, which starts with the time of the migration
+	var latest string
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), snapshotFileSuffix) {
+			latest = f.Name()
+		}
+	}
+	if latest == "" {
+		return "", errors.Errorf("no migration snapshot found in %s", home)
+	}
+	return filepath.Join(home, latest), nil
+}
+
+// Rollback restores the data changed by a migration, and the previous schema,
+// from a snapshot created by Migrate. When no snapshot is specified, the most
+// recent snapshot in PORTER_HOME is used. The installations that were migrated
+// are replaced with their claims from before the migration, so any changes made
+// to those installations after the migration are lost.
+func (m *Manager) Rollback(snapshotPath string) (string, error) {
+	var err error
+	if snapshotPath == "" {
+		snapshotPath, err = m.findLatestSnapshot()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	f, err := m.FileSystem.Open(snapshotPath)
+	if err != nil {
+		return snapshotPath, errors.Wrapf(err, "could not open the migration snapshot %s", snapshotPath)
+	}
+	manifest, err := readArchive(f)
+	f.Close()
+	if err != nil {
+		return snapshotPath, errors.WithMessagef(err, "invalid migration snapshot %s", snapshotPath)
+	}
+
+	m.resetSchema()
+	m.allowOutOfDateSchema = true
+	defer func() {
+		m.allowOutOfDateSchema = false
+		// Reload the restored schema the next time we connect
+		m.resetSchema()
+	}()
+
+	err = m.Connect()
+	if err != nil {
+		return snapshotPath, err
+	}
+	defer m.Close()
+
+	return snapshotPath, m.restoreSnapshot(m.Err, manifest)
+}
+
+func (m *Manager) restoreSnapshot(w io.Writer, manifest ArchiveManifest) error {
+	// Remove the migrated installations, which may only have been partially
+	// migrated, before restoring their claims in the old format
+	claimStore := claim.NewClaimStore(m.BackingStore, nil, nil)
+	for _, item := range manifest.Items {
+		if item.ItemType != claim.ItemTypeClaims {
+			continue
+		}
+
+		installation := getClaimInstallation(item.Name, item.data)
+		err := claimStore.DeleteInstallation(installation)
+		if err != nil && err != claim.ErrInstallationNotFound {
+			return errors.Wrapf(err, "could not remove the migrated installation %s", installation)
+		}
+	}
+
+	for _, item := range manifest.Items {
+		fmt.Fprintf(w, " - Restoring %s %s\n", item.ItemType, item.Name)
+		err := m.BackingStore.Save(item.ItemType, item.Group, item.Name, item.data)
+		if err != nil {
+			return errors.Wrapf(err, "could not restore %s %s", item.ItemType, item.Name)
+		}
+	}
+
+	// The schema document didn't exist before the migration
+	if manifest.StorageSchema == (Schema{}) {
+		err := m.BackingStore.Delete("", "schema")
+		if err != nil && !strings.Contains(err.Error(), crud.ErrRecordDoesNotExist.Error()) {
+			return errors.Wrap(err, "could not remove the storage schema")
+		}
+	} else if err := m.saveSchema(manifest.StorageSchema); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "Restored the previous schema.json")
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"get.porter.sh/porter/pkg/config"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupUnmigratedHome creates a PORTER_HOME with a claim, credential set and
+// parameter set from before their schemas were versioned.
+func setupUnmigratedHome(t *testing.T) (*config.TestConfig, *Manager) {
+	c, mgr := newArchiveTestManager(t)
+	home, err := c.GetHomeDir()
+	require.NoError(t, err)
+
+	for _, dir := range []string{"claims", "credentials", "parameters"} {
+		require.NoError(t, c.FileSystem.MkdirAll(filepath.Join(home, dir), 0755))
+	}
+	c.TestContext.AddTestFile("testdata/claims/has-name.json", filepath.Join(home, "claims", "mysql.json"))
+	c.TestContext.AddTestFile("testdata/credentials/mybun.json", filepath.Join(home, "credentials", "mybun.json"))
+	c.TestContext.AddTestFile("testdata/parameters/mybun.json", filepath.Join(home, "parameters", "mybun.json"))
+	return c, mgr
+}
+
+func TestManager_PlanMigration(t *testing.T) {
+	c, mgr := setupUnmigratedHome(t)
+	defer c.TestContext.Cleanup()
+
+	changes, err := mgr.PlanMigration()
+	require.NoError(t, err)
+
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.String())
+	}
+	want := []string{
+		"claims mysql: move claim.Name to claim.Installation and split into the claims, results and outputs of installation example-exec-outputs",
+		"credentials mybun: set schemaVersion to " + string(credentials.DefaultSchemaVersion),
+		"parameters mybun: set schemaVersion to " + string(ParameterSetDefaultSchemaVersion),
+		fmt.Sprintf("schema: update to claims %s, credentials %s, parameters %s", claim.CNABSpecVersion, credentials.CNABSpecVersion, ParameterSetCNABSpecVersion),
+	}
+	assert.Equal(t, want, got)
+
+	_, err = mgr.Read(claim.ItemTypeClaims, "mysql")
+	require.NoError(t, err, "the claim should not have been migrated")
+	_, err = mgr.findLatestSnapshot()
+	require.Error(t, err, "a dry run should not save a snapshot")
+}
+
+func TestManager_PlanMigration_UpToDate(t *testing.T) {
+	c, mgr := newArchiveTestManager(t)
+	defer c.TestContext.Cleanup()
+	saveTestCredentialSet(t, mgr, "mycreds", "secret")
+
+	changes, err := mgr.PlanMigration()
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestManager_Rollback(t *testing.T) {
+	c, mgr := setupUnmigratedHome(t)
+	defer c.TestContext.Cleanup()
+
+	oldClaim, err := ioutil.ReadFile("testdata/claims/has-name.json")
+	require.NoError(t, err)
+
+	_, err = mgr.Migrate()
+	require.NoError(t, err, "Migrate failed")
+	assert.Contains(t, c.TestContext.GetError(), "Saved a snapshot of the data to migrate to")
+
+	snapshotPath, err := mgr.findLatestSnapshot()
+	require.NoError(t, err, "the migration should have saved a snapshot")
+
+	claimStore := claim.NewClaimStore(mgr, nil, nil)
+	_, err = claimStore.ReadLastClaim("example-exec-outputs")
+	require.NoError(t, err, "the claim should have been migrated")
+
+	restoredFrom, err := mgr.Rollback("")
+	require.NoError(t, err, "Rollback failed")
+	assert.Equal(t, snapshotPath, restoredFrom, "the latest snapshot should be used by default")
+
+	// Check the data directly, now that the storage requires a migration again
+	mgr.allowOutOfDateSchema = true
+	claimB, err := mgr.Read(claim.ItemTypeClaims, "mysql")
+	require.NoError(t, err, "the claim should have been restored")
+	assert.Equal(t, string(oldClaim), string(claimB))
+
+	installations, err := mgr.List(claim.ItemTypeInstallations, "")
+	require.NoError(t, err)
+	assert.Empty(t, installations, "the migrated installation should have been removed")
+
+	credB, err := mgr.Read(credentials.ItemType, "mybun")
+	require.NoError(t, err)
+	assert.Empty(t, getSchemaVersion(credB), "the credential set should have been restored")
+
+	_, err = mgr.Read("", "schema")
+	require.Error(t, err, "schema.json didn't exist before the migration")
+	mgr.allowOutOfDateSchema = false
+
+	// The data can be migrated again
+	_, err = mgr.Migrate()
+	require.NoError(t, err, "Migrate failed after the rollback")
+	_, err = claimStore.ReadLastClaim("example-exec-outputs")
+	require.NoError(t, err, "the claim should have been migrated again")
+}
+
+func TestManager_Rollback_NoSnapshot(t *testing.T) {
+	c, mgr := newArchiveTestManager(t)
+	defer c.TestContext.Cleanup()
+
+	_, err := mgr.Rollback("")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no migration snapshot found in")
+}
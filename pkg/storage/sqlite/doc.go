@@ -0,0 +1,3 @@
+// Package sqlite implements the crudstore plugin interface, storing data
+// in a SQLite database in the porter home directory.
+package sqlite // import "get.porter.sh/porter/pkg/storage/sqlite"
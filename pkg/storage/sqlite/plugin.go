@@ -0,0 +1,36 @@
+package sqlite
+
+import (
+	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/storage/crudstore"
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+)
+
+const PluginKey = crudstore.PluginInterface + ".porter.sqlite"
+
+var _ crud.Store = &Plugin{}
+
+// Plugin is the plugin wrapper for the SQLite storage. It embeds a
+// crud.ManagedStore so that the plugin reads every item in a group with a
+// single query.
+type Plugin struct {
+	crud.ManagedStore
+}
+
+func NewPlugin(c config.Config) plugin.Plugin {
+	// Create an hclog.Logger
+	logger := hclog.New(&hclog.LoggerOptions{
+		Name:       PluginKey,
+		Output:     c.Err,
+		Level:      hclog.Debug,
+		JSONFormat: true,
+	})
+
+	return &crudstore.Plugin{
+		Impl: &Plugin{
+			ManagedStore: NewStore(c, logger),
+		},
+	}
+}
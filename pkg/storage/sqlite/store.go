@@ -0,0 +1,255 @@
+package sqlite
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+
+	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/storage/crudstore"
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/hashicorp/go-hclog"
+	"github.com/pkg/errors"
+	_ "modernc.org/sqlite"
+)
+
+// DatabaseFile is the name of the database file in the porter home directory.
+const DatabaseFile = "porter.db"
+
+// schema of the database. Items are unique by their type and name, like the
+// filesystem store, and are indexed by their group so that listing the claims
+// for an installation, or the results of a claim, doesn't scan every item.
+const schema = `
+CREATE TABLE IF NOT EXISTS items (
+	item_type TEXT NOT NULL,
+	item_group TEXT NOT NULL,
+	name TEXT NOT NULL,
+	data BLOB NOT NULL,
+	PRIMARY KEY (item_type, name)
+);
+CREATE INDEX IF NOT EXISTS items_by_group ON items (item_type, item_group, name);
+`
+
+var _ crud.Store = &Store{}
+var _ crudstore.HasReadAll = &Store{}
+
+// Store is a SQLite store that stores data in a single database file in the
+// porter home directory.
+type Store struct {
+	config.Config
+	logger hclog.Logger
+	db     *sql.DB
+}
+
+func NewStore(c config.Config, l hclog.Logger) crud.ManagedStore {
+	// Wrapping ourselves in a backing store so that our Connect and Close are used.
+	return &managedStore{
+		BackingStore: crud.NewBackingStore(&Store{
+			Config: c,
+			logger: l,
+		}),
+	}
+}
+
+// managedStore is a crud.BackingStore that reads every item in a group with
+// a single query, instead of listing the items and reading them one at a time.
+type managedStore struct {
+	*crud.BackingStore
+}
+
+func (s *managedStore) ReadAll(itemType string, group string) ([][]byte, error) {
+	handleClose, err := s.HandleConnect()
+	defer handleClose()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetDataStore().(*Store).ReadAll(itemType, group)
+}
+
+// GetDatabasePath returns the location of the database file.
+func (s *Store) GetDatabasePath() (string, error) {
+	home, err := s.Config.GetHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "could not determine home directory for sqlite storage")
+	}
+	return filepath.Join(home, DatabaseFile), nil
+}
+
+func (s *Store) Connect() error {
+	if s.db != nil {
+		return nil
+	}
+
+	dbPath, err := s.GetDatabasePath()
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("PORTER DATABASE: " + dbPath)
+
+	err = os.MkdirAll(filepath.Dir(dbPath), 0700)
+	if err != nil {
+		return errors.Wrapf(err, "could not create the directory for %s", dbPath)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return errors.Wrapf(err, "could not open the sqlite database %s", dbPath)
+	}
+
+	// Wait for other porter commands to release their lock instead of failing
+	// immediately. The timeout is set on the connection, so only one connection
+	// is used.
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`PRAGMA busy_timeout = 5000`)
+	if err != nil {
+		db.Close()
+		return errors.Wrapf(err, "could not configure the sqlite database %s", dbPath)
+	}
+
+	_, err = db.Exec(schema)
+	if err != nil {
+		db.Close()
+		return errors.Wrapf(err, "could not initialize the sqlite database %s", dbPath)
+	}
+
+	s.db = db
+	return nil
+}
+
+func (s *Store) Close() error {
+	if s.db == nil {
+		return nil
+	}
+
+	err := s.db.Close()
+	s.db = nil
+	return errors.Wrap(err, "could not close the sqlite database")
+}
+
+// Count the number of items of the type and optional group. Like the filesystem
+// store, counting the items in a group that doesn't exist returns crud.ErrRecordDoesNotExist.
+func (s *Store) Count(itemType string, group string) (int, error) {
+	var count int
+	var err error
+	if group == "" {
+		err = s.db.QueryRow(`SELECT COUNT(*) FROM items WHERE item_type = ?`, itemType).Scan(&count)
+	} else {
+		err = s.db.QueryRow(`SELECT COUNT(*) FROM items WHERE item_type = ? AND item_group = ?`, itemType, group).Scan(&count)
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "could not count the %s items", itemType)
+	}
+
+	if count == 0 && group != "" {
+		return 0, crud.ErrRecordDoesNotExist
+	}
+	return count, nil
+}
+
+// List the names of the items of the type and optional group, sorted by name.
+// Like the filesystem store, listing the items in a group that doesn't exist
+// returns crud.ErrRecordDoesNotExist.
+func (s *Store) List(itemType string, group string) ([]string, error) {
+	var rows *sql.Rows
+	var err error
+	if group == "" {
+		rows, err = s.db.Query(`SELECT name FROM items WHERE item_type = ? ORDER BY name`, itemType)
+	} else {
+		rows, err = s.db.Query(`SELECT name FROM items WHERE item_type = ? AND item_group = ? ORDER BY name`, itemType, group)
+	}
+	if err != nil {
+		return []string{}, errors.Wrapf(err, "could not list the %s items", itemType)
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return []string{}, errors.Wrapf(err, "could not list the %s items", itemType)
+		}
+		names = append(names, name)
+	}
+	if err = rows.Err(); err != nil {
+		return []string{}, errors.Wrapf(err, "could not list the %s items", itemType)
+	}
+
+	if len(names) == 0 && group != "" {
+		return names, crud.ErrRecordDoesNotExist
+	}
+	return names, nil
+}
+
+// ReadAll reads the data for the items of the type and optional group, sorted
+// by name, with a single query. Like List, reading the items in a group that
+// doesn't exist returns crud.ErrRecordDoesNotExist.
+func (s *Store) ReadAll(itemType string, group string) ([][]byte, error) {
+	var rows *sql.Rows
+	var err error
+	if group == "" {
+		rows, err = s.db.Query(`SELECT data FROM items WHERE item_type = ? ORDER BY name`, itemType)
+	} else {
+		rows, err = s.db.Query(`SELECT data FROM items WHERE item_type = ? AND item_group = ? ORDER BY name`, itemType, group)
+	}
+	if err != nil {
+		return [][]byte{}, errors.Wrapf(err, "could not read the %s items", itemType)
+	}
+	defer rows.Close()
+
+	results := [][]byte{}
+	for rows.Next() {
+		var data []byte
+		if err = rows.Scan(&data); err != nil {
+			return [][]byte{}, errors.Wrapf(err, "could not read the %s items", itemType)
+		}
+		results = append(results, data)
+	}
+	if err = rows.Err(); err != nil {
+		return [][]byte{}, errors.Wrapf(err, "could not read the %s items", itemType)
+	}
+
+	if len(results) == 0 && group != "" {
+		return results, crud.ErrRecordDoesNotExist
+	}
+	return results, nil
+}
+
+// Save an item, replacing the item with the same type and name when it exists.
+func (s *Store) Save(itemType string, group string, name string, data []byte) error {
+	// The driver binds empty data as NULL, so store it as an empty blob instead
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO items (item_type, item_group, name, data) VALUES (?, ?, ?, IFNULL(?, X''))`,
+		itemType, group, name, data)
+	return errors.Wrapf(err, "could not save %s %s", itemType, name)
+}
+
+// Read the data for an item.
+func (s *Store) Read(itemType string, name string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM items WHERE item_type = ? AND name = ?`, itemType, name).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, errors.Wrapf(crud.ErrRecordDoesNotExist, "no record found for %s %s", itemType, name)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s %s", itemType, name)
+	}
+	return data, nil
+}
+
+// Delete an item.
+func (s *Store) Delete(itemType string, name string) error {
+	result, err := s.db.Exec(`DELETE FROM items WHERE item_type = ? AND name = ?`, itemType, name)
+	if err != nil {
+		return errors.Wrapf(err, "could not delete %s %s", itemType, name)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "could not delete %s %s", itemType, name)
+	}
+	if count == 0 {
+		return errors.Wrapf(crud.ErrRecordDoesNotExist, "no record found for %s %s", itemType, name)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"get.porter.sh/porter/pkg/config"
+	"get.porter.sh/porter/pkg/storage"
+	"github.com/cnabio/cnab-go/bundle"
+	"github.com/cnabio/cnab-go/bundle/definition"
+	"github.com/cnabio/cnab-go/claim"
+	"github.com/cnabio/cnab-go/utils/crud"
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testItemType = "items"
+
+func newTestStore(t *testing.T) (*config.TestConfig, crud.ManagedStore) {
+	c := config.NewTestConfig(t)
+	_, home := c.TestContext.UseFilesystem()
+	c.SetHomeDir(home)
+
+	return c, NewStore(*c.Config, hclog.NewNullLogger())
+}
+
+func TestStore(t *testing.T) {
+	testcases := []struct {
+		name  string
+		group string
+	}{
+		{name: "no group supplied", group: ""},
+		{name: "group supplied", group: "testgroup"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, s := newTestStore(t)
+			defer c.TestContext.Cleanup()
+
+			keys := []string{"test.key1", "test.key2"}
+			val := []byte("testval")
+
+			// Save some records
+			for _, key := range keys {
+				require.NoError(t, s.Save(testItemType, tc.group, key, val))
+			}
+
+			// List the records
+			list, err := s.List(testItemType, tc.group)
+			require.NoError(t, err)
+			require.Equal(t, []string{"test.key1", "test.key2"}, list)
+
+			// Read each record
+			for _, key := range keys {
+				d, err := s.Read(testItemType, key)
+				require.NoError(t, err)
+				require.Equal(t, []byte("testval"), d)
+			}
+
+			// Delete a record
+			require.NoError(t, s.Delete(testItemType, keys[0]))
+
+			list, err = s.List(testItemType, tc.group)
+			require.NoError(t, err)
+			require.Len(t, list, len(keys)-1)
+
+			// Delete last record
+			require.NoError(t, s.Delete(testItemType, keys[1]))
+
+			// An empty group doesn't exist, like a removed group directory in the filesystem store
+			list, err = s.List(testItemType, tc.group)
+			if tc.group != "" {
+				require.Equal(t, crud.ErrRecordDoesNotExist, err)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, list, 0)
+			}
+
+			_, err = s.Read(testItemType, keys[0])
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), crud.ErrRecordDoesNotExist.Error())
+
+			err = s.Delete(testItemType, keys[0])
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), crud.ErrRecordDoesNotExist.Error())
+		})
+	}
+}
+
+func TestStore_Count(t *testing.T) {
+	c, s := newTestStore(t)
+	defer c.TestContext.Cleanup()
+
+	count, err := s.Count(testItemType, "")
+	require.NoError(t, err, "Count failed")
+	assert.Equal(t, 0, count, "Count should be 0 for an empty datastore")
+
+	require.NoError(t, s.Save(testItemType, "group1", "key1", []byte("value1")))
+	require.NoError(t, s.Save(testItemType, "group2", "key2", []byte("value2")))
+	require.NoError(t, s.Save("other", "group1", "key3", []byte("value3")))
+
+	count, err = s.Count(testItemType, "")
+	require.NoError(t, err, "Count failed")
+	assert.Equal(t, 2, count, "Count should include every group")
+
+	count, err = s.Count(testItemType, "group1")
+	require.NoError(t, err, "Count failed")
+	assert.Equal(t, 1, count, "Count should be limited to the group")
+}
+
+func TestStore_ReadAll(t *testing.T) {
+	c, s := newTestStore(t)
+	defer c.TestContext.Cleanup()
+
+	require.NoError(t, s.Save(testItemType, "group1", "key2", []byte("value2")))
+	require.NoError(t, s.Save(testItemType, "group1", "key1", []byte("value1")))
+	require.NoError(t, s.Save(testItemType, "group2", "key3", []byte("value3")))
+	require.NoError(t, s.Save("other", "group1", "key4", []byte("value4")))
+
+	results, err := s.ReadAll(testItemType, "group1")
+	require.NoError(t, err, "ReadAll failed")
+	assert.Equal(t, [][]byte{[]byte("value1"), []byte("value2")}, results, "the items in the group should be read in order by name")
+
+	results, err = s.ReadAll(testItemType, "")
+	require.NoError(t, err, "ReadAll failed")
+	assert.Equal(t, [][]byte{[]byte("value1"), []byte("value2"), []byte("value3")}, results, "the items in every group should be read")
+
+	_, err = s.ReadAll(testItemType, "missing")
+	assert.Equal(t, crud.ErrRecordDoesNotExist, err, "reading a group that doesn't exist should return ErrRecordDoesNotExist")
+}
+
+func TestStore_Save_Overwrite(t *testing.T) {
+	c, s := newTestStore(t)
+	defer c.TestContext.Cleanup()
+
+	require.NoError(t, s.Save(testItemType, "group1", "key1", []byte("value1")))
+	require.NoError(t, s.Save(testItemType, "group2", "key1", []byte("value2")))
+
+	d, err := s.Read(testItemType, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value2"), d)
+
+	_, err = s.List(testItemType, "group1")
+	assert.Equal(t, crud.ErrRecordDoesNotExist, err, "the item should have moved to the new group")
+
+	list, err := s.List(testItemType, "group2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"key1"}, list)
+}
+
+func TestStore_Persisted(t *testing.T) {
+	c, s := newTestStore(t)
+	defer c.TestContext.Cleanup()
+
+	require.NoError(t, s.Save(testItemType, "", "key1", []byte("value1")))
+
+	home, err := c.GetHomeDir()
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(home, DatabaseFile))
+
+	// Use a new store so that the data is read from the database file
+	s = NewStore(*c.Config, hclog.NewNullLogger())
+	d, err := s.Read(testItemType, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value1"), d)
+}
+
+func TestStore_ClaimStore(t *testing.T) {
+	c, s := newTestStore(t)
+	defer c.TestContext.Cleanup()
+
+	mgr := storage.NewManager(c.Config, s)
+	cp := claim.NewClaimStore(mgr, nil, nil)
+
+	bun := bundle.Bundle{
+		Definitions: map[string]*definition.Schema{
+			"output1": {Type: "string"},
+		},
+		Outputs: map[string]bundle.Output{
+			"output1": {Definition: "output1"},
+		},
+	}
+
+	foo, err := claim.New("foo", claim.ActionInstall, bun, nil)
+	require.NoError(t, err)
+	require.NoError(t, cp.SaveClaim(foo))
+	fooResult, err := foo.NewResult(claim.StatusSucceeded)
+	require.NoError(t, err)
+	require.NoError(t, cp.SaveResult(fooResult))
+	require.NoError(t, cp.SaveOutput(claim.NewOutput(foo, fooResult, "output1", []byte("foo-output"))))
+
+	fooUpgrade, err := foo.NewClaim(claim.ActionUpgrade, bun, nil)
+	require.NoError(t, err)
+	require.NoError(t, cp.SaveClaim(fooUpgrade))
+
+	bar, err := claim.New("bar", claim.ActionInstall, bun, nil)
+	require.NoError(t, err)
+	require.NoError(t, cp.SaveClaim(bar))
+
+	installations, err := cp.ListInstallations()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar", "foo"}, installations)
+
+	i, err := cp.ReadInstallation("foo")
+	require.NoError(t, err)
+	require.Len(t, i.Claims, 2)
+	assert.Equal(t, foo.ID, i.Claims[0].ID)
+	assert.Equal(t, fooUpgrade.ID, i.Claims[1].ID)
+
+	results, err := cp.ReadAllResults(foo.ID)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, claim.StatusSucceeded, results[0].Status)
+
+	outputs, err := cp.ReadLastOutputs("foo")
+	require.NoError(t, err)
+	output, ok := outputs.GetByName("output1")
+	require.True(t, ok)
+	assert.Equal(t, "foo-output", string(output.Value))
+
+	require.NoError(t, cp.DeleteInstallation("foo"))
+
+	_, err = cp.ReadInstallation("foo")
+	assert.Equal(t, claim.ErrInstallationNotFound, err)
+
+	_, err = cp.ReadResult(fooResult.ID)
+	assert.Equal(t, claim.ErrResultNotFound, err)
+
+	installations, err = cp.ListInstallations()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar"}, installations)
+}
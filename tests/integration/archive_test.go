@@ -58,7 +58,7 @@ func TestArchive(t *testing.T) {
 
 	// Publish bundle from archive, with new reference
 	publishFromArchiveOpts := porter.PublishOptions{
-		ArchiveFile: archiveFile1,
+		ArchiveFiles: []string{archiveFile1},
 		BundlePullOptions: porter.BundlePullOptions{
 			Reference: fmt.Sprintf("localhost:5000/archived-mysql:v0.1.3"),
 		},
@@ -30,10 +30,10 @@ func TestClaimMigration_List(t *testing.T) {
 	p.AddTestFile(filepath.Join(p.RepoRoot, "pkg/storage/testdata/claims", "upgraded.json"), filepath.Join(home, "claims", "mybun.json"))
 	p.FileSystem.Remove(filepath.Join(home, "schema.json"))
 
-	err = p.MigrateStorage()
+	err = p.MigrateStorage(porter.MigrateStorageOptions{})
 	require.NoError(t, err, "MigrateStorage failed")
 
-	installations, err := p.ListInstallations()
+	installations, err := p.ListInstallations(nil)
 	require.NoError(t, err, "could not list installations")
 	require.Len(t, installations, 1, "expected one installation")
 	assert.Equal(t, "mybun", installations[0].Name, "unexpected list of installation names")